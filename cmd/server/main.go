@@ -13,6 +13,7 @@ import (
 	"github.com/BohdanBoriak/boilerplate-go-back/config/container"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/mqtt"
 )
 
 func main() {
@@ -51,6 +52,27 @@ func main() {
 		log.Fatalf("Unable to create container: %q\n", err)
 	}
 
+	// Workers
+	go cont.CommandService.Run(ctx)
+	go cont.AutomationService.Run(ctx)
+	go cont.ScheduleService.Run(ctx)
+	go cont.BudgetService.Run(ctx)
+	go cont.DemandService.Run(ctx)
+	go cont.ApprovalService.Run(ctx)
+
+	// MQTT
+	if conf.MqttMode != "" {
+		bridge, err := mqtt.NewBridge(conf, cont.Hub, cont.DeviceService, cont.MeasurementService, cont.EventService, cont.CommandService, cont.DeviceTwinService)
+		if err != nil {
+			log.Fatalf("Unable to create mqtt bridge: %q\n", err)
+		}
+		err = bridge.Start()
+		if err != nil {
+			log.Fatalf("Unable to start mqtt bridge: %q\n", err)
+		}
+		defer bridge.Close()
+	}
+
 	// HTTP Server
 	err = http.Server(
 		ctx,
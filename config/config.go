@@ -16,6 +16,12 @@ type Configuration struct {
 	FileStorageLocation string
 	JwtSecret           string
 	JwtTTL              time.Duration
+	MqttMode            string
+	MqttListenAddress   string
+	MqttBrokerUrl       string
+	MqttClientId        string
+	MqttUsername        string
+	MqttPassword        string
 }
 
 func GetConfiguration() Configuration {
@@ -29,6 +35,12 @@ func GetConfiguration() Configuration {
 		FileStorageLocation: getOrDefault("FILES_LOCATION", "file_storage"),
 		JwtSecret:           getOrDefault("JWT_SECRET", "1234567890"),
 		JwtTTL:              72 * time.Hour,
+		MqttMode:            getOrDefault("MQTT_MODE", ""),
+		MqttListenAddress:   getOrDefault("MQTT_LISTEN_ADDRESS", ":1883"),
+		MqttBrokerUrl:       getOrDefault("MQTT_BROKER_URL", "tcp://127.0.0.1:1883"),
+		MqttClientId:        getOrDefault("MQTT_CLIENT_ID", "boilerplate-go-back"),
+		MqttUsername:        getOrDefault("MQTT_USERNAME", ""),
+		MqttPassword:        getOrDefault("MQTT_PASSWORD", ""),
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/controllers"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/middlewares"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/pubsub"
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/upper/db/v4"
 	"github.com/upper/db/v4/adapter/postgresql"
@@ -18,10 +19,13 @@ type Container struct {
 	Middlewares
 	Services
 	Controllers
+	Hub pubsub.Hub
 }
 
 type Middlewares struct {
-	AuthMw func(http.Handler) http.Handler
+	AuthMw       func(http.Handler) http.Handler
+	StreamAuthMw func(http.Handler) http.Handler
+	DeviceAuthMw func(http.Handler) http.Handler
 }
 
 type Services struct {
@@ -32,21 +36,50 @@ type Services struct {
 	app.DeviceService
 	app.MeasurementService
 	app.EventService
+	app.CommandService
+	app.DeviceTwinService
+	app.AutomationService
+	app.ScheduleService
+	app.TariffService
+	app.BudgetService
+	app.NotificationService
+	app.EmissionFactorService
+	app.DemandService
+	app.InterlockService
+	app.ApprovalService
+	app.AnomalyService
+	app.CalibrationService
 }
 
 type Controllers struct {
-	AuthController         controllers.AuthController
-	UserController         controllers.UserController
-	OrganizationController controllers.OrganizationController
-	RoomController         controllers.RoomController
-	DeviceController       controllers.DeviceController
-	MeasurementController  controllers.MeasurementController
-	EventController        controllers.EventController
+	AuthController           controllers.AuthController
+	UserController           controllers.UserController
+	OrganizationController   controllers.OrganizationController
+	RoomController           controllers.RoomController
+	DeviceController         controllers.DeviceController
+	MeasurementController    controllers.MeasurementController
+	EventController          controllers.EventController
+	StreamController         controllers.StreamController
+	CommandController        controllers.CommandController
+	DeviceTwinController     controllers.DeviceTwinController
+	RuleController           controllers.RuleController
+	ScheduleController       controllers.ScheduleController
+	TariffController         controllers.TariffController
+	BudgetController         controllers.BudgetController
+	NotificationController   controllers.NotificationController
+	EmissionFactorController controllers.EmissionFactorController
+	DemandController         controllers.DemandController
+	InterlockController      controllers.InterlockController
+	ApprovalController       controllers.ApprovalController
+	AnomalyController        controllers.AnomalyController
+	CalibrationController    controllers.CalibrationController
+	UnitController           controllers.UnitController
 }
 
 func New(conf config.Configuration) (Container, error) {
 	tknAuth := jwtauth.New("HS256", []byte(conf.JwtSecret), nil)
 	sess := getDbSess(conf)
+	hub := pubsub.NewHub()
 
 	sessionRepository := database.NewSessRepository(sess)
 	userRepository := database.NewUserRepository(sess)
@@ -55,31 +88,82 @@ func New(conf config.Configuration) (Container, error) {
 	deviceRepository := database.NewDeviceRepository(sess)
 	measurementRepository := database.NewMeasurementRepository(sess)
 	eventRepository := database.NewEventRepository(sess, deviceRepository)
+	commandRepository := database.NewCommandRepository(sess)
+	deviceTwinRepository := database.NewDeviceTwinRepository(sess)
+	ruleRepository := database.NewRuleRepository(sess)
+	scheduleRepository := database.NewScheduleRepository(sess)
+	deviceInstallationRepository := database.NewDeviceInstallationRepository(sess)
+	tariffRepository := database.NewTariffRepository(sess)
+	budgetRepository := database.NewBudgetRepository(sess)
+	notificationRepository := database.NewNotificationRepository(sess)
+	emissionFactorRepository := database.NewEmissionFactorRepository(sess)
+	demandRepository := database.NewDemandRepository(sess)
+	interlockRepository := database.NewInterlockRepository(sess)
+	memberRepository := database.NewMemberRepository(sess)
+	approvalRepository := database.NewApprovalRepository(sess)
+	anomalyRepository := database.NewAnomalyRepository(sess)
+	calibrationRepository := database.NewCalibrationRepository(sess)
+	channelRepository := database.NewChannelRepository(sess)
+	transactor := database.NewTransactor(sess)
 
 	userService := app.NewUserService(userRepository)
 	authService := app.NewAuthService(sessionRepository, userRepository, tknAuth, conf.JwtTTL)
-	organizationService := app.NewOrganizationService(organizationRepository, roomRepository)
+	organizationService := app.NewOrganizationService(organizationRepository, roomRepository, memberRepository, userRepository)
 	roomService, err := app.NewRoomService(roomRepository, organizationRepository, deviceRepository)
 	if err != nil {
 		return Container{}, err
 	}
-	deviceService := app.NewDeviceService(deviceRepository, measurementRepository, eventRepository)
-	measurementService := app.NewMeasurementService(measurementRepository)
-	eventService := app.NewEventService(eventRepository, deviceRepository, roomRepository)
+	deviceService := app.NewDeviceService(deviceRepository, channelRepository, measurementRepository, eventRepository, deviceInstallationRepository, hub)
+	notificationService := app.NewNotificationService(notificationRepository, hub)
+	anomalyService := app.NewAnomalyService(anomalyRepository, measurementRepository, organizationRepository, notificationService)
+	measurementService := app.NewMeasurementService(measurementRepository, deviceRepository, channelRepository, calibrationRepository, anomalyService, hub)
+	eventService := app.NewEventService(eventRepository, deviceRepository, commandRepository, roomRepository, deviceInstallationRepository, measurementRepository, demandRepository, interlockRepository, deviceTwinRepository, transactor, hub)
+	commandService := app.NewCommandService(commandRepository, deviceRepository, approvalRepository, eventService, hub)
+	deviceTwinService := app.NewDeviceTwinService(deviceTwinRepository, deviceRepository, commandService, hub)
+	automationService := app.NewAutomationService(ruleRepository, deviceRepository, channelRepository, roomRepository, organizationRepository, measurementRepository, eventService, hub)
+	tariffService := app.NewTariffService(tariffRepository)
+	emissionFactorService := app.NewEmissionFactorService(emissionFactorRepository)
+	scheduleService := app.NewScheduleService(scheduleRepository, deviceRepository, organizationRepository, eventService)
+	budgetService := app.NewBudgetService(budgetRepository, organizationRepository, roomRepository, tariffRepository, eventService, notificationService)
+	demandService := app.NewDemandService(demandRepository, deviceRepository, eventService, commandService)
+	interlockService := app.NewInterlockService(interlockRepository, deviceRepository)
+	approvalService := app.NewApprovalService(approvalRepository, organizationService, commandService)
+	calibrationService := app.NewCalibrationService(calibrationRepository, measurementRepository, deviceRepository, channelRepository, anomalyService)
 
 	authController := controllers.NewAuthController(authService, userService)
 	userController := controllers.NewUserController(userService, authService)
 	organizationController := controllers.NewOrganizationController(organizationService)
 	roomController := controllers.NewRoomController(roomService, organizationService)
-	deviceController := controllers.NewDeviceController(deviceService, roomService, organizationService)
-	measurementController := controllers.NewMeasurementController(measurementService, deviceService)
-	eventController := controllers.NewEventController(eventService, deviceRepository)
+	deviceController := controllers.NewDeviceController(deviceService, roomService, organizationService, notificationService)
+	measurementController := controllers.NewMeasurementController(measurementService, deviceService, organizationService)
+	eventController := controllers.NewEventController(eventService, deviceRepository, organizationService, tariffService, emissionFactorService)
+	streamController := controllers.NewStreamController(hub)
+	commandController := controllers.NewCommandController(commandService, deviceService, organizationService)
+	deviceTwinController := controllers.NewDeviceTwinController(deviceTwinService, organizationService)
+	ruleController := controllers.NewRuleController(automationService, organizationService)
+	scheduleController := controllers.NewScheduleController(scheduleService, organizationService)
+	tariffController := controllers.NewTariffController(tariffService, organizationService)
+	budgetController := controllers.NewBudgetController(budgetService, organizationService)
+	notificationController := controllers.NewNotificationController(notificationService)
+	emissionFactorController := controllers.NewEmissionFactorController(emissionFactorService, organizationService)
+	demandController := controllers.NewDemandController(demandService)
+	interlockController := controllers.NewInterlockController(interlockService, organizationService)
+	approvalController := controllers.NewApprovalController(approvalService, organizationService)
+	anomalyController := controllers.NewAnomalyController(anomalyService, organizationService)
+	calibrationController := controllers.NewCalibrationController(calibrationService, organizationService)
+	unitController := controllers.NewUnitController()
 
 	authMiddleware := middlewares.AuthMiddleware(tknAuth, authService, userService)
+	// Browsers can't set headers on EventSource and WebSocket connections
+	streamAuthMiddleware := middlewares.AuthMiddleware(tknAuth, authService, userService, jwtauth.TokenFromHeader, jwtauth.TokenFromQuery)
+	deviceAuthMiddleware := middlewares.DeviceAuthMiddleware(deviceService)
 
 	return Container{
+		Hub: hub,
 		Middlewares: Middlewares{
-			AuthMw: authMiddleware,
+			AuthMw:       authMiddleware,
+			StreamAuthMw: streamAuthMiddleware,
+			DeviceAuthMw: deviceAuthMiddleware,
 		},
 		Services: Services{
 			authService,
@@ -89,6 +173,19 @@ func New(conf config.Configuration) (Container, error) {
 			deviceService,
 			measurementService,
 			eventService,
+			commandService,
+			deviceTwinService,
+			automationService,
+			scheduleService,
+			tariffService,
+			budgetService,
+			notificationService,
+			emissionFactorService,
+			demandService,
+			interlockService,
+			approvalService,
+			anomalyService,
+			calibrationService,
 		},
 		Controllers: Controllers{
 			authController,
@@ -98,6 +195,21 @@ func New(conf config.Configuration) (Container, error) {
 			deviceController,
 			*measurementController,
 			*eventController,
+			streamController,
+			commandController,
+			deviceTwinController,
+			ruleController,
+			scheduleController,
+			tariffController,
+			budgetController,
+			notificationController,
+			emissionFactorController,
+			demandController,
+			interlockController,
+			approvalController,
+			anomalyController,
+			calibrationController,
+			unitController,
 		},
 	}, nil
 }
@@ -0,0 +1,178 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+// madScale turns a median absolute deviation into the standard deviation of a normal distribution.
+const madScale = 0.6745
+
+// anomalyFinding is an anomaly of a reading before it is stored.
+type anomalyFinding struct {
+	Kind   domain.AnomalyKind
+	Score  float64
+	Reason string
+}
+
+// anomalyState is what the detector remembers of a sensor between its readings: the recent
+// readings, a fast moving average and a slow baseline with its variance.
+type anomalyState struct {
+	settings    domain.AnomalySettings
+	window      []float64
+	ready       bool
+	ewma        float64
+	baseline    float64
+	baselineVar float64
+	stuck       int
+	// active are the kinds the last reading was flagged for, only a new kind is notified
+	active map[domain.AnomalyKind]bool
+}
+
+func newAnomalyState(settings domain.AnomalySettings, history []float64) *anomalyState {
+	st := &anomalyState{settings: settings, active: make(map[domain.AnomalyKind]bool)}
+	for _, v := range history {
+		st.inspect(v)
+	}
+	// the history was already flagged when it was measured
+	st.active = make(map[domain.AnomalyKind]bool)
+	return st
+}
+
+// inspect flags the reading and learns from it. A reading is only inspected once the sensor
+// has MinAnomalySamples readings.
+func (st *anomalyState) inspect(v float64) []anomalyFinding {
+	set := st.settings
+	if len(st.window) > 0 && v == st.window[len(st.window)-1] {
+		st.stuck++
+	} else {
+		st.stuck = 1
+	}
+
+	if !st.ready {
+		st.push(v)
+		if len(st.window) >= domain.MinAnomalySamples {
+			st.baseline, st.baselineVar = meanAndVariance(st.window)
+			st.ewma = st.baseline
+			st.ready = true
+		}
+		return nil
+	}
+
+	var findings []anomalyFinding
+	spike := false
+	if set.ZThreshold > 0 {
+		median, mad := medianAndMad(st.window)
+		if mad > 0 {
+			z := madScale * (v - median) / mad
+			if math.Abs(z) > set.ZThreshold {
+				spike = true
+				findings = append(findings, anomalyFinding{
+					Kind:   domain.SpikeAnomaly,
+					Score:  z,
+					Reason: fmt.Sprintf("%g is %.1f MADs from the median %g of the last %d readings", v, math.Abs(z), median, len(st.window)),
+				})
+			}
+		}
+	}
+
+	// a spike is left out of the averages, so one outlier doesn't read as a drift
+	if !spike {
+		st.ewma += set.EwmaAlpha * (v - st.ewma)
+		d := v - st.baseline
+		st.baseline += set.BaselineAlpha * d
+		st.baselineVar = (1 - set.BaselineAlpha) * (st.baselineVar + set.BaselineAlpha*d*d)
+	}
+	sd := math.Sqrt(st.baselineVar)
+	if set.DriftThreshold > 0 && sd > 0 {
+		// the control limits of an EWMA chart
+		limit := sd * math.Sqrt(set.EwmaAlpha/(2-set.EwmaAlpha))
+		score := (st.ewma - st.baseline) / limit
+		if math.Abs(score) > set.DriftThreshold {
+			findings = append(findings, anomalyFinding{
+				Kind:   domain.DriftAnomaly,
+				Score:  score,
+				Reason: fmt.Sprintf("the moving average %.4g drifted %.1f control limits from the baseline %.4g", st.ewma, math.Abs(score), st.baseline),
+			})
+		}
+	}
+
+	if set.StuckCount > 0 && st.stuck >= set.StuckCount {
+		findings = append(findings, anomalyFinding{
+			Kind:   domain.StuckAnomaly,
+			Score:  float64(st.stuck),
+			Reason: fmt.Sprintf("%g was reported %d times in a row", v, st.stuck),
+		})
+	}
+
+	st.push(v)
+	if set.FlatlineCount > 0 && set.FlatlineRatio > 0 && sd > 0 && len(st.window) >= set.FlatlineCount {
+		recent := st.window[len(st.window)-set.FlatlineCount:]
+		low, high := recent[0], recent[0]
+		for _, r := range recent {
+			low, high = math.Min(low, r), math.Max(high, r)
+		}
+		// identical readings are stuck rather than flat
+		if high > low && high-low <= set.FlatlineRatio*sd {
+			findings = append(findings, anomalyFinding{
+				Kind:   domain.FlatlineAnomaly,
+				Score:  float64(set.FlatlineCount),
+				Reason: fmt.Sprintf("the last %d readings ranged within %.4g, the usual deviation is %.4g", set.FlatlineCount, high-low, sd),
+			})
+		}
+	}
+	return findings
+}
+
+func (st *anomalyState) push(v float64) {
+	st.window = append(st.window, v)
+	if len(st.window) > st.settings.Window {
+		st.window = st.window[len(st.window)-st.settings.Window:]
+	}
+}
+
+// fresh returns the findings of kinds the previous reading wasn't flagged for and remembers
+// the kinds of this one.
+func (st *anomalyState) fresh(findings []anomalyFinding) []anomalyFinding {
+	active := make(map[domain.AnomalyKind]bool, len(findings))
+	var res []anomalyFinding
+	for _, f := range findings {
+		active[f.Kind] = true
+		if !st.active[f.Kind] {
+			res = append(res, f)
+		}
+	}
+	st.active = active
+	return res
+}
+
+func meanAndVariance(values []float64) (float64, float64) {
+	m := mean(values)
+	var sq float64
+	for _, v := range values {
+		sq += (v - m) * (v - m)
+	}
+	return m, sq / float64(len(values))
+}
+
+func medianAndMad(values []float64) (float64, float64) {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	median := medianOfSorted(sorted)
+	for i, v := range sorted {
+		sorted[i] = math.Abs(v - median)
+	}
+	sort.Float64s(sorted)
+	return median, medianOfSorted(sorted)
+}
+
+func medianOfSorted(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,242 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
+)
+
+const anomalyHistoryLimit = 100
+
+type AnomalyService interface {
+	Inspect(device domain.Device, m domain.Measurement) []domain.MeasurementAnomaly
+	FindSettings(device domain.Device) (domain.AnomalySettings, error)
+	SaveSettings(s domain.AnomalySettings) (domain.AnomalySettings, error)
+	DeleteSettings(device domain.Device) error
+	FindByDeviceId(deviceId uint64, startDate, endDate time.Time) ([]domain.MeasurementAnomaly, error)
+	FindByOrgId(orgId uint64) ([]domain.MeasurementAnomaly, error)
+	Reset(deviceId uint64)
+}
+
+// anomalyService detects anomalies in the readings of sensors as they arrive. The state of a
+// sensor, or of each of its channels, is kept in memory and rebuilt from its last readings after
+// a restart.
+type anomalyService struct {
+	anomalyRepo         database.AnomalyRepository
+	measurementRepo     database.MeasurementRepository
+	orgRepo             database.OrganizationRepository
+	notificationService NotificationService
+
+	mu     sync.Mutex
+	states map[anomalySeries]*anomalyState
+}
+
+// anomalySeries identifies the readings of a sensor's own value, with no channel, or of one of
+// its channels.
+type anomalySeries struct {
+	deviceId  uint64
+	channelId uint64
+}
+
+func seriesOf(m domain.Measurement) anomalySeries {
+	series := anomalySeries{deviceId: m.DeviceId}
+	if m.ChannelId != nil {
+		series.channelId = *m.ChannelId
+	}
+	return series
+}
+
+func NewAnomalyService(ar database.AnomalyRepository, mr database.MeasurementRepository, or database.OrganizationRepository, ns NotificationService) AnomalyService {
+	return &anomalyService{
+		anomalyRepo:         ar,
+		measurementRepo:     mr,
+		orgRepo:             or,
+		notificationService: ns,
+		states:              make(map[anomalySeries]*anomalyState),
+	}
+}
+
+// Inspect flags the saved measurement of a sensor, stores what it found and notifies the owner of
+// the organization when the sensor turns anomalous in a new way.
+func (s *anomalyService) Inspect(device domain.Device, m domain.Measurement) []domain.MeasurementAnomaly {
+	if device.Category != domain.Sensor {
+		return nil
+	}
+
+	s.mu.Lock()
+	st, err := s.state(device, m)
+	if err != nil {
+		s.mu.Unlock()
+		log.Printf("AnomalyService: device %d: %s", device.Id, err)
+		return nil
+	}
+	if !st.settings.Enabled {
+		s.mu.Unlock()
+		return nil
+	}
+	findings := st.inspect(m.Value)
+	fresh := st.fresh(findings)
+	s.mu.Unlock()
+
+	notify := make(map[domain.AnomalyKind]bool, len(fresh))
+	for _, f := range fresh {
+		notify[f.Kind] = true
+	}
+	var anomalies []domain.MeasurementAnomaly
+	for _, f := range findings {
+		a, err := s.anomalyRepo.Save(domain.MeasurementAnomaly{
+			MeasurementId:  m.Id,
+			OrganizationId: device.OrganizationId,
+			DeviceId:       device.Id,
+			ChannelId:      m.ChannelId,
+			Kind:           f.Kind,
+			Value:          m.Value,
+			Score:          f.Score,
+			Reason:         f.Reason,
+		})
+		if err != nil {
+			continue
+		}
+		anomalies = append(anomalies, a)
+		log.Printf("AnomalyService: %s reading %d of device %d: %s", a.Kind, m.Id, device.Id, a.Reason)
+		if notify[a.Kind] && st.settings.Notify {
+			s.notify(device, a)
+		}
+	}
+	return anomalies
+}
+
+func (s *anomalyService) FindSettings(device domain.Device) (domain.AnomalySettings, error) {
+	settings, err := s.anomalyRepo.FindSettings(device.Id)
+	if errors.Is(err, db.ErrNoMoreRows) {
+		return domain.DefaultAnomalySettings(device.OrganizationId, device.Id), nil
+	}
+	if err != nil {
+		log.Printf("AnomalyService: %s", err)
+		return domain.AnomalySettings{}, err
+	}
+
+	return settings, nil
+}
+
+func (s *anomalyService) SaveSettings(settings domain.AnomalySettings) (domain.AnomalySettings, error) {
+	err := settings.Validate()
+	if err != nil {
+		return domain.AnomalySettings{}, err
+	}
+
+	settings, err = s.anomalyRepo.SaveSettings(settings)
+	if err != nil {
+		log.Printf("AnomalyService: %s", err)
+		return domain.AnomalySettings{}, err
+	}
+
+	s.Reset(settings.DeviceId)
+	return settings, nil
+}
+
+func (s *anomalyService) DeleteSettings(device domain.Device) error {
+	err := s.anomalyRepo.DeleteSettings(device.Id)
+	if err != nil {
+		log.Printf("AnomalyService: %s", err)
+		return err
+	}
+
+	s.Reset(device.Id)
+	return nil
+}
+
+func (s *anomalyService) FindByDeviceId(deviceId uint64, startDate, endDate time.Time) ([]domain.MeasurementAnomaly, error) {
+	anomalies, err := s.anomalyRepo.FindByDeviceId(deviceId, startDate, endDate)
+	if err != nil {
+		log.Printf("AnomalyService: %s", err)
+		return nil, err
+	}
+
+	return anomalies, nil
+}
+
+func (s *anomalyService) FindByOrgId(orgId uint64) ([]domain.MeasurementAnomaly, error) {
+	anomalies, err := s.anomalyRepo.FindByOrgId(orgId, anomalyHistoryLimit)
+	if err != nil {
+		log.Printf("AnomalyService: %s", err)
+		return nil, err
+	}
+
+	return anomalies, nil
+}
+
+// state returns the state of the series of the current reading, rebuilt from the readings before
+// it when the series wasn't seen since the start or since the settings of its sensor changed. The
+// caller holds mu.
+func (s *anomalyService) state(device domain.Device, current domain.Measurement) (*anomalyState, error) {
+	series := seriesOf(current)
+	if st, ok := s.states[series]; ok {
+		return st, nil
+	}
+
+	settings, err := s.FindSettings(device)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := s.measurementRepo.FindRecentByDeviceId(device.Id, current.ChannelId, uint(settings.Window)+1)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]float64, 0, len(recent))
+	for _, m := range recent {
+		if m.Id != current.Id {
+			history = append(history, m.Value)
+		}
+	}
+
+	st := newAnomalyState(settings, history)
+	s.states[series] = st
+	return st, nil
+}
+
+// Reset makes the detector forget the sensor and its channels, it rebuilds their state from the
+// stored readings.
+func (s *anomalyService) Reset(deviceId uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for series := range s.states {
+		if series.deviceId == deviceId {
+			delete(s.states, series)
+		}
+	}
+}
+
+func (s *anomalyService) notify(device domain.Device, a domain.MeasurementAnomaly) {
+	org, err := s.orgRepo.FindById(device.OrganizationId)
+	if err != nil {
+		log.Printf("AnomalyService: %s", err)
+		return
+	}
+
+	_, err = s.notificationService.Create(domain.Notification{
+		OrganizationId: device.OrganizationId,
+		UserId:         org.UserId,
+		Severity:       domain.WarningNotification,
+		Title:          fmt.Sprintf("Sensor %d reports %s readings", device.Id, anomalyTitles[a.Kind]),
+		Message:        fmt.Sprintf("Reading %d: %s.", a.MeasurementId, a.Reason),
+		Source:         "anomaly",
+		SourceId:       a.Id,
+	})
+	if err != nil {
+		log.Printf("AnomalyService: anomaly %d: %s", a.Id, err)
+	}
+}
+
+var anomalyTitles = map[domain.AnomalyKind]string{
+	domain.SpikeAnomaly:    "spiking",
+	domain.DriftAnomaly:    "drifting",
+	domain.StuckAnomaly:    "stuck",
+	domain.FlatlineAnomaly: "flatlined",
+}
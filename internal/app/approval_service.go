@@ -0,0 +1,217 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
+)
+
+const approvalExpiryInterval = 30 * time.Second
+
+var (
+	ErrNotManager      = errors.New("only managers of the organization may decide on approvals")
+	ErrOwnApproval     = errors.New("a command can't be approved by the member who requested it")
+	ErrApprovalDecided = errors.New("approval was already decided")
+)
+
+type ApprovalService interface {
+	Find(id uint64) (interface{}, error)
+	FindByOrgId(orgId uint64, status *domain.ApprovalStatus) ([]domain.CommandApproval, error)
+	Approve(a domain.CommandApproval, userId uint64) (domain.CommandApproval, error)
+	Reject(a domain.CommandApproval, userId uint64, comment *string) (domain.CommandApproval, error)
+	ExpireOverdue() error
+	Run(ctx context.Context)
+}
+
+type approvalService struct {
+	approvalRepo        database.ApprovalRepository
+	organizationService OrganizationService
+	commandService      CommandService
+}
+
+func NewApprovalService(ar database.ApprovalRepository, os OrganizationService, cs CommandService) ApprovalService {
+	return approvalService{
+		approvalRepo:        ar,
+		organizationService: os,
+		commandService:      cs,
+	}
+}
+
+func (s approvalService) Find(id uint64) (interface{}, error) {
+	a, err := s.approvalRepo.Find(id)
+	if err != nil {
+		log.Printf("ApprovalService: %s", err)
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (s approvalService) FindByOrgId(orgId uint64, status *domain.ApprovalStatus) ([]domain.CommandApproval, error) {
+	approvals, err := s.approvalRepo.FindByOrgId(orgId, status)
+	if err != nil {
+		log.Printf("ApprovalService: %s", err)
+		return nil, err
+	}
+
+	return approvals, nil
+}
+
+// Approve creates the held command on behalf of the member who requested it. When the command
+// can't be created, for example because of an interlock, the approval stays pending.
+func (s approvalService) Approve(a domain.CommandApproval, userId uint64) (domain.CommandApproval, error) {
+	now := time.Now()
+	err := s.checkDecision(a, userId, now)
+	if err != nil {
+		return domain.CommandApproval{}, err
+	}
+
+	a.Status = domain.ApprovalApproved
+	a.DecidedBy = &userId
+	a.DecidedDate = &now
+	a, err = s.decide(a)
+	if err != nil {
+		return domain.CommandApproval{}, err
+	}
+
+	c, err := s.commandService.Create(domain.Command{
+		DeviceId:     a.DeviceId,
+		UserId:       a.RequestedBy,
+		Action:       a.Action,
+		ActionParams: a.ActionParams,
+		ApprovalId:   &a.Id,
+		Override:     a.Override,
+	}, a.CommandTtl)
+	if errors.Is(err, domain.ErrDemandQueued) {
+		// the demand limit releases the command later, it keeps the approval
+		return a, err
+	}
+	if err != nil {
+		log.Printf("ApprovalService: Error creating command of approval %d: %s", a.Id, err)
+		a.Status = domain.ApprovalPending
+		a.DecidedBy = nil
+		a.DecidedDate = nil
+		_, uErr := s.approvalRepo.Update(a)
+		if uErr != nil {
+			log.Printf("ApprovalService: %s", uErr)
+		}
+		return domain.CommandApproval{}, err
+	}
+
+	a.CommandId = &c.Id
+	a, err = s.approvalRepo.Update(a)
+	if err != nil {
+		log.Printf("ApprovalService: %s", err)
+		return domain.CommandApproval{}, err
+	}
+
+	log.Printf("ApprovalService: Approval %d approved by user %d, command %d created", a.Id, userId, c.Id)
+	return a, nil
+}
+
+func (s approvalService) Reject(a domain.CommandApproval, userId uint64, comment *string) (domain.CommandApproval, error) {
+	now := time.Now()
+	err := s.checkDecision(a, userId, now)
+	if err != nil {
+		return domain.CommandApproval{}, err
+	}
+
+	a.Status = domain.ApprovalRejected
+	a.DecidedBy = &userId
+	a.Comment = comment
+	a.DecidedDate = &now
+	a, err = s.decide(a)
+	if err != nil {
+		return domain.CommandApproval{}, err
+	}
+
+	log.Printf("ApprovalService: Approval %d rejected by user %d", a.Id, userId)
+	return a, nil
+}
+
+func (s approvalService) ExpireOverdue() error {
+	approvals, err := s.approvalRepo.FindOverdue(time.Now())
+	if err != nil {
+		log.Printf("ApprovalService: %s", err)
+		return err
+	}
+
+	for _, a := range approvals {
+		_, err = s.expire(a)
+		if err != nil && !errors.Is(err, ErrApprovalDecided) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s approvalService) Run(ctx context.Context) {
+	ticker := time.NewTicker(approvalExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = s.ExpireOverdue()
+		}
+	}
+}
+
+// checkDecision makes sure the user may decide on the approval now.
+func (s approvalService) checkDecision(a domain.CommandApproval, userId uint64, now time.Time) error {
+	if !a.IsOpen() {
+		return ErrApprovalDecided
+	}
+	if now.After(a.ExpiresDate) {
+		_, err := s.expire(a)
+		if err != nil {
+			return err
+		}
+		return errors.New("approval has expired")
+	}
+	if a.RequestedBy == userId {
+		return ErrOwnApproval
+	}
+
+	role, ok, err := s.organizationService.MemberRole(a.OrganizationId, userId)
+	if err != nil {
+		return err
+	}
+	if !ok || role != domain.MemberRoleManager {
+		return ErrNotManager
+	}
+	return nil
+}
+
+func (s approvalService) decide(a domain.CommandApproval) (domain.CommandApproval, error) {
+	a, err := s.approvalRepo.Decide(a)
+	if errors.Is(err, db.ErrNoMoreRows) {
+		return domain.CommandApproval{}, ErrApprovalDecided
+	}
+	if err != nil {
+		log.Printf("ApprovalService: %s", err)
+		return domain.CommandApproval{}, err
+	}
+	return a, nil
+}
+
+func (s approvalService) expire(a domain.CommandApproval) (domain.CommandApproval, error) {
+	now := time.Now()
+	a.Status = domain.ApprovalExpired
+	a.DecidedDate = &now
+	a, err := s.decide(a)
+	if err != nil {
+		return domain.CommandApproval{}, err
+	}
+
+	log.Printf("ApprovalService: Approval %d expired", a.Id)
+	return a, nil
+}
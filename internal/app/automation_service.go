@@ -0,0 +1,651 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/pubsub"
+)
+
+const (
+	ruleTickInterval = 30 * time.Second
+	// maxRuleChainDepth limits how many rules may fire in a row because of events other rules created
+	maxRuleChainDepth = 3
+	// a rule firing more often than this is assumed to be fighting with another rule
+	ruleBurstWindow         = time.Minute
+	maxRuleFiringsPerWindow = 5
+	ruleExecutionsLimit     = 100
+	producedEventTTL        = 10 * time.Minute
+)
+
+type AutomationService interface {
+	Save(r domain.Rule) (domain.Rule, error)
+	Find(id uint64) (interface{}, error)
+	FindByOrgId(orgId uint64) ([]domain.Rule, error)
+	Update(r domain.Rule) (domain.Rule, error)
+	Delete(id uint64) error
+	FindExecutions(ruleId uint64) ([]domain.RuleExecution, error)
+	Run(ctx context.Context)
+}
+
+// ruleState is the in-memory evaluation state of a measurement rule.
+type ruleState struct {
+	matchingSince *time.Time
+	latched       bool
+	firings       []time.Time
+}
+
+type producedEvent struct {
+	depth uint
+	at    time.Time
+}
+
+type automationService struct {
+	ruleRepo        database.RuleRepository
+	deviceRepo      database.DeviceRepository
+	channelRepo     database.ChannelRepository
+	roomRepo        database.RoomRepository
+	orgRepo         database.OrganizationRepository
+	measurementRepo database.MeasurementRepository
+	eventService    EventService
+	hub             pubsub.Hub
+
+	mu       sync.Mutex
+	states   map[uint64]*ruleState
+	produced map[uint64]producedEvent
+}
+
+func NewAutomationService(rr database.RuleRepository, dr database.DeviceRepository, chr database.ChannelRepository, ror database.RoomRepository, or database.OrganizationRepository, mr database.MeasurementRepository, es EventService, h pubsub.Hub) AutomationService {
+	return &automationService{
+		ruleRepo:        rr,
+		deviceRepo:      dr,
+		channelRepo:     chr,
+		roomRepo:        ror,
+		orgRepo:         or,
+		measurementRepo: mr,
+		eventService:    es,
+		hub:             h,
+		states:          make(map[uint64]*ruleState),
+		produced:        make(map[uint64]producedEvent),
+	}
+}
+
+func (s *automationService) Save(r domain.Rule) (domain.Rule, error) {
+	err := s.validate(r)
+	if err != nil {
+		return domain.Rule{}, err
+	}
+
+	r, err = s.ruleRepo.Save(r)
+	if err != nil {
+		log.Printf("AutomationService: Error saving rule: %s", err)
+		return domain.Rule{}, err
+	}
+
+	return r, nil
+}
+
+func (s *automationService) Find(id uint64) (interface{}, error) {
+	r, err := s.ruleRepo.Find(id)
+	if err != nil {
+		log.Printf("AutomationService: %s", err)
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (s *automationService) FindByOrgId(orgId uint64) ([]domain.Rule, error) {
+	rules, err := s.ruleRepo.FindByOrgId(orgId)
+	if err != nil {
+		log.Printf("AutomationService: %s", err)
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (s *automationService) Update(r domain.Rule) (domain.Rule, error) {
+	err := s.validate(r)
+	if err != nil {
+		return domain.Rule{}, err
+	}
+
+	r, err = s.ruleRepo.Update(r)
+	if err != nil {
+		log.Printf("AutomationService: Error updating rule: %s", err)
+		return domain.Rule{}, err
+	}
+
+	s.resetState(r.Id)
+	return r, nil
+}
+
+func (s *automationService) Delete(id uint64) error {
+	err := s.ruleRepo.Delete(id)
+	if err != nil {
+		log.Printf("AutomationService: Error deleting rule: %s", err)
+		return err
+	}
+
+	s.resetState(id)
+	return nil
+}
+
+func (s *automationService) FindExecutions(ruleId uint64) ([]domain.RuleExecution, error) {
+	execs, err := s.ruleRepo.FindExecutions(ruleId, ruleExecutionsLimit)
+	if err != nil {
+		log.Printf("AutomationService: %s", err)
+		return nil, err
+	}
+
+	return execs, nil
+}
+
+// Run evaluates the rules against new measurements and events, and fires time triggers.
+func (s *automationService) Run(ctx context.Context) {
+	sub := s.hub.Subscribe(pubsub.Filter{Types: []pubsub.MessageType{pubsub.MeasurementSaved, pubsub.EventSaved}})
+	defer s.hub.Unsubscribe(sub)
+
+	ticker := time.NewTicker(ruleTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m := <-sub.C:
+			switch p := m.Payload.(type) {
+			case domain.Measurement:
+				s.onMeasurement(m.OrganizationId, p)
+			case domain.Event:
+				s.onEvent(m.OrganizationId, p)
+			}
+		case now := <-ticker.C:
+			s.onTick(now)
+		}
+	}
+}
+
+func (s *automationService) onMeasurement(orgId uint64, m domain.Measurement) {
+	if !m.Usable() {
+		return
+	}
+	rules, err := s.ruleRepo.FindEnabledByOrgId(orgId)
+	if err != nil {
+		log.Printf("AutomationService: %s", err)
+		return
+	}
+
+	var (
+		units         *string
+		unitsResolved bool
+	)
+	for _, r := range rules {
+		t := r.Trigger
+		if t.Type != domain.MeasurementTrigger {
+			continue
+		}
+		if t.DeviceId != nil && (*t.DeviceId != m.DeviceId || !sameId(t.ChannelId, m.ChannelId)) {
+			continue
+		}
+		if t.DeviceId == nil {
+			if m.RoomId == nil || *t.RoomId != *m.RoomId {
+				continue
+			}
+			if t.Units != nil {
+				if !unitsResolved {
+					units, err = s.readingUnits(m)
+					if err != nil {
+						log.Printf("AutomationService: %s", err)
+						return
+					}
+					unitsResolved = true
+				}
+				if units == nil || !domain.SameUnits(*units, *t.Units) {
+					continue
+				}
+			}
+		}
+
+		state := s.state(r.Id)
+		if !t.Operator.Compare(m.Value, *t.Threshold) {
+			s.mu.Lock()
+			state.matchingSince, state.latched = nil, false
+			s.mu.Unlock()
+			continue
+		}
+
+		s.mu.Lock()
+		if state.matchingSince == nil {
+			since := m.CreatedDate
+			state.matchingSince = &since
+		}
+		s.mu.Unlock()
+
+		cause := fmt.Sprintf("measurement %g from device %d %s %g", m.Value, m.DeviceId, t.Operator, *t.Threshold)
+		s.fireIfHeld(r, cause, time.Now())
+	}
+}
+
+func (s *automationService) onEvent(orgId uint64, e domain.Event) {
+	s.mu.Lock()
+	origin, produced := s.produced[e.Id]
+	s.mu.Unlock()
+
+	var depth uint
+	if produced {
+		depth = origin.depth
+	}
+
+	rules, err := s.ruleRepo.FindEnabledByOrgId(orgId)
+	if err != nil {
+		log.Printf("AutomationService: %s", err)
+		return
+	}
+
+	for _, r := range rules {
+		t := r.Trigger
+		if t.Type != domain.EventTrigger || *t.DeviceId != e.DeviceId {
+			continue
+		}
+		if t.Action != nil && *t.Action != e.Action {
+			continue
+		}
+
+		s.fire(r, fmt.Sprintf("event %s on device %d", e.Action, e.DeviceId), depth)
+	}
+}
+
+func (s *automationService) onTick(now time.Time) {
+	rules, err := s.ruleRepo.FindEnabled()
+	if err != nil {
+		log.Printf("AutomationService: %s", err)
+		return
+	}
+
+	for _, r := range rules {
+		switch r.Trigger.Type {
+		case domain.MeasurementTrigger:
+			s.fireIfHeld(r, "measurement threshold held", now)
+		case domain.TimeTrigger:
+			if s.timeTriggerDue(r, now) {
+				s.fire(r, "scheduled at "+r.Trigger.At, 0)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	for id, p := range s.produced {
+		if now.Sub(p.at) > producedEventTTL {
+			delete(s.produced, id)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// fireIfHeld fires a measurement rule once its comparison has held for the configured time.
+// The rule fires again only after the comparison stopped holding in between.
+func (s *automationService) fireIfHeld(r domain.Rule, cause string, now time.Time) {
+	state := s.state(r.Id)
+
+	s.mu.Lock()
+	due := !state.latched && state.matchingSince != nil &&
+		now.Sub(*state.matchingSince) >= time.Duration(r.Trigger.ForSeconds)*time.Second
+	if due {
+		state.latched = true
+	}
+	s.mu.Unlock()
+
+	if due {
+		s.fire(r, cause, 0)
+	}
+}
+
+// timeTriggerDue is true in the tick after the time of day was reached, when the rule
+// hasn't fired for it yet. Times are in the timezone of the organization.
+func (s *automationService) timeTriggerDue(r domain.Rule, now time.Time) bool {
+	org := s.organization(r.OrganizationId)
+	now = now.In(org.Location())
+	if len(r.Trigger.Weekdays) > 0 && !slices.Contains(r.Trigger.Weekdays, int(now.Weekday())) {
+		return false
+	}
+
+	spec, err := domain.ParseClockSpec(r.Trigger.At)
+	if err != nil {
+		return false
+	}
+	fireAt, ok := spec.On(now, org.Lat, org.Lon)
+	if !ok {
+		return false
+	}
+	if now.Before(fireAt) || now.Sub(fireAt) > 2*ruleTickInterval {
+		return false
+	}
+
+	return r.LastFiredDate == nil || r.LastFiredDate.Before(fireAt)
+}
+
+// fire checks the loop protection and the conditions, then runs the actions of the rule.
+// Every attempt is recorded in the execution history.
+func (s *automationService) fire(r domain.Rule, cause string, depth uint) {
+	now := time.Now()
+	exec := domain.RuleExecution{
+		RuleId:         r.Id,
+		OrganizationId: r.OrganizationId,
+		Cause:          cause,
+		Depth:          depth,
+	}
+
+	switch {
+	case depth >= maxRuleChainDepth:
+		exec.Status = domain.RuleSuppressed
+		exec.Reason = reason("triggered by a chain of %d rules, possible automation loop", depth)
+	case r.LastFiredDate != nil && now.Sub(*r.LastFiredDate) < time.Duration(r.CooldownSeconds)*time.Second:
+		exec.Status = domain.RuleSkipped
+		exec.Reason = reason("cooling down since %s", r.LastFiredDate.Format(time.RFC3339))
+	case s.bursting(r.Id, now):
+		exec.Status = domain.RuleSuppressed
+		exec.Reason = reason("fired more than %d times in %s, possible automation loop", maxRuleFiringsPerWindow, ruleBurstWindow)
+	default:
+		failed, err := s.checkConditions(r, now)
+		if err != nil {
+			exec.Status = domain.RuleFailed
+			exec.Reason = reason("%s", err)
+		} else if failed != "" {
+			exec.Status = domain.RuleSkipped
+			exec.Reason = &failed
+		} else {
+			s.runActions(r, &exec)
+		}
+	}
+
+	if exec.Status == domain.RuleExecuted || exec.Status == domain.RuleDryRun {
+		err := s.ruleRepo.MarkFired(r.Id, now)
+		if err != nil {
+			log.Printf("AutomationService: %s", err)
+		}
+	}
+
+	exec, err := s.ruleRepo.SaveExecution(exec)
+	if err != nil {
+		log.Printf("AutomationService: %s", err)
+		return
+	}
+
+	log.Printf("AutomationService: Rule %d %s: %s", r.Id, exec.Status, cause)
+	s.hub.Publish(pubsub.Message{
+		Type:           pubsub.RuleExecuted,
+		OrganizationId: r.OrganizationId,
+		Payload:        exec,
+		CreatedDate:    exec.CreatedDate,
+	})
+}
+
+func (s *automationService) runActions(r domain.Rule, exec *domain.RuleExecution) {
+	exec.Status = domain.RuleExecuted
+	if r.DryRun {
+		exec.Status = domain.RuleDryRun
+	}
+
+	failures := 0
+	for _, a := range r.Actions {
+		res := domain.RuleActionResult{DeviceId: a.DeviceId, Action: a.Action}
+		if r.DryRun {
+			exec.Results = append(exec.Results, res)
+			continue
+		}
+
+		device, err := s.deviceRepo.Find(a.DeviceId)
+		if err == nil {
+			var event domain.Event
+			event, err = s.eventService.Save(domain.Event{
+				DeviceId:     a.DeviceId,
+				RoomId:       device.RoomId,
+				Action:       a.Action,
+				ActionParams: a.Params(),
+			})
+			if err == nil {
+				res.EventId = &event.Id
+				s.mu.Lock()
+				s.produced[event.Id] = producedEvent{depth: exec.Depth + 1, at: time.Now()}
+				s.mu.Unlock()
+			}
+		}
+		if err != nil && !errors.Is(err, domain.ErrNoStateChange) {
+			failures++
+		}
+		if err != nil {
+			msg := err.Error()
+			res.Error = &msg
+		}
+		exec.Results = append(exec.Results, res)
+	}
+
+	if failures == len(r.Actions) {
+		exec.Status = domain.RuleFailed
+		exec.Reason = reason("all actions failed")
+	}
+}
+
+// checkConditions returns a description of the first condition that doesn't hold.
+func (s *automationService) checkConditions(r domain.Rule, now time.Time) (string, error) {
+	for _, c := range r.Conditions {
+		switch c.Type {
+		case domain.DeviceStateCondition:
+			device, err := s.deviceRepo.Find(*c.DeviceId)
+			if err != nil {
+				return "", err
+			}
+			if device.CurrentState == nil || *device.CurrentState != *c.State {
+				return fmt.Sprintf("device %d is not %s", device.Id, *c.State), nil
+			}
+		case domain.MeasurementCondition:
+			m, err := s.measurementRepo.FindLatestByDeviceId(*c.DeviceId, c.ChannelId)
+			if err != nil {
+				return fmt.Sprintf("device %d has no measurements", *c.DeviceId), nil
+			}
+			if !c.Operator.Compare(m.Value, *c.Threshold) {
+				return fmt.Sprintf("measurement %g of device %d is not %s %g", m.Value, *c.DeviceId, c.Operator, *c.Threshold), nil
+			}
+		case domain.TimeWindowCondition:
+			if !inTimeWindow(s.organization(r.OrganizationId), now, c.From, c.To) {
+				return fmt.Sprintf("outside of %s-%s", c.From, c.To), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// validate checks that all devices and rooms of the rule belong to its organization.
+func (s *automationService) validate(r domain.Rule) error {
+	err := r.Validate()
+	if err != nil {
+		return err
+	}
+
+	t := r.Trigger
+	if t.DeviceId != nil {
+		device, err := s.orgDevice(r.OrganizationId, *t.DeviceId)
+		if err != nil {
+			return err
+		}
+		if t.Type == domain.MeasurementTrigger && device.Category != domain.Sensor {
+			return errors.New("measurement trigger needs a sensor")
+		}
+		if t.Type == domain.MeasurementTrigger {
+			err = s.validateChannel(device, t.ChannelId)
+			if err != nil {
+				return err
+			}
+		}
+		if t.Type == domain.EventTrigger && device.Category != domain.Actuator {
+			return errors.New("event trigger needs an actuator")
+		}
+	} else if t.RoomId != nil {
+		room, err := s.roomRepo.Find(*t.RoomId)
+		if err != nil || room.OrganizationId != r.OrganizationId {
+			return fmt.Errorf("room %d not found", *t.RoomId)
+		}
+		if t.Units != nil {
+			_, err = domain.ParseUnit(*t.Units)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, c := range r.Conditions {
+		if c.DeviceId == nil {
+			continue
+		}
+		device, err := s.orgDevice(r.OrganizationId, *c.DeviceId)
+		if err != nil {
+			return err
+		}
+		if c.Type == domain.MeasurementCondition {
+			err = s.validateChannel(device, c.ChannelId)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, a := range r.Actions {
+		device, err := s.orgDevice(r.OrganizationId, a.DeviceId)
+		if err != nil {
+			return err
+		}
+		err = device.ValidateAction(a.Action, a.Params())
+		if err != nil {
+			return fmt.Errorf("device %d: %w", a.DeviceId, err)
+		}
+	}
+
+	return nil
+}
+
+// validateChannel checks that a measurement trigger or condition watches a channel of a sensor
+// with channels, and no channel of one without.
+func (s *automationService) validateChannel(device domain.Device, channelId *uint64) error {
+	channels, err := s.channelRepo.FindByDeviceId(device.Id)
+	if err != nil {
+		return err
+	}
+	device.Channels = channels
+	return device.ValidateReadingChannel(channelId)
+}
+
+// readingUnits returns the units of the channel of the reading, or of its sensor without one.
+func (s *automationService) readingUnits(m domain.Measurement) (*string, error) {
+	if m.ChannelId != nil {
+		ch, err := s.channelRepo.Find(*m.ChannelId)
+		if err != nil {
+			return nil, err
+		}
+		return ch.Units, nil
+	}
+	device, err := s.deviceRepo.Find(m.DeviceId)
+	if err != nil {
+		return nil, err
+	}
+	return device.Units, nil
+}
+
+func (s *automationService) orgDevice(orgId, deviceId uint64) (domain.Device, error) {
+	device, err := s.deviceRepo.Find(deviceId)
+	if err != nil {
+		return domain.Device{}, err
+	}
+	if device.Id == 0 || device.DeletedDate != nil || device.OrganizationId != orgId {
+		return domain.Device{}, fmt.Errorf("device %d not found", deviceId)
+	}
+	return device, nil
+}
+
+// organization is used for its timezone and location, an unknown organization works in UTC.
+func (s *automationService) organization(orgId uint64) domain.Organization {
+	org, err := s.orgRepo.FindById(orgId)
+	if err != nil {
+		log.Printf("AutomationService: %s", err)
+		return domain.Organization{Id: orgId}
+	}
+	return org
+}
+
+func (s *automationService) state(ruleId uint64) *ruleState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.states[ruleId]
+	if !ok {
+		st = &ruleState{}
+		s.states[ruleId] = st
+	}
+	return st
+}
+
+func (s *automationService) resetState(ruleId uint64) {
+	s.mu.Lock()
+	delete(s.states, ruleId)
+	s.mu.Unlock()
+}
+
+// bursting records a firing and reports whether the rule fired too often recently.
+func (s *automationService) bursting(ruleId uint64, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.states[ruleId]
+	if !ok {
+		st = &ruleState{}
+		s.states[ruleId] = st
+	}
+
+	recent := st.firings[:0]
+	for _, f := range st.firings {
+		if now.Sub(f) < ruleBurstWindow {
+			recent = append(recent, f)
+		}
+	}
+	st.firings = append(recent, now)
+	return len(st.firings) > maxRuleFiringsPerWindow
+}
+
+func inTimeWindow(org domain.Organization, now time.Time, from, to string) bool {
+	now = now.In(org.Location())
+	fromSpec, err := domain.ParseClockSpec(from)
+	if err != nil {
+		return false
+	}
+	toSpec, err := domain.ParseClockSpec(to)
+	if err != nil {
+		return false
+	}
+	f, ok := fromSpec.On(now, org.Lat, org.Lon)
+	if !ok {
+		return false
+	}
+	t, ok := toSpec.On(now, org.Lat, org.Lon)
+	if !ok {
+		return false
+	}
+
+	if !t.Before(f) {
+		return !now.Before(f) && now.Before(t)
+	}
+	// the window wraps around midnight
+	return !now.Before(f) || now.Before(t)
+}
+
+func reason(format string, args ...interface{}) *string {
+	r := fmt.Sprintf(format, args...)
+	return &r
+}
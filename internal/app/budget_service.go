@@ -0,0 +1,296 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
+)
+
+const (
+	budgetTickInterval = 15 * time.Minute
+	// a projection from less than a day of the month is too noisy to alert on
+	minProjectionElapsed = 24 * time.Hour
+)
+
+type BudgetService interface {
+	Save(b domain.Budget) (domain.Budget, error)
+	Find(id uint64) (interface{}, error)
+	FindByOrgId(orgId uint64) ([]domain.Budget, error)
+	Update(b domain.Budget) (domain.Budget, error)
+	Delete(id uint64) error
+	Status(b domain.Budget, now time.Time) (domain.BudgetStatus, error)
+	Run(ctx context.Context)
+}
+
+type budgetService struct {
+	budgetRepo          database.BudgetRepository
+	orgRepo             database.OrganizationRepository
+	roomRepo            database.RoomRepository
+	tariffRepo          database.TariffRepository
+	eventService        EventService
+	notificationService NotificationService
+}
+
+func NewBudgetService(br database.BudgetRepository, or database.OrganizationRepository, rr database.RoomRepository, tr database.TariffRepository, es EventService, ns NotificationService) BudgetService {
+	return budgetService{
+		budgetRepo:          br,
+		orgRepo:             or,
+		roomRepo:            rr,
+		tariffRepo:          tr,
+		eventService:        es,
+		notificationService: ns,
+	}
+}
+
+func (s budgetService) Save(b domain.Budget) (domain.Budget, error) {
+	err := s.validate(b)
+	if err != nil {
+		return domain.Budget{}, err
+	}
+
+	b, err = s.budgetRepo.Save(b)
+	if err != nil {
+		log.Printf("BudgetService: %s", err)
+		return domain.Budget{}, err
+	}
+
+	return b, nil
+}
+
+func (s budgetService) Find(id uint64) (interface{}, error) {
+	b, err := s.budgetRepo.Find(id)
+	if err != nil {
+		log.Printf("BudgetService: %s", err)
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (s budgetService) FindByOrgId(orgId uint64) ([]domain.Budget, error) {
+	budgets, err := s.budgetRepo.FindByOrgId(orgId)
+	if err != nil {
+		log.Printf("BudgetService: %s", err)
+		return nil, err
+	}
+
+	return budgets, nil
+}
+
+func (s budgetService) Update(b domain.Budget) (domain.Budget, error) {
+	err := s.validate(b)
+	if err != nil {
+		return domain.Budget{}, err
+	}
+
+	b, err = s.budgetRepo.Update(b)
+	if err != nil {
+		log.Printf("BudgetService: %s", err)
+		return domain.Budget{}, err
+	}
+
+	return b, nil
+}
+
+func (s budgetService) Delete(id uint64) error {
+	err := s.budgetRepo.Delete(id)
+	if err != nil {
+		log.Printf("BudgetService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+// Status measures the use of the budget in the calendar month of now, in the timezone of the
+// organization, and projects it linearly to the end of the month.
+func (s budgetService) Status(b domain.Budget, now time.Time) (domain.BudgetStatus, error) {
+	org, err := s.orgRepo.FindById(b.OrganizationId)
+	if err != nil {
+		log.Printf("BudgetService: %s", err)
+		return domain.BudgetStatus{}, err
+	}
+
+	month, err := domain.PeriodContaining(domain.MonthPeriod, now, org.Location())
+	if err != nil {
+		return domain.BudgetStatus{}, err
+	}
+	status := domain.BudgetStatus{
+		BudgetId: b.Id,
+		Metric:   b.Metric,
+		Month:    month,
+		AsOf:     now.In(month.Location),
+		Amount:   b.Amount,
+	}
+	sofar := domain.Period{Start: month.Start, End: now, Location: month.Location}
+
+	switch b.Metric {
+	case domain.CostBudget:
+		tariff, err := s.tariffRepo.FindActive(b.OrganizationId)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.BudgetStatus{}, errors.New("organization has no active tariff to price the budget")
+		}
+		if err != nil {
+			log.Printf("BudgetService: %s", err)
+			return domain.BudgetStatus{}, err
+		}
+		cost, err := s.eventService.GetEnergyCost(b.Scope(), sofar, tariff)
+		if err != nil {
+			return domain.BudgetStatus{}, err
+		}
+		status.Used, status.Currency = cost.Cost, cost.Currency
+	default:
+		status.Used, err = s.eventService.GetEnergy(b.Scope(), sofar.Start, sofar.End)
+		if err != nil {
+			return domain.BudgetStatus{}, err
+		}
+	}
+
+	status.Projected = status.Used
+	if elapsed := sofar.Duration(); elapsed > 0 {
+		status.Projected = status.Used * float64(month.Duration()) / float64(elapsed)
+	}
+	return status, nil
+}
+
+// Run evaluates the enabled budgets periodically and notifies the owner of the organization.
+func (s budgetService) Run(ctx context.Context) {
+	ticker := time.NewTicker(budgetTickInterval)
+	defer ticker.Stop()
+
+	s.evaluate(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.evaluate(now)
+		}
+	}
+}
+
+func (s budgetService) evaluate(now time.Time) {
+	budgets, err := s.budgetRepo.FindEnabled()
+	if err != nil {
+		log.Printf("BudgetService: %s", err)
+		return
+	}
+
+	for _, b := range budgets {
+		status, err := s.Status(b, now)
+		if err != nil {
+			log.Printf("BudgetService: budget %d: %s", b.Id, err)
+			continue
+		}
+		s.alertThresholds(b, status)
+		s.alertProjection(b, status)
+	}
+}
+
+// alertThresholds records every threshold the use has reached. When several are reached at
+// once only the highest one is notified.
+func (s budgetService) alertThresholds(b domain.Budget, status domain.BudgetStatus) {
+	var highest float64
+	for _, t := range status.CrossedThresholds(b) {
+		fresh, err := s.budgetRepo.SaveAlert(s.alert(b, status, domain.ThresholdAlert, t))
+		if err != nil {
+			return
+		}
+		if fresh {
+			highest = t
+		}
+	}
+	if highest == 0 {
+		return
+	}
+
+	severity := domain.WarningNotification
+	if highest >= 100 {
+		severity = domain.CriticalNotification
+	}
+	s.notify(b, severity,
+		fmt.Sprintf("%s reached %g%% of its budget", b.Name, highest),
+		fmt.Sprintf("%s of %s used in %s (%.0f%%).",
+			budgetAmount(status.Used, status), budgetAmount(b.Amount, status), status.Month.Start.Format("January 2006"), status.Percent()),
+	)
+}
+
+// alertProjection warns once a month when the budget isn't exhausted yet but is projected to be.
+func (s budgetService) alertProjection(b domain.Budget, status domain.BudgetStatus) {
+	if !b.AlertOnProjection || status.Used >= b.Amount || status.Projected <= b.Amount {
+		return
+	}
+	if status.AsOf.Sub(status.Month.Start) < minProjectionElapsed {
+		return
+	}
+
+	fresh, err := s.budgetRepo.SaveAlert(s.alert(b, status, domain.ProjectionAlert, 0))
+	if err != nil || !fresh {
+		return
+	}
+	s.notify(b, domain.WarningNotification,
+		fmt.Sprintf("%s is projected to overrun its budget", b.Name),
+		fmt.Sprintf("At the current rate %s will be used by the end of %s, the budget is %s (%.0f%%).",
+			budgetAmount(status.Projected, status), status.Month.Start.Format("January 2006"), budgetAmount(b.Amount, status), status.ProjectedPercent()),
+	)
+}
+
+func (s budgetService) alert(b domain.Budget, status domain.BudgetStatus, kind domain.BudgetAlertKind, threshold float64) domain.BudgetAlert {
+	return domain.BudgetAlert{
+		BudgetId:  b.Id,
+		Month:     status.Month.Start.Format("2006-01"),
+		Kind:      kind,
+		Threshold: threshold,
+		Used:      status.Used,
+		Projected: status.Projected,
+	}
+}
+
+func (s budgetService) notify(b domain.Budget, severity domain.NotificationSeverity, title, message string) {
+	org, err := s.orgRepo.FindById(b.OrganizationId)
+	if err != nil {
+		log.Printf("BudgetService: %s", err)
+		return
+	}
+
+	_, err = s.notificationService.Create(domain.Notification{
+		OrganizationId: b.OrganizationId,
+		UserId:         org.UserId,
+		Severity:       severity,
+		Title:          title,
+		Message:        message,
+		Source:         "budget",
+		SourceId:       b.Id,
+	})
+	if err != nil {
+		log.Printf("BudgetService: budget %d: %s", b.Id, err)
+	}
+}
+
+func (s budgetService) validate(b domain.Budget) error {
+	err := b.Validate()
+	if err != nil {
+		return err
+	}
+
+	if b.RoomId != nil {
+		room, err := s.roomRepo.Find(*b.RoomId)
+		if err != nil || room.OrganizationId != b.OrganizationId {
+			return fmt.Errorf("room %d not found", *b.RoomId)
+		}
+	}
+	return nil
+}
+
+func budgetAmount(v float64, status domain.BudgetStatus) string {
+	if status.Metric == domain.CostBudget {
+		return fmt.Sprintf("%.2f %s", v, status.Currency)
+	}
+	return fmt.Sprintf("%.1f kWh", v)
+}
@@ -0,0 +1,202 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
+)
+
+type CalibrationService interface {
+	Save(c domain.Calibration) (domain.Calibration, error)
+	Find(id uint64) (interface{}, error)
+	FindByDeviceId(deviceId uint64) ([]domain.Calibration, error)
+	Recompute(c domain.Calibration) (int, error)
+	Delete(c domain.Calibration, recompute bool) (int, error)
+}
+
+type calibrationService struct {
+	calibrationRepo database.CalibrationRepository
+	measurementRepo database.MeasurementRepository
+	deviceRepo      database.DeviceRepository
+	channelRepo     database.ChannelRepository
+	anomalyService  AnomalyService
+}
+
+func NewCalibrationService(clr database.CalibrationRepository, mr database.MeasurementRepository, dr database.DeviceRepository, chr database.ChannelRepository, as AnomalyService) CalibrationService {
+	return calibrationService{
+		calibrationRepo: clr,
+		measurementRepo: mr,
+		deviceRepo:      dr,
+		channelRepo:     chr,
+		anomalyService:  as,
+	}
+}
+
+func (s calibrationService) Save(c domain.Calibration) (domain.Calibration, error) {
+	err := c.Validate()
+	if err != nil {
+		return domain.Calibration{}, err
+	}
+	device, err := s.deviceRepo.Find(c.DeviceId)
+	if err != nil || device.DeletedDate != nil || device.OrganizationId != c.OrganizationId {
+		return domain.Calibration{}, errors.New("device not found in organization")
+	}
+	if device.Category != domain.Sensor {
+		return domain.Calibration{}, errors.New("only sensors can be calibrated")
+	}
+	device.Channels, err = s.channelRepo.FindByDeviceId(device.Id)
+	if err != nil {
+		log.Printf("CalibrationService: %s", err)
+		return domain.Calibration{}, err
+	}
+	err = device.ValidateReadingChannel(c.ChannelId)
+	if err != nil {
+		return domain.Calibration{}, err
+	}
+
+	c, err = s.calibrationRepo.Save(c)
+	if err != nil {
+		log.Printf("CalibrationService: %s", err)
+		return domain.Calibration{}, err
+	}
+
+	// the detector would take the corrected readings for a drift
+	s.anomalyService.Reset(c.DeviceId)
+	log.Printf("CalibrationService: Device %d calibrated from %s", c.DeviceId, c.EffectiveFrom.Format(time.RFC3339))
+	return c, nil
+}
+
+func (s calibrationService) Find(id uint64) (interface{}, error) {
+	c, err := s.calibrationRepo.Find(id)
+	if err != nil {
+		log.Printf("CalibrationService: %s", err)
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (s calibrationService) FindByDeviceId(deviceId uint64) ([]domain.Calibration, error) {
+	calibrations, err := s.calibrationRepo.FindByDeviceId(deviceId)
+	if err != nil {
+		log.Printf("CalibrationService: %s", err)
+		return nil, err
+	}
+
+	return calibrations, nil
+}
+
+// Recompute corrects the readings taken while the calibration was in effect by it, for a
+// calibration which was applied retroactively. It returns how many readings it corrected.
+func (s calibrationService) Recompute(c domain.Calibration) (int, error) {
+	return s.recompute(c.DeviceId, c.ChannelId, c.EffectiveFrom, &c)
+}
+
+// Delete removes the calibration from the history. With recompute the readings taken while it
+// was in effect are corrected by the calibration before it again.
+func (s calibrationService) Delete(c domain.Calibration, recompute bool) (int, error) {
+	err := s.calibrationRepo.Delete(c.Id)
+	if err != nil {
+		log.Printf("CalibrationService: %s", err)
+		return 0, err
+	}
+	s.anomalyService.Reset(c.DeviceId)
+	if !recompute {
+		return 0, nil
+	}
+
+	var previous *domain.Calibration
+	p, err := s.calibrationRepo.FindEffective(c.DeviceId, c.ChannelId, c.EffectiveFrom)
+	if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
+		log.Printf("CalibrationService: %s", err)
+		return 0, err
+	}
+	if err == nil {
+		previous = &p
+	}
+	return s.recompute(c.DeviceId, c.ChannelId, c.EffectiveFrom, previous)
+}
+
+// recompute corrects the readings of the device or its channel from the time until the next
+// calibration takes effect by c, or leaves them raw when c is nil. Their quality is assessed
+// again in order.
+func (s calibrationService) recompute(deviceId uint64, channelId *uint64, from time.Time, c *domain.Calibration) (int, error) {
+	device, err := s.deviceRepo.Find(deviceId)
+	if err != nil {
+		log.Printf("CalibrationService: %s", err)
+		return 0, err
+	}
+	if channelId != nil {
+		ch, err := s.channelRepo.Find(*channelId)
+		if err != nil {
+			log.Printf("CalibrationService: %s", err)
+			return 0, err
+		}
+		device = device.ForChannel(&ch)
+	}
+
+	to := time.Now().Add(time.Minute)
+	next, err := s.calibrationRepo.FindNext(deviceId, channelId, from)
+	if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
+		log.Printf("CalibrationService: %s", err)
+		return 0, err
+	}
+	if err == nil {
+		to = next.EffectiveFrom
+	}
+
+	all, err := s.measurementRepo.FindByDeviceAndDate(deviceId, from, to)
+	if err != nil {
+		log.Printf("CalibrationService: %s", err)
+		return 0, err
+	}
+	var readings []domain.Measurement
+	for _, m := range all {
+		if sameId(m.ChannelId, channelId) {
+			readings = append(readings, m)
+		}
+	}
+	sort.Slice(readings, func(i, j int) bool {
+		if readings[i].CreatedDate.Equal(readings[j].CreatedDate) {
+			return readings[i].Id < readings[j].Id
+		}
+		return readings[i].CreatedDate.Before(readings[j].CreatedDate)
+	})
+
+	var prev *domain.Measurement
+	before, err := s.measurementRepo.FindLastBefore(deviceId, channelId, from)
+	if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
+		log.Printf("CalibrationService: %s", err)
+		return 0, err
+	}
+	if err == nil {
+		prev = &before
+	}
+
+	for i := range readings {
+		m := &readings[i]
+		m.Value = c.Apply(m.RawValue)
+		m.CalibrationId = nil
+		if c != nil {
+			m.CalibrationId = &c.Id
+		}
+		m.Quality, m.QualityReason = device.AssessReading(m.Value, m.CreatedDate, prev)
+		if m.Usable() {
+			prev = m
+		}
+	}
+
+	err = s.measurementRepo.UpdateValues(readings)
+	if err != nil {
+		return 0, err
+	}
+
+	s.anomalyService.Reset(deviceId)
+	log.Printf("CalibrationService: %d readings of device %d recomputed", len(readings), deviceId)
+	return len(readings), nil
+}
@@ -0,0 +1,364 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/pubsub"
+)
+
+const (
+	DefaultCommandTTL     = 5 * time.Minute
+	DefaultApprovalTTL    = 30 * time.Minute
+	commandExpiryInterval = 10 * time.Second
+)
+
+type CommandService interface {
+	Create(c domain.Command, ttl time.Duration) (domain.Command, error)
+	Find(id uint64) (interface{}, error)
+	FindByDeviceId(deviceId uint64) ([]domain.Command, error)
+	Poll(ctx context.Context, device domain.Device, wait time.Duration) ([]domain.Command, error)
+	MarkDelivered(c domain.Command) (domain.Command, error)
+	Acknowledge(device domain.Device, commandId uint64, success bool, errMsg string) (domain.Command, error)
+	ExpireOverdue() error
+	Run(ctx context.Context)
+}
+
+type commandService struct {
+	commandRepo  database.CommandRepository
+	deviceRepo   database.DeviceRepository
+	approvalRepo database.ApprovalRepository
+	eventService EventService
+	hub          pubsub.Hub
+}
+
+func NewCommandService(cr database.CommandRepository, dr database.DeviceRepository, ar database.ApprovalRepository, es EventService, h pubsub.Hub) CommandService {
+	return &commandService{
+		commandRepo:  cr,
+		deviceRepo:   dr,
+		approvalRepo: ar,
+		eventService: es,
+		hub:          h,
+	}
+}
+
+func (s *commandService) Create(c domain.Command, ttl time.Duration) (domain.Command, error) {
+	device, err := s.deviceRepo.Find(c.DeviceId)
+	if err != nil {
+		log.Printf("CommandService: %s", err)
+		return domain.Command{}, err
+	}
+	if device.Id == 0 {
+		return domain.Command{}, errors.New("device not found")
+	}
+	err = device.ValidateAction(c.Action, c.ActionParams)
+	if err != nil {
+		return domain.Command{}, err
+	}
+	if c.Action == domain.SetSetpoint && c.SetpointUnit == nil {
+		c.SetpointUnit = &device.Capabilities.Setpoint.Unit
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultCommandTTL
+	}
+	if device.Critical && c.ApprovalId == nil {
+		// the interlocks are checked again once the approved command is created
+		err = s.eventService.CheckInterlocks(c.DeviceId, c.Action, c.Override)
+		if err != nil {
+			return domain.Command{}, err
+		}
+		return domain.Command{}, s.requestApproval(device, c, ttl)
+	}
+
+	userId := c.UserId
+	req := domain.DemandRequest{
+		DeviceId:     c.DeviceId,
+		UserId:       &userId,
+		Source:       domain.CommandDemand,
+		Action:       c.Action,
+		ActionParams: c.ActionParams,
+		CommandTtl:   ttl,
+		ApprovalId:   c.ApprovalId,
+	}
+	if c.DemandRequestId != nil {
+		req.Id = *c.DemandRequestId
+	}
+	c.OrganizationId = device.OrganizationId
+	c.Status = domain.CommandPending
+	c.ExpiresDate = time.Now().Add(ttl)
+
+	err = s.eventService.Admit(req, c.Override, func(es EventService, tx database.Tx) error {
+		saved, err := tx.Commands.Save(c)
+		if err != nil {
+			return err
+		}
+		c = saved
+		return nil
+	})
+	if err != nil {
+		return domain.Command{}, err
+	}
+
+	log.Printf("CommandService: Command %d queued for device %d", c.Id, c.DeviceId)
+	s.publish(pubsub.CommandCreated, c, device.RoomId)
+	return c, nil
+}
+
+// requestApproval holds a command to a critical device until another manager approves it.
+func (s *commandService) requestApproval(device domain.Device, c domain.Command, ttl time.Duration) error {
+	a, err := s.approvalRepo.Save(domain.CommandApproval{
+		OrganizationId: device.OrganizationId,
+		DeviceId:       device.Id,
+		RequestedBy:    c.UserId,
+		Action:         c.Action,
+		ActionParams:   c.ActionParams,
+		CommandTtl:     ttl,
+		Status:         domain.ApprovalPending,
+		ExpiresDate:    time.Now().Add(DefaultApprovalTTL),
+		Override:       c.Override,
+	})
+	if err != nil {
+		log.Printf("CommandService: Error requesting approval: %s", err)
+		return err
+	}
+
+	log.Printf("CommandService: Command for critical device %d waits for approval %d", device.Id, a.Id)
+	return fmt.Errorf("%w: approval %d", domain.ErrApprovalPending, a.Id)
+}
+
+func (s *commandService) Find(id uint64) (interface{}, error) {
+	c, err := s.commandRepo.Find(id)
+	if err != nil {
+		log.Printf("CommandService: %s", err)
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (s *commandService) FindByDeviceId(deviceId uint64) ([]domain.Command, error) {
+	cmds, err := s.commandRepo.FindByDeviceId(deviceId)
+	if err != nil {
+		log.Printf("CommandService: %s", err)
+		return nil, err
+	}
+
+	return cmds, nil
+}
+
+// Poll returns the open commands of a device. When there are none it waits for a new
+// one up to the given duration. Returned commands are marked as delivered.
+func (s *commandService) Poll(ctx context.Context, device domain.Device, wait time.Duration) ([]domain.Command, error) {
+	sub := s.hub.Subscribe(pubsub.Filter{DeviceId: &device.Id, Types: []pubsub.MessageType{pubsub.CommandCreated}})
+	defer s.hub.Unsubscribe(sub)
+
+	cmds, err := s.commandRepo.FindOpenByDeviceId(device.Id, time.Now())
+	if err != nil {
+		log.Printf("CommandService: %s", err)
+		return nil, err
+	}
+
+	if len(cmds) == 0 && wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-timer.C:
+			return nil, nil
+		case <-sub.C:
+		}
+
+		cmds, err = s.commandRepo.FindOpenByDeviceId(device.Id, time.Now())
+		if err != nil {
+			log.Printf("CommandService: %s", err)
+			return nil, err
+		}
+	}
+
+	for i, c := range cmds {
+		cmds[i], err = s.MarkDelivered(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cmds, nil
+}
+
+func (s *commandService) MarkDelivered(c domain.Command) (domain.Command, error) {
+	if c.Status != domain.CommandPending {
+		return c, nil
+	}
+
+	now := time.Now()
+	c.Status = domain.CommandDelivered
+	c.DeliveredDate = &now
+	c, err := s.commandRepo.Update(c)
+	if err != nil {
+		log.Printf("CommandService: Error marking command %d as delivered: %s", c.Id, err)
+		return domain.Command{}, err
+	}
+
+	s.publish(pubsub.CommandUpdated, c, nil)
+	return c, nil
+}
+
+// Acknowledge records the device response. Only a successful acknowledgement writes the event,
+// in the same transaction which closes the command. The interlocks are checked again under the
+// lock of the organization, as other devices may have changed their state since the command was
+// created, and a command which would break one fails unless it carries an override. A repeated
+// acknowledgement, such as a redelivered MQTT message, returns the command as it was closed by
+// the first one.
+func (s *commandService) Acknowledge(device domain.Device, commandId uint64, success bool, errMsg string) (domain.Command, error) {
+	c, err := s.commandRepo.Find(commandId)
+	if err != nil {
+		log.Printf("CommandService: %s", err)
+		return domain.Command{}, err
+	}
+	if c.DeviceId != device.Id {
+		return domain.Command{}, errors.New("command does not belong to device")
+	}
+	if !c.IsOpen() {
+		return c, nil
+	}
+
+	now := time.Now()
+	if now.After(c.ExpiresDate) {
+		c, err = s.expire(c)
+		if err != nil {
+			return domain.Command{}, err
+		}
+		if c.Status != domain.CommandExpired {
+			return c, nil
+		}
+		return domain.Command{}, errors.New("command has expired")
+	}
+
+	c.AcknowledgedDate = &now
+	c.Status = domain.CommandAcknowledged
+	if !success {
+		if errMsg == "" {
+			errMsg = "rejected by device"
+		}
+		c.Status = domain.CommandFailed
+		c.Error = &errMsg
+	}
+
+	acked := c
+	closed := false
+	err = s.eventService.Tx(func(es EventService, tx database.Tx) error {
+		c = acked
+		err := tx.LockOrganization(device.OrganizationId)
+		if err != nil {
+			return err
+		}
+		if success {
+			err = es.CheckInterlocks(device.Id, c.Action, c.Override)
+			if errors.Is(err, domain.ErrInterlock) {
+				msg := err.Error()
+				c.Status = domain.CommandFailed
+				c.Error = &msg
+			} else if err != nil {
+				return err
+			}
+		}
+
+		closed, err = tx.Commands.Close(c)
+		if err != nil || !closed || c.Status != domain.CommandAcknowledged {
+			return err
+		}
+
+		event, err := es.Record(domain.Event{
+			DeviceId:     device.Id,
+			RoomId:       device.RoomId,
+			Action:       c.Action,
+			ActionParams: c.ActionParams,
+		})
+		// the device was already in the requested state, there is nothing to record
+		if errors.Is(err, domain.ErrNoStateChange) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		c.EventId = &event.Id
+		c, err = tx.Commands.Update(c)
+		return err
+	})
+	if err != nil {
+		log.Printf("CommandService: Error acknowledging command %d: %s", commandId, err)
+		return domain.Command{}, err
+	}
+	if !closed {
+		return s.commandRepo.Find(commandId)
+	}
+
+	s.publish(pubsub.CommandUpdated, c, device.RoomId)
+	return c, nil
+}
+
+func (s *commandService) ExpireOverdue() error {
+	cmds, err := s.commandRepo.FindOverdue(time.Now())
+	if err != nil {
+		log.Printf("CommandService: %s", err)
+		return err
+	}
+
+	for _, c := range cmds {
+		_, err = s.expire(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *commandService) Run(ctx context.Context) {
+	ticker := time.NewTicker(commandExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = s.ExpireOverdue()
+		}
+	}
+}
+
+// expire closes the command unless the device acknowledged it in the meantime.
+func (s *commandService) expire(c domain.Command) (domain.Command, error) {
+	c.Status = domain.CommandExpired
+	closed, err := s.commandRepo.Close(c)
+	if err != nil {
+		log.Printf("CommandService: Error expiring command %d: %s", c.Id, err)
+		return domain.Command{}, err
+	}
+	if !closed {
+		return s.commandRepo.Find(c.Id)
+	}
+
+	log.Printf("CommandService: Command %d expired", c.Id)
+	s.publish(pubsub.CommandUpdated, c, nil)
+	return c, nil
+}
+
+func (s *commandService) publish(t pubsub.MessageType, c domain.Command, roomId *uint64) {
+	s.hub.Publish(pubsub.Message{
+		Type:           t,
+		OrganizationId: c.OrganizationId,
+		RoomId:         roomId,
+		DeviceId:       c.DeviceId,
+		Payload:        c,
+	})
+}
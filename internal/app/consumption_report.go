@@ -0,0 +1,106 @@
+package app
+
+import (
+	"sort"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+// buildConsumptionReport sums the room intervals up per room, per device in a room and per device.
+func buildConsumptionReport(orgId uint64, start, end time.Time, devices []domain.Device, rooms []domain.Room, intervals []roomInterval) domain.ConsumptionReport {
+	report := domain.ConsumptionReport{
+		OrganizationId: orgId,
+		Start:          start,
+		End:            end,
+		Rooms:          []domain.RoomConsumption{},
+		Devices:        []domain.DeviceConsumption{},
+	}
+
+	deviceNames := make(map[uint64]string, len(devices))
+	for _, d := range devices {
+		deviceNames[d.Id] = d.InventoryNumber
+	}
+	roomNames := make(map[uint64]string, len(rooms))
+	for _, r := range rooms {
+		roomNames[r.Id] = r.Name
+	}
+
+	type roomDevice struct {
+		room   uint64
+		device uint64
+	}
+	// room 0 is the time devices weren't installed, room ids start at 1
+	byRoom := make(map[uint64]*domain.RoomConsumption)
+	byRoomDevice := make(map[roomDevice]*domain.DeviceConsumption)
+	byDevice := make(map[uint64]*domain.DeviceConsumption)
+
+	for _, i := range intervals {
+		var roomKey uint64
+		if i.RoomId != nil {
+			roomKey = *i.RoomId
+		}
+		hours, energy := i.Hours(), i.Energy()
+
+		rc, ok := byRoom[roomKey]
+		if !ok {
+			rc = &domain.RoomConsumption{RoomId: i.RoomId, Name: roomNames[roomKey]}
+			byRoom[roomKey] = rc
+		}
+		rc.OnHours += hours
+		rc.Energy += energy
+
+		key := roomDevice{room: roomKey, device: i.DeviceId}
+		rdc, ok := byRoomDevice[key]
+		if !ok {
+			rdc = &domain.DeviceConsumption{DeviceId: i.DeviceId, InventoryNumber: deviceNames[i.DeviceId], RoomId: i.RoomId}
+			byRoomDevice[key] = rdc
+		}
+		rdc.OnHours += hours
+		rdc.Energy += energy
+
+		dc, ok := byDevice[i.DeviceId]
+		if !ok {
+			dc = &domain.DeviceConsumption{DeviceId: i.DeviceId, InventoryNumber: deviceNames[i.DeviceId]}
+			byDevice[i.DeviceId] = dc
+		}
+		dc.OnHours += hours
+		dc.Energy += energy
+
+		report.OnHours += hours
+		report.Energy += energy
+	}
+
+	share := func(energy float64) float64 {
+		if report.Energy == 0 {
+			return 0
+		}
+		return energy / report.Energy
+	}
+
+	for key, rdc := range byRoomDevice {
+		rdc.Share = share(rdc.Energy)
+		byRoom[key.room].Devices = append(byRoom[key.room].Devices, *rdc)
+	}
+	for _, rc := range byRoom {
+		rc.Share = share(rc.Energy)
+		sort.Slice(rc.Devices, func(i, j int) bool { return rc.Devices[i].DeviceId < rc.Devices[j].DeviceId })
+		report.Rooms = append(report.Rooms, *rc)
+	}
+	for _, dc := range byDevice {
+		dc.Share = share(dc.Energy)
+		report.Devices = append(report.Devices, *dc)
+	}
+
+	// devices which weren't installed anywhere come last
+	sort.Slice(report.Rooms, func(i, j int) bool {
+		a, b := report.Rooms[i].RoomId, report.Rooms[j].RoomId
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return *a < *b
+	})
+	sort.Slice(report.Devices, func(i, j int) bool { return report.Devices[i].DeviceId < report.Devices[j].DeviceId })
+
+	return report
+}
@@ -0,0 +1,158 @@
+package app
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
+)
+
+const demandEpsilon = 1e-9
+
+// runningDevice is an actuator which is on and the kW it draws.
+type runningDevice struct {
+	Device domain.Device
+	Kw     float64
+}
+
+// demandEvaluation is a request for power against the load of its organization. Sheddable are the
+// running devices of a lower class than the requesting one which aren't critical, the lowest class
+// and largest first.
+type demandEvaluation struct {
+	RequestedKw float64
+	LoadKw      float64
+	Sheddable   []runningDevice
+}
+
+func (e demandEvaluation) fits(p domain.DemandPolicy) bool {
+	return e.LoadKw+e.RequestedKw <= p.MaxKw+demandEpsilon
+}
+
+// shedPlan returns the fewest sheddable devices in order which make the request fit, false when
+// shedding all of them isn't enough.
+func (e demandEvaluation) shedPlan(p domain.DemandPolicy) ([]runningDevice, bool) {
+	load := e.LoadKw
+	var plan []runningDevice
+	for _, rd := range e.Sheddable {
+		if load+e.RequestedKw <= p.MaxKw+demandEpsilon {
+			break
+		}
+		plan = append(plan, rd)
+		load -= rd.Kw
+	}
+	return plan, load+e.RequestedKw <= p.MaxKw+demandEpsilon
+}
+
+func (e demandEvaluation) sheddableKw() float64 {
+	var kw float64
+	for _, rd := range e.Sheddable {
+		kw += rd.Kw
+	}
+	return kw
+}
+
+// demandLimiter measures requests for power against the demand policy of an organization. The
+// load is the rated power of the actuators which are on or have an open command to turn on,
+// scaled by their level. A released demand request is counted by the event or command it became.
+type demandLimiter struct {
+	demandRepo  database.DemandRepository
+	deviceRepo  database.DeviceRepository
+	eventRepo   database.EventRepository
+	commandRepo database.CommandRepository
+}
+
+// policy returns the enabled policy of the organization, false when there is none.
+func (l demandLimiter) policy(orgId uint64) (domain.DemandPolicy, bool, error) {
+	p, err := l.demandRepo.FindPolicy(orgId)
+	if errors.Is(err, db.ErrNoMoreRows) {
+		return domain.DemandPolicy{}, false, nil
+	}
+	if err != nil {
+		return domain.DemandPolicy{}, false, err
+	}
+	return p, p.Enabled, nil
+}
+
+// applies reports whether the action of the device draws additional power.
+func (l demandLimiter) applies(device domain.Device, action domain.EventAction) bool {
+	if action != domain.TurnOn || device.Category != domain.Actuator || device.PowerConsumption == nil {
+		return false
+	}
+	return device.CurrentState == nil || *device.CurrentState != domain.TurnOn
+}
+
+func (l demandLimiter) evaluate(p domain.DemandPolicy, device domain.Device, params domain.ActionParams) (demandEvaluation, error) {
+	requested, err := l.power(device, params.Level)
+	if err != nil {
+		return demandEvaluation{}, err
+	}
+	eval := demandEvaluation{RequestedKw: requested}
+
+	devices, err := l.deviceRepo.FindByOrgId(device.OrganizationId)
+	if err != nil {
+		return demandEvaluation{}, err
+	}
+	cmds, err := l.commandRepo.FindOpenByOrgId(device.OrganizationId, time.Now())
+	if err != nil {
+		return demandEvaluation{}, err
+	}
+	// the devices which will draw power once they acknowledge the command
+	turningOn := make(map[uint64]bool)
+	for _, c := range cmds {
+		if c.Action == domain.TurnOn {
+			turningOn[c.DeviceId] = true
+		}
+	}
+
+	rank := p.ClassOf(device.Id).Rank()
+	for _, d := range devices {
+		if d.Id == device.Id || d.DeletedDate != nil || d.Category != domain.Actuator {
+			continue
+		}
+		on := d.CurrentState != nil && *d.CurrentState == domain.TurnOn
+		if !on && !turningOn[d.Id] {
+			continue
+		}
+		kw, err := l.power(d, nil)
+		if err != nil {
+			return demandEvaluation{}, err
+		}
+		eval.LoadKw += kw
+		// a device which isn't on yet can't be turned off to make room, a critical one mustn't be
+		if on && !d.Critical && p.ClassOf(d.Id).Rank() < rank && kw > 0 {
+			eval.Sheddable = append(eval.Sheddable, runningDevice{Device: d, Kw: kw})
+		}
+	}
+
+	sort.SliceStable(eval.Sheddable, func(i, j int) bool {
+		a, b := p.ClassOf(eval.Sheddable[i].Device.Id).Rank(), p.ClassOf(eval.Sheddable[j].Device.Id).Rank()
+		if a != b {
+			return a < b
+		}
+		return eval.Sheddable[i].Kw > eval.Sheddable[j].Kw
+	})
+	return eval, nil
+}
+
+// power returns the kW the device draws at the level, at its last level when level is nil.
+func (l demandLimiter) power(d domain.Device, level *float64) (float64, error) {
+	if d.PowerConsumption == nil {
+		return 0, nil
+	}
+	if level == nil {
+		last, err := l.eventRepo.FindLastBefore(d.Id, domain.SetLevel, time.Now())
+		if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
+			return 0, err
+		}
+		if err == nil {
+			level = last.Level
+		}
+	}
+	if level == nil {
+		return *d.PowerConsumption, nil
+	}
+	return *d.PowerConsumption * *level / fullLevel, nil
+}
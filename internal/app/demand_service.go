@@ -0,0 +1,244 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+)
+
+const (
+	demandTickInterval = 10 * time.Second
+	demandQueueTTL     = time.Hour
+	demandHistoryLimit = 100
+)
+
+type DemandService interface {
+	FindPolicy(orgId uint64) (domain.DemandPolicy, error)
+	SavePolicy(p domain.DemandPolicy) (domain.DemandPolicy, error)
+	DeletePolicy(orgId uint64) error
+	FindDecisions(orgId uint64) ([]domain.DemandDecision, error)
+	FindRequests(orgId uint64) ([]domain.DemandRequest, error)
+	Run(ctx context.Context)
+}
+
+type demandService struct {
+	demandRepo     database.DemandRepository
+	deviceRepo     database.DeviceRepository
+	eventService   EventService
+	commandService CommandService
+}
+
+func NewDemandService(dmr database.DemandRepository, dr database.DeviceRepository, es EventService, cs CommandService) DemandService {
+	return demandService{
+		demandRepo:     dmr,
+		deviceRepo:     dr,
+		eventService:   es,
+		commandService: cs,
+	}
+}
+
+func (s demandService) FindPolicy(orgId uint64) (domain.DemandPolicy, error) {
+	p, err := s.demandRepo.FindPolicy(orgId)
+	if err != nil {
+		log.Printf("DemandService: %s", err)
+		return domain.DemandPolicy{}, err
+	}
+
+	return p, nil
+}
+
+func (s demandService) SavePolicy(p domain.DemandPolicy) (domain.DemandPolicy, error) {
+	err := p.Validate()
+	if err != nil {
+		return domain.DemandPolicy{}, err
+	}
+	for _, dp := range p.Priorities {
+		device, err := s.deviceRepo.Find(dp.DeviceId)
+		if err != nil || device.OrganizationId != p.OrganizationId || device.DeletedDate != nil {
+			return domain.DemandPolicy{}, fmt.Errorf("device %d not found in organization", dp.DeviceId)
+		}
+		if device.Category != domain.Actuator {
+			return domain.DemandPolicy{}, fmt.Errorf("device %d is not an actuator", dp.DeviceId)
+		}
+	}
+
+	p, err = s.demandRepo.SavePolicy(p)
+	if err != nil {
+		log.Printf("DemandService: %s", err)
+		return domain.DemandPolicy{}, err
+	}
+
+	return p, nil
+}
+
+func (s demandService) DeletePolicy(orgId uint64) error {
+	err := s.demandRepo.DeletePolicy(orgId)
+	if err != nil {
+		log.Printf("DemandService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s demandService) FindDecisions(orgId uint64) ([]domain.DemandDecision, error) {
+	decisions, err := s.demandRepo.FindDecisionsByOrgId(orgId, demandHistoryLimit)
+	if err != nil {
+		log.Printf("DemandService: %s", err)
+		return nil, err
+	}
+
+	return decisions, nil
+}
+
+func (s demandService) FindRequests(orgId uint64) ([]domain.DemandRequest, error) {
+	reqs, err := s.demandRepo.FindRequestsByOrgId(orgId, demandHistoryLimit)
+	if err != nil {
+		log.Printf("DemandService: %s", err)
+		return nil, err
+	}
+
+	return reqs, nil
+}
+
+func (s demandService) Run(ctx context.Context) {
+	ticker := time.NewTicker(demandTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.release(now)
+		}
+	}
+}
+
+// release submits the queued requests again, in the order they were queued. The demand is
+// checked as for any other request, one which still doesn't fit stays queued and holds back the
+// later ones of its organization.
+func (s demandService) release(now time.Time) {
+	reqs, err := s.demandRepo.FindQueued()
+	if err != nil {
+		log.Printf("DemandService: %s", err)
+		return
+	}
+
+	blocked := make(map[uint64]bool)
+	for _, req := range reqs {
+		if now.After(req.ExpiresDate) {
+			s.expire(req)
+			continue
+		}
+		if blocked[req.OrganizationId] {
+			continue
+		}
+
+		if !s.submit(req, now) {
+			blocked[req.OrganizationId] = true
+		}
+	}
+}
+
+// submit creates the command or event of the request, false when the demand still doesn't allow it.
+func (s demandService) submit(req domain.DemandRequest, now time.Time) bool {
+	device, err := s.deviceRepo.Find(req.DeviceId)
+	if err != nil {
+		s.fail(req, err)
+		return true
+	}
+	if device.DeletedDate != nil {
+		s.fail(req, errors.New("device was deleted"))
+		return true
+	}
+
+	switch req.Source {
+	case domain.CommandDemand:
+		c := domain.Command{
+			DeviceId:        req.DeviceId,
+			Action:          req.Action,
+			ActionParams:    req.ActionParams,
+			ApprovalId:      req.ApprovalId,
+			Override:        req.Override,
+			DemandRequestId: &req.Id,
+		}
+		if req.UserId != nil {
+			c.UserId = *req.UserId
+		}
+		_, err = s.commandService.Create(c, req.CommandTtl)
+	default:
+		_, err = s.eventService.Save(domain.Event{
+			DeviceId:        req.DeviceId,
+			RoomId:          device.RoomId,
+			Action:          req.Action,
+			ActionParams:    req.ActionParams,
+			Override:        req.Override,
+			DemandRequestId: &req.Id,
+		})
+		// someone else turned the device on in the meantime
+		if errors.Is(err, domain.ErrNoStateChange) {
+			err = nil
+		}
+	}
+	if errors.Is(err, domain.ErrDemandQueued) {
+		return false
+	}
+	if err != nil {
+		s.fail(req, err)
+		return true
+	}
+
+	req.Status = domain.DemandRequestReleased
+	req.ReleasedDate = &now
+	_, err = s.demandRepo.UpdateRequest(req)
+	if err != nil {
+		log.Printf("DemandService: Error releasing request %d: %s", req.Id, err)
+		return true
+	}
+	s.recordDecision(req, domain.DemandReleased, "the demand allows the request")
+	log.Printf("DemandService: Request %d released for device %d", req.Id, req.DeviceId)
+	return true
+}
+
+func (s demandService) expire(req domain.DemandRequest) {
+	req.Status = domain.DemandRequestExpired
+	_, err := s.demandRepo.UpdateRequest(req)
+	if err != nil {
+		log.Printf("DemandService: Error expiring request %d: %s", req.Id, err)
+		return
+	}
+	s.recordDecision(req, domain.DemandExpired, fmt.Sprintf("the demand didn't allow the request within %s", demandQueueTTL))
+}
+
+func (s demandService) fail(req domain.DemandRequest, cause error) {
+	msg := cause.Error()
+	req.Status = domain.DemandRequestFailed
+	req.Error = &msg
+	_, err := s.demandRepo.UpdateRequest(req)
+	if err != nil {
+		log.Printf("DemandService: Error failing request %d: %s", req.Id, err)
+		return
+	}
+	log.Printf("DemandService: Request %d failed: %s", req.Id, msg)
+}
+
+func (s demandService) recordDecision(req domain.DemandRequest, outcome domain.DemandOutcome, reason string) {
+	_, err := s.demandRepo.SaveDecision(domain.DemandDecision{
+		OrganizationId: req.OrganizationId,
+		DeviceId:       req.DeviceId,
+		Source:         req.Source,
+		Action:         req.Action,
+		Outcome:        outcome,
+		RequestId:      &req.Id,
+		Reason:         reason,
+	})
+	if err != nil {
+		log.Printf("DemandService: Error saving demand decision: %s", err)
+	}
+}
@@ -1,39 +1,76 @@
 package app
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/pubsub"
 	"github.com/google/uuid"
+	"github.com/upper/db/v4"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type DeviceService interface {
 	Save(d domain.Device) (domain.Device, error)
 	Find(id uint64) (interface{}, error)
 	FindAll() ([]domain.Device, error)
+	FindByGUID(guid string) (domain.Device, error)
+	Authenticate(guid, secret string) (domain.Device, error)
+	RotateSecret(d domain.Device) (domain.Device, error)
 	Update(d domain.Device) (domain.Device, error)
 	InstallDevice(deviceId uint64, roomId uint64) error
 	UninstallDevice(device domain.Device) (domain.Device, error)
 	Delete(id uint64) error
+	FindChannels(device domain.Device) ([]domain.DeviceChannel, error)
+	SaveChannel(device domain.Device, ch domain.DeviceChannel) (domain.DeviceChannel, error)
+	UpdateChannel(device domain.Device, ch domain.DeviceChannel) (domain.DeviceChannel, error)
+	DeleteChannel(device domain.Device, key string) error
 }
 
 type deviceService struct {
 	deviceRepo      database.DeviceRepository
+	channelRepo     database.ChannelRepository
 	measurementRepo database.MeasurementRepository
 	eventRepo       database.EventRepository
+	installRepo     database.DeviceInstallationRepository
+	hub             pubsub.Hub
 }
 
-func NewDeviceService(dr database.DeviceRepository, mr database.MeasurementRepository, er database.EventRepository) DeviceService {
+func NewDeviceService(dr database.DeviceRepository, chr database.ChannelRepository, mr database.MeasurementRepository, er database.EventRepository, ir database.DeviceInstallationRepository, h pubsub.Hub) DeviceService {
 	return &deviceService{
 		deviceRepo:      dr,
+		channelRepo:     chr,
 		measurementRepo: mr,
 		eventRepo:       er,
+		installRepo:     ir,
+		hub:             h,
 	}
 }
 
 func (s *deviceService) Save(dd domain.Device) (domain.Device, error) {
+	dd, err := s.prepareMeter(dd)
+	if err != nil {
+		return domain.Device{}, err
+	}
+	dd, err = s.normalizeUnits(dd, nil)
+	if err != nil {
+		return domain.Device{}, err
+	}
+
 	dd.GUID = uuid.New().String()
+	secret, hash, err := s.generateSecret()
+	if err != nil {
+		log.Printf("DeviceService: Error generating device secret: %s", err)
+		return domain.Device{}, err
+	}
+	dd.SecretHash = &hash
+
 	createdDevice, err := s.deviceRepo.Save(dd)
 	if err != nil {
 		log.Printf("DeviceService: Error saving device: %s", err)
@@ -41,6 +78,8 @@ func (s *deviceService) Save(dd domain.Device) (domain.Device, error) {
 	}
 
 	log.Printf("DeviceService: Device saved successfully: %+v", createdDevice)
+	s.installChanged(nil, createdDevice)
+	createdDevice.Secret = secret
 	return createdDevice, nil
 }
 
@@ -54,6 +93,48 @@ func (s *deviceService) FindAll() ([]domain.Device, error) {
 	return devices, nil
 }
 
+func (s *deviceService) FindByGUID(guid string) (domain.Device, error) {
+	device, err := s.deviceRepo.FindByGUID(guid)
+	if err != nil {
+		log.Printf("DeviceService: %s", err)
+		return domain.Device{}, err
+	}
+
+	return device, nil
+}
+
+func (s *deviceService) Authenticate(guid, secret string) (domain.Device, error) {
+	device, err := s.deviceRepo.FindByGUID(guid)
+	if err != nil {
+		log.Printf("DeviceService: failed to find device %s: %s", guid, err)
+		return domain.Device{}, errors.New("invalid device credentials")
+	}
+
+	if device.SecretHash == nil || bcrypt.CompareHashAndPassword([]byte(*device.SecretHash), []byte(secret)) != nil {
+		return domain.Device{}, errors.New("invalid device credentials")
+	}
+
+	return device, nil
+}
+
+func (s *deviceService) RotateSecret(dd domain.Device) (domain.Device, error) {
+	secret, hash, err := s.generateSecret()
+	if err != nil {
+		log.Printf("DeviceService: Error generating device secret: %s", err)
+		return domain.Device{}, err
+	}
+	dd.SecretHash = &hash
+
+	device, err := s.deviceRepo.Update(dd)
+	if err != nil {
+		log.Printf("DeviceService: Error updating device secret: %s", err)
+		return domain.Device{}, err
+	}
+
+	device.Secret = secret
+	return device, nil
+}
+
 func (s *deviceService) Find(id uint64) (interface{}, error) {
 	log.Printf("DeviceService: Finding device with ID %d", id)
 
@@ -71,6 +152,14 @@ func (s *deviceService) Find(id uint64) (interface{}, error) {
 
 	device.Measurements = measurements
 
+	if device.Category == domain.Sensor {
+		device.Channels, err = s.channelRepo.FindByDeviceId(device.Id)
+		if err != nil {
+			log.Printf("DeviceService: %s", err)
+			return nil, err
+		}
+	}
+
 	events, err := s.eventRepo.FindByDeviceId(device.Id)
 	if err != nil {
 		return domain.Device{}, err
@@ -83,6 +172,21 @@ func (s *deviceService) Find(id uint64) (interface{}, error) {
 func (s *deviceService) Update(dd domain.Device) (domain.Device, error) {
 	log.Printf("DeviceService: Updating device %+v", dd)
 
+	old, err := s.deviceRepo.Find(dd.Id)
+	if err != nil {
+		log.Printf("DeviceService: Error finding device: %s", err)
+		return domain.Device{}, err
+	}
+
+	dd, err = s.prepareMeter(dd)
+	if err != nil {
+		return domain.Device{}, err
+	}
+	dd, err = s.normalizeUnits(dd, &old)
+	if err != nil {
+		return domain.Device{}, err
+	}
+
 	device, err := s.deviceRepo.Update(dd)
 	if err != nil {
 		log.Printf("DeviceService: Error updating device: %s", err)
@@ -90,16 +194,26 @@ func (s *deviceService) Update(dd domain.Device) (domain.Device, error) {
 	}
 
 	log.Printf("DeviceService: Device updated successfully: %+v", device)
+	s.installChanged(old.RoomId, device)
 	return device, nil
 }
 
 func (s *deviceService) InstallDevice(deviceId uint64, roomId uint64) error {
-	err := s.deviceRepo.InstallDevice(deviceId, roomId)
+	old, err := s.deviceRepo.Find(deviceId)
 	if err != nil {
 		log.Printf("DeviceService: %s", err)
 		return err
 	}
 
+	err = s.deviceRepo.InstallDevice(deviceId, roomId)
+	if err != nil {
+		log.Printf("DeviceService: %s", err)
+		return err
+	}
+
+	device := old
+	device.RoomId = &roomId
+	s.installChanged(old.RoomId, device)
 	return nil
 }
 
@@ -110,6 +224,7 @@ func (s *deviceService) UninstallDevice(device domain.Device) (domain.Device, er
 		return domain.Device{}, err
 	}
 	log.Printf("DeviceService: Uninstalled device with ID %d", device.Id)
+	s.installChanged(device.RoomId, uninstalledDevice)
 	return uninstalledDevice, nil
 }
 
@@ -123,5 +238,274 @@ func (s *deviceService) Delete(id uint64) error {
 	}
 
 	log.Printf("DeviceService: Device deleted successfully")
+	err = s.installRepo.Move(id, nil, time.Now())
+	if err != nil {
+		log.Printf("DeviceService: Error ending installation of device %d: %s", id, err)
+	}
 	return nil
 }
+
+func (s *deviceService) FindChannels(device domain.Device) ([]domain.DeviceChannel, error) {
+	channels, err := s.channelRepo.FindByDeviceId(device.Id)
+	if err != nil {
+		log.Printf("DeviceService: %s", err)
+		return nil, err
+	}
+
+	return channels, nil
+}
+
+// SaveChannel adds a quantity the sensor reports. Invalid channels wrap domain.ErrInvalidChannel.
+func (s *deviceService) SaveChannel(device domain.Device, ch domain.DeviceChannel) (domain.DeviceChannel, error) {
+	if device.Category != domain.Sensor {
+		return domain.DeviceChannel{}, fmt.Errorf("%w: only sensors have channels", domain.ErrInvalidChannel)
+	}
+	err := ch.Validate()
+	if err != nil {
+		return domain.DeviceChannel{}, fmt.Errorf("%w: %s", domain.ErrInvalidChannel, err)
+	}
+
+	channels, err := s.channelRepo.FindByDeviceId(device.Id)
+	if err != nil {
+		log.Printf("DeviceService: %s", err)
+		return domain.DeviceChannel{}, err
+	}
+	if len(channels) >= domain.MaxDeviceChannels {
+		return domain.DeviceChannel{}, fmt.Errorf("%w: a sensor may have at most %d channels", domain.ErrInvalidChannel, domain.MaxDeviceChannels)
+	}
+	device.Channels = channels
+	if _, ok := device.Channel(ch.Key); ok {
+		return domain.DeviceChannel{}, fmt.Errorf("%w: device %d already has channel %q", domain.ErrInvalidChannel, device.Id, ch.Key)
+	}
+
+	ch.DeviceId = device.Id
+	ch.Units, _ = domain.NormalizeUnits(ch.Units)
+	ch, err = s.channelRepo.Save(ch)
+	if err != nil {
+		log.Printf("DeviceService: Error saving channel: %s", err)
+		return domain.DeviceChannel{}, err
+	}
+
+	log.Printf("DeviceService: Device %d reports channel %s", device.Id, ch.Key)
+	return ch, nil
+}
+
+// UpdateChannel changes the quantity, the units and the limits of the channel with the key of ch.
+// The units of a channel with readings can't change, that fails with domain.ErrUnitsInUse.
+// Units which don't change are kept as they are stored.
+func (s *deviceService) UpdateChannel(device domain.Device, ch domain.DeviceChannel) (domain.DeviceChannel, error) {
+	err := ch.ValidateFields()
+	if err != nil {
+		return domain.DeviceChannel{}, fmt.Errorf("%w: %s", domain.ErrInvalidChannel, err)
+	}
+	existing, err := s.channel(device, ch.Key)
+	if err != nil {
+		return domain.DeviceChannel{}, err
+	}
+	units := existing.Units
+	if !domain.SameUnitsOf(existing.Units, ch.Units) {
+		units, err = domain.NormalizeUnits(ch.Units)
+		if err != nil {
+			return domain.DeviceChannel{}, fmt.Errorf("%w: %s", domain.ErrInvalidChannel, err)
+		}
+		err = s.checkUnitsChange(device.Id, &existing.Id, existing.Units, units)
+		if err != nil {
+			return domain.DeviceChannel{}, err
+		}
+	}
+
+	existing.Quantity = ch.Quantity
+	existing.Units = units
+	existing.ValidMin = ch.ValidMin
+	existing.ValidMax = ch.ValidMax
+	existing.MaxRate = ch.MaxRate
+	ch, err = s.channelRepo.Update(existing)
+	if err != nil {
+		log.Printf("DeviceService: Error updating channel: %s", err)
+		return domain.DeviceChannel{}, err
+	}
+
+	return ch, nil
+}
+
+// DeleteChannel retires the channel, its readings are kept.
+func (s *deviceService) DeleteChannel(device domain.Device, key string) error {
+	ch, err := s.channel(device, key)
+	if err != nil {
+		return err
+	}
+
+	err = s.channelRepo.Delete(ch.Id)
+	if err != nil {
+		log.Printf("DeviceService: Error deleting channel: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+// channel returns the channel of the device with the key, db.ErrNoMoreRows without one.
+func (s *deviceService) channel(device domain.Device, key string) (domain.DeviceChannel, error) {
+	channels, err := s.channelRepo.FindByDeviceId(device.Id)
+	if err != nil {
+		log.Printf("DeviceService: %s", err)
+		return domain.DeviceChannel{}, err
+	}
+	device.Channels = channels
+	ch, ok := device.Channel(key)
+	if !ok {
+		return domain.DeviceChannel{}, db.ErrNoMoreRows
+	}
+	return ch, nil
+}
+
+// installChanged records the move of the device in its installation history and notifies subscribers.
+func (s *deviceService) installChanged(oldRoomId *uint64, device domain.Device) {
+	if sameId(oldRoomId, device.RoomId) {
+		return
+	}
+
+	err := s.installRepo.Move(device.Id, device.RoomId, time.Now())
+	if err != nil {
+		log.Printf("DeviceService: Error recording installation of device %d: %s", device.Id, err)
+	}
+
+	if oldRoomId != nil {
+		s.hub.Publish(pubsub.Message{
+			Type:           pubsub.DeviceUninstalled,
+			OrganizationId: device.OrganizationId,
+			RoomId:         oldRoomId,
+			DeviceId:       device.Id,
+			Payload:        device,
+		})
+	}
+	if device.RoomId != nil {
+		s.hub.Publish(pubsub.Message{
+			Type:           pubsub.DeviceInstalled,
+			OrganizationId: device.OrganizationId,
+			RoomId:         device.RoomId,
+			DeviceId:       device.Id,
+			Payload:        device,
+		})
+	}
+}
+
+// sameId reports whether two optional ids are the same, or both missing.
+func sameId(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (s *deviceService) generateSecret() (string, string, error) {
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", "", err
+	}
+	secret := hex.EncodeToString(b)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, string(hash), nil
+}
+
+// normalizeUnits stores the units of a device other than a meter by their code in the registry,
+// unknown units wrap domain.ErrInvalidUnits. Units which don't change on update are kept as they
+// are stored, even when they aren't in the registry. Readings are stored without their unit, so
+// the units of an existing device with readings can't change, that fails with
+// domain.ErrUnitsInUse.
+func (s *deviceService) normalizeUnits(dd domain.Device, old *domain.Device) (domain.Device, error) {
+	if dd.Category == domain.Meter {
+		return dd, nil
+	}
+	if old != nil && domain.SameUnitsOf(old.Units, dd.Units) {
+		dd.Units = old.Units
+		return dd, nil
+	}
+
+	units, err := domain.NormalizeUnits(dd.Units)
+	if err != nil {
+		return domain.Device{}, err
+	}
+	if old != nil {
+		err = s.checkUnitsChange(dd.Id, nil, old.Units, units)
+		if err != nil {
+			return domain.Device{}, err
+		}
+	}
+	dd.Units = units
+	return dd, nil
+}
+
+// checkUnitsChange fails with domain.ErrUnitsInUse when the units change while readings of the
+// channel, or of the device's own value when channelId is nil, are stored in the old ones.
+func (s *deviceService) checkUnitsChange(deviceId uint64, channelId *uint64, old, units *string) error {
+	if domain.SameUnitsOf(old, units) {
+		return nil
+	}
+	has, err := s.measurementRepo.HasReadings(deviceId, channelId)
+	if err != nil {
+		log.Printf("DeviceService: %s", err)
+		return err
+	}
+	if has {
+		return fmt.Errorf("%w: the readings of device %d are in %s", domain.ErrUnitsInUse, deviceId, unitsName(old))
+	}
+	return nil
+}
+
+func unitsName(units *string) string {
+	if units == nil {
+		return "no units"
+	}
+	return *units
+}
+
+// prepareMeter defaults the units of a meter and checks that it meters an actuator of the same
+// organization which has no other meter. Failures wrap domain.ErrInvalidMeter, invalid quality
+// limits wrap domain.ErrInvalidQualityLimits.
+func (s *deviceService) prepareMeter(dd domain.Device) (domain.Device, error) {
+	err := dd.ValidateMeter()
+	if err != nil {
+		return domain.Device{}, fmt.Errorf("%w: %s", domain.ErrInvalidMeter, err)
+	}
+	err = dd.ValidateQualityLimits()
+	if err != nil {
+		return domain.Device{}, fmt.Errorf("%w: %s", domain.ErrInvalidQualityLimits, err)
+	}
+	if dd.Category != domain.Meter {
+		return dd, nil
+	}
+
+	units := domain.MeterUnits
+	dd.Units = &units
+	if dd.MeteredDeviceId == nil {
+		return dd, nil
+	}
+
+	metered, err := s.deviceRepo.Find(*dd.MeteredDeviceId)
+	if err != nil {
+		return domain.Device{}, err
+	}
+	if metered.Id == 0 || metered.DeletedDate != nil || metered.OrganizationId != dd.OrganizationId {
+		return domain.Device{}, fmt.Errorf("%w: device %d not found", domain.ErrInvalidMeter, *dd.MeteredDeviceId)
+	}
+	if metered.Category != domain.Actuator {
+		return domain.Device{}, fmt.Errorf("%w: only actuators can be metered", domain.ErrInvalidMeter)
+	}
+
+	devices, err := s.deviceRepo.FindByOrgId(dd.OrganizationId)
+	if err != nil {
+		return domain.Device{}, err
+	}
+	for _, d := range devices {
+		if d.Id != dd.Id && d.DeletedDate == nil && d.Category == domain.Meter && d.MeteredId() == metered.Id {
+			return domain.Device{}, fmt.Errorf("%w: device %d already has meter %d", domain.ErrInvalidMeter, metered.Id, d.Id)
+		}
+	}
+	return dd, nil
+}
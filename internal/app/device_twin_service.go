@@ -0,0 +1,231 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/pubsub"
+	"github.com/upper/db/v4"
+)
+
+// twinSaveAttempts is how often a twin is read and changed again when someone else saved it in between.
+const twinSaveAttempts = 3
+
+type DeviceTwinService interface {
+	Find(device domain.Device) (domain.DeviceTwin, error)
+	UpdateDesired(device domain.Device, userId uint64, desired domain.TwinState, version *uint64) (domain.DeviceTwin, error)
+	UpdateReported(device domain.Device, reported domain.TwinState) (domain.DeviceTwin, error)
+	FindDriftForOrg(orgId uint64) ([]domain.TwinDrift, error)
+}
+
+type deviceTwinService struct {
+	twins          twinStore
+	deviceRepo     database.DeviceRepository
+	commandService CommandService
+}
+
+func NewDeviceTwinService(tr database.DeviceTwinRepository, dr database.DeviceRepository, cs CommandService, h pubsub.Hub) DeviceTwinService {
+	return &deviceTwinService{
+		twins:          twinStore{twinRepo: tr, hub: h},
+		deviceRepo:     dr,
+		commandService: cs,
+	}
+}
+
+func (s *deviceTwinService) Find(device domain.Device) (domain.DeviceTwin, error) {
+	return s.twins.find(device)
+}
+
+// UpdateDesired stores the desired state and asks the actuator to follow it when it differs
+// from what the device reported. A non-nil version must match the current desired version.
+func (s *deviceTwinService) UpdateDesired(device domain.Device, userId uint64, desired domain.TwinState, version *uint64) (domain.DeviceTwin, error) {
+	if device.Category != domain.Actuator {
+		return domain.DeviceTwin{}, errors.New("only actuators have a desired state")
+	}
+
+	cmds := desiredCommands(device, userId, desired)
+	for _, c := range cmds {
+		err := device.ValidateAction(c.Action, c.ActionParams)
+		if err != nil {
+			return domain.DeviceTwin{}, err
+		}
+	}
+
+	twin, err := s.twins.update(device, func(twin *domain.DeviceTwin) error {
+		if version != nil && *version != twin.DesiredVersion {
+			return domain.ErrTwinVersionConflict
+		}
+		now := time.Now()
+		twin.Desired = desired
+		twin.DesiredVersion++
+		twin.DesiredDate = &now
+		return nil
+	})
+	if err != nil {
+		return domain.DeviceTwin{}, err
+	}
+
+	drifting := make(map[string]bool)
+	for _, d := range twin.Drift() {
+		drifting[d.Field] = true
+	}
+	for _, c := range cmds {
+		if !drifting[twinField(c.Action)] {
+			continue
+		}
+		_, err = s.commandService.Create(c, 0)
+		if err != nil {
+			log.Printf("DeviceTwinService: Error sending command for device %d: %s", device.Id, err)
+		}
+	}
+
+	return twin, nil
+}
+
+// UpdateReported merges the reported fields into the twin.
+func (s *deviceTwinService) UpdateReported(device domain.Device, reported domain.TwinState) (domain.DeviceTwin, error) {
+	return s.twins.updateReported(device, reported)
+}
+
+func (s *deviceTwinService) FindDriftForOrg(orgId uint64) ([]domain.TwinDrift, error) {
+	twins, err := s.twins.twinRepo.FindByOrgId(orgId)
+	if err != nil {
+		log.Printf("DeviceTwinService: %s", err)
+		return nil, err
+	}
+
+	drift := []domain.TwinDrift{}
+	for _, t := range twins {
+		device, err := s.deviceRepo.Find(t.DeviceId)
+		if err != nil {
+			log.Printf("DeviceTwinService: %s", err)
+			return nil, err
+		}
+		if device.DeletedDate != nil {
+			continue
+		}
+		t.RoomId = device.RoomId
+		drift = append(drift, t.Drift()...)
+	}
+
+	return drift, nil
+}
+
+// twinStore reads and writes the twins of devices. Besides the twin service the event service
+// uses it to report the state every event it records sets, right after the event is saved.
+type twinStore struct {
+	twinRepo database.DeviceTwinRepository
+	hub      pubsub.Hub
+}
+
+func (s twinStore) find(device domain.Device) (domain.DeviceTwin, error) {
+	twin, err := s.twinRepo.Find(device.Id)
+	if err != nil {
+		if !errors.Is(err, db.ErrNoMoreRows) {
+			log.Printf("DeviceTwinService: %s", err)
+			return domain.DeviceTwin{}, err
+		}
+		twin = domain.DeviceTwin{
+			DeviceId:       device.Id,
+			OrganizationId: device.OrganizationId,
+		}
+	}
+
+	twin.RoomId = device.RoomId
+	return twin, nil
+}
+
+func (s twinStore) updateReported(device domain.Device, reported domain.TwinState) (domain.DeviceTwin, error) {
+	return s.update(device, func(twin *domain.DeviceTwin) error {
+		now := time.Now()
+		twin.Reported = twin.Reported.Merge(reported)
+		twin.ReportedVersion++
+		twin.ReportedDate = &now
+		return nil
+	})
+}
+
+// update applies the change to the current twin of the device and saves it. When someone else
+// saved the twin in the meantime it's read and changed again.
+func (s twinStore) update(device domain.Device, change func(twin *domain.DeviceTwin) error) (domain.DeviceTwin, error) {
+	for attempt := 1; ; attempt++ {
+		twin, err := s.find(device)
+		if err != nil {
+			return domain.DeviceTwin{}, err
+		}
+
+		desiredVersion, reportedVersion := twin.DesiredVersion, twin.ReportedVersion
+		err = change(&twin)
+		if err != nil {
+			return domain.DeviceTwin{}, err
+		}
+
+		twin, err = s.save(twin, device, desiredVersion, reportedVersion)
+		if errors.Is(err, domain.ErrTwinVersionConflict) && attempt < twinSaveAttempts {
+			continue
+		}
+		return twin, err
+	}
+}
+
+func (s twinStore) save(twin domain.DeviceTwin, device domain.Device, desiredVersion, reportedVersion uint64) (domain.DeviceTwin, error) {
+	twin.OrganizationId = device.OrganizationId
+	twin, err := s.twinRepo.Save(twin, desiredVersion, reportedVersion)
+	if err != nil {
+		log.Printf("DeviceTwinService: Error saving twin of device %d: %s", device.Id, err)
+		return domain.DeviceTwin{}, err
+	}
+
+	twin.RoomId = device.RoomId
+	s.hub.Publish(pubsub.Message{
+		Type:           pubsub.TwinUpdated,
+		OrganizationId: twin.OrganizationId,
+		RoomId:         twin.RoomId,
+		DeviceId:       twin.DeviceId,
+		Payload:        twin,
+	})
+	return twin, nil
+}
+
+// desiredCommands returns the commands that bring an actuator to the desired state.
+func desiredCommands(device domain.Device, userId uint64, desired domain.TwinState) []domain.Command {
+	var cmds []domain.Command
+	add := func(action domain.EventAction, p domain.ActionParams) {
+		cmds = append(cmds, domain.Command{
+			DeviceId:     device.Id,
+			UserId:       userId,
+			Action:       action,
+			ActionParams: p,
+		})
+	}
+
+	if desired.Power != nil {
+		add(*desired.Power, domain.ActionParams{})
+	}
+	if desired.Level != nil {
+		add(domain.SetLevel, domain.ActionParams{Level: desired.Level})
+	}
+	if desired.Setpoint != nil {
+		add(domain.SetSetpoint, domain.ActionParams{Setpoint: desired.Setpoint})
+	}
+	if desired.Mode != nil {
+		add(domain.SetMode, domain.ActionParams{Mode: desired.Mode})
+	}
+	return cmds
+}
+
+func twinField(action domain.EventAction) string {
+	switch action {
+	case domain.SetLevel:
+		return "level"
+	case domain.SetSetpoint:
+		return "setpoint"
+	case domain.SetMode:
+		return "mode"
+	default:
+		return "power"
+	}
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"log"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+)
+
+type EmissionFactorService interface {
+	Save(f domain.EmissionFactor) (domain.EmissionFactor, error)
+	Find(id uint64) (interface{}, error)
+	FindByOrgId(orgId uint64) ([]domain.EmissionFactor, error)
+	FindActive(orgId uint64) (domain.EmissionFactor, error)
+	Update(f domain.EmissionFactor) (domain.EmissionFactor, error)
+	Delete(id uint64) error
+}
+
+type emissionFactorService struct {
+	factorRepo database.EmissionFactorRepository
+}
+
+func NewEmissionFactorService(fr database.EmissionFactorRepository) EmissionFactorService {
+	return emissionFactorService{
+		factorRepo: fr,
+	}
+}
+
+func (s emissionFactorService) Save(f domain.EmissionFactor) (domain.EmissionFactor, error) {
+	err := f.Validate()
+	if err != nil {
+		return domain.EmissionFactor{}, err
+	}
+
+	f, err = s.factorRepo.Save(f)
+	if err != nil {
+		log.Printf("EmissionFactorService: %s", err)
+		return domain.EmissionFactor{}, err
+	}
+
+	return f, nil
+}
+
+func (s emissionFactorService) Find(id uint64) (interface{}, error) {
+	f, err := s.factorRepo.Find(id)
+	if err != nil {
+		log.Printf("EmissionFactorService: %s", err)
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (s emissionFactorService) FindByOrgId(orgId uint64) ([]domain.EmissionFactor, error) {
+	factors, err := s.factorRepo.FindByOrgId(orgId)
+	if err != nil {
+		log.Printf("EmissionFactorService: %s", err)
+		return nil, err
+	}
+
+	return factors, nil
+}
+
+func (s emissionFactorService) FindActive(orgId uint64) (domain.EmissionFactor, error) {
+	f, err := s.factorRepo.FindActive(orgId)
+	if err != nil {
+		log.Printf("EmissionFactorService: %s", err)
+		return domain.EmissionFactor{}, err
+	}
+
+	return f, nil
+}
+
+func (s emissionFactorService) Update(f domain.EmissionFactor) (domain.EmissionFactor, error) {
+	err := f.Validate()
+	if err != nil {
+		return domain.EmissionFactor{}, err
+	}
+
+	f, err = s.factorRepo.Update(f)
+	if err != nil {
+		log.Printf("EmissionFactorService: %s", err)
+		return domain.EmissionFactor{}, err
+	}
+
+	return f, nil
+}
+
+func (s emissionFactorService) Delete(id uint64) error {
+	err := s.factorRepo.Delete(id)
+	if err != nil {
+		log.Printf("EmissionFactorService: %s", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+// computeEmissions converts the intervals within the period by the emission factor. The period is
+// cut wherever the total power or the factor may change, so every segment has a single factor.
+func computeEmissions(f domain.EmissionFactor, intervals []powerInterval, period domain.Period) domain.Emissions {
+	res := domain.Emissions{
+		FactorId:   f.Id,
+		FactorName: f.Name,
+		Start:      period.Start,
+		End:        period.End,
+	}
+
+	var cuts []time.Time
+	for _, e := range f.Entries {
+		if e.Start.After(period.Start) && e.Start.Before(period.End) {
+			cuts = append(cuts, e.Start)
+		}
+	}
+
+	for _, seg := range constantSegments(intervals, period, cuts) {
+		energy := seg.Power * seg.To.Sub(seg.From).Hours()
+		res.Energy += energy
+		res.Co2e += energy * f.FactorAt(seg.From)
+	}
+	return res
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+// priceEnergy prices the intervals within the period. The period is cut wherever the total power
+// or the price may change, so every segment has a single rate.
+func priceEnergy(t domain.Tariff, intervals []powerInterval, period domain.Period) domain.EnergyCost {
+	cost := domain.EnergyCost{
+		TariffId:   t.Id,
+		TariffName: t.Name,
+		Currency:   t.Currency,
+		Start:      period.Start,
+		End:        period.End,
+		Items:      []domain.CostLineItem{},
+	}
+
+	var cuts []time.Time
+	first := period.Start.In(period.Location)
+	first = time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, period.Location)
+	for day := first; day.Before(period.End); day = day.AddDate(0, 0, 1) {
+		cuts = append(cuts, t.Boundaries(day)...)
+	}
+
+	items := make(map[string]*domain.CostLineItem)
+	var order []string
+	add := func(name string, rate, energy float64) {
+		key := fmt.Sprintf("%s/%g", name, rate)
+		item, ok := items[key]
+		if !ok {
+			item = &domain.CostLineItem{Name: name, Rate: rate}
+			items[key] = item
+			order = append(order, key)
+		}
+		item.Energy += energy
+	}
+
+	// consumption so far of each month, for the tiers
+	monthly := make(map[string]float64)
+	for _, seg := range constantSegments(intervals, period, cuts) {
+		energy := seg.Power * seg.To.Sub(seg.From).Hours()
+		cost.Energy += energy
+		local := seg.From.In(period.Location)
+		if t.Type != domain.TieredTariff {
+			name, rate := t.RateAt(local)
+			add(name, rate, energy)
+			continue
+		}
+
+		month := local.Format("2006-01")
+		for n, tier := range t.Tiers {
+			if energy <= 0 {
+				break
+			}
+			take := energy
+			if tier.UpToKwh != nil {
+				take = math.Min(energy, math.Max(*tier.UpToKwh-monthly[month], 0))
+			}
+			if take <= 0 {
+				continue
+			}
+			add(fmt.Sprintf("tier %d", n+1), tier.Rate, take)
+			monthly[month] += take
+			energy -= take
+		}
+	}
+
+	for _, key := range order {
+		item := items[key]
+		item.Cost = roundMoney(item.Energy * item.Rate)
+		cost.Cost += item.Cost
+		cost.Items = append(cost.Items, *item)
+	}
+	cost.Cost = roundMoney(cost.Cost)
+	return cost
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -1,35 +1,201 @@
 package app
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/pubsub"
+	"github.com/upper/db/v4"
 )
 
 type EventService interface {
 	Save(event domain.Event) (domain.Event, error)
+	Record(event domain.Event) (domain.Event, error)
+	Tx(fn func(es EventService, tx database.Tx) error) error
+	Admit(req domain.DemandRequest, override *domain.InterlockOverride, insert func(es EventService, tx database.Tx) error) error
+	CheckInterlocks(deviceId uint64, action domain.EventAction, override *domain.InterlockOverride) error
 	Find(id uint64) (interface{}, error)
 	FindAll() ([]domain.Event, error)
 	GetPowerConsumptionByRoom(roomID uint64, startDate, endDate time.Time) (float64, error)
+	GetConsumptionReport(orgId uint64, startDate, endDate time.Time) (domain.ConsumptionReport, error)
+	GetPowerSeries(scope domain.ConsumptionScope, period domain.Period, size domain.BucketSize) (domain.PowerSeries, error)
+	GetEnergyCost(scope domain.ConsumptionScope, period domain.Period, tariff domain.Tariff) (domain.EnergyCost, error)
+	GetEnergy(scope domain.ConsumptionScope, startDate, endDate time.Time) (float64, error)
+	GetEmissions(scope domain.ConsumptionScope, period domain.Period, factor domain.EmissionFactor) (domain.Emissions, error)
+	GetForecast(scope domain.ConsumptionScope, period domain.Period, size domain.BucketSize, opts domain.ForecastOptions) (domain.Forecast, error)
 }
 
 type eventService struct {
-	eventRepo  database.EventRepository
-	deviceRepo database.DeviceRepository
-	roomRepo   database.RoomRepository
+	eventRepo       database.EventRepository
+	deviceRepo      database.DeviceRepository
+	commandRepo     database.CommandRepository
+	roomRepo        database.RoomRepository
+	installRepo     database.DeviceInstallationRepository
+	measurementRepo database.MeasurementRepository
+	interlockRepo   database.InterlockRepository
+	limiter         demandLimiter
+	twins           twinStore
+	transactor      database.Transactor
+	hub             pubsub.Hub
+	// tx and recorded are set on a service bound to a transaction, see Tx
+	tx       *database.Tx
+	recorded *[]domain.Event
 }
 
-func NewEventService(er database.EventRepository, dr database.DeviceRepository, rr database.RoomRepository) EventService {
+func NewEventService(er database.EventRepository, dr database.DeviceRepository, cr database.CommandRepository, rr database.RoomRepository, ir database.DeviceInstallationRepository, mr database.MeasurementRepository, dmr database.DemandRepository, ilr database.InterlockRepository, dtr database.DeviceTwinRepository, tr database.Transactor, h pubsub.Hub) EventService {
 	return &eventService{
-		eventRepo:  er,
-		deviceRepo: dr,
-		roomRepo:   rr,
+		eventRepo:       er,
+		deviceRepo:      dr,
+		commandRepo:     cr,
+		roomRepo:        rr,
+		installRepo:     ir,
+		measurementRepo: mr,
+		interlockRepo:   ilr,
+		limiter:         demandLimiter{demandRepo: dmr, deviceRepo: dr, eventRepo: er, commandRepo: cr},
+		twins:           twinStore{twinRepo: dtr, hub: h},
+		transactor:      tr,
+		hub:             h,
 	}
 }
 
+// Tx runs fn in one transaction together with the writes of the repositories of tx. The event
+// service given to fn records within the transaction and publishes the events once it commits.
+func (s *eventService) Tx(fn func(es EventService, tx database.Tx) error) error {
+	return s.inTx(func(es *eventService, tx database.Tx) error {
+		return fn(es, tx)
+	})
+}
+
+func (s *eventService) inTx(fn func(es *eventService, tx database.Tx) error) error {
+	if s.tx != nil {
+		return fn(s, *s.tx)
+	}
+
+	var recorded []domain.Event
+	err := s.transactor.Tx(func(tx database.Tx) error {
+		recorded = nil
+		return fn(s.bind(tx, &recorded), tx)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range recorded {
+		s.publish(e)
+	}
+	return nil
+}
+
+// bind returns a copy of the service which reads and writes through the repositories of tx and
+// collects the events it records instead of publishing them.
+func (s *eventService) bind(tx database.Tx, recorded *[]domain.Event) *eventService {
+	bound := *s
+	bound.tx = &tx
+	bound.recorded = recorded
+	bound.eventRepo = tx.Events
+	bound.deviceRepo = tx.Devices
+	bound.commandRepo = tx.Commands
+	bound.interlockRepo = tx.Interlocks
+	bound.limiter.deviceRepo = tx.Devices
+	bound.limiter.eventRepo = tx.Events
+	bound.limiter.demandRepo = tx.Demand
+	bound.limiter.commandRepo = tx.Commands
+	return &bound
+}
+
+// Save records the event once the interlocks and the demand policy of the organization allow it.
 func (s *eventService) Save(event domain.Event) (domain.Event, error) {
+	req := domain.DemandRequest{
+		DeviceId:     event.DeviceId,
+		Source:       domain.EventDemand,
+		Action:       event.Action,
+		ActionParams: event.ActionParams,
+	}
+	if event.DemandRequestId != nil {
+		req.Id = *event.DemandRequestId
+	}
+
+	var createdEvent domain.Event
+	err := s.Admit(req, event.Override, func(es EventService, tx database.Tx) error {
+		var err error
+		createdEvent, err = es.Record(event)
+		return err
+	})
+	if err != nil {
+		return domain.Event{}, err
+	}
+
+	return createdEvent, nil
+}
+
+// Admit runs insert once the interlocks and the demand policy of the organization allow the
+// transition of the request. The checks and insert share a transaction which holds the lock of
+// the organization, so concurrent requests see the writes of each other. A request which the
+// policy queues or rejects is recorded as such and returned as domain.ErrDemandQueued or
+// domain.ErrDemandLimit without running insert. The interlocks an override breaks are recorded
+// together with the insert. A critical device only takes the transitions of approved commands,
+// any other request for it fails with domain.ErrApprovalRequired.
+func (s *eventService) Admit(req domain.DemandRequest, override *domain.InterlockOverride, insert func(es EventService, tx database.Tx) error) error {
+	device, err := s.deviceRepo.Find(req.DeviceId)
+	if err != nil {
+		log.Printf("EventService: %s", err)
+		return err
+	}
+	if device.Critical && req.ApprovalId == nil {
+		return domain.ErrApprovalRequired
+	}
+
+	var refusal error
+	err = s.inTx(func(es *eventService, tx database.Tx) error {
+		refusal = nil
+		err := tx.LockOrganization(device.OrganizationId)
+		if err != nil {
+			return err
+		}
+
+		overrides, err := es.interlockOverrides(req.DeviceId, req.Action, override)
+		if err != nil {
+			return err
+		}
+		req.Override = override
+		err = es.checkDemand(req)
+		if errors.Is(err, domain.ErrDemandQueued) || errors.Is(err, domain.ErrDemandLimit) {
+			// the queued request and the decision are kept
+			refusal = err
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = insert(es, tx)
+		if err != nil {
+			return err
+		}
+		for _, o := range overrides {
+			_, err = tx.Interlocks.SaveOverride(o)
+			if err != nil {
+				return err
+			}
+			log.Printf("EventService: User %d overrode interlock %d for device %d: %s", o.UserId, o.InterlockId, o.DeviceId, o.Reason)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return refusal
+}
+
+// Record saves an event which has already happened, such as one reported by the device itself,
+// without checking the interlocks and the demand policy.
+func (s *eventService) Record(event domain.Event) (domain.Event, error) {
 	event.CreatedDate = time.Now()
 	createdEvent, err := s.eventRepo.Save(event)
 	if err != nil {
@@ -38,9 +204,279 @@ func (s *eventService) Save(event domain.Event) (domain.Event, error) {
 	}
 
 	log.Printf("EventService: Event saved successfully: %+v", createdEvent)
+
+	if s.recorded != nil {
+		*s.recorded = append(*s.recorded, createdEvent)
+		return createdEvent, nil
+	}
+	s.publish(createdEvent)
+
 	return createdEvent, nil
 }
 
+// publish reports the state the event sets to the twin of the device and publishes the event.
+func (s *eventService) publish(event domain.Event) {
+	device, err := s.deviceRepo.Find(event.DeviceId)
+	if err != nil {
+		log.Printf("EventService: Error fetching device for publishing: %s", err)
+		return
+	}
+
+	_, err = s.twins.updateReported(device, domain.TwinStateFromEvent(event))
+	if err != nil {
+		log.Printf("EventService: Error updating reported state of device %d: %s", device.Id, err)
+	}
+
+	s.hub.Publish(pubsub.Message{
+		Type:           pubsub.EventSaved,
+		OrganizationId: device.OrganizationId,
+		RoomId:         event.RoomId,
+		DeviceId:       event.DeviceId,
+		Payload:        event,
+		CreatedDate:    event.CreatedDate,
+	})
+}
+
+// checkDemand measures a request to turn on an actuator against the demand policy of its
+// organization. A request which doesn't fit is rejected with domain.ErrDemandLimit, queued with
+// domain.ErrDemandQueued or let through after shedding devices of a lower priority. A request
+// which was queued before, one with an id, stays queued as it is.
+func (s *eventService) checkDemand(req domain.DemandRequest) error {
+	if req.Action != domain.TurnOn {
+		return nil
+	}
+	device, err := s.deviceRepo.Find(req.DeviceId)
+	if err != nil {
+		log.Printf("EventService: %s", err)
+		return err
+	}
+	if !s.limiter.applies(device, req.Action) {
+		return nil
+	}
+
+	p, enabled, err := s.limiter.policy(device.OrganizationId)
+	if err != nil {
+		log.Printf("EventService: %s", err)
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	eval, err := s.limiter.evaluate(p, device, req.ActionParams)
+	if err != nil {
+		log.Printf("EventService: %s", err)
+		return err
+	}
+	if eval.fits(p) {
+		return nil
+	}
+
+	decision := domain.DemandDecision{
+		OrganizationId: device.OrganizationId,
+		DeviceId:       device.Id,
+		Source:         req.Source,
+		Action:         req.Action,
+		RequestedKw:    eval.RequestedKw,
+		LoadKw:         eval.LoadKw,
+		LimitKw:        p.MaxKw,
+	}
+	reason := fmt.Sprintf("%.2f kW requested with %.2f kW running exceeds the limit of %.2f kW", eval.RequestedKw, eval.LoadKw, p.MaxKw)
+
+	switch p.Strategy {
+	case domain.ShedDemand:
+		eval.Sheddable, err = s.interlockSafe(eval.Sheddable, domain.TurnOff)
+		if err != nil {
+			return err
+		}
+		plan, ok := eval.shedPlan(p)
+		if !ok {
+			reason = fmt.Sprintf("%s, shedding lower priority devices frees only %.2f kW", reason, eval.sheddableKw())
+			break
+		}
+		decision.ShedDeviceIds, err = s.shed(plan)
+		if err != nil {
+			return err
+		}
+		decision.Outcome = domain.DemandShed
+		decision.Reason = fmt.Sprintf("%s, turned off %d lower priority devices", reason, len(decision.ShedDeviceIds))
+		s.recordDecision(decision)
+		return nil
+	case domain.QueueDemand:
+		if req.Id != 0 {
+			return fmt.Errorf("%w: request %d", domain.ErrDemandQueued, req.Id)
+		}
+		req.OrganizationId = device.OrganizationId
+		req.Status = domain.DemandRequestQueued
+		req.ExpiresDate = time.Now().Add(demandQueueTTL)
+		req, err = s.limiter.demandRepo.SaveRequest(req)
+		if err != nil {
+			log.Printf("EventService: Error queueing demand request: %s", err)
+			return err
+		}
+		decision.Outcome = domain.DemandQueued
+		decision.RequestId = &req.Id
+		decision.Reason = reason
+		s.recordDecision(decision)
+		return fmt.Errorf("%w: request %d", domain.ErrDemandQueued, req.Id)
+	}
+
+	decision.Outcome = domain.DemandRejected
+	decision.Reason = reason
+	s.recordDecision(decision)
+	return fmt.Errorf("%w: %s", domain.ErrDemandLimit, reason)
+}
+
+// shed turns off the devices and returns the ids of those which were turned off.
+func (s *eventService) shed(plan []runningDevice) ([]uint64, error) {
+	ids := make([]uint64, 0, len(plan))
+	for _, rd := range plan {
+		_, err := s.Record(domain.Event{
+			DeviceId: rd.Device.Id,
+			RoomId:   rd.Device.RoomId,
+			Action:   domain.TurnOff,
+		})
+		if errors.Is(err, domain.ErrNoStateChange) {
+			continue
+		}
+		if err != nil {
+			log.Printf("EventService: Error shedding device %d: %s", rd.Device.Id, err)
+			return nil, err
+		}
+		ids = append(ids, rd.Device.Id)
+	}
+	return ids, nil
+}
+
+// CheckInterlocks rejects a power transition of the device which an interlock forbids with
+// domain.ErrInterlock, an override lets it through. Nothing is recorded, Admit records the
+// overridden interlocks with the transition.
+func (s *eventService) CheckInterlocks(deviceId uint64, action domain.EventAction, override *domain.InterlockOverride) error {
+	_, err := s.interlockOverrides(deviceId, action, override)
+	return err
+}
+
+// interlockOverrides checks the interlocks of the transition like CheckInterlocks and returns a
+// record for every interlock the override breaks.
+func (s *eventService) interlockOverrides(deviceId uint64, action domain.EventAction, override *domain.InterlockOverride) ([]domain.InterlockOverride, error) {
+	if !action.IsPower() {
+		return nil, nil
+	}
+	device, err := s.deviceRepo.Find(deviceId)
+	if err != nil {
+		log.Printf("EventService: %s", err)
+		return nil, err
+	}
+	if device.PowerState() == action {
+		return nil, nil
+	}
+
+	broken, err := s.brokenInterlocks(device, action)
+	if err != nil {
+		log.Printf("EventService: %s", err)
+		return nil, err
+	}
+	if len(broken) == 0 {
+		return nil, nil
+	}
+	if override != nil && override.Reason == "" {
+		return nil, errors.New("an interlock override needs a reason")
+	}
+	if override == nil {
+		v := broken[0]
+		return nil, fmt.Errorf("%w %q: device %d can't be %s while device %d is or is about to be %s", domain.ErrInterlock, v.Interlock.Name, device.Id, action, v.Other.Id, v.OtherState)
+	}
+
+	overrides := make([]domain.InterlockOverride, len(broken))
+	for i, v := range broken {
+		overrides[i] = domain.InterlockOverride{
+			InterlockId:    v.Interlock.Id,
+			OrganizationId: device.OrganizationId,
+			DeviceId:       device.Id,
+			Action:         action,
+			UserId:         override.UserId,
+			Reason:         override.Reason,
+		}
+	}
+	return overrides, nil
+}
+
+type brokenInterlock struct {
+	Interlock  domain.Interlock
+	Other      domain.Device
+	OtherState domain.EventAction
+}
+
+// brokenInterlocks returns the interlocks the device entering the state would break. The other
+// device counts as in its state as well when an open command is about to put it there.
+func (s *eventService) brokenInterlocks(device domain.Device, state domain.EventAction) ([]brokenInterlock, error) {
+	interlocks, err := s.interlockRepo.FindEnabledByDeviceId(device.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	var broken []brokenInterlock
+	for _, i := range interlocks {
+		otherId, otherState, ok := i.Counterpart(device.Id, state)
+		if !ok {
+			continue
+		}
+		other, err := s.deviceRepo.Find(otherId)
+		if err != nil {
+			return nil, err
+		}
+		if other.DeletedDate != nil {
+			continue
+		}
+		in := other.PowerState() == otherState
+		if !in {
+			in, err = s.commanded(other, otherState)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if in {
+			broken = append(broken, brokenInterlock{Interlock: i, Other: other, OtherState: otherState})
+		}
+	}
+	return broken, nil
+}
+
+// commanded reports whether the device has an open command to enter the state.
+func (s *eventService) commanded(device domain.Device, state domain.EventAction) (bool, error) {
+	cmds, err := s.commandRepo.FindOpenByDeviceId(device.Id, time.Now())
+	if err != nil {
+		return false, err
+	}
+	for _, c := range cmds {
+		if c.Action == state {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// interlockSafe returns the running devices which may enter the state without breaking an interlock.
+func (s *eventService) interlockSafe(devices []runningDevice, state domain.EventAction) ([]runningDevice, error) {
+	var safe []runningDevice
+	for _, rd := range devices {
+		broken, err := s.brokenInterlocks(rd.Device, state)
+		if err != nil {
+			return nil, err
+		}
+		if len(broken) == 0 {
+			safe = append(safe, rd)
+		}
+	}
+	return safe, nil
+}
+
+func (s *eventService) recordDecision(d domain.DemandDecision) {
+	_, err := s.limiter.demandRepo.SaveDecision(d)
+	if err != nil {
+		log.Printf("EventService: Error saving demand decision: %s", err)
+	}
+}
+
 func (s *eventService) Find(id uint64) (interface{}, error) {
 	event, err := s.eventRepo.Find(id)
 	if err != nil {
@@ -61,78 +497,319 @@ func (s *eventService) FindAll() ([]domain.Event, error) {
 	return events, nil
 }
 
+// GetPowerConsumptionByRoom counts the devices for the time they were installed in the room.
 func (s *eventService) GetPowerConsumptionByRoom(roomID uint64, startDate, endDate time.Time) (float64, error) {
-	events, err := s.eventRepo.FindByRoomAndDate(roomID, startDate, endDate)
+	room, err := s.roomRepo.Find(roomID)
 	if err != nil {
-		log.Printf("EventService: Error finding events by room: %s", err)
+		log.Printf("EventService: Error finding room: %s", err)
 		return 0, err
 	}
 
-	totalPC, err := s.calculatePowerConsumption(events, startDate, endDate)
+	intervals, err := s.scopedIntervals(domain.ConsumptionScope{OrganizationId: room.OrganizationId, RoomId: &roomID}, startDate, endDate)
 	if err != nil {
 		log.Printf("EventService: Error calculating power consumption by room: %s", err)
 		return 0, err
 	}
 
-	return totalPC, nil
+	totalPowerConsumption := 0.0
+	for _, i := range intervals {
+		totalPowerConsumption += i.Energy()
+	}
+
+	return totalPowerConsumption, nil
 }
 
-func (s *eventService) calculatePowerConsumption(events []domain.Event, startDate, endDate time.Time) (float64, error) {
-	totalPowerConsumption := 0.0
-	currentTime := time.Now()
-	consumptionByDevice := make(map[uint64]float64)
-	deviceOnTimes := make(map[uint64]time.Time)
-
-	for _, event := range events {
-		if event.Action == domain.TurnOn {
-			deviceOnTimes[event.DeviceId] = event.CreatedDate
-		} else if event.Action == domain.TurnOff {
-			onTime, exists := deviceOnTimes[event.DeviceId]
-			if exists {
-				device, err := s.deviceRepo.Find(event.DeviceId)
-				if err != nil {
-					log.Printf("EventService: Error fetching device: %s", err)
-					return 0, err
-				}
-
-				onTimeVal := maxTime(onTime, startDate)
-				offTimeVal := minTime(event.CreatedDate, endDate)
-
-				if onTimeVal.Before(offTimeVal) {
-					duration := offTimeVal.Sub(onTimeVal).Hours()
-					consumptionByDevice[event.DeviceId] += duration * *device.PowerConsumption
-					log.Printf("Device ID: %d, OnTime: %v, OffTime: %v, Duration: %f hours, PowerConsumption: %f",
-						event.DeviceId, onTimeVal, offTimeVal, duration, *device.PowerConsumption)
-				}
-				delete(deviceOnTimes, event.DeviceId)
-			}
-		}
+// GetPowerSeries returns the energy per bucket of the period and the peak power of the scope.
+func (s *eventService) GetPowerSeries(scope domain.ConsumptionScope, period domain.Period, size domain.BucketSize) (domain.PowerSeries, error) {
+	buckets, err := period.Split(size)
+	if err != nil {
+		return domain.PowerSeries{}, err
+	}
+
+	intervals, err := s.scopedIntervals(scope, period.Start, period.End)
+	if err != nil {
+		log.Printf("EventService: Error calculating power series: %s", err)
+		return domain.PowerSeries{}, err
+	}
+
+	return buildPowerSeries(intervals, buckets, size), nil
+}
+
+// GetEnergyCost prices the consumption of the scope within the period by the tariff.
+func (s *eventService) GetEnergyCost(scope domain.ConsumptionScope, period domain.Period, tariff domain.Tariff) (domain.EnergyCost, error) {
+	intervals, err := s.scopedIntervals(scope, period.Start, period.End)
+	if err != nil {
+		log.Printf("EventService: Error calculating energy cost: %s", err)
+		return domain.EnergyCost{}, err
+	}
+
+	return priceEnergy(tariff, intervals, period), nil
+}
+
+// GetEmissions converts the consumption of the scope within the period into CO₂e by the factor.
+func (s *eventService) GetEmissions(scope domain.ConsumptionScope, period domain.Period, factor domain.EmissionFactor) (domain.Emissions, error) {
+	intervals, err := s.scopedIntervals(scope, period.Start, period.End)
+	if err != nil {
+		log.Printf("EventService: Error calculating emissions: %s", err)
+		return domain.Emissions{}, err
+	}
+
+	return computeEmissions(factor, intervals, period), nil
+}
+
+// GetForecast trains the model on the weeks of consumption of the scope before the current hour
+// and forecasts the part of the period which is still ahead.
+func (s *eventService) GetForecast(scope domain.ConsumptionScope, period domain.Period, size domain.BucketSize, opts domain.ForecastOptions) (domain.Forecast, error) {
+	err := opts.Validate()
+	if err != nil {
+		return domain.Forecast{}, fmt.Errorf("%w: %s", domain.ErrInvalidForecast, err)
+	}
+
+	loc := period.Location
+	trainingEnd := hourStart(time.Now(), loc)
+	if !period.End.After(trainingEnd) {
+		return domain.Forecast{}, fmt.Errorf("%w: period must end in the future", domain.ErrInvalidForecast)
+	}
+	if period.End.Sub(trainingEnd) > maxForecastHorizon {
+		return domain.Forecast{}, fmt.Errorf("%w: period must end within %d days", domain.ErrInvalidForecast, int(maxForecastHorizon.Hours()/24))
+	}
+	if period.Start.Before(trainingEnd) {
+		period.Start = trainingEnd
+	}
+	buckets, err := period.Split(size)
+	if err != nil {
+		return domain.Forecast{}, fmt.Errorf("%w: %s", domain.ErrInvalidForecast, err)
+	}
+	// the models continue the history hour by hour up to the end of the period
+	hours, err := domain.Period{Start: trainingEnd, End: period.End, Location: loc}.Split(domain.HourBucket)
+	if err != nil {
+		return domain.Forecast{}, fmt.Errorf("%w: %s", domain.ErrInvalidForecast, err)
+	}
+
+	training := domain.Period{Start: trainingEnd.AddDate(0, 0, -7*opts.TrainingWeeks), End: trainingEnd, Location: loc}
+	history, err := s.GetPowerSeries(scope, training, domain.HourBucket)
+	if err != nil {
+		return domain.Forecast{}, err
+	}
+
+	forecasts, ok := forecastHours(opts.Model, history.Buckets, hours)
+	if !ok {
+		return domain.Forecast{}, fmt.Errorf("%w: %d weeks of history are too few for the %s model", domain.ErrInvalidForecast, opts.TrainingWeeks, opts.Model)
+	}
+	// only the hours within the period are summed up
+	first := 0
+	for first < len(hours) && !hours[first].End.After(period.Start) {
+		first++
+	}
+	hours, forecasts = hours[first:], forecasts[first:]
+	if len(hours) > 0 && hours[0].Start.Before(period.Start) {
+		scale := hours[0].End.Sub(period.Start).Hours() / hours[0].Duration().Hours()
+		forecasts[0] = hourForecast{Energy: forecasts[0].Energy * scale, Sigma: forecasts[0].Sigma * scale}
+		hours[0].Start = period.Start
+	}
+
+	z := confidenceZ[opts.Confidence]
+	fBuckets, energy, variance := buildForecast(hours, forecasts, buckets, z)
+	lower, upper := band(energy, variance, z)
+	return domain.Forecast{
+		Model:         opts.Model,
+		Confidence:    opts.Confidence,
+		TrainingStart: training.Start,
+		TrainingEnd:   training.End,
+		Start:         period.Start,
+		End:           period.End,
+		Size:          size,
+		Energy:        energy,
+		Lower:         lower,
+		Upper:         upper,
+		Buckets:       fBuckets,
+		Backtest:      backtest(opts.Model, history.Buckets, z),
+	}, nil
+}
+
+// GetEnergy returns the kWh the scope consumed between the dates.
+func (s *eventService) GetEnergy(scope domain.ConsumptionScope, startDate, endDate time.Time) (float64, error) {
+	intervals, err := s.scopedIntervals(scope, startDate, endDate)
+	if err != nil {
+		log.Printf("EventService: Error calculating energy: %s", err)
+		return 0, err
 	}
 
-	for deviceId, onTime := range deviceOnTimes {
-		device, err := s.deviceRepo.Find(deviceId)
+	var energy float64
+	for _, i := range intervals {
+		energy += i.Energy()
+	}
+	return energy, nil
+}
+
+// scopedIntervals returns the power intervals of the devices in the scope. For a room only the
+// time the devices were installed there is counted.
+func (s *eventService) scopedIntervals(scope domain.ConsumptionScope, startDate, endDate time.Time) ([]powerInterval, error) {
+	orgDevices, err := s.deviceRepo.FindByOrgId(scope.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	var installs []domain.DeviceInstallation
+	installed := make(map[uint64]bool)
+	if scope.RoomId != nil {
+		installs, err = s.installRepo.FindByRoomId(*scope.RoomId, startDate, endDate)
 		if err != nil {
-			log.Printf("EventService: Error fetching device: %s", err)
-			return 0, err
+			return nil, err
+		}
+		for _, in := range installs {
+			installed[in.DeviceId] = true
 		}
+	}
 
-		onTimeVal := maxTime(onTime, startDate)
-		offTimeVal := minTime(currentTime, endDate)
+	// a meter of a device is in the scope of the device
+	var devices []domain.Device
+	for _, d := range orgDevices {
+		if scope.DeviceId != nil && d.Id != *scope.DeviceId && d.MeteredId() != *scope.DeviceId {
+			continue
+		}
+		if scope.RoomId != nil && !installed[d.MeteredId()] {
+			continue
+		}
+		devices = append(devices, d)
+	}
 
-		if onTimeVal.Before(offTimeVal) {
-			duration := offTimeVal.Sub(onTimeVal).Hours()
-			consumptionByDevice[deviceId] += duration * *device.PowerConsumption
+	intervals, err := s.powerIntervals(devices, startDate, endDate)
+	if err != nil || scope.RoomId == nil {
+		return intervals, err
+	}
 
-			log.Printf("Device ID: %d, OnTime: %v, OffTime: %v, Duration: %f hours, PowerConsumption: %f",
-				deviceId, onTimeVal, offTimeVal, duration, *device.PowerConsumption)
+	var inRoom []powerInterval
+	for _, i := range splitByInstallations(intervals, installs) {
+		if i.RoomId != nil && *i.RoomId == *scope.RoomId {
+			inRoom = append(inRoom, i.powerInterval)
 		}
 	}
+	return inRoom, nil
+}
 
-	for _, consumption := range consumptionByDevice {
-		totalPowerConsumption += consumption
+// GetConsumptionReport breaks the consumption of the organization down by room and by device.
+// Devices moved during the period are counted in every room for the time they spent there.
+func (s *eventService) GetConsumptionReport(orgId uint64, startDate, endDate time.Time) (domain.ConsumptionReport, error) {
+	devices, err := s.deviceRepo.FindByOrgId(orgId)
+	if err != nil {
+		log.Printf("EventService: Error finding devices: %s", err)
+		return domain.ConsumptionReport{}, err
+	}
+	rooms, err := s.roomRepo.FindByOrgId(orgId)
+	if err != nil {
+		log.Printf("EventService: Error finding rooms: %s", err)
+		return domain.ConsumptionReport{}, err
 	}
 
-	return totalPowerConsumption, nil
+	intervals, err := s.powerIntervals(devices, startDate, endDate)
+	if err != nil {
+		log.Printf("EventService: Error calculating consumption: %s", err)
+		return domain.ConsumptionReport{}, err
+	}
+	ids := make([]uint64, len(devices))
+	for i, d := range devices {
+		ids[i] = d.Id
+	}
+	installs, err := s.installRepo.FindByDeviceIds(ids, startDate, endDate)
+	if err != nil {
+		log.Printf("EventService: Error finding installations: %s", err)
+		return domain.ConsumptionReport{}, err
+	}
+
+	return buildConsumptionReport(orgId, startDate, endDate, devices, rooms, splitByInstallations(intervals, installs)), nil
+}
+
+// powerIntervals returns the intervals of constant power of the devices within the period. Devices
+// which were already on at its start are counted from the start.
+func (s *eventService) powerIntervals(devices []domain.Device, startDate, endDate time.Time) ([]powerInterval, error) {
+	byId := make(map[uint64]domain.Device)
+	var ids []uint64
+	for _, d := range devices {
+		if d.Category == domain.Actuator && d.PowerConsumption != nil {
+			byId[d.Id] = d
+			ids = append(ids, d.Id)
+		}
+	}
+
+	events, err := s.eventRepo.FindByDevicesAndDate(ids, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	levels := make(map[uint64]float64)
+	for _, id := range ids {
+		last, err := s.eventRepo.FindLastBefore(id, domain.SetLevel, startDate)
+		if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
+			return nil, err
+		}
+		if err == nil && last.Level != nil {
+			levels[id] = *last.Level
+		}
+
+		power, err := s.eventRepo.FindLastPowerBefore(id, startDate)
+		if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
+			return nil, err
+		}
+		if err == nil && power.Action == domain.TurnOn {
+			events = append(events, power)
+		}
+	}
+
+	end := minTime(time.Now(), endDate)
+	intervals := buildPowerIntervals(events, byId, levels, startDate, end)
+
+	// metered consumption is preferred over the estimates wherever there are readings
+	for _, d := range devices {
+		if d.Category != domain.Meter {
+			continue
+		}
+		readings, err := s.meterReadings(d.Id, startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+		metered, from, to := meterIntervals(d, readings, startDate, end)
+		intervals = append(replaceEstimates(intervals, d.MeteredId(), from, to), metered...)
+	}
+	return intervals, nil
+}
+
+// meterReadings returns the usable readings of a meter within the period together with the last
+// one before and the first one after it, sorted by time.
+func (s *eventService) meterReadings(meterId uint64, startDate, endDate time.Time) ([]domain.Measurement, error) {
+	all, err := s.measurementRepo.FindByDeviceAndDate(meterId, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	var readings []domain.Measurement
+	for _, m := range all {
+		if m.Usable() {
+			readings = append(readings, m)
+		}
+	}
+
+	before, err := s.measurementRepo.FindLastBefore(meterId, nil, startDate)
+	if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
+		return nil, err
+	}
+	if err == nil {
+		readings = append(readings, before)
+	}
+	after, err := s.measurementRepo.FindFirstFrom(meterId, nil, endDate)
+	if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
+		return nil, err
+	}
+	if err == nil {
+		readings = append(readings, after)
+	}
+
+	sort.Slice(readings, func(i, j int) bool {
+		if readings[i].CreatedDate.Equal(readings[j].CreatedDate) {
+			return readings[i].Id < readings[j].Id
+		}
+		return readings[i].CreatedDate.Before(readings[j].CreatedDate)
+	})
+	return readings, nil
 }
 
 func minTime(a, b time.Time) time.Time {
@@ -0,0 +1,294 @@
+package app
+
+import (
+	"math"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+const (
+	// hoursPerWeek is the season of the models, consumption mostly repeats by the week.
+	hoursPerWeek = 7 * 24
+	// holtWintersDamping flattens the trend, so it doesn't run away over a month of hours.
+	holtWintersDamping = 0.98
+	// maxForecastHorizon keeps forecasts to about the next quarter.
+	maxForecastHorizon = 93 * 24 * time.Hour
+)
+
+// confidenceZ is the two-sided quantile of the normal distribution for each allowed confidence.
+var confidenceZ = map[float64]float64{
+	0.8:  1.2816,
+	0.9:  1.6449,
+	0.95: 1.9600,
+	0.99: 2.5758,
+}
+
+// hourForecast is the expected energy of an hour and its standard deviation.
+type hourForecast struct {
+	Energy float64
+	Sigma  float64
+}
+
+// forecastHours trains the model on the hourly history and forecasts the hours, which follow
+// the history. It returns false when the history is too short for the model.
+func forecastHours(model domain.ForecastModel, history []domain.PowerBucket, hours []domain.Period) ([]hourForecast, bool) {
+	switch model {
+	case domain.HoltWintersModel:
+		return holtWintersForecast(history, hours)
+	default:
+		return profileForecast(history, hours)
+	}
+}
+
+// profileForecast expects each hour to use the mean of the same hour of the week in the history.
+// An hour of the week seen less than twice falls back to the same hour of every day.
+func profileForecast(history []domain.PowerBucket, hours []domain.Period) ([]hourForecast, bool) {
+	var week [hoursPerWeek][]float64
+	var day [24][]float64
+	for _, b := range history {
+		// a bucket cut by the training period says little about its hour
+		if b.End.Sub(b.Start) != time.Hour {
+			continue
+		}
+		slot := weekHour(b.Start)
+		week[slot] = append(week[slot], b.Energy)
+		day[slot%24] = append(day[slot%24], b.Energy)
+	}
+
+	res := make([]hourForecast, len(hours))
+	for i, h := range hours {
+		slot := weekHour(h.Start)
+		samples := week[slot]
+		if len(samples) < 2 {
+			samples = day[slot%24]
+		}
+		if len(samples) < 2 {
+			return nil, false
+		}
+		mean, sigma := meanAndDeviation(samples)
+		res[i] = hourForecast{Energy: mean, Sigma: sigma}
+	}
+	return res, true
+}
+
+// weekHour numbers the hours of the week from Sunday midnight in the location of t.
+func weekHour(t time.Time) int {
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+func meanAndDeviation(samples []float64) (float64, float64) {
+	var sum float64
+	for _, s := range samples {
+		sum += s
+	}
+	mean := sum / float64(len(samples))
+	var sq float64
+	for _, s := range samples {
+		sq += (s - mean) * (s - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(samples)-1))
+}
+
+// holtWinters is additive triple exponential smoothing with a damped trend.
+type holtWinters struct {
+	Alpha float64
+	Beta  float64
+	Gamma float64
+}
+
+// holtWintersFit is the state of a model after the history and the deviation of its one step
+// ahead errors.
+type holtWintersFit struct {
+	Params holtWinters
+	Level  float64
+	Trend  float64
+	Season []float64
+	Steps  int
+	Sigma  float64
+	sse    float64
+}
+
+func (p holtWinters) fit(values []float64, m int) holtWintersFit {
+	first, second := mean(values[:m]), mean(values[m:2*m])
+	f := holtWintersFit{
+		Params: p,
+		Level:  first,
+		Trend:  (second - first) / float64(m),
+		Season: make([]float64, m),
+		Steps:  len(values),
+	}
+	for i := 0; i < m; i++ {
+		f.Season[i] = values[i] - first
+	}
+
+	var n int
+	for t := m; t < len(values); t++ {
+		s := f.Season[t%m]
+		e := values[t] - (f.Level + holtWintersDamping*f.Trend + s)
+		f.sse += e * e
+		n++
+
+		prev := f.Level
+		f.Level = p.Alpha*(values[t]-s) + (1-p.Alpha)*(prev+holtWintersDamping*f.Trend)
+		f.Trend = p.Beta*(f.Level-prev) + (1-p.Beta)*holtWintersDamping*f.Trend
+		f.Season[t%m] = p.Gamma*(values[t]-f.Level) + (1-p.Gamma)*s
+	}
+	f.Sigma = math.Sqrt(f.sse / float64(n))
+	return f
+}
+
+// at forecasts the value h steps after the history, h starting at 1.
+func (f holtWintersFit) at(h int) float64 {
+	var damp, phi float64 = 0, 1
+	for i := 0; i < h; i++ {
+		phi *= holtWintersDamping
+		damp += phi
+	}
+	return f.Level + damp*f.Trend + f.Season[(f.Steps+h-1)%len(f.Season)]
+}
+
+// holtWintersForecast picks the smoothing parameters with the smallest one step ahead error on
+// the history. The deviation of an hour is the one of those errors.
+func holtWintersForecast(history []domain.PowerBucket, hours []domain.Period) ([]hourForecast, bool) {
+	if len(history) < 2*hoursPerWeek {
+		return nil, false
+	}
+	values := make([]float64, len(history))
+	for i, b := range history {
+		values[i] = b.Energy
+	}
+
+	var best *holtWintersFit
+	for _, alpha := range []float64{0.05, 0.1, 0.2, 0.4} {
+		for _, beta := range []float64{0, 0.01, 0.05} {
+			for _, gamma := range []float64{0.05, 0.1, 0.2, 0.4} {
+				f := holtWinters{Alpha: alpha, Beta: beta, Gamma: gamma}.fit(values, hoursPerWeek)
+				if best == nil || f.sse < best.sse {
+					best = &f
+				}
+			}
+		}
+	}
+
+	end := history[len(history)-1].End
+	res := make([]hourForecast, len(hours))
+	for i, h := range hours {
+		step := int(h.Start.Sub(end)/time.Hour) + 1
+		res[i] = hourForecast{Energy: math.Max(best.at(step), 0), Sigma: best.Sigma}
+	}
+	return res, true
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// buildForecast sums the hours up per bucket. Hours cut by the period count by the part of them
+// within it. The band treats the errors of the hours as independent, so the deviation of a bucket
+// is the root of the summed variances.
+func buildForecast(hours []domain.Period, forecasts []hourForecast, buckets []domain.Period, z float64) ([]domain.ForecastBucket, float64, float64) {
+	res := make([]domain.ForecastBucket, len(buckets))
+	variances := make([]float64, len(buckets))
+	var (
+		next     int
+		total    float64
+		variance float64
+	)
+	for i, b := range buckets {
+		res[i] = domain.ForecastBucket{Start: b.Start, End: b.End}
+		for ; next < len(hours) && hours[next].Start.Before(b.End); next++ {
+			share := hours[next].Duration().Hours()
+			res[i].Energy += forecasts[next].Energy * share
+			variances[i] += math.Pow(forecasts[next].Sigma*share, 2)
+		}
+		total += res[i].Energy
+		variance += variances[i]
+	}
+	for i := range res {
+		res[i].Lower, res[i].Upper = band(res[i].Energy, variances[i], z)
+	}
+	return res, total, variance
+}
+
+// band returns the bounds around the energy with the variance, never below zero.
+func band(energy, variance, z float64) (float64, float64) {
+	d := z * math.Sqrt(variance)
+	return math.Max(energy-d, 0), energy + d
+}
+
+// backtest forecasts the last week of the history by the weeks before it and compares the hours
+// with the ones consumed. It returns nil when the weeks before are too few for the model.
+func backtest(model domain.ForecastModel, history []domain.PowerBucket, z float64) *domain.ForecastAccuracy {
+	if len(history) == 0 {
+		return nil
+	}
+	testStart := history[len(history)-1].End.AddDate(0, 0, -7)
+	split := 0
+	for split < len(history) && history[split].Start.Before(testStart) {
+		split++
+	}
+	train, test := history[:split], history[split:]
+	if len(train) == 0 || len(test) == 0 {
+		return nil
+	}
+
+	hours := make([]domain.Period, len(test))
+	for i, b := range test {
+		hours[i] = domain.Period{Start: b.Start, End: b.End}
+	}
+	forecasts, ok := forecastHours(model, train, hours)
+	if !ok {
+		return nil
+	}
+
+	acc := domain.ForecastAccuracy{Start: test[0].Start, End: test[len(test)-1].End, Hours: len(test)}
+	var (
+		absSum, sqSum, errSum, actualSum, pctSum float64
+		pctHours, covered                        int
+	)
+	for i, b := range test {
+		e := forecasts[i].Energy - b.Energy
+		absSum += math.Abs(e)
+		sqSum += e * e
+		errSum += e
+		actualSum += b.Energy
+		if b.Energy > 0 {
+			pctSum += math.Abs(e) / b.Energy
+			pctHours++
+		}
+		lower, upper := band(forecasts[i].Energy, forecasts[i].Sigma*forecasts[i].Sigma, z)
+		if b.Energy >= lower-demandEpsilon && b.Energy <= upper+demandEpsilon {
+			covered++
+		}
+	}
+	n := float64(len(test))
+	acc.Mae = absSum / n
+	acc.Rmse = math.Sqrt(sqSum / n)
+	acc.Bias = errSum / n
+	acc.Coverage = float64(covered) / n
+	if pctHours > 0 {
+		mape := 100 * pctSum / float64(pctHours)
+		acc.Mape = &mape
+	}
+	if actualSum > 0 {
+		wape := 100 * absSum / actualSum
+		acc.Wape = &wape
+	}
+	return &acc
+}
+
+// hourStart returns the start of the hour of t in loc.
+func hourStart(t time.Time, loc *time.Location) time.Time {
+	l := t.In(loc)
+	start := time.Date(l.Year(), l.Month(), l.Day(), l.Hour(), 0, 0, 0, loc)
+	if start.After(l) {
+		// the repeated hour when clocks are turned back
+		start = start.Add(-time.Hour)
+	}
+	return start
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+)
+
+const interlockOverrideLimit = 100
+
+type InterlockService interface {
+	Save(i domain.Interlock) (domain.Interlock, error)
+	Find(id uint64) (interface{}, error)
+	FindByOrgId(orgId uint64) ([]domain.Interlock, error)
+	Update(i domain.Interlock) (domain.Interlock, error)
+	Delete(id uint64) error
+	FindOverrides(orgId uint64) ([]domain.InterlockOverride, error)
+}
+
+type interlockService struct {
+	interlockRepo database.InterlockRepository
+	deviceRepo    database.DeviceRepository
+}
+
+func NewInterlockService(ilr database.InterlockRepository, dr database.DeviceRepository) InterlockService {
+	return interlockService{
+		interlockRepo: ilr,
+		deviceRepo:    dr,
+	}
+}
+
+func (s interlockService) Save(i domain.Interlock) (domain.Interlock, error) {
+	err := s.validate(i)
+	if err != nil {
+		return domain.Interlock{}, err
+	}
+
+	i, err = s.interlockRepo.Save(i)
+	if err != nil {
+		log.Printf("InterlockService: %s", err)
+		return domain.Interlock{}, err
+	}
+
+	return i, nil
+}
+
+func (s interlockService) Find(id uint64) (interface{}, error) {
+	i, err := s.interlockRepo.Find(id)
+	if err != nil {
+		log.Printf("InterlockService: %s", err)
+		return nil, err
+	}
+
+	return i, nil
+}
+
+func (s interlockService) FindByOrgId(orgId uint64) ([]domain.Interlock, error) {
+	interlocks, err := s.interlockRepo.FindByOrgId(orgId)
+	if err != nil {
+		log.Printf("InterlockService: %s", err)
+		return nil, err
+	}
+
+	return interlocks, nil
+}
+
+func (s interlockService) Update(i domain.Interlock) (domain.Interlock, error) {
+	err := s.validate(i)
+	if err != nil {
+		return domain.Interlock{}, err
+	}
+
+	i, err = s.interlockRepo.Update(i)
+	if err != nil {
+		log.Printf("InterlockService: %s", err)
+		return domain.Interlock{}, err
+	}
+
+	return i, nil
+}
+
+func (s interlockService) Delete(id uint64) error {
+	err := s.interlockRepo.Delete(id)
+	if err != nil {
+		log.Printf("InterlockService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s interlockService) FindOverrides(orgId uint64) ([]domain.InterlockOverride, error) {
+	overrides, err := s.interlockRepo.FindOverridesByOrgId(orgId, interlockOverrideLimit)
+	if err != nil {
+		log.Printf("InterlockService: %s", err)
+		return nil, err
+	}
+
+	return overrides, nil
+}
+
+func (s interlockService) validate(i domain.Interlock) error {
+	err := i.Validate()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range []uint64{i.DeviceId, i.OtherDeviceId} {
+		device, err := s.deviceRepo.Find(id)
+		if err != nil || device.OrganizationId != i.OrganizationId || device.DeletedDate != nil {
+			return fmt.Errorf("device %d not found in organization", id)
+		}
+		if device.Category != domain.Actuator {
+			return fmt.Errorf("device %d is not an actuator", id)
+		}
+	}
+	return nil
+}
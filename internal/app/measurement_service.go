@@ -1,31 +1,153 @@
 package app
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/pubsub"
+	"github.com/upper/db/v4"
 )
 
 type MeasurementService interface {
 	Save(m domain.Measurement) (domain.Measurement, error)
-	FindByDeviceAndDate(deviceId uint64, startDate, endDate time.Time) (interface{}, error)
+	SaveReadings(deviceId uint64, roomId *uint64, readings []domain.ChannelReading) ([]domain.Measurement, error)
+	FindByDeviceAndDate(deviceId uint64, startDate, endDate time.Time) ([]domain.Measurement, error)
+	ConvertTo(device domain.Device, measurements []domain.Measurement, unit domain.Unit) ([]domain.Measurement, error)
+	Summarize(device domain.Device, channels []string, period domain.Period, size domain.BucketSize, unit *domain.Unit) ([]domain.ChannelSummary, error)
 	Find(id uint64) (interface{}, error)
 	FindAll() ([]domain.Measurement, error)
 }
 
 type measurementService struct {
 	measurementRepo database.MeasurementRepository
+	deviceRepo      database.DeviceRepository
+	channelRepo     database.ChannelRepository
+	calibrationRepo database.CalibrationRepository
+	anomalyService  AnomalyService
+	hub             pubsub.Hub
 }
 
-func NewMeasurementService(mr database.MeasurementRepository) MeasurementService {
+func NewMeasurementService(mr database.MeasurementRepository, dr database.DeviceRepository, chr database.ChannelRepository, clr database.CalibrationRepository, as AnomalyService, h pubsub.Hub) MeasurementService {
 	return &measurementService{
 		measurementRepo: mr,
+		deviceRepo:      dr,
+		channelRepo:     chr,
+		calibrationRepo: clr,
+		anomalyService:  as,
+		hub:             h,
 	}
 }
 
+// Save corrects the raw reading by the calibration of the sensor or its channel in effect, flags
+// the quality of the corrected value by the limits of the device or the channel and stores both.
+// Readings out of the limits are kept, they are only left out of aggregations. A sensor with
+// channels only takes readings of its channels.
 func (s *measurementService) Save(dm domain.Measurement) (domain.Measurement, error) {
+	device, err := s.device(dm.DeviceId)
+	if err != nil {
+		return domain.Measurement{}, err
+	}
+	err = device.ValidateReadingChannel(dm.ChannelId)
+	if err != nil {
+		return domain.Measurement{}, err
+	}
+
+	return s.save(device, dm)
+}
+
+// SaveReadings stores the readings of several channels of a sensor taken at once. Nothing is
+// stored when one of the channels is unknown.
+func (s *measurementService) SaveReadings(deviceId uint64, roomId *uint64, readings []domain.ChannelReading) ([]domain.Measurement, error) {
+	device, err := s.device(deviceId)
+	if err != nil {
+		return nil, err
+	}
+
+	measurements := make([]domain.Measurement, len(readings))
+	seen := make(map[string]bool, len(readings))
+	for i, r := range readings {
+		ch, ok := device.Channel(r.Channel)
+		if !ok {
+			return nil, fmt.Errorf("%w: device %d has no channel %q", domain.ErrInvalidChannel, device.Id, r.Channel)
+		}
+		if seen[r.Channel] {
+			return nil, fmt.Errorf("%w: channel %q is reported twice", domain.ErrInvalidChannel, r.Channel)
+		}
+		seen[r.Channel] = true
+		measurements[i] = domain.Measurement{
+			DeviceId:  device.Id,
+			RoomId:    roomId,
+			ChannelId: &ch.Id,
+			Value:     r.Value,
+		}
+	}
+
+	for i, m := range measurements {
+		measurements[i], err = s.save(device, m)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return measurements, nil
+}
+
+// device returns the device together with its channels.
+func (s *measurementService) device(id uint64) (domain.Device, error) {
+	device, err := s.deviceRepo.Find(id)
+	if err != nil {
+		log.Printf("MeasurementService: Error fetching device: %s", err)
+		return domain.Device{}, err
+	}
+	if device.Category != domain.Sensor {
+		return device, nil
+	}
+
+	device.Channels, err = s.channelRepo.FindByDeviceId(device.Id)
+	if err != nil {
+		log.Printf("MeasurementService: Error fetching channels: %s", err)
+		return domain.Device{}, err
+	}
+	return device, nil
+}
+
+func (s *measurementService) save(device domain.Device, dm domain.Measurement) (domain.Measurement, error) {
+	limits := device
+	if dm.ChannelId != nil {
+		ch, _ := device.ChannelById(*dm.ChannelId)
+		limits = device.ForChannel(&ch)
+	}
+
+	now := time.Now()
+	dm.RawValue = dm.Value
+	if device.Category == domain.Sensor {
+		c, err := s.calibrationRepo.FindEffective(device.Id, dm.ChannelId, now)
+		if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
+			log.Printf("MeasurementService: Error fetching calibration: %s", err)
+			return domain.Measurement{}, err
+		}
+		if err == nil {
+			dm.Value = c.Apply(dm.RawValue)
+			dm.CalibrationId = &c.Id
+		}
+	}
+
+	var prev *domain.Measurement
+	if limits.MaxRate != nil {
+		last, err := s.measurementRepo.FindLatestByDeviceId(device.Id, dm.ChannelId)
+		if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
+			log.Printf("MeasurementService: Error fetching last measurement: %s", err)
+			return domain.Measurement{}, err
+		}
+		if err == nil {
+			prev = &last
+		}
+	}
+	dm.Quality, dm.QualityReason = limits.AssessReading(dm.Value, now, prev)
+
 	createdMeasurement, err := s.measurementRepo.Save(dm)
 	if err != nil {
 		log.Printf("MeasurementService: Error saving measurement: %s", err)
@@ -33,10 +155,22 @@ func (s *measurementService) Save(dm domain.Measurement) (domain.Measurement, er
 	}
 
 	log.Printf("MeasurementService: Measurement saved successfully: %+v", createdMeasurement)
+	s.hub.Publish(pubsub.Message{
+		Type:           pubsub.MeasurementSaved,
+		OrganizationId: device.OrganizationId,
+		RoomId:         createdMeasurement.RoomId,
+		DeviceId:       createdMeasurement.DeviceId,
+		Payload:        createdMeasurement,
+		CreatedDate:    createdMeasurement.CreatedDate,
+	})
+	if createdMeasurement.Usable() {
+		s.anomalyService.Inspect(device, createdMeasurement)
+	}
+
 	return createdMeasurement, nil
 }
 
-func (s *measurementService) FindByDeviceAndDate(deviceId uint64, startDate, endDate time.Time) (interface{}, error) {
+func (s *measurementService) FindByDeviceAndDate(deviceId uint64, startDate, endDate time.Time) ([]domain.Measurement, error) {
 	measurements, err := s.measurementRepo.FindByDeviceAndDate(deviceId, startDate, endDate)
 	if err != nil {
 		log.Printf("MeasurementService: %s", err)
@@ -46,6 +180,101 @@ func (s *measurementService) FindByDeviceAndDate(deviceId uint64, startDate, end
 	return measurements, nil
 }
 
+// ConvertTo converts the readings of the device into the unit. Readings in units of another kind
+// fail with domain.ErrIncompatibleUnits.
+func (s *measurementService) ConvertTo(device domain.Device, measurements []domain.Measurement, unit domain.Unit) ([]domain.Measurement, error) {
+	var err error
+	device.Channels, err = s.channelRepo.FindByDeviceId(device.Id)
+	if err != nil {
+		log.Printf("MeasurementService: %s", err)
+		return nil, err
+	}
+
+	res := make([]domain.Measurement, len(measurements))
+	for i, m := range measurements {
+		from, err := readingUnit(device, m.ChannelId)
+		if err != nil {
+			return nil, err
+		}
+		m.Value, err = from.Convert(m.Value, unit)
+		if err != nil {
+			return nil, err
+		}
+		m.RawValue, _ = from.Convert(m.RawValue, unit)
+		res[i] = m
+	}
+	return res, nil
+}
+
+// Summarize sums up the usable readings of the channels of the sensor with the keys, of all its
+// channels without keys, or of its own value when it has no channels. With a unit the statistics
+// are converted into it.
+func (s *measurementService) Summarize(device domain.Device, channels []string, period domain.Period, size domain.BucketSize, unit *domain.Unit) ([]domain.ChannelSummary, error) {
+	buckets, err := period.Split(size)
+	if err != nil {
+		return nil, err
+	}
+
+	device.Channels, err = s.channelRepo.FindByDeviceId(device.Id)
+	if err != nil {
+		log.Printf("MeasurementService: %s", err)
+		return nil, err
+	}
+	var series []domain.ChannelSummary
+	switch {
+	case len(device.Channels) == 0 && len(channels) > 0:
+		return nil, fmt.Errorf("%w: device %d has no channels", domain.ErrInvalidChannel, device.Id)
+	case len(device.Channels) == 0:
+		series = append(series, domain.ChannelSummary{Units: device.Units})
+	case len(channels) == 0:
+		for _, ch := range device.Channels {
+			series = append(series, channelSummary(ch))
+		}
+	default:
+		for _, key := range channels {
+			ch, ok := device.Channel(key)
+			if !ok {
+				return nil, fmt.Errorf("%w: device %d has no channel %q", domain.ErrInvalidChannel, device.Id, key)
+			}
+			series = append(series, channelSummary(ch))
+		}
+	}
+
+	measurements, err := s.measurementRepo.FindByDeviceAndDate(device.Id, period.Start, period.End)
+	if err != nil {
+		log.Printf("MeasurementService: %s", err)
+		return nil, err
+	}
+	for i := range series {
+		var readings []domain.Measurement
+		for _, m := range measurements {
+			if sameId(m.ChannelId, series[i].ChannelId) {
+				readings = append(readings, m)
+			}
+		}
+		series[i].DeviceId = device.Id
+		series[i].Size = size
+		series[i] = buildChannelSummary(series[i], readings, buckets)
+		if unit != nil {
+			series[i], err = convertSummary(series[i], *unit)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return series, nil
+}
+
+func channelSummary(ch domain.DeviceChannel) domain.ChannelSummary {
+	id := ch.Id
+	return domain.ChannelSummary{
+		ChannelId: &id,
+		Channel:   ch.Key,
+		Quantity:  ch.Quantity,
+		Units:     ch.Units,
+	}
+}
+
 func (s *measurementService) Find(id uint64) (interface{}, error) {
 	measurement, err := s.measurementRepo.Find(id)
 	if err != nil {
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+// statsAccumulator collects the readings of a series or a bucket.
+type statsAccumulator struct {
+	count    int
+	min, max float64
+	sum      float64
+}
+
+func (a *statsAccumulator) add(v float64) {
+	if a.count == 0 || v < a.min {
+		a.min = v
+	}
+	if a.count == 0 || v > a.max {
+		a.max = v
+	}
+	a.count++
+	a.sum += v
+}
+
+func (a statsAccumulator) stats() domain.MeasurementStats {
+	if a.count == 0 {
+		return domain.MeasurementStats{}
+	}
+	mean := a.sum / float64(a.count)
+	return domain.MeasurementStats{Count: a.count, Min: &a.min, Max: &a.max, Mean: &mean}
+}
+
+// buildChannelSummary sums up the usable readings of the series per bucket and over all buckets.
+func buildChannelSummary(summary domain.ChannelSummary, readings []domain.Measurement, buckets []domain.Period) domain.ChannelSummary {
+	summary.Buckets = make([]domain.MeasurementBucket, len(buckets))
+	if len(buckets) == 0 {
+		return summary
+	}
+	summary.Start, summary.End = buckets[0].Start, buckets[len(buckets)-1].End
+
+	total := statsAccumulator{}
+	perBucket := make([]statsAccumulator, len(buckets))
+	for _, m := range readings {
+		if !m.Usable() {
+			continue
+		}
+		i := sort.Search(len(buckets), func(i int) bool { return buckets[i].End.After(m.CreatedDate) })
+		if i == len(buckets) || m.CreatedDate.Before(buckets[i].Start) {
+			continue
+		}
+		perBucket[i].add(m.Value)
+		total.add(m.Value)
+	}
+
+	for i, b := range buckets {
+		summary.Buckets[i] = domain.MeasurementBucket{
+			Start:            b.Start,
+			End:              b.End,
+			MeasurementStats: perBucket[i].stats(),
+		}
+	}
+	summary.MeasurementStats = total.stats()
+	return summary
+}
+
+// convertSummary converts the statistics of the summary from its units into the unit.
+func convertSummary(summary domain.ChannelSummary, to domain.Unit) (domain.ChannelSummary, error) {
+	if summary.Units == nil {
+		return domain.ChannelSummary{}, fmt.Errorf("%w: device %d reports no units", domain.ErrIncompatibleUnits, summary.DeviceId)
+	}
+	from, err := domain.ParseUnit(*summary.Units)
+	if err != nil {
+		return domain.ChannelSummary{}, err
+	}
+	if _, err := from.Convert(0, to); err != nil {
+		return domain.ChannelSummary{}, err
+	}
+
+	convert := func(st *domain.MeasurementStats) {
+		for _, v := range []*float64{st.Min, st.Max, st.Mean} {
+			if v != nil {
+				*v, _ = from.Convert(*v, to)
+			}
+		}
+	}
+	convert(&summary.MeasurementStats)
+	for i := range summary.Buckets {
+		convert(&summary.Buckets[i].MeasurementStats)
+	}
+	code := to.Code
+	summary.Units = &code
+	return summary, nil
+}
+
+// readingUnit returns the unit the readings of the channel of the device, or of its own value
+// without a channel, are reported in.
+func readingUnit(device domain.Device, channelId *uint64) (domain.Unit, error) {
+	units := device.Units
+	if channelId != nil {
+		ch, ok := device.ChannelById(*channelId)
+		if !ok {
+			return domain.Unit{}, fmt.Errorf("%w: channel %d of device %d was removed", domain.ErrIncompatibleUnits, *channelId, device.Id)
+		}
+		units = ch.Units
+	}
+	if units == nil {
+		return domain.Unit{}, fmt.Errorf("%w: device %d reports no units", domain.ErrIncompatibleUnits, device.Id)
+	}
+	return domain.ParseUnit(*units)
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+// meterIntervals turns the readings of a meter, sorted by time, into intervals of the average
+// power between consecutive readings clipped to [start, end). The intervals belong to the metered
+// device. It also returns the span the readings cover, where they replace the estimates.
+func meterIntervals(meter domain.Device, readings []domain.Measurement, start, end time.Time) ([]powerInterval, time.Time, time.Time) {
+	if len(readings) < 2 {
+		return nil, time.Time{}, time.Time{}
+	}
+
+	var intervals []powerInterval
+	for i := 1; i < len(readings); i++ {
+		prev, cur := readings[i-1], readings[i]
+		hours := cur.CreatedDate.Sub(prev.CreatedDate).Hours()
+		if hours <= 0 {
+			continue
+		}
+		from, to := maxTime(prev.CreatedDate, start), minTime(cur.CreatedDate, end)
+		if !from.Before(to) {
+			continue
+		}
+		energy := domain.CounterDelta(prev.Value, cur.Value, meter.CounterMax)
+		if energy == 0 {
+			continue
+		}
+		intervals = append(intervals, powerInterval{
+			DeviceId: meter.MeteredId(),
+			Start:    from,
+			End:      to,
+			Power:    energy / hours,
+		})
+	}
+
+	from := maxTime(readings[0].CreatedDate, start)
+	to := minTime(readings[len(readings)-1].CreatedDate, end)
+	return intervals, from, to
+}
+
+// replaceEstimates cuts the span [from, to) out of the intervals of the device.
+func replaceEstimates(intervals []powerInterval, deviceId uint64, from, to time.Time) []powerInterval {
+	if !from.Before(to) {
+		return intervals
+	}
+
+	var res []powerInterval
+	for _, i := range intervals {
+		if i.DeviceId != deviceId || !i.Start.Before(to) || !i.End.After(from) {
+			res = append(res, i)
+			continue
+		}
+		if i.Start.Before(from) {
+			before := i
+			before.End = from
+			res = append(res, before)
+		}
+		if i.End.After(to) {
+			after := i
+			after.Start = to
+			res = append(res, after)
+		}
+	}
+	return res
+}
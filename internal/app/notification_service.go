@@ -0,0 +1,81 @@
+package app
+
+import (
+	"log"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/pubsub"
+)
+
+const maxNotifications = 100
+
+type NotificationService interface {
+	Create(n domain.Notification) (domain.Notification, error)
+	Find(id uint64) (interface{}, error)
+	FindForUser(userId uint64, unread bool) ([]domain.Notification, error)
+	MarkRead(n domain.Notification) (domain.Notification, error)
+}
+
+type notificationService struct {
+	notificationRepo database.NotificationRepository
+	hub              pubsub.Hub
+}
+
+func NewNotificationService(nr database.NotificationRepository, h pubsub.Hub) NotificationService {
+	return notificationService{
+		notificationRepo: nr,
+		hub:              h,
+	}
+}
+
+// Create saves the notification and publishes it to the streams of the organization.
+func (s notificationService) Create(n domain.Notification) (domain.Notification, error) {
+	n, err := s.notificationRepo.Save(n)
+	if err != nil {
+		log.Printf("NotificationService: %s", err)
+		return domain.Notification{}, err
+	}
+
+	s.hub.Publish(pubsub.Message{
+		Type:           pubsub.NotificationCreated,
+		OrganizationId: n.OrganizationId,
+		Payload:        n,
+		CreatedDate:    n.CreatedDate,
+	})
+	return n, nil
+}
+
+func (s notificationService) Find(id uint64) (interface{}, error) {
+	n, err := s.notificationRepo.Find(id)
+	if err != nil {
+		log.Printf("NotificationService: %s", err)
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (s notificationService) FindForUser(userId uint64, unread bool) ([]domain.Notification, error) {
+	notifications, err := s.notificationRepo.FindForUser(userId, unread, maxNotifications)
+	if err != nil {
+		log.Printf("NotificationService: %s", err)
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (s notificationService) MarkRead(n domain.Notification) (domain.Notification, error) {
+	if n.ReadDate != nil {
+		return n, nil
+	}
+
+	n, err := s.notificationRepo.MarkRead(n.Id)
+	if err != nil {
+		log.Printf("NotificationService: %s", err)
+		return domain.Notification{}, err
+	}
+
+	return n, nil
+}
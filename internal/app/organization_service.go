@@ -1,10 +1,14 @@
 package app
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
 )
 
 type OrganizationService interface {
@@ -13,17 +17,26 @@ type OrganizationService interface {
 	Find(id uint64) (interface{}, error)
 	Update(o domain.Organization) (domain.Organization, error)
 	Delete(id uint64) error
+	SolarTimes(o domain.Organization, from time.Time, days int) []domain.SolarTimes
+	SaveMember(org domain.Organization, email string, role domain.MemberRole) (domain.OrganizationMember, error)
+	FindMembers(orgId uint64) ([]domain.OrganizationMember, error)
+	DeleteMember(orgId, userId uint64) error
+	MemberRole(orgId, userId uint64) (domain.MemberRole, bool, error)
 }
 
 type organizationService struct {
 	organizationRepo database.OrganizationRepository
 	roomRepo         database.RoomRepository
+	memberRepo       database.MemberRepository
+	userRepo         database.UserRepository
 }
 
-func NewOrganizationService(or database.OrganizationRepository, rr database.RoomRepository) OrganizationService {
+func NewOrganizationService(or database.OrganizationRepository, rr database.RoomRepository, mr database.MemberRepository, ur database.UserRepository) OrganizationService {
 	return organizationService{
 		organizationRepo: or,
 		roomRepo:         rr,
+		memberRepo:       mr,
+		userRepo:         ur,
 	}
 }
 
@@ -82,3 +95,83 @@ func (s organizationService) Delete(id uint64) error {
 
 	return nil
 }
+
+// SolarTimes returns the solar events at the location of the organization for the days
+// starting with the day of from in the organization's timezone.
+func (s organizationService) SolarTimes(o domain.Organization, from time.Time, days int) []domain.SolarTimes {
+	from = from.In(o.Location())
+	times := make([]domain.SolarTimes, days)
+	for i := range times {
+		times[i] = domain.CalculateSolarTimes(o.Lat, o.Lon, from.AddDate(0, 0, i))
+	}
+	return times
+}
+
+// SaveMember adds the user with the email to the organization or changes the role of a member.
+func (s organizationService) SaveMember(org domain.Organization, email string, role domain.MemberRole) (domain.OrganizationMember, error) {
+	if role != domain.MemberRoleMember && role != domain.MemberRoleManager {
+		return domain.OrganizationMember{}, errors.New("role must be MEMBER or MANAGER")
+	}
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		return domain.OrganizationMember{}, fmt.Errorf("user %s not found", email)
+	}
+	if user.Id == org.UserId {
+		return domain.OrganizationMember{}, errors.New("the owner is already a manager of the organization")
+	}
+
+	m, err := s.memberRepo.Save(domain.OrganizationMember{
+		OrganizationId: org.Id,
+		UserId:         user.Id,
+		Role:           role,
+	})
+	if err != nil {
+		log.Printf("OrganizationService: %s", err)
+		return domain.OrganizationMember{}, err
+	}
+
+	return m, nil
+}
+
+func (s organizationService) FindMembers(orgId uint64) ([]domain.OrganizationMember, error) {
+	members, err := s.memberRepo.FindByOrgId(orgId)
+	if err != nil {
+		log.Printf("OrganizationService: %s", err)
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (s organizationService) DeleteMember(orgId, userId uint64) error {
+	err := s.memberRepo.Delete(orgId, userId)
+	if err != nil {
+		log.Printf("OrganizationService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+// MemberRole returns the role of the user in the organization, false when the user doesn't
+// belong to it. The owner is a manager.
+func (s organizationService) MemberRole(orgId, userId uint64) (domain.MemberRole, bool, error) {
+	org, err := s.organizationRepo.FindById(orgId)
+	if err != nil {
+		log.Printf("OrganizationService: %s", err)
+		return "", false, err
+	}
+	if org.UserId == userId {
+		return domain.MemberRoleManager, true, nil
+	}
+
+	m, err := s.memberRepo.Find(orgId, userId)
+	if errors.Is(err, db.ErrNoMoreRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		log.Printf("OrganizationService: %s", err)
+		return "", false, err
+	}
+	return m.Role, true, nil
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"sort"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+const fullLevel = 100.0
+
+// powerInterval is a span of time during which a device drew a constant power.
+type powerInterval struct {
+	DeviceId uint64
+	Start    time.Time
+	End      time.Time
+	Power    float64
+}
+
+func (i powerInterval) Hours() float64 {
+	return i.End.Sub(i.Start).Hours()
+}
+
+func (i powerInterval) Energy() float64 {
+	return i.Hours() * i.Power
+}
+
+// buildPowerIntervals turns ON, OFF and SET_LEVEL events into intervals of constant power
+// clipped to [start, end). levels holds the level each device had at start, devices
+// without an entry are assumed to run at full power. Devices missing from the map or
+// without a power consumption are skipped.
+func buildPowerIntervals(events []domain.Event, devices map[uint64]domain.Device, levels map[uint64]float64, start, end time.Time) []powerInterval {
+	byDevice := make(map[uint64][]domain.Event)
+	for _, e := range events {
+		byDevice[e.DeviceId] = append(byDevice[e.DeviceId], e)
+	}
+
+	deviceIds := make([]uint64, 0, len(byDevice))
+	for id := range byDevice {
+		deviceIds = append(deviceIds, id)
+	}
+	sort.Slice(deviceIds, func(i, j int) bool { return deviceIds[i] < deviceIds[j] })
+
+	var intervals []powerInterval
+	for _, id := range deviceIds {
+		device, ok := devices[id]
+		if !ok || device.PowerConsumption == nil {
+			continue
+		}
+
+		evs := byDevice[id]
+		sort.SliceStable(evs, func(i, j int) bool {
+			if evs[i].CreatedDate.Equal(evs[j].CreatedDate) {
+				return evs[i].Id < evs[j].Id
+			}
+			return evs[i].CreatedDate.Before(evs[j].CreatedDate)
+		})
+
+		level, ok := levels[id]
+		if !ok {
+			level = fullLevel
+		}
+
+		var (
+			on    bool
+			since time.Time
+		)
+		emit := func(to time.Time) {
+			from := maxTime(since, start)
+			to = minTime(to, end)
+			if from.Before(to) {
+				intervals = append(intervals, powerInterval{
+					DeviceId: id,
+					Start:    from,
+					End:      to,
+					Power:    *device.PowerConsumption * level / fullLevel,
+				})
+			}
+		}
+
+		for _, e := range evs {
+			switch e.Action {
+			case domain.TurnOn:
+				if !on {
+					on, since = true, e.CreatedDate
+				}
+			case domain.TurnOff:
+				if on {
+					emit(e.CreatedDate)
+					on = false
+				}
+			case domain.SetLevel:
+				if e.Level == nil {
+					continue
+				}
+				if on {
+					emit(e.CreatedDate)
+					since = e.CreatedDate
+				}
+				level = *e.Level
+			}
+		}
+		if on {
+			emit(end)
+		}
+	}
+
+	return intervals
+}
+
+// roomInterval is a part of a power interval spent in one room, RoomId is nil while the
+// device wasn't installed anywhere.
+type roomInterval struct {
+	powerInterval
+	RoomId *uint64
+}
+
+// splitByInstallations cuts the intervals at the times their devices were moved between rooms.
+func splitByInstallations(intervals []powerInterval, installs []domain.DeviceInstallation) []roomInterval {
+	byDevice := make(map[uint64][]domain.DeviceInstallation)
+	for _, in := range installs {
+		byDevice[in.DeviceId] = append(byDevice[in.DeviceId], in)
+	}
+	for _, ins := range byDevice {
+		sort.Slice(ins, func(i, j int) bool { return ins[i].InstalledDate.Before(ins[j].InstalledDate) })
+	}
+
+	var res []roomInterval
+	part := func(i powerInterval, from, to time.Time, roomId *uint64) {
+		if from.Before(to) {
+			i.Start, i.End = from, to
+			res = append(res, roomInterval{powerInterval: i, RoomId: roomId})
+		}
+	}
+
+	for _, i := range intervals {
+		cursor := i.Start
+		for _, in := range byDevice[i.DeviceId] {
+			from := maxTime(in.InstalledDate, i.Start)
+			to := i.End
+			if in.RemovedDate != nil {
+				to = minTime(*in.RemovedDate, i.End)
+			}
+			if !from.Before(to) {
+				continue
+			}
+			part(i, cursor, from, nil)
+			roomId := in.RoomId
+			part(i, from, to, &roomId)
+			cursor = to
+		}
+		part(i, cursor, i.End, nil)
+	}
+	return res
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"sort"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+// powerStep is the total power drawn from At until the next step.
+type powerStep struct {
+	At    time.Time
+	Power float64
+}
+
+// powerSteps turns overlapping intervals into the step function of the total power drawn.
+func powerSteps(intervals []powerInterval) []powerStep {
+	type change struct {
+		at    time.Time
+		delta float64
+	}
+	changes := make([]change, 0, 2*len(intervals))
+	for _, i := range intervals {
+		changes = append(changes, change{i.Start, i.Power}, change{i.End, -i.Power})
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].at.Before(changes[j].at) })
+
+	var (
+		steps []powerStep
+		power float64
+	)
+	for i := 0; i < len(changes); {
+		at := changes[i].at
+		// all changes at the same time are applied at once, so an interval which ends when
+		// another one of the same device starts doesn't count twice
+		for ; i < len(changes) && changes[i].at.Equal(at); i++ {
+			power += changes[i].delta
+		}
+		if power < 1e-9 {
+			power = 0
+		}
+		steps = append(steps, powerStep{At: at, Power: power})
+	}
+	return steps
+}
+
+// powerSegment is a part of a period in which the total power doesn't change.
+type powerSegment struct {
+	From  time.Time
+	To    time.Time
+	Power float64
+}
+
+// constantSegments cuts the period wherever the total power of the intervals changes and at the
+// extra cuts, and returns the segments in which power is drawn.
+func constantSegments(intervals []powerInterval, period domain.Period, extraCuts []time.Time) []powerSegment {
+	steps := powerSteps(intervals)
+	cuts := []time.Time{period.Start, period.End}
+	for _, st := range steps {
+		cuts = append(cuts, st.At)
+	}
+	cuts = append(cuts, extraCuts...)
+	sort.Slice(cuts, func(i, j int) bool { return cuts[i].Before(cuts[j]) })
+
+	var (
+		segments []powerSegment
+		power    float64
+		next     int
+	)
+	for i := 0; i+1 < len(cuts); i++ {
+		from, to := cuts[i], cuts[i+1]
+		for next < len(steps) && !steps[next].At.After(from) {
+			power = steps[next].Power
+			next++
+		}
+		if !from.Before(to) || from.Before(period.Start) || to.After(period.End) || power == 0 {
+			continue
+		}
+		segments = append(segments, powerSegment{From: from, To: to, Power: power})
+	}
+	return segments
+}
+
+// buildPowerSeries sums the energy of the intervals up per bucket and finds the peak power.
+func buildPowerSeries(intervals []powerInterval, buckets []domain.Period, size domain.BucketSize) domain.PowerSeries {
+	series := domain.PowerSeries{
+		Size:    size,
+		Buckets: make([]domain.PowerBucket, len(buckets)),
+	}
+	if len(buckets) == 0 {
+		return series
+	}
+	series.Start, series.End = buckets[0].Start, buckets[len(buckets)-1].End
+
+	for i, b := range buckets {
+		series.Buckets[i] = domain.PowerBucket{Start: b.Start, End: b.End}
+		for _, in := range intervals {
+			from, to := maxTime(in.Start, b.Start), minTime(in.End, b.End)
+			if from.Before(to) {
+				series.Buckets[i].Energy += to.Sub(from).Hours() * in.Power
+			}
+		}
+		series.Energy += series.Buckets[i].Energy
+	}
+
+	steps := powerSteps(intervals)
+	for _, st := range steps {
+		if st.Power > series.Peak {
+			at := st.At
+			series.Peak, series.PeakAt = st.Power, &at
+		}
+	}
+
+	// the power at the start of a bucket is the one of the last step before it
+	var (
+		current float64
+		next    int
+	)
+	for i, b := range series.Buckets {
+		for next < len(steps) && !steps[next].At.After(b.Start) {
+			current = steps[next].Power
+			next++
+		}
+		peak := current
+		for j := next; j < len(steps) && steps[j].At.Before(b.End); j++ {
+			peak = max(peak, steps[j].Power)
+		}
+		series.Buckets[i].Peak = peak
+	}
+
+	return series
+}
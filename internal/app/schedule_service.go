@@ -0,0 +1,251 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
+)
+
+const (
+	scheduleTickInterval = 30 * time.Second
+	// boundaries missed while the server was down for less than this are still acted on
+	scheduleLookback = 15 * time.Minute
+)
+
+type ScheduleService interface {
+	Save(s domain.Schedule) (domain.Schedule, error)
+	Find(id uint64) (interface{}, error)
+	FindByOrgId(orgId uint64) ([]domain.Schedule, error)
+	Update(s domain.Schedule) (domain.Schedule, error)
+	Delete(id uint64) error
+	Run(ctx context.Context)
+}
+
+type scheduleService struct {
+	scheduleRepo database.ScheduleRepository
+	deviceRepo   database.DeviceRepository
+	orgRepo      database.OrganizationRepository
+	eventService EventService
+	lastCheck    time.Time
+}
+
+func NewScheduleService(sr database.ScheduleRepository, dr database.DeviceRepository, or database.OrganizationRepository, es EventService) ScheduleService {
+	return &scheduleService{
+		scheduleRepo: sr,
+		deviceRepo:   dr,
+		orgRepo:      or,
+		eventService: es,
+	}
+}
+
+func (s *scheduleService) Save(sch domain.Schedule) (domain.Schedule, error) {
+	err := s.validate(sch)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+
+	sch, err = s.scheduleRepo.Save(sch)
+	if err != nil {
+		log.Printf("ScheduleService: %s", err)
+		return domain.Schedule{}, err
+	}
+
+	return sch, nil
+}
+
+func (s *scheduleService) Find(id uint64) (interface{}, error) {
+	sch, err := s.scheduleRepo.Find(id)
+	if err != nil {
+		log.Printf("ScheduleService: %s", err)
+		return nil, err
+	}
+
+	return sch, nil
+}
+
+func (s *scheduleService) FindByOrgId(orgId uint64) ([]domain.Schedule, error) {
+	schedules, err := s.scheduleRepo.FindByOrgId(orgId)
+	if err != nil {
+		log.Printf("ScheduleService: %s", err)
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (s *scheduleService) Update(sch domain.Schedule) (domain.Schedule, error) {
+	err := s.validate(sch)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+
+	sch, err = s.scheduleRepo.Update(sch)
+	if err != nil {
+		log.Printf("ScheduleService: %s", err)
+		return domain.Schedule{}, err
+	}
+
+	return sch, nil
+}
+
+func (s *scheduleService) Delete(id uint64) error {
+	err := s.scheduleRepo.Delete(id)
+	if err != nil {
+		log.Printf("ScheduleService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+// Run switches the scheduled devices on and off at the boundaries of their blocks.
+func (s *scheduleService) Run(ctx context.Context) {
+	ticker := time.NewTicker(scheduleTickInterval)
+	defer ticker.Stop()
+
+	s.onTick(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.onTick(now)
+		}
+	}
+}
+
+func (s *scheduleService) onTick(now time.Time) {
+	now = now.Truncate(time.Minute)
+	from := s.lastCheck
+	if from.IsZero() || now.Sub(from) > scheduleLookback {
+		from = now.Add(-scheduleLookback)
+	}
+	if !now.After(from) {
+		return
+	}
+
+	schedules, err := s.scheduleRepo.FindEnabledForDevices()
+	if err != nil {
+		log.Printf("ScheduleService: %s", err)
+		return
+	}
+
+	for _, sch := range schedules {
+		calendar, err := s.calendar(sch)
+		if err != nil {
+			log.Printf("ScheduleService: schedule %d: %s", sch.Id, err)
+			continue
+		}
+
+		for t := from.Add(time.Minute); !t.After(now); t = t.Add(time.Minute) {
+			on := calendar.IsOn(t)
+			if on == calendar.IsOn(t.Add(-time.Minute)) {
+				continue
+			}
+			action := domain.TurnOff
+			if on {
+				action = domain.TurnOn
+			}
+			s.fire(sch, t, action)
+		}
+	}
+	s.lastCheck = now
+}
+
+// fire records the boundary first, a boundary which was recorded already isn't acted on again.
+func (s *scheduleService) fire(sch domain.Schedule, at time.Time, action domain.EventAction) {
+	fresh, err := s.scheduleRepo.SaveFiring(domain.ScheduleFiring{
+		ScheduleId: sch.Id,
+		DeviceId:   *sch.DeviceId,
+		FireDate:   at,
+		Action:     action,
+	})
+	if err != nil || !fresh {
+		return
+	}
+
+	device, err := s.deviceRepo.Find(*sch.DeviceId)
+	if err != nil {
+		log.Printf("ScheduleService: %s", err)
+		return
+	}
+
+	event, err := s.eventService.Save(domain.Event{
+		DeviceId: device.Id,
+		RoomId:   device.RoomId,
+		Action:   action,
+	})
+	if errors.Is(err, domain.ErrNoStateChange) {
+		log.Printf("ScheduleService: device %d is already %s", device.Id, action)
+		return
+	}
+	if err != nil {
+		log.Printf("ScheduleService: schedule %d: %s", sch.Id, err)
+		return
+	}
+
+	err = s.scheduleRepo.SetFiringEvent(sch.Id, at, event.Id)
+	if err != nil {
+		log.Printf("ScheduleService: %s", err)
+	}
+	log.Printf("ScheduleService: schedule %d switched device %d %s", sch.Id, device.Id, action)
+}
+
+func (s *scheduleService) calendar(sch domain.Schedule) (domain.ScheduleCalendar, error) {
+	org, err := s.orgRepo.FindById(sch.OrganizationId)
+	if err != nil {
+		return domain.ScheduleCalendar{}, err
+	}
+
+	calendar := domain.ScheduleCalendar{
+		Device:   sch,
+		Location: org.Location(),
+		Lat:      org.Lat,
+		Lon:      org.Lon,
+	}
+	orgSchedule, err := s.scheduleRepo.FindForOrganization(sch.OrganizationId)
+	if err == nil && orgSchedule.Enabled {
+		calendar.Organization = &orgSchedule
+	} else if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
+		return domain.ScheduleCalendar{}, err
+	}
+	return calendar, nil
+}
+
+// validate checks the schedule and that an organization or a device has only one schedule.
+func (s *scheduleService) validate(sch domain.Schedule) error {
+	err := sch.Validate()
+	if err != nil {
+		return err
+	}
+
+	var existing domain.Schedule
+	if sch.DeviceId == nil {
+		existing, err = s.scheduleRepo.FindForOrganization(sch.OrganizationId)
+	} else {
+		device, derr := s.deviceRepo.Find(*sch.DeviceId)
+		if derr != nil || device.Id == 0 || device.DeletedDate != nil || device.OrganizationId != sch.OrganizationId {
+			return fmt.Errorf("device %d not found", *sch.DeviceId)
+		}
+		if device.Category != domain.Actuator {
+			return errors.New("only actuators can be scheduled")
+		}
+		existing, err = s.scheduleRepo.FindForDevice(*sch.DeviceId)
+	}
+	if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
+		return err
+	}
+	if err == nil && existing.Id != sch.Id {
+		if sch.DeviceId == nil {
+			return errors.New("organization already has a schedule")
+		}
+		return errors.New("device already has a schedule")
+	}
+	return nil
+}
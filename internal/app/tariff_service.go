@@ -0,0 +1,97 @@
+package app
+
+import (
+	"log"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+)
+
+type TariffService interface {
+	Save(t domain.Tariff) (domain.Tariff, error)
+	Find(id uint64) (interface{}, error)
+	FindByOrgId(orgId uint64) ([]domain.Tariff, error)
+	FindActive(orgId uint64) (domain.Tariff, error)
+	Update(t domain.Tariff) (domain.Tariff, error)
+	Delete(id uint64) error
+}
+
+type tariffService struct {
+	tariffRepo database.TariffRepository
+}
+
+func NewTariffService(tr database.TariffRepository) TariffService {
+	return tariffService{
+		tariffRepo: tr,
+	}
+}
+
+func (s tariffService) Save(t domain.Tariff) (domain.Tariff, error) {
+	err := t.Validate()
+	if err != nil {
+		return domain.Tariff{}, err
+	}
+
+	t, err = s.tariffRepo.Save(t)
+	if err != nil {
+		log.Printf("TariffService: %s", err)
+		return domain.Tariff{}, err
+	}
+
+	return t, nil
+}
+
+func (s tariffService) Find(id uint64) (interface{}, error) {
+	t, err := s.tariffRepo.Find(id)
+	if err != nil {
+		log.Printf("TariffService: %s", err)
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s tariffService) FindByOrgId(orgId uint64) ([]domain.Tariff, error) {
+	tariffs, err := s.tariffRepo.FindByOrgId(orgId)
+	if err != nil {
+		log.Printf("TariffService: %s", err)
+		return nil, err
+	}
+
+	return tariffs, nil
+}
+
+func (s tariffService) FindActive(orgId uint64) (domain.Tariff, error) {
+	t, err := s.tariffRepo.FindActive(orgId)
+	if err != nil {
+		log.Printf("TariffService: %s", err)
+		return domain.Tariff{}, err
+	}
+
+	return t, nil
+}
+
+func (s tariffService) Update(t domain.Tariff) (domain.Tariff, error) {
+	err := t.Validate()
+	if err != nil {
+		return domain.Tariff{}, err
+	}
+
+	t, err = s.tariffRepo.Update(t)
+	if err != nil {
+		log.Printf("TariffService: %s", err)
+		return domain.Tariff{}, err
+	}
+
+	return t, nil
+}
+
+func (s tariffService) Delete(id uint64) error {
+	err := s.tariffRepo.Delete(id)
+	if err != nil {
+		log.Printf("TariffService: %s", err)
+		return err
+	}
+
+	return nil
+}
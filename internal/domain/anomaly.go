@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+type AnomalyKind string
+
+const (
+	// SpikeAnomaly is a reading far from the median of the recent ones, measured in MADs.
+	SpikeAnomaly AnomalyKind = "SPIKE"
+	// DriftAnomaly is a fast moving average which left the control limits of the slow baseline.
+	DriftAnomaly AnomalyKind = "DRIFT"
+	// StuckAnomaly is the same value reported over and over.
+	StuckAnomaly AnomalyKind = "STUCK"
+	// FlatlineAnomaly is a sensor which lost its usual noise while the values still differ.
+	FlatlineAnomaly AnomalyKind = "FLATLINE"
+)
+
+// AnomalySettings tune the anomaly detection of a sensor. Sensors without settings use
+// DefaultAnomalySettings. A zero count or threshold turns the detection of its kind off.
+type AnomalySettings struct {
+	Id             uint64
+	OrganizationId uint64
+	DeviceId       uint64
+	Enabled        bool
+	// Window is how many recent readings the median and the MAD are taken over.
+	Window     int
+	ZThreshold float64
+	// EwmaAlpha weights the fast average, BaselineAlpha the slow baseline.
+	EwmaAlpha      float64
+	BaselineAlpha  float64
+	DriftThreshold float64
+	StuckCount     int
+	FlatlineCount  int
+	// FlatlineRatio is the share of the usual deviation the readings must range within to flatline.
+	FlatlineRatio float64
+	Notify        bool
+	CreatedDate   time.Time
+	UpdatedDate   time.Time
+}
+
+// MinAnomalySamples is how many readings a sensor needs before its readings are inspected.
+const MinAnomalySamples = 10
+
+func DefaultAnomalySettings(orgId, deviceId uint64) AnomalySettings {
+	return AnomalySettings{
+		OrganizationId: orgId,
+		DeviceId:       deviceId,
+		Enabled:        true,
+		Window:         60,
+		ZThreshold:     3.5,
+		EwmaAlpha:      0.1,
+		BaselineAlpha:  0.01,
+		DriftThreshold: 3,
+		StuckCount:     10,
+		FlatlineCount:  30,
+		FlatlineRatio:  0.05,
+		Notify:         true,
+	}
+}
+
+func (s AnomalySettings) Validate() error {
+	if s.Window < MinAnomalySamples || s.Window > 1000 {
+		return errors.New("window must be between 10 and 1000")
+	}
+	if s.ZThreshold < 0 || s.DriftThreshold < 0 || s.FlatlineRatio < 0 {
+		return errors.New("thresholds must not be negative")
+	}
+	if s.EwmaAlpha <= 0 || s.EwmaAlpha >= 1 || s.BaselineAlpha <= 0 || s.BaselineAlpha >= 1 {
+		return errors.New("ewmaAlpha and baselineAlpha must be between 0 and 1")
+	}
+	if s.BaselineAlpha >= s.EwmaAlpha {
+		return errors.New("baselineAlpha must be smaller than ewmaAlpha")
+	}
+	if s.StuckCount < 0 || s.FlatlineCount < 0 || s.StuckCount == 1 || s.FlatlineCount == 1 {
+		return errors.New("stuckCount and flatlineCount must be 0 or at least 2")
+	}
+	if s.FlatlineCount > s.Window {
+		return errors.New("flatlineCount must not exceed the window")
+	}
+	return nil
+}
+
+// MeasurementAnomaly is a reading the detector flagged. Score is the robust z-score of a spike,
+// the distance of the average in control limits for a drift and the number of readings of a stuck
+// or flatlined sensor.
+type MeasurementAnomaly struct {
+	Id             uint64
+	MeasurementId  uint64
+	OrganizationId uint64
+	DeviceId       uint64
+	ChannelId      *uint64
+	Kind           AnomalyKind
+	Value          float64
+	Score          float64
+	Reason         string
+	CreatedDate    time.Time
+}
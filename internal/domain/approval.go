@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrApprovalPending  = errors.New("waiting for approval")
+	ErrApprovalRequired = errors.New("critical devices only take approved commands")
+)
+
+type ApprovalStatus string
+
+const (
+	ApprovalPending  ApprovalStatus = "PENDING"
+	ApprovalApproved ApprovalStatus = "APPROVED"
+	ApprovalRejected ApprovalStatus = "REJECTED"
+	ApprovalExpired  ApprovalStatus = "EXPIRED"
+)
+
+// CommandApproval holds a command to a critical device until a manager other than the one who
+// requested it approves it. The command is only created then, CommandTtl counts from there.
+type CommandApproval struct {
+	Id             uint64
+	OrganizationId uint64
+	DeviceId       uint64
+	RequestedBy    uint64
+	Action         EventAction
+	ActionParams
+	CommandTtl  time.Duration
+	Status      ApprovalStatus
+	DecidedBy   *uint64
+	Comment     *string
+	CommandId   *uint64
+	ExpiresDate time.Time
+	DecidedDate *time.Time
+	CreatedDate time.Time
+	UpdatedDate time.Time
+	// Override lets the command through the interlocks once it's approved
+	Override *InterlockOverride
+}
+
+func (a CommandApproval) IsOpen() bool {
+	return a.Status == ApprovalPending
+}
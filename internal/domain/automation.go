@@ -0,0 +1,241 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type TriggerType string
+
+const (
+	MeasurementTrigger TriggerType = "MEASUREMENT"
+	EventTrigger       TriggerType = "EVENT"
+	TimeTrigger        TriggerType = "TIME"
+)
+
+type ConditionType string
+
+const (
+	DeviceStateCondition ConditionType = "DEVICE_STATE"
+	MeasurementCondition ConditionType = "MEASUREMENT"
+	TimeWindowCondition  ConditionType = "TIME_WINDOW"
+)
+
+type Comparison string
+
+const (
+	Greater        Comparison = ">"
+	GreaterOrEqual Comparison = ">="
+	Less           Comparison = "<"
+	LessOrEqual    Comparison = "<="
+	Equal          Comparison = "=="
+	NotEqual       Comparison = "!="
+)
+
+func (c Comparison) Compare(a, b float64) bool {
+	switch c {
+	case Greater:
+		return a > b
+	case GreaterOrEqual:
+		return a >= b
+	case Less:
+		return a < b
+	case LessOrEqual:
+		return a <= b
+	case Equal:
+		return a == b
+	case NotEqual:
+		return a != b
+	default:
+		return false
+	}
+}
+
+func (c Comparison) IsValid() bool {
+	switch c {
+	case Greater, GreaterOrEqual, Less, LessOrEqual, Equal, NotEqual:
+		return true
+	default:
+		return false
+	}
+}
+
+// RuleTrigger starts a rule. A measurement trigger watches one sensor or one channel of it, or
+// every sensor and channel of a room with the given units, and fires once the comparison has held
+// for ForSeconds. An event trigger
+// fires on an actuator event and a time trigger fires daily at At, a time of day ("15:04") or
+// a solar event at the organization's location ("sunset-15").
+type RuleTrigger struct {
+	Type       TriggerType  `json:"type"`
+	DeviceId   *uint64      `json:"deviceId,omitempty"`
+	ChannelId  *uint64      `json:"channelId,omitempty"`
+	RoomId     *uint64      `json:"roomId,omitempty"`
+	Units      *string      `json:"units,omitempty"`
+	Operator   Comparison   `json:"operator,omitempty"`
+	Threshold  *float64     `json:"threshold,omitempty"`
+	ForSeconds uint64       `json:"forSeconds,omitempty"`
+	Action     *EventAction `json:"action,omitempty"`
+	At         string       `json:"at,omitempty"`
+	Weekdays   []int        `json:"weekdays,omitempty"`
+}
+
+// RuleCondition has to hold when the trigger fires, otherwise the rule is skipped.
+type RuleCondition struct {
+	Type      ConditionType `json:"type"`
+	DeviceId  *uint64       `json:"deviceId,omitempty"`
+	ChannelId *uint64       `json:"channelId,omitempty"`
+	State     *EventAction  `json:"state,omitempty"`
+	Operator  Comparison    `json:"operator,omitempty"`
+	Threshold *float64      `json:"threshold,omitempty"`
+	From      string        `json:"from,omitempty"`
+	To        string        `json:"to,omitempty"`
+}
+
+type RuleAction struct {
+	DeviceId     uint64      `json:"deviceId"`
+	Action       EventAction `json:"action"`
+	Level        *float64    `json:"level,omitempty"`
+	Setpoint     *float64    `json:"setpoint,omitempty"`
+	SetpointUnit *string     `json:"setpointUnit,omitempty"`
+	Mode         *string     `json:"mode,omitempty"`
+}
+
+func (a RuleAction) Params() ActionParams {
+	return ActionParams{
+		Level:        a.Level,
+		Setpoint:     a.Setpoint,
+		SetpointUnit: a.SetpointUnit,
+		Mode:         a.Mode,
+	}.For(a.Action)
+}
+
+type Rule struct {
+	Id              uint64
+	OrganizationId  uint64
+	UserId          uint64
+	Name            string
+	Enabled         bool
+	DryRun          bool
+	Trigger         RuleTrigger
+	Conditions      []RuleCondition
+	Actions         []RuleAction
+	CooldownSeconds uint64
+	LastFiredDate   *time.Time
+	CreatedDate     time.Time
+	UpdatedDate     time.Time
+	DeletedDate     *time.Time
+}
+
+// Validate checks the structure of the rule, the devices it refers to are checked by the service.
+func (r Rule) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Actions) == 0 {
+		return errors.New("at least one action is required")
+	}
+
+	t := r.Trigger
+	switch t.Type {
+	case MeasurementTrigger:
+		if t.DeviceId == nil && t.RoomId == nil {
+			return errors.New("measurement trigger needs a device or a room")
+		}
+		if !t.Operator.IsValid() || t.Threshold == nil {
+			return errors.New("measurement trigger needs an operator and a threshold")
+		}
+		if t.ChannelId != nil && t.DeviceId == nil {
+			return errors.New("measurement trigger needs the device of the channel")
+		}
+	case EventTrigger:
+		if t.DeviceId == nil {
+			return errors.New("event trigger needs a device")
+		}
+	case TimeTrigger:
+		if _, err := ParseClockSpec(t.At); err != nil {
+			return fmt.Errorf("time trigger: %w", err)
+		}
+		for _, d := range t.Weekdays {
+			if d < 0 || d > 6 {
+				return errors.New("weekdays must be between 0 (Sunday) and 6 (Saturday)")
+			}
+		}
+	default:
+		return errors.New("invalid trigger type")
+	}
+
+	for _, c := range r.Conditions {
+		switch c.Type {
+		case DeviceStateCondition:
+			if c.DeviceId == nil || c.State == nil {
+				return errors.New("device state condition needs a device and a state")
+			}
+		case MeasurementCondition:
+			if c.DeviceId == nil || !c.Operator.IsValid() || c.Threshold == nil {
+				return errors.New("measurement condition needs a device, an operator and a threshold")
+			}
+		case TimeWindowCondition:
+			if _, err := ParseClockSpec(c.From); err != nil {
+				return fmt.Errorf("time window: %w", err)
+			}
+			if _, err := ParseClockSpec(c.To); err != nil {
+				return fmt.Errorf("time window: %w", err)
+			}
+		default:
+			return errors.New("invalid condition type")
+		}
+	}
+
+	return nil
+}
+
+// ParseClock parses a time of day in the "15:04" format and returns it as an offset from midnight.
+func ParseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, errors.New("time of day must be in HH:MM format")
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// wallClock returns the time of day t shows on the clock in its timezone. Unlike the time since
+// midnight it's the same on days when the clocks are changed.
+func wallClock(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+}
+
+// atClock returns the moment the clock shows the time of day on the date of day, in its timezone.
+func atClock(day time.Time, clock time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+type RuleExecutionStatus string
+
+const (
+	RuleExecuted   RuleExecutionStatus = "EXECUTED"
+	RuleDryRun     RuleExecutionStatus = "DRY_RUN"
+	RuleSkipped    RuleExecutionStatus = "SKIPPED"
+	RuleSuppressed RuleExecutionStatus = "SUPPRESSED"
+	RuleFailed     RuleExecutionStatus = "FAILED"
+)
+
+type RuleActionResult struct {
+	DeviceId uint64      `json:"deviceId"`
+	Action   EventAction `json:"action"`
+	EventId  *uint64     `json:"eventId,omitempty"`
+	Error    *string     `json:"error,omitempty"`
+}
+
+type RuleExecution struct {
+	Id             uint64
+	RuleId         uint64
+	OrganizationId uint64
+	Status         RuleExecutionStatus
+	Cause          string
+	Depth          uint
+	Results        []RuleActionResult
+	Reason         *string
+	CreatedDate    time.Time
+}
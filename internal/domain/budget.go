@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+type BudgetMetric string
+
+const (
+	KwhBudget  BudgetMetric = "KWH"
+	CostBudget BudgetMetric = "COST"
+)
+
+type BudgetAlertKind string
+
+const (
+	ThresholdAlert  BudgetAlertKind = "THRESHOLD"
+	ProjectionAlert BudgetAlertKind = "PROJECTION"
+)
+
+// DefaultBudgetThresholds are the percentages of the budget alerted on when none are given.
+var DefaultBudgetThresholds = []float64{80, 100}
+
+// Budget is the monthly allowance of an organization, or of a room when RoomId is set.
+// A COST budget is priced by the active tariff of the organization.
+type Budget struct {
+	Id                uint64
+	OrganizationId    uint64
+	RoomId            *uint64
+	UserId            uint64
+	Name              string
+	Metric            BudgetMetric
+	Amount            float64
+	Thresholds        []float64
+	AlertOnProjection bool
+	Enabled           bool
+	CreatedDate       time.Time
+	UpdatedDate       time.Time
+	DeletedDate       *time.Time
+}
+
+func (b Budget) Validate() error {
+	if b.Name == "" {
+		return errors.New("name is required")
+	}
+	if b.Metric != KwhBudget && b.Metric != CostBudget {
+		return errors.New("metric must be KWH or COST")
+	}
+	if b.Amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	seen := make(map[float64]bool, len(b.Thresholds))
+	for _, t := range b.Thresholds {
+		if t <= 0 || t > 1000 {
+			return errors.New("thresholds must be percentages between 0 and 1000")
+		}
+		if seen[t] {
+			return errors.New("thresholds must be unique")
+		}
+		seen[t] = true
+	}
+	return nil
+}
+
+// Scope returns what the budget counts the consumption of.
+func (b Budget) Scope() ConsumptionScope {
+	return ConsumptionScope{OrganizationId: b.OrganizationId, RoomId: b.RoomId}
+}
+
+// BudgetStatus is the use of a budget in the month so far. Projected extrapolates the
+// use so far linearly to the end of the month.
+type BudgetStatus struct {
+	BudgetId  uint64
+	Metric    BudgetMetric
+	Currency  string
+	Month     Period
+	AsOf      time.Time
+	Amount    float64
+	Used      float64
+	Projected float64
+}
+
+func (s BudgetStatus) Percent() float64 {
+	if s.Amount == 0 {
+		return 0
+	}
+	return s.Used / s.Amount * 100
+}
+
+func (s BudgetStatus) ProjectedPercent() float64 {
+	if s.Amount == 0 {
+		return 0
+	}
+	return s.Projected / s.Amount * 100
+}
+
+// CrossedThresholds returns the thresholds of the budget the use has reached, in ascending order.
+func (s BudgetStatus) CrossedThresholds(b Budget) []float64 {
+	var res []float64
+	for _, t := range b.Thresholds {
+		if s.Percent() >= t {
+			res = append(res, t)
+		}
+	}
+	sort.Float64s(res)
+	return res
+}
+
+// BudgetAlert records that a budget was alerted on in a month, so every alert is sent only once.
+// Threshold is zero for projection alerts.
+type BudgetAlert struct {
+	BudgetId    uint64
+	Month       string
+	Kind        BudgetAlertKind
+	Threshold   float64
+	Used        float64
+	Projected   float64
+	CreatedDate time.Time
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Calibration corrects the raw readings of a sensor, or of one of its channels, taken from EffectiveFrom until the next
+// calibration of the sensor takes effect. The corrected value is Offset + Gain·p(raw), where p
+// is the polynomial c0 + c1·raw + c2·raw² + … of the Coefficients, or raw itself without them.
+type Calibration struct {
+	Id             uint64
+	OrganizationId uint64
+	DeviceId       uint64
+	// ChannelId is the channel of a sensor reporting several quantities the calibration corrects
+	ChannelId     *uint64
+	UserId        uint64
+	Offset        float64
+	Gain          float64
+	Coefficients  []float64
+	EffectiveFrom time.Time
+	Note          *string
+	CreatedDate   time.Time
+	DeletedDate   *time.Time
+}
+
+const maxCalibrationDegree = 5
+
+func (c Calibration) Validate() error {
+	if c.Gain == 0 || math.IsNaN(c.Gain) || math.IsInf(c.Gain, 0) {
+		return errors.New("gain must be a non-zero number")
+	}
+	if math.IsNaN(c.Offset) || math.IsInf(c.Offset, 0) {
+		return errors.New("offset must be a number")
+	}
+	if len(c.Coefficients) > maxCalibrationDegree+1 {
+		return errors.New("polynomial may have at most 6 coefficients")
+	}
+	for _, k := range c.Coefficients {
+		if math.IsNaN(k) || math.IsInf(k, 0) {
+			return errors.New("coefficients must be numbers")
+		}
+	}
+	if c.EffectiveFrom.IsZero() {
+		return errors.New("effectiveFrom is required")
+	}
+	return nil
+}
+
+// Apply returns the corrected value of a raw reading. A nil calibration leaves it as it is.
+func (c *Calibration) Apply(raw float64) float64 {
+	if c == nil {
+		return raw
+	}
+	p := raw
+	if len(c.Coefficients) > 0 {
+		// Horner's method
+		p = 0
+		for i := len(c.Coefficients) - 1; i >= 0; i-- {
+			p = p*raw + c.Coefficients[i]
+		}
+	}
+	return c.Offset + c.Gain*p
+}
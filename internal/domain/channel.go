@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ErrInvalidChannel = errors.New("invalid channel")
+
+// MaxDeviceChannels is how many quantities a single sensor may report.
+const MaxDeviceChannels = 16
+
+var channelKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// DeviceChannel is one quantity a sensor reports, e.g. the humidity of a climate sensor. A sensor
+// without channels reports a single value in its Units within its own limits, the readings of a
+// channel are flagged by the limits of the channel.
+type DeviceChannel struct {
+	Id       uint64
+	DeviceId uint64
+	// Key names the channel in readings, e.g. "co2"
+	Key      string
+	Quantity string
+	Units    *string
+	ValidMin *float64
+	ValidMax *float64
+	MaxRate  *float64
+
+	CreatedDate time.Time
+	UpdatedDate time.Time
+	DeletedDate *time.Time
+}
+
+func (ch DeviceChannel) Validate() error {
+	err := ch.ValidateFields()
+	if err != nil {
+		return err
+	}
+	_, err = ParseUnit(*ch.Units)
+	return err
+}
+
+// ValidateFields checks the channel except that its units are in the registry, which the units
+// of a channel stored before the registry may not be.
+func (ch DeviceChannel) ValidateFields() error {
+	if !channelKeyPattern.MatchString(ch.Key) {
+		return errors.New("key must be 1 to 32 lowercase letters, digits, '_' or '-'")
+	}
+	if strings.TrimSpace(ch.Quantity) == "" {
+		return errors.New("quantity is required")
+	}
+	if ch.Units == nil || strings.TrimSpace(*ch.Units) == "" {
+		return errors.New("units are required")
+	}
+	return Device{Category: Sensor}.ForChannel(&ch).ValidateQualityLimits()
+}
+
+// ChannelReading is the value of one channel in a reading which reports several at once.
+type ChannelReading struct {
+	Channel string
+	Value   float64
+}
+
+// ForChannel returns the device with the units and the limits of the channel, which its readings
+// of the channel are assessed by. A nil channel leaves the device as it is.
+func (d Device) ForChannel(ch *DeviceChannel) Device {
+	if ch == nil {
+		return d
+	}
+	d.Units = ch.Units
+	d.ValidMin = ch.ValidMin
+	d.ValidMax = ch.ValidMax
+	d.MaxRate = ch.MaxRate
+	return d
+}
+
+// Channel returns the channel of the device with the key.
+func (d Device) Channel(key string) (DeviceChannel, bool) {
+	for _, ch := range d.Channels {
+		if ch.Key == key {
+			return ch, true
+		}
+	}
+	return DeviceChannel{}, false
+}
+
+// ChannelById returns the channel of the device with the id.
+func (d Device) ChannelById(id uint64) (DeviceChannel, bool) {
+	for _, ch := range d.Channels {
+		if ch.Id == id {
+			return ch, true
+		}
+	}
+	return DeviceChannel{}, false
+}
+
+// ValidateReadingChannel checks that a reading of the device reports one of its channels, or no
+// channel when the device has none.
+func (d Device) ValidateReadingChannel(channelId *uint64) error {
+	if len(d.Channels) == 0 {
+		if channelId != nil {
+			return fmt.Errorf("%w: device %d has no channels", ErrInvalidChannel, d.Id)
+		}
+		return nil
+	}
+	if channelId == nil {
+		return fmt.Errorf("%w: device %d reports several channels, the channel is required", ErrInvalidChannel, d.Id)
+	}
+	if _, ok := d.ChannelById(*channelId); !ok {
+		return fmt.Errorf("%w: channel %d not found on device %d", ErrInvalidChannel, *channelId, d.Id)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+type CommandStatus string
+
+const (
+	CommandPending      CommandStatus = "PENDING"
+	CommandDelivered    CommandStatus = "DELIVERED"
+	CommandAcknowledged CommandStatus = "ACKNOWLEDGED"
+	CommandFailed       CommandStatus = "FAILED"
+	CommandExpired      CommandStatus = "EXPIRED"
+)
+
+type Command struct {
+	Id             uint64
+	OrganizationId uint64
+	DeviceId       uint64
+	UserId         uint64
+	Action         EventAction
+	ActionParams
+	Status           CommandStatus
+	EventId          *uint64
+	ApprovalId       *uint64
+	Error            *string
+	ExpiresDate      time.Time
+	DeliveredDate    *time.Time
+	AcknowledgedDate *time.Time
+	CreatedDate      time.Time
+	UpdatedDate      time.Time
+	DeletedDate      *time.Time
+	// Override lets the command through the interlocks until the device acknowledges it
+	Override *InterlockOverride
+	// DemandRequestId is the queued demand request the command releases, it isn't stored either
+	DemandRequestId *uint64
+}
+
+// IsOpen reports whether the device may still receive or confirm the command.
+func (c Command) IsOpen() bool {
+	return c.Status == CommandPending || c.Status == CommandDelivered
+}
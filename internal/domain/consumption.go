@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// DeviceConsumption is the energy a device used, in a room or in total. OnHours is the time the
+// device was on, Share is the part of the total energy of the report.
+type DeviceConsumption struct {
+	DeviceId        uint64
+	InventoryNumber string
+	RoomId          *uint64
+	OnHours         float64
+	Energy          float64
+	Share           float64
+}
+
+// RoomConsumption sums up the devices while they were installed in the room. OnHours is the sum
+// of the on-hours of the devices. A nil RoomId collects the time devices weren't installed anywhere.
+type RoomConsumption struct {
+	RoomId  *uint64
+	Name    string
+	OnHours float64
+	Energy  float64
+	Share   float64
+	Devices []DeviceConsumption
+}
+
+type ConsumptionReport struct {
+	OrganizationId uint64
+	Start          time.Time
+	End            time.Time
+	OnHours        float64
+	Energy         float64
+	Rooms          []RoomConsumption
+	Devices        []DeviceConsumption
+}
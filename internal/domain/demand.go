@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrDemandLimit  = errors.New("demand limit exceeded")
+	ErrDemandQueued = errors.New("queued until the demand allows it")
+)
+
+// DemandStrategy is what happens to a request which would exceed the limit. SHED turns off devices
+// of a lower priority class and falls back to REJECT when that isn't enough.
+type DemandStrategy string
+
+const (
+	RejectDemand DemandStrategy = "REJECT"
+	QueueDemand  DemandStrategy = "QUEUE"
+	ShedDemand   DemandStrategy = "SHED"
+)
+
+type DemandClass string
+
+const (
+	HighPriority   DemandClass = "HIGH"
+	NormalPriority DemandClass = "NORMAL"
+	LowPriority    DemandClass = "LOW"
+)
+
+// Rank orders the classes, devices are only shed for a request of a higher rank.
+func (c DemandClass) Rank() int {
+	switch c {
+	case HighPriority:
+		return 2
+	case LowPriority:
+		return 0
+	default:
+		return 1
+	}
+}
+
+type DevicePriority struct {
+	DeviceId uint64      `json:"deviceId"`
+	Class    DemandClass `json:"class"`
+}
+
+// DemandPolicy limits the power the actuators of an organization may draw at once, in kW.
+// Devices without a priority are NORMAL.
+type DemandPolicy struct {
+	Id             uint64
+	OrganizationId uint64
+	UserId         uint64
+	MaxKw          float64
+	Strategy       DemandStrategy
+	Enabled        bool
+	Priorities     []DevicePriority
+	CreatedDate    time.Time
+	UpdatedDate    time.Time
+}
+
+func (p DemandPolicy) Validate() error {
+	if p.MaxKw <= 0 {
+		return errors.New("maxKw must be positive")
+	}
+	switch p.Strategy {
+	case RejectDemand, QueueDemand, ShedDemand:
+	default:
+		return errors.New("strategy must be REJECT, QUEUE or SHED")
+	}
+	seen := make(map[uint64]bool, len(p.Priorities))
+	for _, dp := range p.Priorities {
+		switch dp.Class {
+		case HighPriority, NormalPriority, LowPriority:
+		default:
+			return errors.New("class must be HIGH, NORMAL or LOW")
+		}
+		if seen[dp.DeviceId] {
+			return errors.New("a device may have only one priority")
+		}
+		seen[dp.DeviceId] = true
+	}
+	return nil
+}
+
+func (p DemandPolicy) ClassOf(deviceId uint64) DemandClass {
+	for _, dp := range p.Priorities {
+		if dp.DeviceId == deviceId {
+			return dp.Class
+		}
+	}
+	return NormalPriority
+}
+
+type DemandSource string
+
+const (
+	EventDemand   DemandSource = "EVENT"
+	CommandDemand DemandSource = "COMMAND"
+)
+
+type DemandOutcome string
+
+const (
+	DemandRejected DemandOutcome = "REJECTED"
+	DemandQueued   DemandOutcome = "QUEUED"
+	DemandShed     DemandOutcome = "SHED"
+	DemandReleased DemandOutcome = "RELEASED"
+	DemandExpired  DemandOutcome = "EXPIRED"
+)
+
+// DemandDecision records why the limit interfered with a request.
+type DemandDecision struct {
+	Id             uint64
+	OrganizationId uint64
+	DeviceId       uint64
+	Source         DemandSource
+	Action         EventAction
+	Outcome        DemandOutcome
+	RequestedKw    float64
+	LoadKw         float64
+	LimitKw        float64
+	ShedDeviceIds  []uint64
+	RequestId      *uint64
+	Reason         string
+	CreatedDate    time.Time
+}
+
+type DemandRequestStatus string
+
+const (
+	DemandRequestQueued   DemandRequestStatus = "QUEUED"
+	DemandRequestReleased DemandRequestStatus = "RELEASED"
+	DemandRequestExpired  DemandRequestStatus = "EXPIRED"
+	DemandRequestFailed   DemandRequestStatus = "FAILED"
+)
+
+// DemandRequest is an event or a command waiting for the demand to drop. CommandTtl is the time
+// to live of the command once it's released, Override lets it through the interlocks then.
+type DemandRequest struct {
+	Id             uint64
+	OrganizationId uint64
+	DeviceId       uint64
+	UserId         *uint64
+	Source         DemandSource
+	Action         EventAction
+	ActionParams
+	CommandTtl   time.Duration
+	ApprovalId   *uint64
+	Status       DemandRequestStatus
+	Error        *string
+	ExpiresDate  time.Time
+	ReleasedDate *time.Time
+	CreatedDate  time.Time
+	Override     *InterlockOverride
+}
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
 	"time"
 )
@@ -11,19 +13,38 @@ type DeviceCategory string
 const (
 	Sensor   DeviceCategory = "SENSOR"
 	Actuator DeviceCategory = "ACTUATOR"
+	// Meter reports a cumulative kWh counter
+	Meter DeviceCategory = "METER"
 )
 
+const MeterUnits = "kWh"
+
 func ParseDeviceCategory(category string) (DeviceCategory, error) {
 	switch strings.ToUpper(category) {
 	case "SENSOR":
 		return Sensor, nil
 	case "ACTUATOR":
 		return Actuator, nil
+	case "METER":
+		return Meter, nil
 	default:
 		return "", errors.New("invalid device category")
 	}
 }
 
+// DeviceCapabilities lists what an actuator accepts besides ON and OFF.
+type DeviceCapabilities struct {
+	Level    bool           `json:"level,omitempty"`
+	Setpoint *SetpointRange `json:"setpoint,omitempty"`
+	Modes    []string       `json:"modes,omitempty"`
+}
+
+type SetpointRange struct {
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Unit string  `json:"unit"`
+}
+
 type Device struct {
 	Id               uint64
 	OrganizationId   uint64
@@ -35,9 +56,80 @@ type Device struct {
 	Category         DeviceCategory
 	Units            *string
 	PowerConsumption *float64
-	Measurements     []Measurement
-	Events           []Event
-	CreatedDate      time.Time
-	UpdatedDate      time.Time
-	DeletedDate      *time.Time
+	SecretHash       *string
+	Capabilities     DeviceCapabilities
+	// MeteredDeviceId is the actuator a meter measures, a meter without one counts for itself
+	MeteredDeviceId *uint64
+	// CounterMax is the value at which the counter of a meter rolls over to zero
+	CounterMax *float64
+	// Critical actuators only take commands a second member of the organization approved
+	Critical bool
+	// ValidMin and ValidMax bound the readings of a sensor which are physically possible
+	ValidMin *float64
+	ValidMax *float64
+	// MaxRate is how much the readings of a sensor may change per minute
+	MaxRate *float64
+	// CurrentState is the last power state of an actuator, it changes only together with an event
+	CurrentState *EventAction
+	// Channels are the quantities a sensor reports besides its own value
+	Channels []DeviceChannel
+	// Secret is only set right after it was generated, it is never stored
+	Secret       string
+	Measurements []Measurement
+	Events       []Event
+	CreatedDate  time.Time
+	UpdatedDate  time.Time
+	DeletedDate  *time.Time
+}
+
+// PowerState returns the current state of an actuator, OFF when it never reported one.
+func (d Device) PowerState() EventAction {
+	if d.CurrentState == nil {
+		return TurnOff
+	}
+	return *d.CurrentState
+}
+
+// ValidateAction checks that the device supports the action and that its parameters are in range.
+func (d Device) ValidateAction(action EventAction, p ActionParams) error {
+	if d.Category != Actuator {
+		return errors.New("only actuators can have events")
+	}
+
+	switch action {
+	case TurnOn, TurnOff:
+		return nil
+	case SetLevel:
+		if !d.Capabilities.Level {
+			return errors.New("device does not support levels")
+		}
+		if p.Level == nil || *p.Level < 0 || *p.Level > 100 {
+			return errors.New("level must be between 0 and 100")
+		}
+	case SetSetpoint:
+		sp := d.Capabilities.Setpoint
+		if sp == nil {
+			return errors.New("device does not support setpoints")
+		}
+		if p.Setpoint == nil {
+			return errors.New("setpoint is required")
+		}
+		if p.SetpointUnit != nil && !strings.EqualFold(*p.SetpointUnit, sp.Unit) {
+			return fmt.Errorf("setpoint unit must be %s", sp.Unit)
+		}
+		if *p.Setpoint < sp.Min || *p.Setpoint > sp.Max {
+			return fmt.Errorf("setpoint must be between %g and %g %s", sp.Min, sp.Max, sp.Unit)
+		}
+	case SetMode:
+		if len(d.Capabilities.Modes) == 0 {
+			return errors.New("device does not support modes")
+		}
+		if p.Mode == nil || !slices.Contains(d.Capabilities.Modes, *p.Mode) {
+			return fmt.Errorf("mode must be one of %s", strings.Join(d.Capabilities.Modes, ", "))
+		}
+	default:
+		return errors.New("invalid action")
+	}
+
+	return nil
 }
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// DeviceInstallation is a span of time a device spent in a room, RemovedDate is nil while it is still there.
+type DeviceInstallation struct {
+	Id            uint64
+	DeviceId      uint64
+	RoomId        uint64
+	InstalledDate time.Time
+	RemovedDate   *time.Time
+}
@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrTwinVersionConflict = errors.New("twin was changed by someone else, reload it and try again")
+
+type TwinState struct {
+	Power    *EventAction `json:"power,omitempty"`
+	Level    *float64     `json:"level,omitempty"`
+	Setpoint *float64     `json:"setpoint,omitempty"`
+	Mode     *string      `json:"mode,omitempty"`
+}
+
+// TwinStateFromEvent returns the part of the state an event sets.
+func TwinStateFromEvent(e Event) TwinState {
+	var s TwinState
+	switch e.Action {
+	case TurnOn, TurnOff:
+		action := e.Action
+		s.Power = &action
+	case SetLevel:
+		s.Level = e.Level
+	case SetSetpoint:
+		s.Setpoint = e.Setpoint
+	case SetMode:
+		s.Mode = e.Mode
+	}
+	return s
+}
+
+// Merge overwrites the fields that are set in o.
+func (s TwinState) Merge(o TwinState) TwinState {
+	if o.Power != nil {
+		s.Power = o.Power
+	}
+	if o.Level != nil {
+		s.Level = o.Level
+	}
+	if o.Setpoint != nil {
+		s.Setpoint = o.Setpoint
+	}
+	if o.Mode != nil {
+		s.Mode = o.Mode
+	}
+	return s
+}
+
+type DeviceTwin struct {
+	DeviceId        uint64
+	OrganizationId  uint64
+	RoomId          *uint64
+	Desired         TwinState
+	DesiredVersion  uint64
+	DesiredDate     *time.Time
+	Reported        TwinState
+	ReportedVersion uint64
+	ReportedDate    *time.Time
+	CreatedDate     time.Time
+	UpdatedDate     time.Time
+}
+
+type TwinDrift struct {
+	DeviceId        uint64
+	RoomId          *uint64
+	Field           string
+	Desired         interface{}
+	Reported        interface{}
+	DesiredVersion  uint64
+	ReportedVersion uint64
+	DesiredDate     *time.Time
+}
+
+// Drift lists the fields that have a desired value the device hasn't reported yet.
+func (t DeviceTwin) Drift() []TwinDrift {
+	var drift []TwinDrift
+	if t.Desired.Power != nil && (t.Reported.Power == nil || *t.Desired.Power != *t.Reported.Power) {
+		drift = append(drift, t.drift("power", *t.Desired.Power, t.Reported.Power))
+	}
+	if t.Desired.Level != nil && (t.Reported.Level == nil || *t.Desired.Level != *t.Reported.Level) {
+		drift = append(drift, t.drift("level", *t.Desired.Level, t.Reported.Level))
+	}
+	if t.Desired.Setpoint != nil && (t.Reported.Setpoint == nil || *t.Desired.Setpoint != *t.Reported.Setpoint) {
+		drift = append(drift, t.drift("setpoint", *t.Desired.Setpoint, t.Reported.Setpoint))
+	}
+	if t.Desired.Mode != nil && (t.Reported.Mode == nil || *t.Desired.Mode != *t.Reported.Mode) {
+		drift = append(drift, t.drift("mode", *t.Desired.Mode, t.Reported.Mode))
+	}
+	return drift
+}
+
+func (t DeviceTwin) drift(field string, desired interface{}, reported interface{}) TwinDrift {
+	return TwinDrift{
+		DeviceId:        t.DeviceId,
+		RoomId:          t.RoomId,
+		Field:           field,
+		Desired:         desired,
+		Reported:        reported,
+		DesiredVersion:  t.DesiredVersion,
+		ReportedVersion: t.ReportedVersion,
+		DesiredDate:     t.DesiredDate,
+	}
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxEmissionFactorEntries = 50000
+
+// EmissionFactorEntry is the factor from Start until the Start of the next entry.
+type EmissionFactorEntry struct {
+	Start  time.Time `json:"start"`
+	Factor float64   `json:"factor"`
+}
+
+// EmissionFactor converts energy into kg CO₂e per kWh. A static factor has no entries, a factor
+// table uses Factor only before its first entry.
+type EmissionFactor struct {
+	Id             uint64
+	OrganizationId uint64
+	UserId         uint64
+	Name           string
+	Source         string
+	Active         bool
+	Factor         float64
+	Entries        []EmissionFactorEntry
+	CreatedDate    time.Time
+	UpdatedDate    time.Time
+	DeletedDate    *time.Time
+}
+
+func (f EmissionFactor) Validate() error {
+	if f.Name == "" {
+		return errors.New("name is required")
+	}
+	if f.Factor < 0 {
+		return errors.New("factor must not be negative")
+	}
+	if len(f.Entries) > maxEmissionFactorEntries {
+		return fmt.Errorf("factor table must not have more than %d entries", maxEmissionFactorEntries)
+	}
+	for i, e := range f.Entries {
+		if e.Factor < 0 {
+			return errors.New("factor must not be negative")
+		}
+		if i > 0 && !e.Start.After(f.Entries[i-1].Start) {
+			return errors.New("entries must be in ascending order of start without duplicates")
+		}
+	}
+	return nil
+}
+
+// FactorAt returns the kg CO₂e per kWh at t.
+func (f EmissionFactor) FactorAt(t time.Time) float64 {
+	i := sort.Search(len(f.Entries), func(i int) bool { return f.Entries[i].Start.After(t) })
+	if i == 0 {
+		return f.Factor
+	}
+	return f.Entries[i-1].Factor
+}
+
+// ParseEmissionFactorCSV reads a factor table with the columns start and factor. A start is an
+// RFC3339 timestamp, "2006-01-02 15:04" or a date in loc. A header row is skipped, the entries
+// are returned in ascending order.
+func ParseEmissionFactorCSV(r io.Reader, loc *time.Location) ([]EmissionFactorEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	var entries []EmissionFactorEntry
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "start") {
+			continue
+		}
+
+		start, err := parseEmissionStart(strings.TrimSpace(record[0]), loc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		factor, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil || factor < 0 {
+			return nil, fmt.Errorf("line %d: factor must be a non-negative number", line)
+		}
+		entries = append(entries, EmissionFactorEntry{Start: start, Factor: factor})
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("factor table is empty")
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Start.Before(entries[j].Start) })
+	return entries, nil
+}
+
+func parseEmissionStart(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", ScheduleDateFormat} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("start must be an RFC3339 timestamp, YYYY-MM-DD HH:MM or YYYY-MM-DD")
+}
+
+// Emissions is the consumption of a period converted by an emission factor, in kg CO₂e.
+type Emissions struct {
+	FactorId   uint64
+	FactorName string
+	Start      time.Time
+	End        time.Time
+	Energy     float64
+	Co2e       float64
+}
+
+// AverageFactor returns the kg CO₂e per kWh weighted by the consumption.
+func (e Emissions) AverageFactor() float64 {
+	if e.Energy == 0 {
+		return 0
+	}
+	return e.Co2e / e.Energy
+}
@@ -1,20 +1,72 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"strings"
+	"time"
+)
 
 type EventAction string
 
 const (
-	TurnOn  EventAction = "ON"
-	TurnOff EventAction = "OFF"
+	TurnOn      EventAction = "ON"
+	TurnOff     EventAction = "OFF"
+	SetLevel    EventAction = "SET_LEVEL"
+	SetSetpoint EventAction = "SET_SETPOINT"
+	SetMode     EventAction = "SET_MODE"
 )
 
+// ErrNoStateChange is returned for an event that would leave the device in the state it is already in.
+var ErrNoStateChange = errors.New("device is already in this state")
+
+func ParseEventAction(action string) (EventAction, error) {
+	switch a := EventAction(strings.ToUpper(action)); a {
+	case TurnOn, TurnOff, SetLevel, SetSetpoint, SetMode:
+		return a, nil
+	default:
+		return "", errors.New("invalid action")
+	}
+}
+
+// IsPower reports whether the action switches the device on or off.
+func (a EventAction) IsPower() bool {
+	return a == TurnOn || a == TurnOff
+}
+
+// ActionParams carries the arguments of the SET_* actions. Only the field that belongs
+// to the action is set.
+type ActionParams struct {
+	Level        *float64
+	Setpoint     *float64
+	SetpointUnit *string
+	Mode         *string
+}
+
+// For drops the parameters the action doesn't use.
+func (p ActionParams) For(action EventAction) ActionParams {
+	switch action {
+	case SetLevel:
+		return ActionParams{Level: p.Level}
+	case SetSetpoint:
+		return ActionParams{Setpoint: p.Setpoint, SetpointUnit: p.SetpointUnit}
+	case SetMode:
+		return ActionParams{Mode: p.Mode}
+	default:
+		return ActionParams{}
+	}
+}
+
 type Event struct {
-	Id          uint64      `db:"id,omitempty"`
-	DeviceId    uint64      `db:"device_id"`
-	RoomId      *uint64     `db:"room_id"`
-	Action      EventAction `db:"action"`
-	CreatedDate time.Time   `db:"created_date"`
-	UpdatedDate time.Time   `db:"updated_date"`
-	DeletedDate *time.Time  `db:"deleted_date"`
+	Id       uint64      `db:"id,omitempty"`
+	DeviceId uint64      `db:"device_id"`
+	RoomId   *uint64     `db:"room_id"`
+	Action   EventAction `db:"action"`
+	ActionParams
+	CreatedDate time.Time  `db:"created_date"`
+	UpdatedDate time.Time  `db:"updated_date"`
+	DeletedDate *time.Time `db:"deleted_date"`
+	// Override lets the event through the interlocks, it isn't stored with the event
+	Override *InterlockOverride `db:"-"`
+	// DemandRequestId is the queued demand request the event releases, it isn't stored either
+	DemandRequestId *uint64 `db:"-"`
 }
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidForecast is wrapped by the errors of a forecast which can't be made for the
+// requested period and options.
+var ErrInvalidForecast = errors.New("invalid forecast")
+
+type ForecastModel string
+
+const (
+	// ProfileModel forecasts each hour by the mean of the same hour of the week in the past weeks.
+	ProfileModel ForecastModel = "profile"
+	// HoltWintersModel forecasts by additive exponential smoothing with a damped trend and a
+	// weekly season of hours.
+	HoltWintersModel ForecastModel = "holt_winters"
+)
+
+const (
+	MinTrainingWeeks     = 2
+	MaxTrainingWeeks     = 26
+	DefaultTrainingWeeks = 8
+	DefaultConfidence    = 0.95
+)
+
+func (m ForecastModel) Validate() error {
+	switch m {
+	case ProfileModel, HoltWintersModel:
+		return nil
+	default:
+		return errors.New("model must be profile or holt_winters")
+	}
+}
+
+// ForecastOptions select the model, how many weeks of history before the forecast it trains on
+// and the confidence of the bands.
+type ForecastOptions struct {
+	Model         ForecastModel
+	TrainingWeeks int
+	Confidence    float64
+}
+
+func (o ForecastOptions) Validate() error {
+	err := o.Model.Validate()
+	if err != nil {
+		return err
+	}
+	if o.TrainingWeeks < MinTrainingWeeks || o.TrainingWeeks > MaxTrainingWeeks {
+		return errors.New("trainingWeeks must be between 2 and 26")
+	}
+	switch o.Confidence {
+	case 0.8, 0.9, 0.95, 0.99:
+		return nil
+	default:
+		return errors.New("confidence must be 0.8, 0.9, 0.95 or 0.99")
+	}
+}
+
+// ForecastBucket is the expected energy within a bucket and the band it falls in with the
+// confidence of the forecast.
+type ForecastBucket struct {
+	Start  time.Time
+	End    time.Time
+	Energy float64
+	Lower  float64
+	Upper  float64
+}
+
+// ForecastAccuracy compares a forecast of the last week of the training history, made by the
+// weeks before it, with what was consumed. Mape skips the hours nothing was consumed in, Wape is
+// the absolute error relative to the energy of the whole week. Bias is positive when the model
+// forecasts too much. Coverage is the share of hours which fell within the band.
+type ForecastAccuracy struct {
+	Start    time.Time
+	End      time.Time
+	Hours    int
+	Mae      float64
+	Rmse     float64
+	Mape     *float64
+	Wape     *float64
+	Bias     float64
+	Coverage float64
+}
+
+// Forecast is the expected consumption of a device, a room or an organization within a period
+// which hasn't passed yet.
+type Forecast struct {
+	Model         ForecastModel
+	Confidence    float64
+	TrainingStart time.Time
+	TrainingEnd   time.Time
+	Start         time.Time
+	End           time.Time
+	Size          BucketSize
+	Energy        float64
+	Lower         float64
+	Upper         float64
+	Buckets       []ForecastBucket
+	Backtest      *ForecastAccuracy
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInterlock = errors.New("blocked by interlock")
+
+// Interlock forbids DeviceId being in State while OtherDeviceId is in OtherState, for example
+// a heater and a cooler both ON or a pump ON while its valve is OFF. An actuator which never
+// reported a state counts as OFF.
+type Interlock struct {
+	Id             uint64
+	OrganizationId uint64
+	UserId         uint64
+	Name           string
+	DeviceId       uint64
+	State          EventAction
+	OtherDeviceId  uint64
+	OtherState     EventAction
+	Enabled        bool
+	CreatedDate    time.Time
+	UpdatedDate    time.Time
+	DeletedDate    *time.Time
+}
+
+func (i Interlock) Validate() error {
+	if i.Name == "" {
+		return errors.New("name is required")
+	}
+	if i.DeviceId == 0 || i.OtherDeviceId == 0 {
+		return errors.New("deviceId and otherDeviceId are required")
+	}
+	if i.DeviceId == i.OtherDeviceId {
+		return errors.New("an interlock needs two different devices")
+	}
+	if !i.State.IsPower() || !i.OtherState.IsPower() {
+		return errors.New("state and otherState must be ON or OFF")
+	}
+	return nil
+}
+
+// Counterpart returns the other device of the interlock and the state it must not be in for the
+// device to enter state, false when the interlock doesn't restrict the transition.
+func (i Interlock) Counterpart(deviceId uint64, state EventAction) (uint64, EventAction, bool) {
+	switch {
+	case deviceId == i.DeviceId && state == i.State:
+		return i.OtherDeviceId, i.OtherState, true
+	case deviceId == i.OtherDeviceId && state == i.OtherState:
+		return i.DeviceId, i.State, true
+	default:
+		return 0, "", false
+	}
+}
+
+// InterlockOverride lets an admin through the interlocks of a single event or command. Every
+// interlock it overrides is recorded with the reason.
+type InterlockOverride struct {
+	Id             uint64
+	InterlockId    uint64
+	OrganizationId uint64
+	DeviceId       uint64
+	Action         EventAction
+	UserId         uint64
+	Reason         string
+	CreatedDate    time.Time
+}
@@ -1,13 +1,88 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var ErrInvalidQualityLimits = errors.New("invalid quality limits")
+
+// MeasurementQuality flags how far a reading can be trusted. BAD readings are kept but left out
+// of aggregations, SUSPECT ones are counted.
+type MeasurementQuality string
+
+const (
+	GoodQuality    MeasurementQuality = "GOOD"
+	SuspectQuality MeasurementQuality = "SUSPECT"
+	BadQuality     MeasurementQuality = "BAD"
+)
 
 type Measurement struct {
-	Id          uint64
-	DeviceId    uint64
-	RoomId      *uint64
-	Value       float64
-	CreatedDate time.Time
-	UpdatedDate time.Time
-	DeletedDate *time.Time
+	Id       uint64
+	DeviceId uint64
+	RoomId   *uint64
+	// ChannelId is the channel of a sensor reporting several quantities, nil for its own value
+	ChannelId *uint64
+	Value     float64
+	// RawValue is the reading as the sensor reported it, Value is corrected by its calibration
+	RawValue      float64
+	CalibrationId *uint64
+	Quality       MeasurementQuality
+	QualityReason *string
+	CreatedDate   time.Time
+	UpdatedDate   time.Time
+	DeletedDate   *time.Time
+}
+
+// Usable reports whether the reading may be aggregated.
+func (m Measurement) Usable() bool {
+	return m.Quality != BadQuality
+}
+
+// ValidateQualityLimits checks the valid range and the maximum rate of change of a device.
+func (d Device) ValidateQualityLimits() error {
+	if d.ValidMin == nil && d.ValidMax == nil && d.MaxRate == nil {
+		return nil
+	}
+	if d.Category != Sensor && d.Category != Meter {
+		return errors.New("only sensors and meters have a valid range and a maximum rate")
+	}
+	if d.ValidMin != nil && d.ValidMax != nil && *d.ValidMin >= *d.ValidMax {
+		return errors.New("validMin must be less than validMax")
+	}
+	if d.MaxRate != nil && *d.MaxRate <= 0 {
+		return errors.New("maxRate must be positive")
+	}
+	return nil
+}
+
+// AssessReading flags a reading of the device taken at. A reading out of the valid range is BAD,
+// one which changed faster than the maximum rate since the last usable reading prev is SUSPECT.
+func (d Device) AssessReading(value float64, at time.Time, prev *Measurement) (MeasurementQuality, *string) {
+	reason := func(format string, a ...interface{}) *string {
+		r := fmt.Sprintf(format, a...)
+		return &r
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return BadQuality, reason("%g is not a number", value)
+	}
+	if d.ValidMin != nil && value < *d.ValidMin {
+		return BadQuality, reason("%g is below the valid minimum %g", value, *d.ValidMin)
+	}
+	if d.ValidMax != nil && value > *d.ValidMax {
+		return BadQuality, reason("%g is above the valid maximum %g", value, *d.ValidMax)
+	}
+	if d.MaxRate != nil && prev != nil && value != prev.Value {
+		minutes := at.Sub(prev.CreatedDate).Minutes()
+		if minutes <= 0 {
+			return SuspectQuality, reason("%g changed from %g without time passing", value, prev.Value)
+		}
+		rate := math.Abs(value-prev.Value) / minutes
+		if rate > *d.MaxRate {
+			return SuspectQuality, reason("%g changed from %g at %.4g per minute, the maximum is %g", value, prev.Value, rate, *d.MaxRate)
+		}
+	}
+	return GoodQuality, nil
 }
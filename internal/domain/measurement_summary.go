@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// MeasurementStats sum up the usable readings of a series. Min, Max and Mean are nil without
+// readings.
+type MeasurementStats struct {
+	Count int
+	Min   *float64
+	Max   *float64
+	Mean  *float64
+}
+
+// MeasurementBucket sums up the readings taken within a bucket.
+type MeasurementBucket struct {
+	Start time.Time
+	End   time.Time
+	MeasurementStats
+}
+
+// ChannelSummary sums up the readings of a channel of a sensor, or of the sensor's own value when
+// it has no channels, over the whole period and bucketed by time.
+type ChannelSummary struct {
+	DeviceId  uint64
+	ChannelId *uint64
+	// Channel is the key of the channel, empty for the sensor's own value
+	Channel  string
+	Quantity string
+	Units    *string
+	Start    time.Time
+	End      time.Time
+	Size     BucketSize
+	MeasurementStats
+	Buckets []MeasurementBucket
+}
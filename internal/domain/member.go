@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+type MemberRole string
+
+const (
+	MemberRoleMember  MemberRole = "MEMBER"
+	MemberRoleManager MemberRole = "MANAGER"
+)
+
+// OrganizationMember gives a user other than the owner access to an organization. The owner
+// is a manager without being a member.
+type OrganizationMember struct {
+	Id             uint64
+	OrganizationId uint64
+	UserId         uint64
+	Email          string
+	Role           MemberRole
+	CreatedDate    time.Time
+	UpdatedDate    time.Time
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "errors"
+
+var ErrInvalidMeter = errors.New("invalid meter")
+
+// ValidateMeter checks the counter settings of a meter.
+func (d Device) ValidateMeter() error {
+	if d.Category != Meter {
+		if d.MeteredDeviceId != nil || d.CounterMax != nil {
+			return errors.New("only meters have a metered device and a counter maximum")
+		}
+		return nil
+	}
+	if d.CounterMax != nil && *d.CounterMax <= 0 {
+		return errors.New("counter maximum must be positive")
+	}
+	if d.MeteredDeviceId != nil && *d.MeteredDeviceId == d.Id {
+		return errors.New("meter can't meter itself")
+	}
+	return nil
+}
+
+// MeteredId returns the device the consumption of a meter is counted for.
+func (d Device) MeteredId() uint64 {
+	if d.MeteredDeviceId != nil {
+		return *d.MeteredDeviceId
+	}
+	return d.Id
+}
+
+// CounterDelta returns the kWh counted between two readings of a meter. A counter which went
+// down rolled over when the rest up to its maximum plus the new reading is less than half of the
+// maximum, otherwise the meter was reset or replaced and counted from zero.
+func CounterDelta(prev, cur float64, max *float64) float64 {
+	if cur >= prev {
+		return cur - prev
+	}
+	if max != nil && prev <= *max {
+		rolled := *max - prev + cur
+		if rolled < *max/2 {
+			return rolled
+		}
+	}
+	return cur
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+type NotificationSeverity string
+
+const (
+	InfoNotification     NotificationSeverity = "INFO"
+	WarningNotification  NotificationSeverity = "WARNING"
+	CriticalNotification NotificationSeverity = "CRITICAL"
+)
+
+// Notification is a message for the owner of an organization. Source and SourceId point to
+// what raised it, e.g. "budget" and the id of the budget.
+type Notification struct {
+	Id             uint64
+	OrganizationId uint64
+	UserId         uint64
+	Severity       NotificationSeverity
+	Title          string
+	Message        string
+	Source         string
+	SourceId       uint64
+	ReadDate       *time.Time
+	CreatedDate    time.Time
+}
@@ -11,8 +11,21 @@ type Organization struct {
 	Address     string
 	Lat         float64
 	Lon         float64
+	Timezone    string
 	Rooms       []Room
 	CreatedDate time.Time
 	UpdatedDate time.Time
 	DeletedDate *time.Time
 }
+
+// Location returns the timezone of the organization, UTC when it isn't set or unknown.
+func (o Organization) Location() *time.Location {
+	if o.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(o.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
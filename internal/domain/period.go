@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type PeriodUnit string
+
+const (
+	DayPeriod   PeriodUnit = "day"
+	WeekPeriod  PeriodUnit = "week"
+	MonthPeriod PeriodUnit = "month"
+)
+
+// Period is a half-open interval [Start, End) of time in the timezone of an organization.
+type Period struct {
+	Start    time.Time
+	End      time.Time
+	Location *time.Location
+}
+
+// ParsePeriod parses the bounds of a period. A bound is either an RFC3339 timestamp or a date
+// ("2006-01-02") in loc. A start date is its midnight and an end date includes the whole day,
+// so the same date twice is that one day.
+func ParsePeriod(start, end string, loc *time.Location) (Period, error) {
+	s, err := parsePeriodBound(start, loc, false)
+	if err != nil {
+		return Period{}, fmt.Errorf("startDate: %w", err)
+	}
+	e, err := parsePeriodBound(end, loc, true)
+	if err != nil {
+		return Period{}, fmt.Errorf("endDate: %w", err)
+	}
+	if !e.After(s) {
+		return Period{}, errors.New("endDate must be after startDate")
+	}
+	return Period{Start: s.In(loc), End: e.In(loc), Location: loc}, nil
+}
+
+func parsePeriodBound(s string, loc *time.Location, end bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, errors.New("is required")
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseInLocation(ScheduleDateFormat, s, loc)
+	if err != nil {
+		return time.Time{}, errors.New("must be an RFC3339 timestamp or a YYYY-MM-DD date")
+	}
+	if end {
+		// AddDate keeps the wall clock, so the day is 23 or 25 hours long around DST changes
+		d = d.AddDate(0, 0, 1)
+	}
+	return d, nil
+}
+
+// PeriodContaining returns the calendar day, week (starting on Monday) or month in loc
+// which contains t.
+func PeriodContaining(unit PeriodUnit, t time.Time, loc *time.Location) (Period, error) {
+	t = t.In(loc)
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	var end time.Time
+	switch unit {
+	case DayPeriod:
+		end = start.AddDate(0, 0, 1)
+	case WeekPeriod:
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		end = start.AddDate(0, 0, 7)
+	case MonthPeriod:
+		start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		end = start.AddDate(0, 1, 0)
+	default:
+		return Period{}, errors.New("period must be day, week or month")
+	}
+	return Period{Start: start, End: end, Location: loc}, nil
+}
+
+func (p Period) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+type BucketSize string
+
+const (
+	HourBucket BucketSize = "hour"
+	DayBucket  BucketSize = "day"
+
+	maxBuckets = 5000
+)
+
+// Split cuts the period into buckets which start at full hours or midnights in the location of
+// the period. The first and the last bucket are cut to the period.
+func (p Period) Split(size BucketSize) ([]Period, error) {
+	s := p.Start.In(p.Location)
+	var (
+		start time.Time
+		next  func(time.Time) time.Time
+	)
+	switch size {
+	case HourBucket:
+		start = time.Date(s.Year(), s.Month(), s.Day(), s.Hour(), 0, 0, 0, p.Location)
+		next = func(t time.Time) time.Time { return t.Add(time.Hour) }
+		if start.After(s) {
+			// the repeated hour when clocks are turned back
+			start = start.Add(-time.Hour)
+		}
+	case DayBucket:
+		start = time.Date(s.Year(), s.Month(), s.Day(), 0, 0, 0, 0, p.Location)
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	default:
+		return nil, errors.New("interval must be hour or day")
+	}
+
+	var buckets []Period
+	for t := start; t.Before(p.End); t = next(t) {
+		if len(buckets) == maxBuckets {
+			return nil, fmt.Errorf("period is too long for %d buckets of a %s", maxBuckets, size)
+		}
+		b := Period{Start: t, End: next(t), Location: p.Location}
+		if b.Start.Before(p.Start) {
+			b.Start = p.Start
+		}
+		if b.End.After(p.End) {
+			b.End = p.End
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, nil
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// PowerBucket is the energy used within a bucket and the highest power drawn at once.
+type PowerBucket struct {
+	Start  time.Time
+	End    time.Time
+	Energy float64
+	Peak   float64
+}
+
+// PowerSeries is the consumption of a device, a room or an organization bucketed by time.
+// Peak is the highest power drawn at once during the whole period, it was first reached at PeakAt.
+type PowerSeries struct {
+	Start   time.Time
+	End     time.Time
+	Size    BucketSize
+	Energy  float64
+	Peak    float64
+	PeakAt  *time.Time
+	Buckets []PowerBucket
+}
+
+// ConsumptionScope selects the devices of an organization, of one of its rooms or a single device.
+type ConsumptionScope struct {
+	OrganizationId uint64
+	RoomId         *uint64
+	DeviceId       *uint64
+}
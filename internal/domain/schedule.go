@@ -0,0 +1,196 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const ScheduleDateFormat = "2006-01-02"
+
+// ScheduleBlock keeps a device on between Start and End on a weekday, 0 is Sunday. Start and End
+// are times of day ("15:04") or solar events ("sunset+30"). A block which ends before it starts
+// runs over midnight into the next day.
+type ScheduleBlock struct {
+	Weekday int    `json:"weekday"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+}
+
+// ScheduleException replaces the weekly blocks on one date. An exception without blocks
+// is a holiday, the device stays off the whole day.
+type ScheduleException struct {
+	Date   string          `json:"date"`
+	Name   string          `json:"name,omitempty"`
+	Blocks []ScheduleBlock `json:"blocks,omitempty"`
+}
+
+// Schedule without a device is the schedule of the organization: its blocks are the default
+// hours and its exceptions are the holiday calendar for every device schedule of the organization.
+type Schedule struct {
+	Id             uint64
+	OrganizationId uint64
+	DeviceId       *uint64
+	UserId         uint64
+	Name           string
+	Enabled        bool
+	Blocks         []ScheduleBlock
+	Exceptions     []ScheduleException
+	CreatedDate    time.Time
+	UpdatedDate    time.Time
+	DeletedDate    *time.Time
+}
+
+type ScheduleFiring struct {
+	ScheduleId  uint64
+	DeviceId    uint64
+	FireDate    time.Time
+	Action      EventAction
+	EventId     *uint64
+	CreatedDate time.Time
+}
+
+func (s Schedule) Validate() error {
+	if s.Name == "" {
+		return errors.New("name is required")
+	}
+	err := validateBlocks(s.Blocks)
+	if err != nil {
+		return err
+	}
+
+	dates := make(map[string]struct{}, len(s.Exceptions))
+	for _, e := range s.Exceptions {
+		if _, err := time.Parse(ScheduleDateFormat, e.Date); err != nil {
+			return fmt.Errorf("exception date %q must be in YYYY-MM-DD format", e.Date)
+		}
+		if _, ok := dates[e.Date]; ok {
+			return fmt.Errorf("exception date %s is repeated", e.Date)
+		}
+		dates[e.Date] = struct{}{}
+		for _, b := range e.Blocks {
+			if err := b.validate(); err != nil {
+				return fmt.Errorf("exception %s: %w", e.Date, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateBlocks(blocks []ScheduleBlock) error {
+	for _, b := range blocks {
+		if b.Weekday < 0 || b.Weekday > 6 {
+			return errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)")
+		}
+		if err := b.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b ScheduleBlock) validate() error {
+	start, err := ParseClockSpec(b.Start)
+	if err != nil {
+		return err
+	}
+	end, err := ParseClockSpec(b.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return errors.New("block must not start and end at the same time")
+	}
+	return nil
+}
+
+// span returns the start and end of the block which starts on the given day. A block which
+// ends before it starts runs over midnight and ends the next day. It is false when the block
+// depends on a solar event which doesn't happen that day.
+func (b ScheduleBlock) span(day time.Time, lat, lon float64) (time.Time, time.Time, bool) {
+	startSpec, err := ParseClockSpec(b.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	endSpec, err := ParseClockSpec(b.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	start, ok := startSpec.On(day, lat, lon)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	end, ok := endSpec.On(day, lat, lon)
+	if !ok || start.Equal(end) {
+		return time.Time{}, time.Time{}, false
+	}
+	if end.Before(start) {
+		end, ok = endSpec.On(day.AddDate(0, 0, 1), lat, lon)
+		if !ok {
+			return time.Time{}, time.Time{}, false
+		}
+	}
+	return start, end, true
+}
+
+// ScheduleCalendar is a device schedule combined with the schedule of its organization.
+type ScheduleCalendar struct {
+	Device       Schedule
+	Organization *Schedule
+	Location     *time.Location
+	Lat          float64
+	Lon          float64
+}
+
+// IsOn reports whether the device should be on at the given moment.
+func (c ScheduleCalendar) IsOn(t time.Time) bool {
+	t = t.In(c.Location)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.Location)
+
+	// a block which started yesterday may run over midnight
+	for _, d := range []time.Time{day.AddDate(0, 0, -1), day} {
+		for _, b := range c.blocksOn(d) {
+			start, end, ok := b.span(d, c.Lat, c.Lon)
+			if ok && !t.Before(start) && t.Before(end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// blocksOn returns the blocks which start on the given day. Exceptions of the device come
+// before the holidays of the organization, and a device without blocks follows the
+// organization hours.
+func (c ScheduleCalendar) blocksOn(day time.Time) []ScheduleBlock {
+	date := day.Format(ScheduleDateFormat)
+	if e, ok := findException(c.Device.Exceptions, date); ok {
+		return e.Blocks
+	}
+	if c.Organization != nil {
+		if e, ok := findException(c.Organization.Exceptions, date); ok {
+			return e.Blocks
+		}
+	}
+
+	weekly := c.Device.Blocks
+	if len(weekly) == 0 && c.Organization != nil {
+		weekly = c.Organization.Blocks
+	}
+	var blocks []ScheduleBlock
+	for _, b := range weekly {
+		if b.Weekday == int(day.Weekday()) {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks
+}
+
+func findException(exceptions []ScheduleException, date string) (ScheduleException, bool) {
+	for _, e := range exceptions {
+		if e.Date == date {
+			return e, true
+		}
+	}
+	return ScheduleException{}, false
+}
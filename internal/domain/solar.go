@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SolarEvent string
+
+const (
+	CivilDawn SolarEvent = "civil_dawn"
+	Sunrise   SolarEvent = "sunrise"
+	Sunset    SolarEvent = "sunset"
+	CivilDusk SolarEvent = "civil_dusk"
+)
+
+const (
+	// the sun's center is below the horizon at sunrise because of refraction and its radius
+	sunriseZenith = 90.833
+	civilZenith   = 96
+	maxSolarShift = 12 * time.Hour
+)
+
+// SolarTimes are the solar events of one day. An event is nil when it doesn't happen that day,
+// as in the polar day or night.
+type SolarTimes struct {
+	Date      time.Time
+	CivilDawn *time.Time
+	Sunrise   *time.Time
+	Sunset    *time.Time
+	CivilDusk *time.Time
+}
+
+func (t SolarTimes) Get(e SolarEvent) *time.Time {
+	switch e {
+	case CivilDawn:
+		return t.CivilDawn
+	case Sunrise:
+		return t.Sunrise
+	case Sunset:
+		return t.Sunset
+	case CivilDusk:
+		return t.CivilDusk
+	default:
+		return nil
+	}
+}
+
+// CalculateSolarTimes returns the solar events on the calendar day of date at the given
+// coordinates, in the location of date. It follows the NOAA solar calculator, which is
+// accurate to about a minute between the polar circles.
+func CalculateSolarTimes(lat, lon float64, date time.Time) SolarTimes {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	// the sun's position is taken at the approximate solar noon of the place
+	noon := day.Add(time.Duration((12 - lon/15) * float64(time.Hour)))
+	t := julianCentury(noon)
+
+	l0 := math.Mod(280.46646+t*(36000.76983+t*0.0003032), 360)
+	m := 357.52911 + t*(35999.05029-0.0001537*t)
+	e := 0.016708634 - t*(0.000042037+0.0000001267*t)
+	c := sin(m)*(1.914602-t*(0.004817+0.000014*t)) + sin(2*m)*(0.019993-0.000101*t) + sin(3*m)*0.000289
+	omega := 125.04 - 1934.136*t
+	appLong := l0 + c - 0.00569 - 0.00478*sin(omega)
+	meanObliq := 23 + (26+(21.448-t*(46.815+t*(0.00059-t*0.001813)))/60)/60
+	obliq := meanObliq + 0.00256*cos(omega)
+	decl := deg(math.Asin(sin(obliq) * sin(appLong)))
+
+	y := math.Pow(math.Tan(rad(obliq/2)), 2)
+	eqTime := 4 * deg(y*sin(2*l0)-2*e*sin(m)+4*e*y*sin(m)*cos(2*l0)-0.5*y*y*sin(4*l0)-1.25*e*e*sin(2*m))
+	solarNoon := 720 - 4*lon - eqTime
+
+	at := func(zenith float64, sign float64) *time.Time {
+		cosHa := cos(zenith)/(cos(lat)*cos(decl)) - math.Tan(rad(lat))*math.Tan(rad(decl))
+		if cosHa < -1 || cosHa > 1 {
+			return nil
+		}
+		minutes := solarNoon + sign*4*deg(math.Acos(cosHa))
+		res := day.Add(time.Duration(minutes * float64(time.Minute))).Round(time.Second).In(date.Location())
+		return &res
+	}
+
+	return SolarTimes{
+		Date:      time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location()),
+		CivilDawn: at(civilZenith, -1),
+		Sunrise:   at(sunriseZenith, -1),
+		Sunset:    at(sunriseZenith, 1),
+		CivilDusk: at(civilZenith, 1),
+	}
+}
+
+func julianCentury(t time.Time) float64 {
+	jd := float64(t.Unix())/86400 + 2440587.5
+	return (jd - 2451545) / 36525
+}
+
+func rad(d float64) float64 { return d * math.Pi / 180 }
+func deg(r float64) float64 { return r * 180 / math.Pi }
+func sin(d float64) float64 { return math.Sin(rad(d)) }
+func cos(d float64) float64 { return math.Cos(rad(d)) }
+
+// ClockSpec is a time of day, either fixed ("07:30") or relative to a solar event at the
+// location of the organization ("sunset", "sunrise-30", "civil_dusk+1h").
+type ClockSpec struct {
+	Solar  SolarEvent
+	Offset time.Duration
+}
+
+// ParseClockSpec parses a fixed time of day or a solar event with an optional offset in
+// minutes or as a duration.
+func ParseClockSpec(s string) (ClockSpec, error) {
+	if clock, err := ParseClock(s); err == nil {
+		return ClockSpec{Offset: clock}, nil
+	}
+
+	invalid := errors.New("time of day must be in HH:MM format or a solar event like sunset+30")
+	name, offset := s, ""
+	if i := strings.IndexAny(s, "+-"); i >= 0 {
+		name, offset = s[:i], s[i:]
+	}
+
+	spec := ClockSpec{Solar: SolarEvent(strings.ToLower(name))}
+	switch spec.Solar {
+	case CivilDawn, Sunrise, Sunset, CivilDusk:
+	default:
+		return ClockSpec{}, invalid
+	}
+
+	if offset != "" {
+		if minutes, err := strconv.Atoi(offset); err == nil {
+			spec.Offset = time.Duration(minutes) * time.Minute
+		} else if d, err := time.ParseDuration(offset); err == nil {
+			spec.Offset = d
+		} else {
+			return ClockSpec{}, invalid
+		}
+	}
+	if spec.Offset > maxSolarShift || spec.Offset < -maxSolarShift {
+		return ClockSpec{}, errors.New("offset from a solar event must be within 12 hours")
+	}
+	return spec, nil
+}
+
+func (c ClockSpec) IsSolar() bool {
+	return c.Solar != ""
+}
+
+// On returns the moment of the time of day on the given day. The day has to be in the location
+// of the organization, a fixed time is read off its wall clock so it holds on days when the
+// clocks are changed. It is false when the solar event doesn't happen that day.
+func (c ClockSpec) On(day time.Time, lat, lon float64) (time.Time, bool) {
+	if !c.IsSolar() {
+		return atClock(day, c.Offset), true
+	}
+
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	at := CalculateSolarTimes(lat, lon, midnight).Get(c.Solar)
+	if at == nil {
+		return time.Time{}, false
+	}
+	return at.Add(c.Offset).In(day.Location()), true
+}
@@ -0,0 +1,259 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+type TariffType string
+
+const (
+	FlatTariff      TariffType = "FLAT"
+	TimeOfUseTariff TariffType = "TIME_OF_USE"
+	TieredTariff    TariffType = "TIERED"
+	SeasonalTariff  TariffType = "SEASONAL"
+)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// TariffWindow prices the time between From and To ("15:04") on the weekdays, every day when
+// there are none. A window which ends before it starts runs over midnight.
+type TariffWindow struct {
+	Name     string  `json:"name"`
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Weekdays []int   `json:"weekdays,omitempty"`
+	Rate     float64 `json:"rate"`
+}
+
+// TariffTier prices the consumption of a calendar month up to UpToKwh, the last tier has no limit.
+type TariffTier struct {
+	UpToKwh *float64 `json:"upToKwh,omitempty"`
+	Rate    float64  `json:"rate"`
+}
+
+// TariffSeason prices the months (1-12) at its rate and its own peak windows.
+type TariffSeason struct {
+	Name    string         `json:"name"`
+	Months  []int          `json:"months"`
+	Rate    float64        `json:"rate"`
+	Windows []TariffWindow `json:"windows,omitempty"`
+}
+
+// Tariff is a price per kWh in Currency. Rate is the flat price, the off-peak price of a
+// time-of-use tariff and the price of the months no season covers.
+type Tariff struct {
+	Id             uint64
+	OrganizationId uint64
+	UserId         uint64
+	Name           string
+	Currency       string
+	Type           TariffType
+	Active         bool
+	Rate           float64
+	Windows        []TariffWindow
+	Tiers          []TariffTier
+	Seasons        []TariffSeason
+	CreatedDate    time.Time
+	UpdatedDate    time.Time
+	DeletedDate    *time.Time
+}
+
+func (t Tariff) Validate() error {
+	if t.Name == "" {
+		return errors.New("name is required")
+	}
+	if !currencyCode.MatchString(t.Currency) {
+		return errors.New("currency must be an ISO 4217 code like UAH")
+	}
+	if t.Rate < 0 {
+		return errors.New("rate must not be negative")
+	}
+
+	switch t.Type {
+	case FlatTariff:
+	case TimeOfUseTariff:
+		if len(t.Windows) == 0 {
+			return errors.New("time of use tariff needs at least one window")
+		}
+		err := validateWindows(t.Windows)
+		if err != nil {
+			return err
+		}
+	case TieredTariff:
+		if len(t.Tiers) == 0 {
+			return errors.New("tiered tariff needs at least one tier")
+		}
+		var last float64
+		for i, tier := range t.Tiers {
+			if tier.Rate < 0 {
+				return errors.New("rate must not be negative")
+			}
+			if tier.UpToKwh == nil {
+				if i != len(t.Tiers)-1 {
+					return errors.New("only the last tier may be unlimited")
+				}
+				continue
+			}
+			if *tier.UpToKwh <= last {
+				return errors.New("tiers must be in ascending order")
+			}
+			last = *tier.UpToKwh
+		}
+	case SeasonalTariff:
+		if len(t.Seasons) == 0 {
+			return errors.New("seasonal tariff needs at least one season")
+		}
+		months := make(map[int]bool)
+		for _, s := range t.Seasons {
+			if s.Name == "" || len(s.Months) == 0 {
+				return errors.New("season needs a name and months")
+			}
+			if s.Rate < 0 {
+				return errors.New("rate must not be negative")
+			}
+			for _, m := range s.Months {
+				if m < 1 || m > 12 {
+					return errors.New("months must be between 1 and 12")
+				}
+				if months[m] {
+					return fmt.Errorf("month %d is in more than one season", m)
+				}
+				months[m] = true
+			}
+			err := validateWindows(s.Windows)
+			if err != nil {
+				return fmt.Errorf("season %s: %w", s.Name, err)
+			}
+		}
+	default:
+		return errors.New("invalid tariff type")
+	}
+	return nil
+}
+
+func validateWindows(windows []TariffWindow) error {
+	for _, w := range windows {
+		if w.Name == "" {
+			return errors.New("window needs a name")
+		}
+		from, err := ParseClock(w.From)
+		if err != nil {
+			return err
+		}
+		to, err := ParseClock(w.To)
+		if err != nil {
+			return err
+		}
+		if from == to {
+			return errors.New("window must not start and end at the same time")
+		}
+		for _, d := range w.Weekdays {
+			if d < 0 || d > 6 {
+				return errors.New("weekdays must be between 0 (Sunday) and 6 (Saturday)")
+			}
+		}
+		if w.Rate < 0 {
+			return errors.New("rate must not be negative")
+		}
+	}
+	return nil
+}
+
+// RateAt returns the name of the price component and the price of a kWh at t, which has to be
+// in the timezone of the organization. Tiered tariffs depend on the consumption and are priced
+// by the caller.
+func (t Tariff) RateAt(at time.Time) (string, float64) {
+	switch t.Type {
+	case TimeOfUseTariff:
+		return windowRate(t.Windows, at, "off-peak", t.Rate)
+	case SeasonalTariff:
+		for _, s := range t.Seasons {
+			if slices.Contains(s.Months, int(at.Month())) {
+				name, rate := windowRate(s.Windows, at, "", s.Rate)
+				if name == "" {
+					return s.Name, rate
+				}
+				return s.Name + " " + name, rate
+			}
+		}
+		return "base", t.Rate
+	default:
+		return "flat", t.Rate
+	}
+}
+
+// Boundaries returns the times on the day of at when the price may change. A window boundary in
+// the hour skipped when clocks are turned forward is moved past it by time.Date.
+func (t Tariff) Boundaries(at time.Time) []time.Time {
+	windows := t.Windows
+	for _, s := range t.Seasons {
+		windows = append(windows, s.Windows...)
+	}
+
+	midnight := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	res := []time.Time{midnight}
+	for _, w := range windows {
+		for _, clock := range []string{w.From, w.To} {
+			offset, err := ParseClock(clock)
+			if err == nil {
+				res = append(res, atClock(at, offset))
+			}
+		}
+	}
+	return res
+}
+
+func windowRate(windows []TariffWindow, at time.Time, name string, rate float64) (string, float64) {
+	clock := wallClock(at)
+	yesterday := time.Date(at.Year(), at.Month(), at.Day()-1, 0, 0, 0, 0, at.Location())
+	onDay := func(w TariffWindow, day time.Weekday) bool {
+		return len(w.Weekdays) == 0 || slices.Contains(w.Weekdays, int(day))
+	}
+
+	for _, w := range windows {
+		from, err := ParseClock(w.From)
+		if err != nil {
+			continue
+		}
+		to, err := ParseClock(w.To)
+		if err != nil {
+			continue
+		}
+
+		var in bool
+		if from < to {
+			in = onDay(w, at.Weekday()) && clock >= from && clock < to
+		} else {
+			// the part after midnight belongs to the window of the day before
+			in = (onDay(w, at.Weekday()) && clock >= from) ||
+				(onDay(w, yesterday.Weekday()) && clock < to)
+		}
+		if in {
+			return w.Name, w.Rate
+		}
+	}
+	return name, rate
+}
+
+type CostLineItem struct {
+	Name   string
+	Rate   float64
+	Energy float64
+	Cost   float64
+}
+
+// EnergyCost is consumption priced by a tariff, Cost is the sum of the line items.
+type EnergyCost struct {
+	TariffId   uint64
+	TariffName string
+	Currency   string
+	Start      time.Time
+	End        time.Time
+	Energy     float64
+	Cost       float64
+	Items      []CostLineItem
+}
@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidUnits      = errors.New("invalid units")
+	ErrIncompatibleUnits = errors.New("incompatible units")
+	// ErrUnitsInUse is returned for a change of units which would reinterpret stored readings,
+	// which are kept without their unit.
+	ErrUnitsInUse = errors.New("units can't change once readings are stored")
+)
+
+// QuantityKind is what a unit measures. Only units of the same kind convert into each other.
+type QuantityKind string
+
+const (
+	Temperature       QuantityKind = "temperature"
+	Ratio             QuantityKind = "ratio"
+	Pressure          QuantityKind = "pressure"
+	Concentration     QuantityKind = "concentration"
+	MassConcentration QuantityKind = "mass_concentration"
+	Illuminance       QuantityKind = "illuminance"
+	Power             QuantityKind = "power"
+	Energy            QuantityKind = "energy"
+	Voltage           QuantityKind = "voltage"
+	Current           QuantityKind = "current"
+	Speed             QuantityKind = "speed"
+	Length            QuantityKind = "length"
+	Volume            QuantityKind = "volume"
+	VolumeFlow        QuantityKind = "volume_flow"
+	SoundLevel        QuantityKind = "sound_level"
+)
+
+// Unit is a unit of the registry. Code is how the unit is stored, Aliases are the UCUM codes and
+// the usual spellings it is recognized by. A value in the unit is Value·Scale + Offset in the
+// canonical unit of its kind.
+type Unit struct {
+	Code    string
+	Name    string
+	Kind    QuantityKind
+	Aliases []string
+	Scale   float64
+	Offset  float64
+}
+
+// unitRegistry lists the units by kind, the first unit of a kind is its canonical unit.
+var unitRegistry = []Unit{
+	{Code: "degC", Name: "degree Celsius", Kind: Temperature, Scale: 1, Aliases: []string{"Cel", "°C", "℃", "C", "celsius"}},
+	{Code: "degF", Name: "degree Fahrenheit", Kind: Temperature, Scale: 5.0 / 9, Offset: -160.0 / 9, Aliases: []string{"[degF]", "°F", "℉", "F", "fahrenheit"}},
+	{Code: "K", Name: "kelvin", Kind: Temperature, Scale: 1, Offset: -273.15, Aliases: []string{"kelvin"}},
+
+	{Code: "%", Name: "percent", Kind: Ratio, Scale: 1, Aliases: []string{"%RH", "percent"}},
+
+	{Code: "Pa", Name: "pascal", Kind: Pressure, Scale: 1, Aliases: []string{"pascal"}},
+	{Code: "hPa", Name: "hectopascal", Kind: Pressure, Scale: 100, Aliases: []string{"mbar"}},
+	{Code: "kPa", Name: "kilopascal", Kind: Pressure, Scale: 1000},
+	{Code: "bar", Name: "bar", Kind: Pressure, Scale: 100000},
+	{Code: "psi", Name: "pound per square inch", Kind: Pressure, Scale: 6894.757293168, Aliases: []string{"[psi]"}},
+	{Code: "mmHg", Name: "millimeter of mercury", Kind: Pressure, Scale: 133.322387415, Aliases: []string{"mm[Hg]"}},
+
+	{Code: "ppm", Name: "part per million", Kind: Concentration, Scale: 1, Aliases: []string{"[ppm]"}},
+	{Code: "ppb", Name: "part per billion", Kind: Concentration, Scale: 0.001, Aliases: []string{"[ppb]"}},
+
+	{Code: "ug/m3", Name: "microgram per cubic meter", Kind: MassConcentration, Scale: 1, Aliases: []string{"µg/m3", "µg/m³", "μg/m³", "ug/m³"}},
+	{Code: "mg/m3", Name: "milligram per cubic meter", Kind: MassConcentration, Scale: 1000, Aliases: []string{"mg/m³"}},
+
+	{Code: "lx", Name: "lux", Kind: Illuminance, Scale: 1, Aliases: []string{"lux"}},
+
+	{Code: "W", Name: "watt", Kind: Power, Scale: 1, Aliases: []string{"watt"}},
+	{Code: "kW", Name: "kilowatt", Kind: Power, Scale: 1000},
+	{Code: "MW", Name: "megawatt", Kind: Power, Scale: 1000000},
+
+	{Code: MeterUnits, Name: "kilowatt hour", Kind: Energy, Scale: 1, Aliases: []string{"kW.h"}},
+	{Code: "Wh", Name: "watt hour", Kind: Energy, Scale: 0.001, Aliases: []string{"W.h"}},
+	{Code: "MWh", Name: "megawatt hour", Kind: Energy, Scale: 1000, Aliases: []string{"MW.h"}},
+	{Code: "J", Name: "joule", Kind: Energy, Scale: 1.0 / 3600000, Aliases: []string{"joule"}},
+	{Code: "kJ", Name: "kilojoule", Kind: Energy, Scale: 1.0 / 3600},
+	{Code: "MJ", Name: "megajoule", Kind: Energy, Scale: 1.0 / 3.6},
+
+	{Code: "V", Name: "volt", Kind: Voltage, Scale: 1, Aliases: []string{"volt"}},
+	{Code: "mV", Name: "millivolt", Kind: Voltage, Scale: 0.001},
+
+	{Code: "A", Name: "ampere", Kind: Current, Scale: 1, Aliases: []string{"amp", "ampere"}},
+	{Code: "mA", Name: "milliampere", Kind: Current, Scale: 0.001},
+
+	{Code: "m/s", Name: "meter per second", Kind: Speed, Scale: 1, Aliases: []string{"m.s-1"}},
+	{Code: "km/h", Name: "kilometer per hour", Kind: Speed, Scale: 1 / 3.6, Aliases: []string{"km.h-1", "kph"}},
+	{Code: "mph", Name: "mile per hour", Kind: Speed, Scale: 0.44704, Aliases: []string{"[mi_i]/h"}},
+
+	{Code: "m", Name: "meter", Kind: Length, Scale: 1, Aliases: []string{"meter", "metre"}},
+	{Code: "cm", Name: "centimeter", Kind: Length, Scale: 0.01},
+	{Code: "mm", Name: "millimeter", Kind: Length, Scale: 0.001},
+
+	{Code: "m3", Name: "cubic meter", Kind: Volume, Scale: 1, Aliases: []string{"m³"}},
+	{Code: "L", Name: "liter", Kind: Volume, Scale: 0.001, Aliases: []string{"l", "liter", "litre"}},
+
+	{Code: "m3/h", Name: "cubic meter per hour", Kind: VolumeFlow, Scale: 1, Aliases: []string{"m³/h", "m3.h-1"}},
+	{Code: "L/min", Name: "liter per minute", Kind: VolumeFlow, Scale: 0.06, Aliases: []string{"l/min", "L.min-1"}},
+	{Code: "L/s", Name: "liter per second", Kind: VolumeFlow, Scale: 3.6, Aliases: []string{"l/s", "L.s-1"}},
+
+	{Code: "dB", Name: "decibel", Kind: SoundLevel, Scale: 1, Aliases: []string{"dBA", "dB(A)"}},
+}
+
+var (
+	unitsByName = make(map[string]Unit)
+	// unitsByFold finds the units whose name is unique regardless of case, "mw" is neither "mW"
+	// nor "MW"
+	unitsByFold = make(map[string]Unit)
+)
+
+func init() {
+	ambiguous := make(map[string]bool)
+	for _, u := range unitRegistry {
+		for _, name := range append([]string{u.Code}, u.Aliases...) {
+			unitsByName[name] = u
+			folded := strings.ToLower(name)
+			if other, ok := unitsByFold[folded]; ok && other.Code != u.Code {
+				ambiguous[folded] = true
+			}
+			unitsByFold[folded] = u
+		}
+	}
+	for folded := range ambiguous {
+		delete(unitsByFold, folded)
+	}
+}
+
+// ParseUnit finds the unit by its code, a UCUM code or a usual spelling.
+func ParseUnit(s string) (Unit, error) {
+	s = strings.TrimSpace(s)
+	if u, ok := unitsByName[s]; ok {
+		return u, nil
+	}
+	if u, ok := unitsByFold[strings.ToLower(s)]; ok {
+		return u, nil
+	}
+	return Unit{}, fmt.Errorf("%w: unknown unit %q", ErrInvalidUnits, s)
+}
+
+// NormalizeUnits returns the code of the units, nil units stay nil.
+func NormalizeUnits(units *string) (*string, error) {
+	if units == nil {
+		return nil, nil
+	}
+	u, err := ParseUnit(*units)
+	if err != nil {
+		return nil, err
+	}
+	return &u.Code, nil
+}
+
+// SameUnits reports whether both are the same unit however they are spelled.
+func SameUnits(a, b string) bool {
+	ua, errA := ParseUnit(a)
+	ub, errB := ParseUnit(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return ua.Code == ub.Code
+}
+
+// SameUnitsOf reports whether both units are unset or the same unit.
+func SameUnitsOf(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return SameUnits(*a, *b)
+}
+
+// UnitRegistry returns all known units, grouped by kind with the canonical unit first.
+func UnitRegistry() []Unit {
+	return append([]Unit(nil), unitRegistry...)
+}
+
+// Canonical reports whether values of the kind are converted through the unit.
+func (u Unit) Canonical() bool {
+	return u.Scale == 1 && u.Offset == 0
+}
+
+// Convert converts the value from the unit to the other one of the same kind.
+func (u Unit) Convert(v float64, to Unit) (float64, error) {
+	if u.Kind != to.Kind {
+		return 0, fmt.Errorf("%w: %s is %s, %s is %s", ErrIncompatibleUnits, u.Code, u.Kind, to.Code, to.Kind)
+	}
+	if u.Code == to.Code {
+		return v, nil
+	}
+	return (v*u.Scale + u.Offset - to.Offset) / to.Scale, nil
+}
@@ -0,0 +1,215 @@
+package database
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const (
+	AnomalySettingsTableName      = "anomaly_settings"
+	MeasurementAnomaliesTableName = "measurement_anomalies"
+)
+
+type anomalySettings struct {
+	Id             uint64    `db:"id,omitempty"`
+	OrganizationId uint64    `db:"organization_id"`
+	DeviceId       uint64    `db:"device_id"`
+	Enabled        bool      `db:"enabled"`
+	Window         int       `db:"window_size"`
+	ZThreshold     float64   `db:"z_threshold"`
+	EwmaAlpha      float64   `db:"ewma_alpha"`
+	BaselineAlpha  float64   `db:"baseline_alpha"`
+	DriftThreshold float64   `db:"drift_threshold"`
+	StuckCount     int       `db:"stuck_count"`
+	FlatlineCount  int       `db:"flatline_count"`
+	FlatlineRatio  float64   `db:"flatline_ratio"`
+	Notify         bool      `db:"notify"`
+	CreatedDate    time.Time `db:"created_date"`
+	UpdatedDate    time.Time `db:"updated_date"`
+}
+
+type measurementAnomaly struct {
+	Id             uint64    `db:"id,omitempty"`
+	MeasurementId  uint64    `db:"measurement_id"`
+	OrganizationId uint64    `db:"organization_id"`
+	DeviceId       uint64    `db:"device_id"`
+	ChannelId      *uint64   `db:"channel_id"`
+	Kind           string    `db:"kind"`
+	Value          float64   `db:"value"`
+	Score          float64   `db:"score"`
+	Reason         string    `db:"reason"`
+	CreatedDate    time.Time `db:"created_date"`
+}
+
+type AnomalyRepository interface {
+	FindSettings(deviceId uint64) (domain.AnomalySettings, error)
+	SaveSettings(s domain.AnomalySettings) (domain.AnomalySettings, error)
+	DeleteSettings(deviceId uint64) error
+	Save(a domain.MeasurementAnomaly) (domain.MeasurementAnomaly, error)
+	FindByDeviceId(deviceId uint64, startDate, endDate time.Time) ([]domain.MeasurementAnomaly, error)
+	FindByOrgId(orgId uint64, limit uint) ([]domain.MeasurementAnomaly, error)
+}
+
+type anomalyRepository struct {
+	sess db.Session
+	coll db.Collection
+}
+
+func NewAnomalyRepository(sess db.Session) AnomalyRepository {
+	return &anomalyRepository{
+		sess: sess,
+		coll: sess.Collection(MeasurementAnomaliesTableName),
+	}
+}
+
+func (r *anomalyRepository) FindSettings(deviceId uint64) (domain.AnomalySettings, error) {
+	var m anomalySettings
+	err := r.sess.Collection(AnomalySettingsTableName).Find(db.Cond{"device_id": deviceId}).One(&m)
+	if err != nil {
+		return domain.AnomalySettings{}, err
+	}
+	return r.mapSettingsToDomain(m), nil
+}
+
+// SaveSettings creates the settings of the device or replaces the existing ones.
+func (r *anomalyRepository) SaveSettings(s domain.AnomalySettings) (domain.AnomalySettings, error) {
+	m := r.mapSettingsToModel(s)
+	now := time.Now()
+	m.UpdatedDate = now
+	err := r.sess.Tx(func(tx db.Session) error {
+		coll := tx.Collection(AnomalySettingsTableName)
+		var existing anomalySettings
+		err := coll.Find(db.Cond{"device_id": m.DeviceId}).One(&existing)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			m.CreatedDate = now
+			return coll.InsertReturning(&m)
+		}
+		if err != nil {
+			return err
+		}
+		m.Id, m.CreatedDate = existing.Id, existing.CreatedDate
+		return coll.Find(db.Cond{"id": m.Id}).Update(&m)
+	})
+	if err != nil {
+		log.Printf("AnomalyRepository: Error saving settings: %s", err)
+		return domain.AnomalySettings{}, err
+	}
+	return r.mapSettingsToDomain(m), nil
+}
+
+func (r *anomalyRepository) DeleteSettings(deviceId uint64) error {
+	return r.sess.Collection(AnomalySettingsTableName).Find(db.Cond{"device_id": deviceId}).Delete()
+}
+
+func (r *anomalyRepository) Save(a domain.MeasurementAnomaly) (domain.MeasurementAnomaly, error) {
+	m := measurementAnomaly{
+		MeasurementId:  a.MeasurementId,
+		OrganizationId: a.OrganizationId,
+		DeviceId:       a.DeviceId,
+		ChannelId:      a.ChannelId,
+		Kind:           string(a.Kind),
+		Value:          a.Value,
+		Score:          a.Score,
+		Reason:         a.Reason,
+		CreatedDate:    time.Now(),
+	}
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		log.Printf("AnomalyRepository: Error saving anomaly: %s", err)
+		return domain.MeasurementAnomaly{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *anomalyRepository) FindByDeviceId(deviceId uint64, startDate, endDate time.Time) ([]domain.MeasurementAnomaly, error) {
+	var anomalies []measurementAnomaly
+	err := r.coll.Find(db.Cond{
+		"device_id":       deviceId,
+		"created_date >=": startDate,
+		"created_date <":  endDate,
+	}).OrderBy("created_date", "id").All(&anomalies)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(anomalies), nil
+}
+
+// FindByOrgId returns the latest anomalies of the organization, the newest first.
+func (r *anomalyRepository) FindByOrgId(orgId uint64, limit uint) ([]domain.MeasurementAnomaly, error) {
+	var anomalies []measurementAnomaly
+	err := r.coll.Find(db.Cond{"organization_id": orgId}).
+		OrderBy("-created_date", "-id").
+		Limit(int(limit)).
+		All(&anomalies)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(anomalies), nil
+}
+
+func (r *anomalyRepository) mapSettingsToModel(d domain.AnomalySettings) anomalySettings {
+	return anomalySettings{
+		Id:             d.Id,
+		OrganizationId: d.OrganizationId,
+		DeviceId:       d.DeviceId,
+		Enabled:        d.Enabled,
+		Window:         d.Window,
+		ZThreshold:     d.ZThreshold,
+		EwmaAlpha:      d.EwmaAlpha,
+		BaselineAlpha:  d.BaselineAlpha,
+		DriftThreshold: d.DriftThreshold,
+		StuckCount:     d.StuckCount,
+		FlatlineCount:  d.FlatlineCount,
+		FlatlineRatio:  d.FlatlineRatio,
+		Notify:         d.Notify,
+		CreatedDate:    d.CreatedDate,
+		UpdatedDate:    d.UpdatedDate,
+	}
+}
+
+func (r *anomalyRepository) mapSettingsToDomain(m anomalySettings) domain.AnomalySettings {
+	return domain.AnomalySettings{
+		Id:             m.Id,
+		OrganizationId: m.OrganizationId,
+		DeviceId:       m.DeviceId,
+		Enabled:        m.Enabled,
+		Window:         m.Window,
+		ZThreshold:     m.ZThreshold,
+		EwmaAlpha:      m.EwmaAlpha,
+		BaselineAlpha:  m.BaselineAlpha,
+		DriftThreshold: m.DriftThreshold,
+		StuckCount:     m.StuckCount,
+		FlatlineCount:  m.FlatlineCount,
+		FlatlineRatio:  m.FlatlineRatio,
+		Notify:         m.Notify,
+		CreatedDate:    m.CreatedDate,
+		UpdatedDate:    m.UpdatedDate,
+	}
+}
+
+func (r *anomalyRepository) mapModelToDomain(m measurementAnomaly) domain.MeasurementAnomaly {
+	return domain.MeasurementAnomaly{
+		Id:             m.Id,
+		MeasurementId:  m.MeasurementId,
+		OrganizationId: m.OrganizationId,
+		DeviceId:       m.DeviceId,
+		ChannelId:      m.ChannelId,
+		Kind:           domain.AnomalyKind(m.Kind),
+		Value:          m.Value,
+		Score:          m.Score,
+		Reason:         m.Reason,
+		CreatedDate:    m.CreatedDate,
+	}
+}
+
+func (r *anomalyRepository) mapModelToDomainCollection(anomalies []measurementAnomaly) []domain.MeasurementAnomaly {
+	res := make([]domain.MeasurementAnomaly, len(anomalies))
+	for i, m := range anomalies {
+		res[i] = r.mapModelToDomain(m)
+	}
+	return res
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const CommandApprovalsTableName = "command_approvals"
+
+type commandApproval struct {
+	Id              uint64 `db:"id,omitempty"`
+	OrganizationId  uint64 `db:"organization_id"`
+	DeviceId        uint64 `db:"device_id"`
+	RequestedBy     uint64 `db:"requested_by"`
+	Action          string `db:"action"`
+	actionParams    `db:",inline"`
+	CommandTtl      time.Duration `db:"command_ttl"`
+	Status          string        `db:"status"`
+	DecidedBy       *uint64       `db:"decided_by"`
+	Comment         *string       `db:"comment"`
+	CommandId       *uint64       `db:"command_id"`
+	ExpiresDate     time.Time     `db:"expires_date"`
+	DecidedDate     *time.Time    `db:"decided_date"`
+	CreatedDate     time.Time     `db:"created_date"`
+	UpdatedDate     time.Time     `db:"updated_date"`
+	overrideColumns `db:",inline"`
+}
+
+type ApprovalRepository interface {
+	Save(a domain.CommandApproval) (domain.CommandApproval, error)
+	Find(id uint64) (domain.CommandApproval, error)
+	FindByOrgId(orgId uint64, status *domain.ApprovalStatus) ([]domain.CommandApproval, error)
+	FindOverdue(now time.Time) ([]domain.CommandApproval, error)
+	Decide(a domain.CommandApproval) (domain.CommandApproval, error)
+	Update(a domain.CommandApproval) (domain.CommandApproval, error)
+}
+
+type approvalRepository struct {
+	coll db.Collection
+}
+
+func NewApprovalRepository(sess db.Session) ApprovalRepository {
+	return &approvalRepository{
+		coll: sess.Collection(CommandApprovalsTableName),
+	}
+}
+
+func (r *approvalRepository) Save(a domain.CommandApproval) (domain.CommandApproval, error) {
+	m := r.mapDomainToModel(a)
+	now := time.Now()
+	m.CreatedDate, m.UpdatedDate = now, now
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		log.Printf("ApprovalRepository: Error saving approval: %s", err)
+		return domain.CommandApproval{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *approvalRepository) Find(id uint64) (domain.CommandApproval, error) {
+	var m commandApproval
+	err := r.coll.Find(db.Cond{"id": id}).One(&m)
+	if err != nil {
+		return domain.CommandApproval{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+// FindByOrgId returns the approvals of the organization, the newest first. A nil status returns all of them.
+func (r *approvalRepository) FindByOrgId(orgId uint64, status *domain.ApprovalStatus) ([]domain.CommandApproval, error) {
+	cond := db.Cond{"organization_id": orgId}
+	if status != nil {
+		cond["status"] = string(*status)
+	}
+
+	var approvals []commandApproval
+	err := r.coll.Find(cond).OrderBy("-created_date", "-id").All(&approvals)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(approvals), nil
+}
+
+func (r *approvalRepository) FindOverdue(now time.Time) ([]domain.CommandApproval, error) {
+	var approvals []commandApproval
+	err := r.coll.Find(db.Cond{
+		"status":          string(domain.ApprovalPending),
+		"expires_date <=": now,
+	}).All(&approvals)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(approvals), nil
+}
+
+// Decide stores the decision on a pending approval. When two managers decide at once only the
+// first one succeeds, the second gets db.ErrNoMoreRows.
+func (r *approvalRepository) Decide(a domain.CommandApproval) (domain.CommandApproval, error) {
+	m := r.mapDomainToModel(a)
+	m.UpdatedDate = time.Now()
+	res, err := r.coll.Session().SQL().
+		Update(CommandApprovalsTableName).
+		Set(
+			"status", m.Status,
+			"decided_by", m.DecidedBy,
+			"comment", m.Comment,
+			"decided_date", m.DecidedDate,
+			"updated_date", m.UpdatedDate,
+		).
+		Where("id = ? AND status = ?", m.Id, string(domain.ApprovalPending)).
+		Exec()
+	if err != nil {
+		log.Printf("ApprovalRepository: Error deciding approval: %s", err)
+		return domain.CommandApproval{}, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return domain.CommandApproval{}, err
+	}
+	if n == 0 {
+		return domain.CommandApproval{}, db.ErrNoMoreRows
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *approvalRepository) Update(a domain.CommandApproval) (domain.CommandApproval, error) {
+	m := r.mapDomainToModel(a)
+	m.UpdatedDate = time.Now()
+	err := r.coll.Find(db.Cond{"id": m.Id}).Update(&m)
+	if err != nil {
+		log.Printf("ApprovalRepository: Error updating approval: %s", err)
+		return domain.CommandApproval{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *approvalRepository) mapDomainToModel(d domain.CommandApproval) commandApproval {
+	return commandApproval{
+		Id:              d.Id,
+		OrganizationId:  d.OrganizationId,
+		DeviceId:        d.DeviceId,
+		RequestedBy:     d.RequestedBy,
+		Action:          string(d.Action),
+		actionParams:    actionParams(d.ActionParams),
+		CommandTtl:      d.CommandTtl,
+		Status:          string(d.Status),
+		DecidedBy:       d.DecidedBy,
+		Comment:         d.Comment,
+		CommandId:       d.CommandId,
+		ExpiresDate:     d.ExpiresDate,
+		DecidedDate:     d.DecidedDate,
+		CreatedDate:     d.CreatedDate,
+		UpdatedDate:     d.UpdatedDate,
+		overrideColumns: newOverrideColumns(d.Override),
+	}
+}
+
+func (r *approvalRepository) mapModelToDomain(m commandApproval) domain.CommandApproval {
+	return domain.CommandApproval{
+		Id:             m.Id,
+		OrganizationId: m.OrganizationId,
+		DeviceId:       m.DeviceId,
+		RequestedBy:    m.RequestedBy,
+		Action:         domain.EventAction(m.Action),
+		ActionParams:   domain.ActionParams(m.actionParams),
+		CommandTtl:     m.CommandTtl,
+		Status:         domain.ApprovalStatus(m.Status),
+		DecidedBy:      m.DecidedBy,
+		Comment:        m.Comment,
+		CommandId:      m.CommandId,
+		ExpiresDate:    m.ExpiresDate,
+		DecidedDate:    m.DecidedDate,
+		CreatedDate:    m.CreatedDate,
+		UpdatedDate:    m.UpdatedDate,
+		Override:       m.overrideColumns.toDomain(),
+	}
+}
+
+func (r *approvalRepository) mapModelToDomainCollection(approvals []commandApproval) []domain.CommandApproval {
+	res := make([]domain.CommandApproval, len(approvals))
+	for i, m := range approvals {
+		res[i] = r.mapModelToDomain(m)
+	}
+	return res
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"database/sql/driver"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+)
+
+const (
+	BudgetsTableName      = "budgets"
+	BudgetAlertsTableName = "budget_alerts"
+)
+
+type budgetThresholds []float64
+
+func (t *budgetThresholds) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(t, src)
+}
+
+func (t budgetThresholds) Value() (driver.Value, error) {
+	if t == nil {
+		t = budgetThresholds{}
+	}
+	return postgresql.JSONBValue([]float64(t))
+}
+
+type budget struct {
+	Id                uint64           `db:"id,omitempty"`
+	OrganizationId    uint64           `db:"organization_id"`
+	RoomId            *uint64          `db:"room_id"`
+	UserId            uint64           `db:"user_id"`
+	Name              string           `db:"name"`
+	Metric            string           `db:"metric"`
+	Amount            float64          `db:"amount"`
+	Thresholds        budgetThresholds `db:"thresholds"`
+	AlertOnProjection bool             `db:"alert_on_projection"`
+	Enabled           bool             `db:"enabled"`
+	CreatedDate       time.Time        `db:"created_date"`
+	UpdatedDate       time.Time        `db:"updated_date"`
+	DeletedDate       *time.Time       `db:"deleted_date"`
+}
+
+type BudgetRepository interface {
+	Save(b domain.Budget) (domain.Budget, error)
+	Find(id uint64) (domain.Budget, error)
+	FindByOrgId(orgId uint64) ([]domain.Budget, error)
+	FindEnabled() ([]domain.Budget, error)
+	Update(b domain.Budget) (domain.Budget, error)
+	Delete(id uint64) error
+	SaveAlert(a domain.BudgetAlert) (bool, error)
+}
+
+type budgetRepository struct {
+	sess db.Session
+	coll db.Collection
+}
+
+func NewBudgetRepository(sess db.Session) BudgetRepository {
+	return &budgetRepository{
+		sess: sess,
+		coll: sess.Collection(BudgetsTableName),
+	}
+}
+
+func (r *budgetRepository) Save(b domain.Budget) (domain.Budget, error) {
+	m := r.mapDomainToModel(b)
+	now := time.Now()
+	m.CreatedDate, m.UpdatedDate = now, now
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		log.Printf("BudgetRepository: Error saving budget: %s", err)
+		return domain.Budget{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *budgetRepository) Find(id uint64) (domain.Budget, error) {
+	var m budget
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&m)
+	if err != nil {
+		return domain.Budget{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *budgetRepository) FindByOrgId(orgId uint64) ([]domain.Budget, error) {
+	var budgets []budget
+	err := r.coll.Find(db.Cond{"organization_id": orgId, "deleted_date": nil}).OrderBy("id").All(&budgets)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(budgets), nil
+}
+
+func (r *budgetRepository) FindEnabled() ([]domain.Budget, error) {
+	var budgets []budget
+	err := r.coll.Find(db.Cond{"enabled": true, "deleted_date": nil}).OrderBy("id").All(&budgets)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(budgets), nil
+}
+
+func (r *budgetRepository) Update(b domain.Budget) (domain.Budget, error) {
+	m := r.mapDomainToModel(b)
+	m.UpdatedDate = time.Now()
+	err := r.coll.Find(db.Cond{"id": m.Id, "deleted_date": nil}).Update(&m)
+	if err != nil {
+		log.Printf("BudgetRepository: Error updating budget: %s", err)
+		return domain.Budget{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *budgetRepository) Delete(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now()})
+}
+
+// SaveAlert records an alert of a budget and reports whether it wasn't recorded before,
+// so every alert is sent once a month, even across restarts.
+func (r *budgetRepository) SaveAlert(a domain.BudgetAlert) (bool, error) {
+	res, err := r.sess.SQL().
+		InsertInto(BudgetAlertsTableName).
+		Columns("budget_id", "month", "kind", "threshold", "used", "projected", "created_date").
+		Values(a.BudgetId, a.Month, string(a.Kind), a.Threshold, a.Used, a.Projected, time.Now()).
+		Amend(func(q string) string {
+			return q + ` ON CONFLICT (budget_id, "month", kind, threshold) DO NOTHING`
+		}).
+		Exec()
+	if err != nil {
+		log.Printf("BudgetRepository: Error saving alert: %s", err)
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *budgetRepository) mapDomainToModel(d domain.Budget) budget {
+	return budget{
+		Id:                d.Id,
+		OrganizationId:    d.OrganizationId,
+		RoomId:            d.RoomId,
+		UserId:            d.UserId,
+		Name:              d.Name,
+		Metric:            string(d.Metric),
+		Amount:            d.Amount,
+		Thresholds:        budgetThresholds(d.Thresholds),
+		AlertOnProjection: d.AlertOnProjection,
+		Enabled:           d.Enabled,
+		CreatedDate:       d.CreatedDate,
+		UpdatedDate:       d.UpdatedDate,
+		DeletedDate:       d.DeletedDate,
+	}
+}
+
+func (r *budgetRepository) mapModelToDomain(m budget) domain.Budget {
+	return domain.Budget{
+		Id:                m.Id,
+		OrganizationId:    m.OrganizationId,
+		RoomId:            m.RoomId,
+		UserId:            m.UserId,
+		Name:              m.Name,
+		Metric:            domain.BudgetMetric(m.Metric),
+		Amount:            m.Amount,
+		Thresholds:        []float64(m.Thresholds),
+		AlertOnProjection: m.AlertOnProjection,
+		Enabled:           m.Enabled,
+		CreatedDate:       m.CreatedDate,
+		UpdatedDate:       m.UpdatedDate,
+		DeletedDate:       m.DeletedDate,
+	}
+}
+
+func (r *budgetRepository) mapModelToDomainCollection(budgets []budget) []domain.Budget {
+	res := make([]domain.Budget, len(budgets))
+	for i, m := range budgets {
+		res[i] = r.mapModelToDomain(m)
+	}
+	return res
+}
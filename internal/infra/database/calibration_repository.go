@@ -0,0 +1,163 @@
+package database
+
+import (
+	"database/sql/driver"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+)
+
+const CalibrationsTableName = "calibrations"
+
+type calibrationCoefficients []float64
+
+func (c *calibrationCoefficients) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(c, src)
+}
+
+func (c calibrationCoefficients) Value() (driver.Value, error) {
+	if c == nil {
+		c = calibrationCoefficients{}
+	}
+	return postgresql.JSONBValue([]float64(c))
+}
+
+type calibration struct {
+	Id             uint64                  `db:"id,omitempty"`
+	OrganizationId uint64                  `db:"organization_id"`
+	DeviceId       uint64                  `db:"device_id"`
+	ChannelId      *uint64                 `db:"channel_id"`
+	UserId         uint64                  `db:"user_id"`
+	Offset         float64                 `db:"offset_value"`
+	Gain           float64                 `db:"gain"`
+	Coefficients   calibrationCoefficients `db:"coefficients"`
+	EffectiveFrom  time.Time               `db:"effective_from"`
+	Note           *string                 `db:"note"`
+	CreatedDate    time.Time               `db:"created_date"`
+	DeletedDate    *time.Time              `db:"deleted_date"`
+}
+
+type CalibrationRepository interface {
+	Save(c domain.Calibration) (domain.Calibration, error)
+	Find(id uint64) (domain.Calibration, error)
+	FindByDeviceId(deviceId uint64) ([]domain.Calibration, error)
+	// FindEffective and FindNext look at the calibrations of the channel, or of the device's own
+	// value when channelId is nil
+	FindEffective(deviceId uint64, channelId *uint64, at time.Time) (domain.Calibration, error)
+	FindNext(deviceId uint64, channelId *uint64, after time.Time) (domain.Calibration, error)
+	Delete(id uint64) error
+}
+
+type calibrationRepository struct {
+	coll db.Collection
+}
+
+func NewCalibrationRepository(sess db.Session) CalibrationRepository {
+	return &calibrationRepository{
+		coll: sess.Collection(CalibrationsTableName),
+	}
+}
+
+func (r *calibrationRepository) Save(c domain.Calibration) (domain.Calibration, error) {
+	m := r.mapDomainToModel(c)
+	m.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		log.Printf("CalibrationRepository: Error saving calibration: %s", err)
+		return domain.Calibration{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *calibrationRepository) Find(id uint64) (domain.Calibration, error) {
+	var m calibration
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&m)
+	if err != nil {
+		return domain.Calibration{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+// FindByDeviceId returns the calibration history of the device, the latest effective first.
+func (r *calibrationRepository) FindByDeviceId(deviceId uint64) ([]domain.Calibration, error) {
+	var calibrations []calibration
+	err := r.coll.Find(db.Cond{"device_id": deviceId, "deleted_date": nil}).OrderBy("-effective_from", "-id").All(&calibrations)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(calibrations), nil
+}
+
+// FindEffective returns the calibration of the device in effect at the time. Of two calibrations
+// effective from the same time the later one wins.
+func (r *calibrationRepository) FindEffective(deviceId uint64, channelId *uint64, at time.Time) (domain.Calibration, error) {
+	var m calibration
+	err := r.coll.Find(db.Cond{"device_id": deviceId, "channel_id": channelCond(channelId), "effective_from <=": at, "deleted_date": nil}).
+		OrderBy("-effective_from", "-id").
+		One(&m)
+	if err != nil {
+		return domain.Calibration{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+// FindNext returns the first calibration of the device which takes effect after the time.
+func (r *calibrationRepository) FindNext(deviceId uint64, channelId *uint64, after time.Time) (domain.Calibration, error) {
+	var m calibration
+	err := r.coll.Find(db.Cond{"device_id": deviceId, "channel_id": channelCond(channelId), "effective_from >": after, "deleted_date": nil}).
+		OrderBy("effective_from", "id").
+		One(&m)
+	if err != nil {
+		return domain.Calibration{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *calibrationRepository) Delete(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now()})
+}
+
+func (r *calibrationRepository) mapDomainToModel(d domain.Calibration) calibration {
+	return calibration{
+		Id:             d.Id,
+		OrganizationId: d.OrganizationId,
+		DeviceId:       d.DeviceId,
+		ChannelId:      d.ChannelId,
+		UserId:         d.UserId,
+		Offset:         d.Offset,
+		Gain:           d.Gain,
+		Coefficients:   calibrationCoefficients(d.Coefficients),
+		EffectiveFrom:  d.EffectiveFrom,
+		Note:           d.Note,
+		CreatedDate:    d.CreatedDate,
+		DeletedDate:    d.DeletedDate,
+	}
+}
+
+func (r *calibrationRepository) mapModelToDomain(m calibration) domain.Calibration {
+	return domain.Calibration{
+		Id:             m.Id,
+		OrganizationId: m.OrganizationId,
+		DeviceId:       m.DeviceId,
+		ChannelId:      m.ChannelId,
+		UserId:         m.UserId,
+		Offset:         m.Offset,
+		Gain:           m.Gain,
+		Coefficients:   []float64(m.Coefficients),
+		EffectiveFrom:  m.EffectiveFrom,
+		Note:           m.Note,
+		CreatedDate:    m.CreatedDate,
+		DeletedDate:    m.DeletedDate,
+	}
+}
+
+func (r *calibrationRepository) mapModelToDomainCollection(calibrations []calibration) []domain.Calibration {
+	res := make([]domain.Calibration, len(calibrations))
+	for i, m := range calibrations {
+		res[i] = r.mapModelToDomain(m)
+	}
+	return res
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const DeviceChannelsTableName = "device_channels"
+
+type deviceChannel struct {
+	Id          uint64     `db:"id,omitempty"`
+	DeviceId    uint64     `db:"device_id"`
+	Key         string     `db:"key"`
+	Quantity    string     `db:"quantity"`
+	Units       *string    `db:"units"`
+	ValidMin    *float64   `db:"valid_min"`
+	ValidMax    *float64   `db:"valid_max"`
+	MaxRate     *float64   `db:"max_rate"`
+	CreatedDate time.Time  `db:"created_date"`
+	UpdatedDate time.Time  `db:"updated_date"`
+	DeletedDate *time.Time `db:"deleted_date"`
+}
+
+type ChannelRepository interface {
+	Save(ch domain.DeviceChannel) (domain.DeviceChannel, error)
+	Update(ch domain.DeviceChannel) (domain.DeviceChannel, error)
+	Find(id uint64) (domain.DeviceChannel, error)
+	FindByDeviceId(deviceId uint64) ([]domain.DeviceChannel, error)
+	Delete(id uint64) error
+}
+
+type channelRepository struct {
+	coll db.Collection
+}
+
+func NewChannelRepository(sess db.Session) ChannelRepository {
+	return &channelRepository{
+		coll: sess.Collection(DeviceChannelsTableName),
+	}
+}
+
+func (r *channelRepository) Save(ch domain.DeviceChannel) (domain.DeviceChannel, error) {
+	m := r.mapDomainToModel(ch)
+	now := time.Now()
+	m.CreatedDate, m.UpdatedDate = now, now
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		log.Printf("ChannelRepository: Error saving channel: %s", err)
+		return domain.DeviceChannel{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *channelRepository) Update(ch domain.DeviceChannel) (domain.DeviceChannel, error) {
+	m := r.mapDomainToModel(ch)
+	m.UpdatedDate = time.Now()
+	err := r.coll.Find(db.Cond{"id": m.Id, "deleted_date": nil}).Update(&m)
+	if err != nil {
+		log.Printf("ChannelRepository: Error updating channel: %s", err)
+		return domain.DeviceChannel{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *channelRepository) Find(id uint64) (domain.DeviceChannel, error) {
+	var m deviceChannel
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&m)
+	if err != nil {
+		return domain.DeviceChannel{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *channelRepository) FindByDeviceId(deviceId uint64) ([]domain.DeviceChannel, error) {
+	var channels []deviceChannel
+	err := r.coll.Find(db.Cond{"device_id": deviceId, "deleted_date": nil}).OrderBy("id").All(&channels)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(channels), nil
+}
+
+// Delete retires the channel, its readings are kept.
+func (r *channelRepository) Delete(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now()})
+}
+
+func (r *channelRepository) mapDomainToModel(d domain.DeviceChannel) deviceChannel {
+	return deviceChannel{
+		Id:          d.Id,
+		DeviceId:    d.DeviceId,
+		Key:         d.Key,
+		Quantity:    d.Quantity,
+		Units:       d.Units,
+		ValidMin:    d.ValidMin,
+		ValidMax:    d.ValidMax,
+		MaxRate:     d.MaxRate,
+		CreatedDate: d.CreatedDate,
+		UpdatedDate: d.UpdatedDate,
+		DeletedDate: d.DeletedDate,
+	}
+}
+
+func (r *channelRepository) mapModelToDomain(m deviceChannel) domain.DeviceChannel {
+	return domain.DeviceChannel{
+		Id:          m.Id,
+		DeviceId:    m.DeviceId,
+		Key:         m.Key,
+		Quantity:    m.Quantity,
+		Units:       m.Units,
+		ValidMin:    m.ValidMin,
+		ValidMax:    m.ValidMax,
+		MaxRate:     m.MaxRate,
+		CreatedDate: m.CreatedDate,
+		UpdatedDate: m.UpdatedDate,
+		DeletedDate: m.DeletedDate,
+	}
+}
+
+func (r *channelRepository) mapModelToDomainCollection(channels []deviceChannel) []domain.DeviceChannel {
+	res := make([]domain.DeviceChannel, len(channels))
+	for i, m := range channels {
+		res[i] = r.mapModelToDomain(m)
+	}
+	return res
+}
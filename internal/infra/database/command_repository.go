@@ -0,0 +1,212 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const CommandsTableName = "commands"
+
+type command struct {
+	Id               uint64 `db:"id,omitempty"`
+	OrganizationId   uint64 `db:"organization_id"`
+	DeviceId         uint64 `db:"device_id"`
+	UserId           uint64 `db:"user_id"`
+	Action           string `db:"action"`
+	actionParams     `db:",inline"`
+	Status           string     `db:"status"`
+	EventId          *uint64    `db:"event_id"`
+	ApprovalId       *uint64    `db:"approval_id"`
+	Error            *string    `db:"error"`
+	ExpiresDate      time.Time  `db:"expires_date"`
+	DeliveredDate    *time.Time `db:"delivered_date"`
+	AcknowledgedDate *time.Time `db:"acknowledged_date"`
+	CreatedDate      time.Time  `db:"created_date"`
+	UpdatedDate      time.Time  `db:"updated_date"`
+	DeletedDate      *time.Time `db:"deleted_date"`
+	overrideColumns  `db:",inline"`
+}
+
+type CommandRepository interface {
+	Save(c domain.Command) (domain.Command, error)
+	Find(id uint64) (domain.Command, error)
+	FindByDeviceId(deviceId uint64) ([]domain.Command, error)
+	FindOpenByDeviceId(deviceId uint64, now time.Time) ([]domain.Command, error)
+	FindOpenByOrgId(orgId uint64, now time.Time) ([]domain.Command, error)
+	FindOverdue(now time.Time) ([]domain.Command, error)
+	Update(c domain.Command) (domain.Command, error)
+	Close(c domain.Command) (bool, error)
+}
+
+type commandRepository struct {
+	coll db.Collection
+}
+
+func NewCommandRepository(sess db.Session) CommandRepository {
+	return &commandRepository{
+		coll: sess.Collection(CommandsTableName),
+	}
+}
+
+func (r *commandRepository) Save(dc domain.Command) (domain.Command, error) {
+	cmd := r.mapDomainToModel(dc)
+	now := time.Now()
+	cmd.CreatedDate, cmd.UpdatedDate = now, now
+	err := r.coll.InsertReturning(&cmd)
+	if err != nil {
+		log.Printf("CommandRepository: Error saving command: %s", err)
+		return domain.Command{}, err
+	}
+	return r.mapModelToDomain(cmd), nil
+}
+
+func (r *commandRepository) Find(id uint64) (domain.Command, error) {
+	var cmd command
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&cmd)
+	if err != nil {
+		return domain.Command{}, err
+	}
+	return r.mapModelToDomain(cmd), nil
+}
+
+func (r *commandRepository) FindByDeviceId(deviceId uint64) ([]domain.Command, error) {
+	var cmds []command
+	err := r.coll.Find(db.Cond{"device_id": deviceId, "deleted_date": nil}).OrderBy("-id").All(&cmds)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(cmds), nil
+}
+
+func (r *commandRepository) FindOpenByDeviceId(deviceId uint64, now time.Time) ([]domain.Command, error) {
+	var cmds []command
+	err := r.coll.Find(db.Cond{
+		"device_id":      deviceId,
+		"status IN":      []string{string(domain.CommandPending), string(domain.CommandDelivered)},
+		"expires_date >": now,
+		"deleted_date":   nil,
+	}).OrderBy("id").All(&cmds)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(cmds), nil
+}
+
+func (r *commandRepository) FindOpenByOrgId(orgId uint64, now time.Time) ([]domain.Command, error) {
+	var cmds []command
+	err := r.coll.Find(db.Cond{
+		"organization_id": orgId,
+		"status IN":       []string{string(domain.CommandPending), string(domain.CommandDelivered)},
+		"expires_date >":  now,
+		"deleted_date":    nil,
+	}).OrderBy("id").All(&cmds)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(cmds), nil
+}
+
+func (r *commandRepository) FindOverdue(now time.Time) ([]domain.Command, error) {
+	var cmds []command
+	err := r.coll.Find(db.Cond{
+		"status IN":       []string{string(domain.CommandPending), string(domain.CommandDelivered)},
+		"expires_date <=": now,
+		"deleted_date":    nil,
+	}).All(&cmds)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(cmds), nil
+}
+
+func (r *commandRepository) Update(dc domain.Command) (domain.Command, error) {
+	cmd := r.mapDomainToModel(dc)
+	cmd.UpdatedDate = time.Now()
+	err := r.coll.Find(db.Cond{"id": cmd.Id, "deleted_date": nil}).Update(&cmd)
+	if err != nil {
+		log.Printf("CommandRepository: Error updating command: %s", err)
+		return domain.Command{}, err
+	}
+	return r.mapModelToDomain(cmd), nil
+}
+
+// Close stores the final status of an open command, false when the command was closed already.
+func (r *commandRepository) Close(dc domain.Command) (bool, error) {
+	cmd := r.mapDomainToModel(dc)
+	cmd.UpdatedDate = time.Now()
+	res, err := r.coll.Session().SQL().
+		Update(CommandsTableName).
+		Set(
+			"status", cmd.Status,
+			"event_id", cmd.EventId,
+			"error", cmd.Error,
+			"acknowledged_date", cmd.AcknowledgedDate,
+			"updated_date", cmd.UpdatedDate,
+		).
+		Where("id = ? AND status IN ? AND deleted_date IS NULL", cmd.Id, []string{string(domain.CommandPending), string(domain.CommandDelivered)}).
+		Exec()
+	if err != nil {
+		log.Printf("CommandRepository: Error closing command: %s", err)
+		return false, err
+	}
+	closed, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return closed > 0, nil
+}
+
+func (r *commandRepository) mapDomainToModel(d domain.Command) command {
+	return command{
+		Id:               d.Id,
+		OrganizationId:   d.OrganizationId,
+		DeviceId:         d.DeviceId,
+		UserId:           d.UserId,
+		Action:           string(d.Action),
+		actionParams:     actionParams(d.ActionParams),
+		Status:           string(d.Status),
+		EventId:          d.EventId,
+		ApprovalId:       d.ApprovalId,
+		Error:            d.Error,
+		ExpiresDate:      d.ExpiresDate,
+		DeliveredDate:    d.DeliveredDate,
+		AcknowledgedDate: d.AcknowledgedDate,
+		CreatedDate:      d.CreatedDate,
+		UpdatedDate:      d.UpdatedDate,
+		DeletedDate:      d.DeletedDate,
+		overrideColumns:  newOverrideColumns(d.Override),
+	}
+}
+
+func (r *commandRepository) mapModelToDomain(m command) domain.Command {
+	return domain.Command{
+		Id:               m.Id,
+		OrganizationId:   m.OrganizationId,
+		DeviceId:         m.DeviceId,
+		UserId:           m.UserId,
+		Action:           domain.EventAction(m.Action),
+		ActionParams:     domain.ActionParams(m.actionParams),
+		Status:           domain.CommandStatus(m.Status),
+		EventId:          m.EventId,
+		ApprovalId:       m.ApprovalId,
+		Error:            m.Error,
+		ExpiresDate:      m.ExpiresDate,
+		DeliveredDate:    m.DeliveredDate,
+		AcknowledgedDate: m.AcknowledgedDate,
+		CreatedDate:      m.CreatedDate,
+		UpdatedDate:      m.UpdatedDate,
+		DeletedDate:      m.DeletedDate,
+		Override:         m.overrideColumns.toDomain(),
+	}
+}
+
+func (r *commandRepository) mapModelToDomainCollection(cmds []command) []domain.Command {
+	commands := make([]domain.Command, len(cmds))
+	for i, c := range cmds {
+		commands[i] = r.mapModelToDomain(c)
+	}
+	return commands
+}
@@ -0,0 +1,338 @@
+package database
+
+import (
+	"database/sql/driver"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+)
+
+const (
+	DemandPoliciesTableName  = "demand_policies"
+	DemandRequestsTableName  = "demand_requests"
+	DemandDecisionsTableName = "demand_decisions"
+)
+
+type devicePriorities []domain.DevicePriority
+
+func (p *devicePriorities) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(p, src)
+}
+
+func (p devicePriorities) Value() (driver.Value, error) {
+	if p == nil {
+		p = devicePriorities{}
+	}
+	return postgresql.JSONBValue([]domain.DevicePriority(p))
+}
+
+type deviceIds []uint64
+
+func (d *deviceIds) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(d, src)
+}
+
+func (d deviceIds) Value() (driver.Value, error) {
+	if d == nil {
+		d = deviceIds{}
+	}
+	return postgresql.JSONBValue([]uint64(d))
+}
+
+type demandPolicy struct {
+	Id             uint64           `db:"id,omitempty"`
+	OrganizationId uint64           `db:"organization_id"`
+	UserId         uint64           `db:"user_id"`
+	MaxKw          float64          `db:"max_kw"`
+	Strategy       string           `db:"strategy"`
+	Enabled        bool             `db:"enabled"`
+	Priorities     devicePriorities `db:"priorities"`
+	CreatedDate    time.Time        `db:"created_date"`
+	UpdatedDate    time.Time        `db:"updated_date"`
+}
+
+type demandRequest struct {
+	Id              uint64  `db:"id,omitempty"`
+	OrganizationId  uint64  `db:"organization_id"`
+	DeviceId        uint64  `db:"device_id"`
+	UserId          *uint64 `db:"user_id"`
+	Source          string  `db:"source"`
+	Action          string  `db:"action"`
+	actionParams    `db:",inline"`
+	CommandTtl      time.Duration `db:"command_ttl"`
+	ApprovalId      *uint64       `db:"approval_id"`
+	Status          string        `db:"status"`
+	Error           *string       `db:"error"`
+	ExpiresDate     time.Time     `db:"expires_date"`
+	ReleasedDate    *time.Time    `db:"released_date"`
+	CreatedDate     time.Time     `db:"created_date"`
+	overrideColumns `db:",inline"`
+}
+
+type demandDecision struct {
+	Id             uint64    `db:"id,omitempty"`
+	OrganizationId uint64    `db:"organization_id"`
+	DeviceId       uint64    `db:"device_id"`
+	Source         string    `db:"source"`
+	Action         string    `db:"action"`
+	Outcome        string    `db:"outcome"`
+	RequestedKw    float64   `db:"requested_kw"`
+	LoadKw         float64   `db:"load_kw"`
+	LimitKw        float64   `db:"limit_kw"`
+	ShedDeviceIds  deviceIds `db:"shed_device_ids"`
+	RequestId      *uint64   `db:"request_id"`
+	Reason         string    `db:"reason"`
+	CreatedDate    time.Time `db:"created_date"`
+}
+
+type DemandRepository interface {
+	FindPolicy(orgId uint64) (domain.DemandPolicy, error)
+	SavePolicy(p domain.DemandPolicy) (domain.DemandPolicy, error)
+	DeletePolicy(orgId uint64) error
+	SaveRequest(r domain.DemandRequest) (domain.DemandRequest, error)
+	UpdateRequest(r domain.DemandRequest) (domain.DemandRequest, error)
+	FindQueued() ([]domain.DemandRequest, error)
+	FindRequestsByOrgId(orgId uint64, limit uint) ([]domain.DemandRequest, error)
+	SaveDecision(d domain.DemandDecision) (domain.DemandDecision, error)
+	FindDecisionsByOrgId(orgId uint64, limit uint) ([]domain.DemandDecision, error)
+}
+
+type demandRepository struct {
+	sess db.Session
+}
+
+func NewDemandRepository(sess db.Session) DemandRepository {
+	return &demandRepository{
+		sess: sess,
+	}
+}
+
+func (r *demandRepository) FindPolicy(orgId uint64) (domain.DemandPolicy, error) {
+	var m demandPolicy
+	err := r.sess.Collection(DemandPoliciesTableName).Find(db.Cond{"organization_id": orgId}).One(&m)
+	if err != nil {
+		return domain.DemandPolicy{}, err
+	}
+	return r.mapPolicyToDomain(m), nil
+}
+
+// SavePolicy creates the policy of the organization or replaces the existing one.
+func (r *demandRepository) SavePolicy(p domain.DemandPolicy) (domain.DemandPolicy, error) {
+	m := r.mapPolicyToModel(p)
+	now := time.Now()
+	m.UpdatedDate = now
+	err := r.sess.Tx(func(tx db.Session) error {
+		coll := tx.Collection(DemandPoliciesTableName)
+		var existing demandPolicy
+		err := coll.Find(db.Cond{"organization_id": m.OrganizationId}).One(&existing)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			m.CreatedDate = now
+			return coll.InsertReturning(&m)
+		}
+		if err != nil {
+			return err
+		}
+		m.Id, m.CreatedDate = existing.Id, existing.CreatedDate
+		return coll.Find(db.Cond{"id": m.Id}).Update(&m)
+	})
+	if err != nil {
+		log.Printf("DemandRepository: Error saving policy: %s", err)
+		return domain.DemandPolicy{}, err
+	}
+	return r.mapPolicyToDomain(m), nil
+}
+
+func (r *demandRepository) DeletePolicy(orgId uint64) error {
+	return r.sess.Collection(DemandPoliciesTableName).Find(db.Cond{"organization_id": orgId}).Delete()
+}
+
+func (r *demandRepository) SaveRequest(dr domain.DemandRequest) (domain.DemandRequest, error) {
+	m := r.mapRequestToModel(dr)
+	m.CreatedDate = time.Now()
+	err := r.sess.Collection(DemandRequestsTableName).InsertReturning(&m)
+	if err != nil {
+		log.Printf("DemandRepository: Error saving request: %s", err)
+		return domain.DemandRequest{}, err
+	}
+	return r.mapRequestToDomain(m), nil
+}
+
+func (r *demandRepository) UpdateRequest(dr domain.DemandRequest) (domain.DemandRequest, error) {
+	m := r.mapRequestToModel(dr)
+	err := r.sess.Collection(DemandRequestsTableName).Find(db.Cond{"id": m.Id}).Update(&m)
+	if err != nil {
+		log.Printf("DemandRepository: Error updating request: %s", err)
+		return domain.DemandRequest{}, err
+	}
+	return r.mapRequestToDomain(m), nil
+}
+
+// FindQueued returns the waiting requests of all organizations, the oldest first.
+func (r *demandRepository) FindQueued() ([]domain.DemandRequest, error) {
+	var requests []demandRequest
+	err := r.sess.Collection(DemandRequestsTableName).
+		Find(db.Cond{"status": string(domain.DemandRequestQueued)}).
+		OrderBy("created_date", "id").
+		All(&requests)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapRequestsToDomain(requests), nil
+}
+
+func (r *demandRepository) FindRequestsByOrgId(orgId uint64, limit uint) ([]domain.DemandRequest, error) {
+	var requests []demandRequest
+	err := r.sess.Collection(DemandRequestsTableName).
+		Find(db.Cond{"organization_id": orgId}).
+		OrderBy("-created_date", "-id").
+		Limit(int(limit)).
+		All(&requests)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapRequestsToDomain(requests), nil
+}
+
+func (r *demandRepository) SaveDecision(d domain.DemandDecision) (domain.DemandDecision, error) {
+	m := r.mapDecisionToModel(d)
+	m.CreatedDate = time.Now()
+	err := r.sess.Collection(DemandDecisionsTableName).InsertReturning(&m)
+	if err != nil {
+		log.Printf("DemandRepository: Error saving decision: %s", err)
+		return domain.DemandDecision{}, err
+	}
+	return r.mapDecisionToDomain(m), nil
+}
+
+func (r *demandRepository) FindDecisionsByOrgId(orgId uint64, limit uint) ([]domain.DemandDecision, error) {
+	var decisions []demandDecision
+	err := r.sess.Collection(DemandDecisionsTableName).
+		Find(db.Cond{"organization_id": orgId}).
+		OrderBy("-created_date", "-id").
+		Limit(int(limit)).
+		All(&decisions)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.DemandDecision, len(decisions))
+	for i, m := range decisions {
+		res[i] = r.mapDecisionToDomain(m)
+	}
+	return res, nil
+}
+
+func (r *demandRepository) mapPolicyToModel(d domain.DemandPolicy) demandPolicy {
+	return demandPolicy{
+		Id:             d.Id,
+		OrganizationId: d.OrganizationId,
+		UserId:         d.UserId,
+		MaxKw:          d.MaxKw,
+		Strategy:       string(d.Strategy),
+		Enabled:        d.Enabled,
+		Priorities:     devicePriorities(d.Priorities),
+		CreatedDate:    d.CreatedDate,
+		UpdatedDate:    d.UpdatedDate,
+	}
+}
+
+func (r *demandRepository) mapPolicyToDomain(m demandPolicy) domain.DemandPolicy {
+	return domain.DemandPolicy{
+		Id:             m.Id,
+		OrganizationId: m.OrganizationId,
+		UserId:         m.UserId,
+		MaxKw:          m.MaxKw,
+		Strategy:       domain.DemandStrategy(m.Strategy),
+		Enabled:        m.Enabled,
+		Priorities:     []domain.DevicePriority(m.Priorities),
+		CreatedDate:    m.CreatedDate,
+		UpdatedDate:    m.UpdatedDate,
+	}
+}
+
+func (r *demandRepository) mapRequestToModel(d domain.DemandRequest) demandRequest {
+	return demandRequest{
+		Id:              d.Id,
+		OrganizationId:  d.OrganizationId,
+		DeviceId:        d.DeviceId,
+		UserId:          d.UserId,
+		Source:          string(d.Source),
+		Action:          string(d.Action),
+		actionParams:    actionParams(d.ActionParams),
+		CommandTtl:      d.CommandTtl,
+		ApprovalId:      d.ApprovalId,
+		Status:          string(d.Status),
+		Error:           d.Error,
+		ExpiresDate:     d.ExpiresDate,
+		ReleasedDate:    d.ReleasedDate,
+		CreatedDate:     d.CreatedDate,
+		overrideColumns: newOverrideColumns(d.Override),
+	}
+}
+
+func (r *demandRepository) mapRequestToDomain(m demandRequest) domain.DemandRequest {
+	return domain.DemandRequest{
+		Id:             m.Id,
+		OrganizationId: m.OrganizationId,
+		DeviceId:       m.DeviceId,
+		UserId:         m.UserId,
+		Source:         domain.DemandSource(m.Source),
+		Action:         domain.EventAction(m.Action),
+		ActionParams:   domain.ActionParams(m.actionParams),
+		CommandTtl:     m.CommandTtl,
+		ApprovalId:     m.ApprovalId,
+		Status:         domain.DemandRequestStatus(m.Status),
+		Error:          m.Error,
+		ExpiresDate:    m.ExpiresDate,
+		ReleasedDate:   m.ReleasedDate,
+		CreatedDate:    m.CreatedDate,
+		Override:       m.overrideColumns.toDomain(),
+	}
+}
+
+func (r *demandRepository) mapRequestsToDomain(requests []demandRequest) []domain.DemandRequest {
+	res := make([]domain.DemandRequest, len(requests))
+	for i, m := range requests {
+		res[i] = r.mapRequestToDomain(m)
+	}
+	return res
+}
+
+func (r *demandRepository) mapDecisionToModel(d domain.DemandDecision) demandDecision {
+	return demandDecision{
+		Id:             d.Id,
+		OrganizationId: d.OrganizationId,
+		DeviceId:       d.DeviceId,
+		Source:         string(d.Source),
+		Action:         string(d.Action),
+		Outcome:        string(d.Outcome),
+		RequestedKw:    d.RequestedKw,
+		LoadKw:         d.LoadKw,
+		LimitKw:        d.LimitKw,
+		ShedDeviceIds:  deviceIds(d.ShedDeviceIds),
+		RequestId:      d.RequestId,
+		Reason:         d.Reason,
+		CreatedDate:    d.CreatedDate,
+	}
+}
+
+func (r *demandRepository) mapDecisionToDomain(m demandDecision) domain.DemandDecision {
+	return domain.DemandDecision{
+		Id:             m.Id,
+		OrganizationId: m.OrganizationId,
+		DeviceId:       m.DeviceId,
+		Source:         domain.DemandSource(m.Source),
+		Action:         domain.EventAction(m.Action),
+		Outcome:        domain.DemandOutcome(m.Outcome),
+		RequestedKw:    m.RequestedKw,
+		LoadKw:         m.LoadKw,
+		LimitKw:        m.LimitKw,
+		ShedDeviceIds:  []uint64(m.ShedDeviceIds),
+		RequestId:      m.RequestId,
+		Reason:         m.Reason,
+		CreatedDate:    m.CreatedDate,
+	}
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const DeviceInstallationsTableName = "device_installations"
+
+type deviceInstallation struct {
+	Id            uint64     `db:"id,omitempty"`
+	DeviceId      uint64     `db:"device_id"`
+	RoomId        uint64     `db:"room_id"`
+	InstalledDate time.Time  `db:"installed_date"`
+	RemovedDate   *time.Time `db:"removed_date"`
+}
+
+type DeviceInstallationRepository interface {
+	Move(deviceId uint64, roomId *uint64, at time.Time) error
+	FindByDeviceIds(deviceIds []uint64, start, end time.Time) ([]domain.DeviceInstallation, error)
+	FindByRoomId(roomId uint64, start, end time.Time) ([]domain.DeviceInstallation, error)
+}
+
+type deviceInstallationRepository struct {
+	sess db.Session
+}
+
+func NewDeviceInstallationRepository(sess db.Session) DeviceInstallationRepository {
+	return &deviceInstallationRepository{
+		sess: sess,
+	}
+}
+
+// Move ends the current installation of the device and starts one in the room, if any.
+func (r *deviceInstallationRepository) Move(deviceId uint64, roomId *uint64, at time.Time) error {
+	err := r.sess.Tx(func(tx db.Session) error {
+		coll := tx.Collection(DeviceInstallationsTableName)
+		err := coll.Find(db.Cond{"device_id": deviceId, "removed_date": nil}).Update(map[string]interface{}{"removed_date": at})
+		if err != nil {
+			return err
+		}
+		if roomId == nil {
+			return nil
+		}
+		_, err = coll.Insert(deviceInstallation{
+			DeviceId:      deviceId,
+			RoomId:        *roomId,
+			InstalledDate: at,
+		})
+		return err
+	})
+	if err != nil {
+		log.Printf("DeviceInstallationRepository: Error moving device %d: %s", deviceId, err)
+	}
+	return err
+}
+
+// FindByDeviceIds returns the installations of the devices which overlap [start, end).
+func (r *deviceInstallationRepository) FindByDeviceIds(deviceIds []uint64, start, end time.Time) ([]domain.DeviceInstallation, error) {
+	if len(deviceIds) == 0 {
+		return nil, nil
+	}
+	return r.find(db.Cond{"device_id": db.In(deviceIds)}, start, end)
+}
+
+// FindByRoomId returns the installations in the room which overlap [start, end).
+func (r *deviceInstallationRepository) FindByRoomId(roomId uint64, start, end time.Time) ([]domain.DeviceInstallation, error) {
+	return r.find(db.Cond{"room_id": roomId}, start, end)
+}
+
+func (r *deviceInstallationRepository) find(cond db.Cond, start, end time.Time) ([]domain.DeviceInstallation, error) {
+	var installs []deviceInstallation
+	err := r.sess.Collection(DeviceInstallationsTableName).Find(
+		cond,
+		db.Cond{"installed_date <": end},
+		db.Or(db.Cond{"removed_date": nil}, db.Cond{"removed_date >": start}),
+	).OrderBy("device_id", "installed_date").All(&installs)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(installs), nil
+}
+
+func (r *deviceInstallationRepository) mapModelToDomain(m deviceInstallation) domain.DeviceInstallation {
+	return domain.DeviceInstallation{
+		Id:            m.Id,
+		DeviceId:      m.DeviceId,
+		RoomId:        m.RoomId,
+		InstalledDate: m.InstalledDate,
+		RemovedDate:   m.RemovedDate,
+	}
+}
+
+func (r *deviceInstallationRepository) mapModelToDomainCollection(installs []deviceInstallation) []domain.DeviceInstallation {
+	res := make([]domain.DeviceInstallation, len(installs))
+	for i, m := range installs {
+		res[i] = r.mapModelToDomain(m)
+	}
+	return res
+}
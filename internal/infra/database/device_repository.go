@@ -1,16 +1,28 @@
 package database
 
 import (
+	"database/sql/driver"
 	"errors"
 	"log"
 	"time"
 
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
 )
 
 const DevicesTableName = "devices"
 
+type deviceCapabilities domain.DeviceCapabilities
+
+func (c *deviceCapabilities) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(c, src)
+}
+
+func (c deviceCapabilities) Value() (driver.Value, error) {
+	return postgresql.JSONBValue(c)
+}
+
 type device struct {
 	Id               uint64                `db:"id,omitempty"`
 	OrganizationId   uint64                `db:"organization_id"`
@@ -22,6 +34,15 @@ type device struct {
 	Category         domain.DeviceCategory `db:"category"`
 	Units            *string               `db:"units"`
 	PowerConsumption *float64              `db:"power_consumption"`
+	SecretHash       *string               `db:"secret_hash"`
+	Capabilities     deviceCapabilities    `db:"capabilities"`
+	MeteredDeviceId  *uint64               `db:"metered_device_id"`
+	CounterMax       *float64              `db:"counter_max"`
+	Critical         bool                  `db:"critical"`
+	ValidMin         *float64              `db:"valid_min"`
+	ValidMax         *float64              `db:"valid_max"`
+	MaxRate          *float64              `db:"max_rate"`
+	CurrentState     *domain.EventAction   `db:"current_state,omitempty"`
 	CreatedDate      time.Time             `db:"created_date"`
 	UpdatedDate      time.Time             `db:"updated_date"`
 	DeletedDate      *time.Time            `db:"deleted_date"`
@@ -31,7 +52,9 @@ type DeviceRepository interface {
 	Save(d domain.Device) (domain.Device, error)
 	FindAll() ([]domain.Device, error)
 	Find(id uint64) (domain.Device, error)
+	FindByGUID(guid string) (domain.Device, error)
 	FindByRoomId(roomId uint64) ([]domain.Device, error)
+	FindByOrgId(orgId uint64) ([]domain.Device, error)
 	Update(d domain.Device) (domain.Device, error)
 	InstallDevice(deviceId uint64, roomId uint64) error
 	UninstallDevice(dd domain.Device) (domain.Device, error)
@@ -97,6 +120,15 @@ func (r *deviceRepository) Find(id uint64) (domain.Device, error) {
 	return dd, nil
 }
 
+func (r *deviceRepository) FindByGUID(guid string) (domain.Device, error) {
+	var dev device
+	err := r.coll.Find(db.Cond{"guid": guid, "deleted_date": nil}).One(&dev)
+	if err != nil {
+		return domain.Device{}, err
+	}
+	return r.mapModelToDomain(dev), nil
+}
+
 func (r *deviceRepository) FindByRoomId(roomId uint64) ([]domain.Device, error) {
 	var devices []device
 	err := r.coll.Find(db.Cond{"room_id": roomId, "deleted_date": nil}).All(&devices)
@@ -114,16 +146,32 @@ func (r *deviceRepository) FindByRoomId(roomId uint64) ([]domain.Device, error)
 	return r.mapModelToDomainCollection(devices), nil
 }
 
+// FindByOrgId returns the devices of the organization including the deleted ones, they are
+// still needed for reports on the time before their deletion.
+func (r *deviceRepository) FindByOrgId(orgId uint64) ([]domain.Device, error) {
+	var devices []device
+	err := r.coll.Find(db.Cond{"organization_id": orgId}).OrderBy("id").All(&devices)
+	if err != nil {
+		log.Printf("DeviceRepository: Error finding devices for organization ID %d: %s", orgId, err)
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(devices), nil
+}
+
 func (r *deviceRepository) Update(dd domain.Device) (domain.Device, error) {
 	device := r.mapDomainToModel(dd)
 	device.UpdatedDate = time.Now()
+	// the state is written by the event repository only
+	device.CurrentState = nil
 	log.Printf("DeviceRepository: Updating device %+v", device)
 	err := r.coll.Find(db.Cond{"id": device.Id, "deleted_date": nil}).Update(&device)
 	if err != nil {
 		log.Printf("DeviceRepository: Error updating device: %s", err)
 		return domain.Device{}, err
 	}
+	state := dd.CurrentState
 	dd = r.mapModelToDomain(device)
+	dd.CurrentState = state
 	log.Printf("DeviceRepository: Updated device %+v", dd)
 	return dd, nil
 }
@@ -139,13 +187,16 @@ func (r *deviceRepository) UninstallDevice(dd domain.Device) (domain.Device, err
 	device := r.mapDomainToModel(dd)
 	device.UpdatedDate = time.Now()
 	device.RoomId = nil
+	device.CurrentState = nil
 	log.Printf("DeviceRepository: Updating device %+v", device)
 	err := r.coll.Find(db.Cond{"id": device.Id, "deleted_date": nil}).Update(&device)
 	if err != nil {
 		log.Printf("DeviceRepository: Error updating device: %s", err)
 		return domain.Device{}, err
 	}
+	state := dd.CurrentState
 	dd = r.mapModelToDomain(device)
+	dd.CurrentState = state
 	log.Printf("DeviceRepository: Updated device %+v", dd)
 	return dd, nil
 }
@@ -173,6 +224,15 @@ func (r deviceRepository) mapDomainToModel(d domain.Device) device {
 		Category:         d.Category,
 		Units:            d.Units,
 		PowerConsumption: d.PowerConsumption,
+		SecretHash:       d.SecretHash,
+		Capabilities:     deviceCapabilities(d.Capabilities),
+		MeteredDeviceId:  d.MeteredDeviceId,
+		CounterMax:       d.CounterMax,
+		Critical:         d.Critical,
+		ValidMin:         d.ValidMin,
+		ValidMax:         d.ValidMax,
+		MaxRate:          d.MaxRate,
+		CurrentState:     d.CurrentState,
 		CreatedDate:      d.CreatedDate,
 		UpdatedDate:      d.UpdatedDate,
 		DeletedDate:      d.DeletedDate,
@@ -191,6 +251,15 @@ func (r deviceRepository) mapModelToDomain(d device) domain.Device {
 		Category:         d.Category,
 		Units:            d.Units,
 		PowerConsumption: d.PowerConsumption,
+		SecretHash:       d.SecretHash,
+		Capabilities:     domain.DeviceCapabilities(d.Capabilities),
+		MeteredDeviceId:  d.MeteredDeviceId,
+		CounterMax:       d.CounterMax,
+		Critical:         d.Critical,
+		ValidMin:         d.ValidMin,
+		ValidMax:         d.ValidMax,
+		MaxRate:          d.MaxRate,
+		CurrentState:     d.CurrentState,
 		CreatedDate:      d.CreatedDate,
 		UpdatedDate:      d.UpdatedDate,
 		DeletedDate:      d.DeletedDate,
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+)
+
+const DeviceTwinsTableName = "device_twins"
+
+type twinState domain.TwinState
+
+func (s *twinState) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(s, src)
+}
+
+func (s twinState) Value() (driver.Value, error) {
+	return postgresql.JSONBValue(s)
+}
+
+type deviceTwin struct {
+	DeviceId        uint64     `db:"device_id"`
+	OrganizationId  uint64     `db:"organization_id"`
+	Desired         twinState  `db:"desired"`
+	DesiredVersion  uint64     `db:"desired_version"`
+	DesiredDate     *time.Time `db:"desired_date"`
+	Reported        twinState  `db:"reported"`
+	ReportedVersion uint64     `db:"reported_version"`
+	ReportedDate    *time.Time `db:"reported_date"`
+	CreatedDate     time.Time  `db:"created_date"`
+	UpdatedDate     time.Time  `db:"updated_date"`
+}
+
+type DeviceTwinRepository interface {
+	Find(deviceId uint64) (domain.DeviceTwin, error)
+	FindByOrgId(orgId uint64) ([]domain.DeviceTwin, error)
+	Save(t domain.DeviceTwin, desiredVersion, reportedVersion uint64) (domain.DeviceTwin, error)
+}
+
+type deviceTwinRepository struct {
+	coll db.Collection
+}
+
+func NewDeviceTwinRepository(sess db.Session) DeviceTwinRepository {
+	return &deviceTwinRepository{
+		coll: sess.Collection(DeviceTwinsTableName),
+	}
+}
+
+func (r *deviceTwinRepository) Find(deviceId uint64) (domain.DeviceTwin, error) {
+	var t deviceTwin
+	err := r.coll.Find(db.Cond{"device_id": deviceId}).One(&t)
+	if err != nil {
+		return domain.DeviceTwin{}, err
+	}
+	return r.mapModelToDomain(t), nil
+}
+
+func (r *deviceTwinRepository) FindByOrgId(orgId uint64) ([]domain.DeviceTwin, error) {
+	var twins []deviceTwin
+	err := r.coll.Find(db.Cond{"organization_id": orgId}).OrderBy("device_id").All(&twins)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(twins), nil
+}
+
+// Save inserts the twin on its first write and updates it afterwards, as long as the stored
+// versions are still desiredVersion and reportedVersion the twin was read at. A twin which isn't
+// stored yet is at version 0. When someone else saved the twin in the meantime nothing is written
+// and domain.ErrTwinVersionConflict is returned.
+func (r *deviceTwinRepository) Save(dt domain.DeviceTwin, desiredVersion, reportedVersion uint64) (domain.DeviceTwin, error) {
+	t := r.mapDomainToModel(dt)
+	now := time.Now()
+	t.UpdatedDate = now
+	if t.CreatedDate.IsZero() {
+		t.CreatedDate = now
+	}
+
+	res, err := r.coll.Session().SQL().Exec(`
+		INSERT INTO `+DeviceTwinsTableName+` (device_id, organization_id, desired, desired_version, desired_date,
+			reported, reported_version, reported_date, created_date, updated_date)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (device_id) DO UPDATE SET
+			organization_id = EXCLUDED.organization_id,
+			desired = EXCLUDED.desired,
+			desired_version = EXCLUDED.desired_version,
+			desired_date = EXCLUDED.desired_date,
+			reported = EXCLUDED.reported,
+			reported_version = EXCLUDED.reported_version,
+			reported_date = EXCLUDED.reported_date,
+			updated_date = EXCLUDED.updated_date
+		WHERE `+DeviceTwinsTableName+`.desired_version = ? AND `+DeviceTwinsTableName+`.reported_version = ?`,
+		t.DeviceId, t.OrganizationId, t.Desired, t.DesiredVersion, t.DesiredDate,
+		t.Reported, t.ReportedVersion, t.ReportedDate, t.CreatedDate, t.UpdatedDate,
+		desiredVersion, reportedVersion,
+	)
+	if err != nil {
+		return domain.DeviceTwin{}, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return domain.DeviceTwin{}, err
+	}
+	if n == 0 {
+		return domain.DeviceTwin{}, domain.ErrTwinVersionConflict
+	}
+
+	dt = r.mapModelToDomain(t)
+	return dt, nil
+}
+
+func (r *deviceTwinRepository) mapDomainToModel(d domain.DeviceTwin) deviceTwin {
+	return deviceTwin{
+		DeviceId:        d.DeviceId,
+		OrganizationId:  d.OrganizationId,
+		Desired:         twinState(d.Desired),
+		DesiredVersion:  d.DesiredVersion,
+		DesiredDate:     d.DesiredDate,
+		Reported:        twinState(d.Reported),
+		ReportedVersion: d.ReportedVersion,
+		ReportedDate:    d.ReportedDate,
+		CreatedDate:     d.CreatedDate,
+		UpdatedDate:     d.UpdatedDate,
+	}
+}
+
+func (r *deviceTwinRepository) mapModelToDomain(m deviceTwin) domain.DeviceTwin {
+	return domain.DeviceTwin{
+		DeviceId:        m.DeviceId,
+		OrganizationId:  m.OrganizationId,
+		Desired:         domain.TwinState(m.Desired),
+		DesiredVersion:  m.DesiredVersion,
+		DesiredDate:     m.DesiredDate,
+		Reported:        domain.TwinState(m.Reported),
+		ReportedVersion: m.ReportedVersion,
+		ReportedDate:    m.ReportedDate,
+		CreatedDate:     m.CreatedDate,
+		UpdatedDate:     m.UpdatedDate,
+	}
+}
+
+func (r *deviceTwinRepository) mapModelToDomainCollection(twins []deviceTwin) []domain.DeviceTwin {
+	res := make([]domain.DeviceTwin, len(twins))
+	for i, t := range twins {
+		res[i] = r.mapModelToDomain(t)
+	}
+	return res
+}
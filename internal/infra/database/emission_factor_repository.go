@@ -0,0 +1,179 @@
+package database
+
+import (
+	"database/sql/driver"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+)
+
+const EmissionFactorsTableName = "emission_factors"
+
+type emissionFactorEntries []domain.EmissionFactorEntry
+
+func (e *emissionFactorEntries) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(e, src)
+}
+
+func (e emissionFactorEntries) Value() (driver.Value, error) {
+	if e == nil {
+		e = emissionFactorEntries{}
+	}
+	return postgresql.JSONBValue([]domain.EmissionFactorEntry(e))
+}
+
+type emissionFactor struct {
+	Id             uint64                `db:"id,omitempty"`
+	OrganizationId uint64                `db:"organization_id"`
+	UserId         uint64                `db:"user_id"`
+	Name           string                `db:"name"`
+	Source         string                `db:"source"`
+	Active         bool                  `db:"active"`
+	Factor         float64               `db:"factor"`
+	Entries        emissionFactorEntries `db:"entries"`
+	CreatedDate    time.Time             `db:"created_date"`
+	UpdatedDate    time.Time             `db:"updated_date"`
+	DeletedDate    *time.Time            `db:"deleted_date"`
+}
+
+type EmissionFactorRepository interface {
+	Save(f domain.EmissionFactor) (domain.EmissionFactor, error)
+	Find(id uint64) (domain.EmissionFactor, error)
+	FindByOrgId(orgId uint64) ([]domain.EmissionFactor, error)
+	FindActive(orgId uint64) (domain.EmissionFactor, error)
+	Update(f domain.EmissionFactor) (domain.EmissionFactor, error)
+	Delete(id uint64) error
+}
+
+type emissionFactorRepository struct {
+	sess db.Session
+	coll db.Collection
+}
+
+func NewEmissionFactorRepository(sess db.Session) EmissionFactorRepository {
+	return &emissionFactorRepository{
+		sess: sess,
+		coll: sess.Collection(EmissionFactorsTableName),
+	}
+}
+
+func (r *emissionFactorRepository) Save(f domain.EmissionFactor) (domain.EmissionFactor, error) {
+	m := r.mapDomainToModel(f)
+	now := time.Now()
+	m.CreatedDate, m.UpdatedDate = now, now
+	err := r.sess.Tx(func(tx db.Session) error {
+		err := r.deactivateOthers(tx, m)
+		if err != nil {
+			return err
+		}
+		return tx.Collection(EmissionFactorsTableName).InsertReturning(&m)
+	})
+	if err != nil {
+		log.Printf("EmissionFactorRepository: Error saving emission factor: %s", err)
+		return domain.EmissionFactor{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *emissionFactorRepository) Find(id uint64) (domain.EmissionFactor, error) {
+	var m emissionFactor
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&m)
+	if err != nil {
+		return domain.EmissionFactor{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *emissionFactorRepository) FindByOrgId(orgId uint64) ([]domain.EmissionFactor, error) {
+	var factors []emissionFactor
+	err := r.coll.Find(db.Cond{"organization_id": orgId, "deleted_date": nil}).OrderBy("id").All(&factors)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(factors), nil
+}
+
+func (r *emissionFactorRepository) FindActive(orgId uint64) (domain.EmissionFactor, error) {
+	var m emissionFactor
+	err := r.coll.Find(db.Cond{"organization_id": orgId, "active": true, "deleted_date": nil}).One(&m)
+	if err != nil {
+		return domain.EmissionFactor{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *emissionFactorRepository) Update(f domain.EmissionFactor) (domain.EmissionFactor, error) {
+	m := r.mapDomainToModel(f)
+	m.UpdatedDate = time.Now()
+	err := r.sess.Tx(func(tx db.Session) error {
+		err := r.deactivateOthers(tx, m)
+		if err != nil {
+			return err
+		}
+		return tx.Collection(EmissionFactorsTableName).Find(db.Cond{"id": m.Id, "deleted_date": nil}).Update(&m)
+	})
+	if err != nil {
+		log.Printf("EmissionFactorRepository: Error updating emission factor: %s", err)
+		return domain.EmissionFactor{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *emissionFactorRepository) Delete(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now(), "active": false})
+}
+
+// deactivateOthers keeps a single active emission factor per organization.
+func (r *emissionFactorRepository) deactivateOthers(tx db.Session, m emissionFactor) error {
+	if !m.Active {
+		return nil
+	}
+	cond := db.Cond{"organization_id": m.OrganizationId, "active": true, "deleted_date": nil}
+	if m.Id != 0 {
+		cond["id !="] = m.Id
+	}
+	return tx.Collection(EmissionFactorsTableName).Find(cond).Update(map[string]interface{}{"active": false})
+}
+
+func (r *emissionFactorRepository) mapDomainToModel(d domain.EmissionFactor) emissionFactor {
+	return emissionFactor{
+		Id:             d.Id,
+		OrganizationId: d.OrganizationId,
+		UserId:         d.UserId,
+		Name:           d.Name,
+		Source:         d.Source,
+		Active:         d.Active,
+		Factor:         d.Factor,
+		Entries:        emissionFactorEntries(d.Entries),
+		CreatedDate:    d.CreatedDate,
+		UpdatedDate:    d.UpdatedDate,
+		DeletedDate:    d.DeletedDate,
+	}
+}
+
+func (r *emissionFactorRepository) mapModelToDomain(m emissionFactor) domain.EmissionFactor {
+	return domain.EmissionFactor{
+		Id:             m.Id,
+		OrganizationId: m.OrganizationId,
+		UserId:         m.UserId,
+		Name:           m.Name,
+		Source:         m.Source,
+		Active:         m.Active,
+		Factor:         m.Factor,
+		Entries:        []domain.EmissionFactorEntry(m.Entries),
+		CreatedDate:    m.CreatedDate,
+		UpdatedDate:    m.UpdatedDate,
+		DeletedDate:    m.DeletedDate,
+	}
+}
+
+func (r *emissionFactorRepository) mapModelToDomainCollection(factors []emissionFactor) []domain.EmissionFactor {
+	res := make([]domain.EmissionFactor, len(factors))
+	for i, m := range factors {
+		res[i] = r.mapModelToDomain(m)
+	}
+	return res
+}
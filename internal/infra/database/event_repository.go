@@ -1,7 +1,6 @@
 package database
 
 import (
-	"errors"
 	"log"
 	"time"
 
@@ -12,13 +11,22 @@ import (
 const EventsTableName = "events"
 
 type event struct {
-	Id          uint64     `db:"id,omitempty"`
-	DeviceId    uint64     `db:"device_id"`
-	RoomId      *uint64    `db:"room_id"`
-	Action      string     `db:"action"`
-	CreatedDate time.Time  `db:"created_date"`
-	UpdatedDate time.Time  `db:"updated_date"`
-	DeletedDate *time.Time `db:"deleted_date"`
+	Id           uint64  `db:"id,omitempty"`
+	DeviceId     uint64  `db:"device_id"`
+	RoomId       *uint64 `db:"room_id"`
+	Action       string  `db:"action"`
+	actionParams `db:",inline"`
+	CreatedDate  time.Time  `db:"created_date"`
+	UpdatedDate  time.Time  `db:"updated_date"`
+	DeletedDate  *time.Time `db:"deleted_date"`
+}
+
+// actionParams holds the arguments of SET_* actions, it's shared by events and commands.
+type actionParams struct {
+	Level        *float64 `db:"level"`
+	Setpoint     *float64 `db:"setpoint"`
+	SetpointUnit *string  `db:"setpoint_unit"`
+	Mode         *string  `db:"mode"`
 }
 
 type EventRepository interface {
@@ -27,6 +35,9 @@ type EventRepository interface {
 	FindAll() ([]domain.Event, error)
 	FindByDeviceId(deviceId uint64) ([]domain.Event, error)
 	FindByRoomAndDate(roomID uint64, startDate, endDate time.Time) ([]domain.Event, error)
+	FindByDevicesAndDate(deviceIds []uint64, startDate, endDate time.Time) ([]domain.Event, error)
+	FindLastBefore(deviceId uint64, action domain.EventAction, before time.Time) (domain.Event, error)
+	FindLastPowerBefore(deviceId uint64, before time.Time) (domain.Event, error)
 }
 
 type eventRepository struct {
@@ -41,11 +52,9 @@ func NewEventRepository(sess db.Session, deviceRepo DeviceRepository) EventRepos
 	}
 }
 
+// Save inserts the event and moves the device to the new power state in one transaction.
+// An ON or OFF event that doesn't change the state is rejected with domain.ErrNoStateChange.
 func (r *eventRepository) Save(de domain.Event) (domain.Event, error) {
-	if de.Action != domain.TurnOn && de.Action != domain.TurnOff {
-		return domain.Event{}, errors.New("invalid action")
-	}
-
 	deviceRepo := NewDeviceRepository(r.coll.Session())
 	device, err := deviceRepo.Find(de.DeviceId)
 	if err != nil {
@@ -53,17 +62,42 @@ func (r *eventRepository) Save(de domain.Event) (domain.Event, error) {
 		return domain.Event{}, err
 	}
 
-	if device.Category != domain.Actuator {
-		err := errors.New("only actuators can have events")
+	err = device.ValidateAction(de.Action, de.ActionParams)
+	if err != nil {
 		log.Printf("EventRepository: %s", err)
 		return domain.Event{}, err
 	}
+	if de.Action == domain.SetSetpoint && de.SetpointUnit == nil {
+		de.SetpointUnit = &device.Capabilities.Setpoint.Unit
+	}
 
 	event := r.mapDomainToModel(de)
 	now := time.Now()
 	event.CreatedDate, event.UpdatedDate = now, now
 	log.Printf("EventRepository: Saving event %+v", event)
-	err = r.coll.InsertReturning(&event)
+	err = inTx(r.coll.Session(), func(tx db.Session) error {
+		if !de.Action.IsPower() {
+			return tx.Collection(EventsTableName).InsertReturning(&event)
+		}
+
+		res, err := tx.SQL().
+			Update(DevicesTableName).
+			Set("current_state", event.Action).
+			Where("id = ? AND current_state IS DISTINCT FROM ?", event.DeviceId, event.Action).
+			Exec()
+		if err != nil {
+			return err
+		}
+		changed, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if changed == 0 {
+			return domain.ErrNoStateChange
+		}
+
+		return tx.Collection(EventsTableName).InsertReturning(&event)
+	})
 	if err != nil {
 		log.Printf("EventRepository: Error saving event: %s", err)
 		return domain.Event{}, err
@@ -104,9 +138,10 @@ func (r *eventRepository) FindByDeviceId(deviceId uint64) ([]domain.Event, error
 func (r *eventRepository) FindByRoomAndDate(roomID uint64, startDate, endDate time.Time) ([]domain.Event, error) {
 	var events []event
 	err := r.coll.Find(db.Cond{
-		"room_id":      roomID,
-		"created_date": db.Between(startDate, endDate),
-		"deleted_date": nil,
+		"room_id":         roomID,
+		"created_date >=": startDate,
+		"created_date <":  endDate,
+		"deleted_date":    nil,
 	}).All(&events)
 	if err != nil {
 		return nil, err
@@ -114,27 +149,75 @@ func (r *eventRepository) FindByRoomAndDate(roomID uint64, startDate, endDate ti
 	return r.mapModelToDomainCollection(events), nil
 }
 
+func (r *eventRepository) FindByDevicesAndDate(deviceIds []uint64, startDate, endDate time.Time) ([]domain.Event, error) {
+	if len(deviceIds) == 0 {
+		return nil, nil
+	}
+	var events []event
+	err := r.coll.Find(db.Cond{
+		"device_id":       db.In(deviceIds),
+		"created_date >=": startDate,
+		"created_date <":  endDate,
+		"deleted_date":    nil,
+	}).OrderBy("created_date", "id").All(&events)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(events), nil
+}
+
+// FindLastPowerBefore returns the last ON or OFF event of the device before the given time.
+func (r *eventRepository) FindLastPowerBefore(deviceId uint64, before time.Time) (domain.Event, error) {
+	var e event
+	err := r.coll.Find(db.Cond{
+		"device_id":      deviceId,
+		"action":         db.In([]string{string(domain.TurnOn), string(domain.TurnOff)}),
+		"created_date <": before,
+		"deleted_date":   nil,
+	}).OrderBy("-created_date", "-id").One(&e)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	return r.mapModelToDomain(e), nil
+}
+
+func (r *eventRepository) FindLastBefore(deviceId uint64, action domain.EventAction, before time.Time) (domain.Event, error) {
+	var e event
+	err := r.coll.Find(db.Cond{
+		"device_id":      deviceId,
+		"action":         string(action),
+		"created_date <": before,
+		"deleted_date":   nil,
+	}).OrderBy("-created_date", "-id").One(&e)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	return r.mapModelToDomain(e), nil
+}
+
 func (r *eventRepository) mapDomainToModel(e domain.Event) event {
 	return event{
-		Id:          e.Id,
-		DeviceId:    e.DeviceId,
-		RoomId:      e.RoomId,
-		Action:      string(e.Action),
-		CreatedDate: e.CreatedDate,
-		UpdatedDate: e.UpdatedDate,
-		DeletedDate: e.DeletedDate,
+		Id:           e.Id,
+		DeviceId:     e.DeviceId,
+		RoomId:       e.RoomId,
+		Action:       string(e.Action),
+		actionParams: actionParams(e.ActionParams),
+		CreatedDate:  e.CreatedDate,
+		UpdatedDate:  e.UpdatedDate,
+		DeletedDate:  e.DeletedDate,
 	}
 }
 
 func (r *eventRepository) mapModelToDomain(e event) domain.Event {
 	return domain.Event{
-		Id:          e.Id,
-		DeviceId:    e.DeviceId,
-		RoomId:      e.RoomId,
-		Action:      domain.EventAction(e.Action),
-		CreatedDate: e.CreatedDate,
-		UpdatedDate: e.UpdatedDate,
-		DeletedDate: e.DeletedDate,
+		Id:           e.Id,
+		DeviceId:     e.DeviceId,
+		RoomId:       e.RoomId,
+		Action:       domain.EventAction(e.Action),
+		ActionParams: domain.ActionParams(e.actionParams),
+		CreatedDate:  e.CreatedDate,
+		UpdatedDate:  e.UpdatedDate,
+		DeletedDate:  e.DeletedDate,
 	}
 }
 
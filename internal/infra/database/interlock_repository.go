@@ -0,0 +1,232 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const (
+	InterlocksTableName         = "interlocks"
+	InterlockOverridesTableName = "interlock_overrides"
+)
+
+type interlock struct {
+	Id             uint64     `db:"id,omitempty"`
+	OrganizationId uint64     `db:"organization_id"`
+	UserId         uint64     `db:"user_id"`
+	Name           string     `db:"name"`
+	DeviceId       uint64     `db:"device_id"`
+	State          string     `db:"state"`
+	OtherDeviceId  uint64     `db:"other_device_id"`
+	OtherState     string     `db:"other_state"`
+	Enabled        bool       `db:"enabled"`
+	CreatedDate    time.Time  `db:"created_date"`
+	UpdatedDate    time.Time  `db:"updated_date"`
+	DeletedDate    *time.Time `db:"deleted_date"`
+}
+
+type interlockOverride struct {
+	Id             uint64    `db:"id,omitempty"`
+	InterlockId    uint64    `db:"interlock_id"`
+	OrganizationId uint64    `db:"organization_id"`
+	DeviceId       uint64    `db:"device_id"`
+	Action         string    `db:"action"`
+	UserId         uint64    `db:"user_id"`
+	Reason         string    `db:"reason"`
+	CreatedDate    time.Time `db:"created_date"`
+}
+
+// overrideColumns store the interlock override of a command or demand request with it, so the
+// override still applies when it's carried out later.
+type overrideColumns struct {
+	OverrideUserId *uint64 `db:"override_user_id"`
+	OverrideReason *string `db:"override_reason"`
+}
+
+func newOverrideColumns(o *domain.InterlockOverride) overrideColumns {
+	if o == nil {
+		return overrideColumns{}
+	}
+	return overrideColumns{OverrideUserId: &o.UserId, OverrideReason: &o.Reason}
+}
+
+func (c overrideColumns) toDomain() *domain.InterlockOverride {
+	if c.OverrideUserId == nil || c.OverrideReason == nil {
+		return nil
+	}
+	return &domain.InterlockOverride{UserId: *c.OverrideUserId, Reason: *c.OverrideReason}
+}
+
+type InterlockRepository interface {
+	Save(i domain.Interlock) (domain.Interlock, error)
+	Find(id uint64) (domain.Interlock, error)
+	FindByOrgId(orgId uint64) ([]domain.Interlock, error)
+	FindEnabledByDeviceId(deviceId uint64) ([]domain.Interlock, error)
+	Update(i domain.Interlock) (domain.Interlock, error)
+	Delete(id uint64) error
+	SaveOverride(o domain.InterlockOverride) (domain.InterlockOverride, error)
+	FindOverridesByOrgId(orgId uint64, limit uint) ([]domain.InterlockOverride, error)
+}
+
+type interlockRepository struct {
+	sess db.Session
+	coll db.Collection
+}
+
+func NewInterlockRepository(sess db.Session) InterlockRepository {
+	return &interlockRepository{
+		sess: sess,
+		coll: sess.Collection(InterlocksTableName),
+	}
+}
+
+func (r *interlockRepository) Save(i domain.Interlock) (domain.Interlock, error) {
+	m := r.mapDomainToModel(i)
+	now := time.Now()
+	m.CreatedDate, m.UpdatedDate = now, now
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		log.Printf("InterlockRepository: Error saving interlock: %s", err)
+		return domain.Interlock{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *interlockRepository) Find(id uint64) (domain.Interlock, error) {
+	var m interlock
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&m)
+	if err != nil {
+		return domain.Interlock{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *interlockRepository) FindByOrgId(orgId uint64) ([]domain.Interlock, error) {
+	var interlocks []interlock
+	err := r.coll.Find(db.Cond{"organization_id": orgId, "deleted_date": nil}).OrderBy("id").All(&interlocks)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(interlocks), nil
+}
+
+// FindEnabledByDeviceId returns the enabled interlocks the device is on either side of.
+func (r *interlockRepository) FindEnabledByDeviceId(deviceId uint64) ([]domain.Interlock, error) {
+	var interlocks []interlock
+	err := r.coll.Find(
+		db.Cond{"enabled": true, "deleted_date": nil},
+		db.Or(db.Cond{"device_id": deviceId}, db.Cond{"other_device_id": deviceId}),
+	).OrderBy("id").All(&interlocks)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(interlocks), nil
+}
+
+func (r *interlockRepository) Update(i domain.Interlock) (domain.Interlock, error) {
+	m := r.mapDomainToModel(i)
+	m.UpdatedDate = time.Now()
+	err := r.coll.Find(db.Cond{"id": m.Id, "deleted_date": nil}).Update(&m)
+	if err != nil {
+		log.Printf("InterlockRepository: Error updating interlock: %s", err)
+		return domain.Interlock{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *interlockRepository) Delete(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now()})
+}
+
+func (r *interlockRepository) SaveOverride(o domain.InterlockOverride) (domain.InterlockOverride, error) {
+	m := interlockOverride{
+		InterlockId:    o.InterlockId,
+		OrganizationId: o.OrganizationId,
+		DeviceId:       o.DeviceId,
+		Action:         string(o.Action),
+		UserId:         o.UserId,
+		Reason:         o.Reason,
+		CreatedDate:    time.Now(),
+	}
+	err := r.sess.Collection(InterlockOverridesTableName).InsertReturning(&m)
+	if err != nil {
+		log.Printf("InterlockRepository: Error saving override: %s", err)
+		return domain.InterlockOverride{}, err
+	}
+	return r.mapOverrideToDomain(m), nil
+}
+
+func (r *interlockRepository) FindOverridesByOrgId(orgId uint64, limit uint) ([]domain.InterlockOverride, error) {
+	var overrides []interlockOverride
+	err := r.sess.Collection(InterlockOverridesTableName).
+		Find(db.Cond{"organization_id": orgId}).
+		OrderBy("-created_date", "-id").
+		Limit(int(limit)).
+		All(&overrides)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.InterlockOverride, len(overrides))
+	for i, m := range overrides {
+		res[i] = r.mapOverrideToDomain(m)
+	}
+	return res, nil
+}
+
+func (r *interlockRepository) mapDomainToModel(d domain.Interlock) interlock {
+	return interlock{
+		Id:             d.Id,
+		OrganizationId: d.OrganizationId,
+		UserId:         d.UserId,
+		Name:           d.Name,
+		DeviceId:       d.DeviceId,
+		State:          string(d.State),
+		OtherDeviceId:  d.OtherDeviceId,
+		OtherState:     string(d.OtherState),
+		Enabled:        d.Enabled,
+		CreatedDate:    d.CreatedDate,
+		UpdatedDate:    d.UpdatedDate,
+		DeletedDate:    d.DeletedDate,
+	}
+}
+
+func (r *interlockRepository) mapModelToDomain(m interlock) domain.Interlock {
+	return domain.Interlock{
+		Id:             m.Id,
+		OrganizationId: m.OrganizationId,
+		UserId:         m.UserId,
+		Name:           m.Name,
+		DeviceId:       m.DeviceId,
+		State:          domain.EventAction(m.State),
+		OtherDeviceId:  m.OtherDeviceId,
+		OtherState:     domain.EventAction(m.OtherState),
+		Enabled:        m.Enabled,
+		CreatedDate:    m.CreatedDate,
+		UpdatedDate:    m.UpdatedDate,
+		DeletedDate:    m.DeletedDate,
+	}
+}
+
+func (r *interlockRepository) mapModelToDomainCollection(interlocks []interlock) []domain.Interlock {
+	res := make([]domain.Interlock, len(interlocks))
+	for i, m := range interlocks {
+		res[i] = r.mapModelToDomain(m)
+	}
+	return res
+}
+
+func (r *interlockRepository) mapOverrideToDomain(m interlockOverride) domain.InterlockOverride {
+	return domain.InterlockOverride{
+		Id:             m.Id,
+		InterlockId:    m.InterlockId,
+		OrganizationId: m.OrganizationId,
+		DeviceId:       m.DeviceId,
+		Action:         domain.EventAction(m.Action),
+		UserId:         m.UserId,
+		Reason:         m.Reason,
+		CreatedDate:    m.CreatedDate,
+	}
+}
@@ -10,13 +10,18 @@ import (
 )
 
 type measurement struct {
-	Id          uint64     `db:"id,omitempty"`
-	DeviceId    uint64     `db:"device_id"`
-	RoomId      *uint64    `db:"room_id"`
-	Value       float64    `db:"value"`
-	CreatedDate time.Time  `db:"created_date"`
-	UpdatedDate time.Time  `db:"updated_date"`
-	DeletedDate *time.Time `db:"deleted_date"`
+	Id            uint64     `db:"id,omitempty"`
+	DeviceId      uint64     `db:"device_id"`
+	RoomId        *uint64    `db:"room_id"`
+	ChannelId     *uint64    `db:"channel_id"`
+	Value         float64    `db:"value"`
+	RawValue      float64    `db:"raw_value"`
+	CalibrationId *uint64    `db:"calibration_id"`
+	Quality       string     `db:"quality"`
+	QualityReason *string    `db:"quality_reason"`
+	CreatedDate   time.Time  `db:"created_date"`
+	UpdatedDate   time.Time  `db:"updated_date"`
+	DeletedDate   *time.Time `db:"deleted_date"`
 }
 
 const MeasurementsTableName = "measurements"
@@ -26,7 +31,15 @@ type MeasurementRepository interface {
 	FindByDeviceAndDate(deviceId uint64, startDate, endDate time.Time) ([]domain.Measurement, error)
 	Find(id uint64) (domain.Measurement, error)
 	FindByDeviceId(deviceId uint64) ([]domain.Measurement, error)
+	// FindLatestByDeviceId, FindRecentByDeviceId, FindLastBefore and FindFirstFrom skip BAD readings.
+	// They look at the readings of the channel, or of the device's own value when channelId is nil.
+	FindLatestByDeviceId(deviceId uint64, channelId *uint64) (domain.Measurement, error)
+	FindRecentByDeviceId(deviceId uint64, channelId *uint64, limit uint) ([]domain.Measurement, error)
+	FindLastBefore(deviceId uint64, channelId *uint64, before time.Time) (domain.Measurement, error)
+	FindFirstFrom(deviceId uint64, channelId *uint64, from time.Time) (domain.Measurement, error)
 	FindAll() ([]domain.Measurement, error)
+	HasReadings(deviceId uint64, channelId *uint64) (bool, error)
+	UpdateValues(measurements []domain.Measurement) error
 }
 
 type measurementRepository struct {
@@ -50,12 +63,18 @@ func (r *measurementRepository) Save(dm domain.Measurement) (domain.Measurement,
 		return domain.Measurement{}, err
 	}
 
-	if device.Category != domain.Sensor {
-		err := errors.New("only sensors can have measurements")
-		log.Printf("MeasurementRepository: Device ID %d is not a sensor", dm.DeviceId)
+	if device.Category != domain.Sensor && device.Category != domain.Meter {
+		err := errors.New("only sensors and meters can have measurements")
+		log.Printf("MeasurementRepository: Device ID %d is not a sensor or meter", dm.DeviceId)
 		return domain.Measurement{}, err
 	}
+	if device.Category == domain.Meter && dm.Value < 0 {
+		return domain.Measurement{}, errors.New("meter readings must not be negative")
+	}
 
+	if dm.Quality == "" {
+		dm.Quality = domain.GoodQuality
+	}
 	measurement := r.mapDomainToModel(dm)
 	now := time.Now()
 	measurement.CreatedDate, measurement.UpdatedDate = now, now
@@ -74,7 +93,7 @@ func (r *measurementRepository) Save(dm domain.Measurement) (domain.Measurement,
 
 func (r *measurementRepository) FindByDeviceAndDate(deviceId uint64, startDate, endDate time.Time) ([]domain.Measurement, error) {
 	var measurements []measurement
-	err := r.coll.Find(db.Cond{"device_id": deviceId, "created_date >=": startDate, "created_date <=": endDate, "deleted_date": nil}).All(&measurements)
+	err := r.coll.Find(db.Cond{"device_id": deviceId, "created_date >=": startDate, "created_date <": endDate, "deleted_date": nil}).All(&measurements)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +126,77 @@ func (r *measurementRepository) FindByDeviceId(deviceId uint64) ([]domain.Measur
 	return r.mapModelToDomainCollection(measurements), nil
 }
 
+func (r *measurementRepository) FindLatestByDeviceId(deviceId uint64, channelId *uint64) (domain.Measurement, error) {
+	var m measurement
+	err := r.coll.Find(r.usable(deviceId, channelId)).OrderBy("-created_date", "-id").One(&m)
+	if err != nil {
+		return domain.Measurement{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+// FindRecentByDeviceId returns the last measurements of the device, the oldest first.
+func (r *measurementRepository) FindRecentByDeviceId(deviceId uint64, channelId *uint64, limit uint) ([]domain.Measurement, error) {
+	var measurements []measurement
+	err := r.coll.Find(r.usable(deviceId, channelId)).OrderBy("-created_date", "-id").Limit(int(limit)).All(&measurements)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.Measurement, len(measurements))
+	for i, m := range measurements {
+		res[len(measurements)-1-i] = r.mapModelToDomain(m)
+	}
+	return res, nil
+}
+
+func (r *measurementRepository) FindLastBefore(deviceId uint64, channelId *uint64, before time.Time) (domain.Measurement, error) {
+	var m measurement
+	err := r.coll.Find(r.usable(deviceId, channelId), db.Cond{"created_date <": before}).OrderBy("-created_date", "-id").One(&m)
+	if err != nil {
+		return domain.Measurement{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *measurementRepository) FindFirstFrom(deviceId uint64, channelId *uint64, from time.Time) (domain.Measurement, error) {
+	var m measurement
+	err := r.coll.Find(r.usable(deviceId, channelId), db.Cond{"created_date >=": from}).OrderBy("created_date", "id").One(&m)
+	if err != nil {
+		return domain.Measurement{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+// UpdateValues stores the corrected values and the quality of the measurements at once.
+func (r *measurementRepository) UpdateValues(measurements []domain.Measurement) error {
+	now := time.Now()
+	err := r.sess.Tx(func(tx db.Session) error {
+		for _, dm := range measurements {
+			m := r.mapDomainToModel(dm)
+			_, err := tx.SQL().
+				Update(MeasurementsTableName).
+				Set(
+					"value", m.Value,
+					"calibration_id", m.CalibrationId,
+					"quality", m.Quality,
+					"quality_reason", m.QualityReason,
+					"updated_date", now,
+				).
+				Where("id = ?", m.Id).
+				Exec()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("MeasurementRepository: Error updating values: %s", err)
+		return err
+	}
+	return nil
+}
+
 func (r *measurementRepository) FindAll() ([]domain.Measurement, error) {
 	var measurements []measurement
 	err := r.coll.Find(db.Cond{"deleted_date": nil}).All(&measurements)
@@ -117,27 +207,56 @@ func (r *measurementRepository) FindAll() ([]domain.Measurement, error) {
 	return res, nil
 }
 
+// HasReadings reports whether any reading of the channel is stored, or of the device's own value
+// when channelId is nil.
+func (r *measurementRepository) HasReadings(deviceId uint64, channelId *uint64) (bool, error) {
+	return r.coll.Find(db.Cond{"device_id": deviceId, "channel_id": channelCond(channelId), "deleted_date": nil}).Exists()
+}
+
+// usable selects the readings of the device or its channel which may be aggregated.
+func (r *measurementRepository) usable(deviceId uint64, channelId *uint64) db.Cond {
+	return db.Cond{"device_id": deviceId, "channel_id": channelCond(channelId), "quality <>": string(domain.BadQuality), "deleted_date": nil}
+}
+
+// channelCond matches the channel, or no channel when channelId is nil.
+func channelCond(channelId *uint64) interface{} {
+	if channelId == nil {
+		return nil
+	}
+	return *channelId
+}
+
 func (r *measurementRepository) mapDomainToModel(d domain.Measurement) measurement {
 	return measurement{
-		Id:          d.Id,
-		DeviceId:    d.DeviceId,
-		RoomId:      d.RoomId,
-		Value:       d.Value,
-		CreatedDate: d.CreatedDate,
-		UpdatedDate: d.UpdatedDate,
-		DeletedDate: d.DeletedDate,
+		Id:            d.Id,
+		DeviceId:      d.DeviceId,
+		RoomId:        d.RoomId,
+		ChannelId:     d.ChannelId,
+		Value:         d.Value,
+		RawValue:      d.RawValue,
+		CalibrationId: d.CalibrationId,
+		Quality:       string(d.Quality),
+		QualityReason: d.QualityReason,
+		CreatedDate:   d.CreatedDate,
+		UpdatedDate:   d.UpdatedDate,
+		DeletedDate:   d.DeletedDate,
 	}
 }
 
 func (r *measurementRepository) mapModelToDomain(m measurement) domain.Measurement {
 	return domain.Measurement{
-		Id:          m.Id,
-		DeviceId:    m.DeviceId,
-		RoomId:      m.RoomId,
-		Value:       m.Value,
-		CreatedDate: m.CreatedDate,
-		UpdatedDate: m.UpdatedDate,
-		DeletedDate: m.DeletedDate,
+		Id:            m.Id,
+		DeviceId:      m.DeviceId,
+		RoomId:        m.RoomId,
+		ChannelId:     m.ChannelId,
+		Value:         m.Value,
+		RawValue:      m.RawValue,
+		CalibrationId: m.CalibrationId,
+		Quality:       domain.MeasurementQuality(m.Quality),
+		QualityReason: m.QualityReason,
+		CreatedDate:   m.CreatedDate,
+		UpdatedDate:   m.UpdatedDate,
+		DeletedDate:   m.DeletedDate,
 	}
 }
 
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const OrganizationMembersTableName = "organization_members"
+
+type organizationMember struct {
+	Id             uint64    `db:"id,omitempty"`
+	OrganizationId uint64    `db:"organization_id"`
+	UserId         uint64    `db:"user_id"`
+	Email          string    `db:"email,omitempty"`
+	Role           string    `db:"role"`
+	CreatedDate    time.Time `db:"created_date"`
+	UpdatedDate    time.Time `db:"updated_date"`
+}
+
+type MemberRepository interface {
+	Save(m domain.OrganizationMember) (domain.OrganizationMember, error)
+	Find(orgId, userId uint64) (domain.OrganizationMember, error)
+	FindByOrgId(orgId uint64) ([]domain.OrganizationMember, error)
+	Delete(orgId, userId uint64) error
+}
+
+type memberRepository struct {
+	sess db.Session
+}
+
+func NewMemberRepository(sess db.Session) MemberRepository {
+	return &memberRepository{
+		sess: sess,
+	}
+}
+
+// Save adds the user to the organization or changes the role of a member.
+func (r *memberRepository) Save(m domain.OrganizationMember) (domain.OrganizationMember, error) {
+	now := time.Now()
+	_, err := r.sess.SQL().
+		InsertInto(OrganizationMembersTableName).
+		Columns("organization_id", "user_id", "role", "created_date", "updated_date").
+		Values(m.OrganizationId, m.UserId, string(m.Role), now, now).
+		Amend(func(q string) string {
+			return q + ` ON CONFLICT (organization_id, user_id) DO UPDATE SET "role" = EXCLUDED."role", updated_date = EXCLUDED.updated_date`
+		}).
+		Exec()
+	if err != nil {
+		log.Printf("MemberRepository: Error saving member: %s", err)
+		return domain.OrganizationMember{}, err
+	}
+	return r.Find(m.OrganizationId, m.UserId)
+}
+
+func (r *memberRepository) Find(orgId, userId uint64) (domain.OrganizationMember, error) {
+	var m organizationMember
+	err := r.query().
+		Where("m.organization_id = ? AND m.user_id = ?", orgId, userId).
+		One(&m)
+	if err != nil {
+		return domain.OrganizationMember{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *memberRepository) FindByOrgId(orgId uint64) ([]domain.OrganizationMember, error) {
+	var members []organizationMember
+	err := r.query().
+		Where("m.organization_id = ?", orgId).
+		OrderBy("m.id").
+		All(&members)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.OrganizationMember, len(members))
+	for i, m := range members {
+		res[i] = r.mapModelToDomain(m)
+	}
+	return res, nil
+}
+
+func (r *memberRepository) Delete(orgId, userId uint64) error {
+	return r.sess.Collection(OrganizationMembersTableName).
+		Find(db.Cond{"organization_id": orgId, "user_id": userId}).
+		Delete()
+}
+
+func (r *memberRepository) query() db.Selector {
+	return r.sess.SQL().
+		Select("m.*", "u.email").
+		From(OrganizationMembersTableName + " AS m").
+		Join(UsersTableName + " AS u").On("u.id = m.user_id")
+}
+
+func (r *memberRepository) mapModelToDomain(m organizationMember) domain.OrganizationMember {
+	return domain.OrganizationMember{
+		Id:             m.Id,
+		OrganizationId: m.OrganizationId,
+		UserId:         m.UserId,
+		Email:          m.Email,
+		Role:           domain.MemberRole(m.Role),
+		CreatedDate:    m.CreatedDate,
+		UpdatedDate:    m.UpdatedDate,
+	}
+}
@@ -0,0 +1 @@
+ALTER TABLE public.devices DROP COLUMN IF EXISTS secret_hash;
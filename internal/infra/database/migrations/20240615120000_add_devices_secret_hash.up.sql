@@ -0,0 +1 @@
+ALTER TABLE public.devices ADD COLUMN IF NOT EXISTS secret_hash varchar(100);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS public.commands
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL references public.organizations(id),
+    device_id           integer NOT NULL references public.devices(id),
+    user_id             integer NOT NULL references public.users(id),
+    "action"            VARCHAR(50) NOT NULL,
+    status              VARCHAR(50) NOT NULL,
+    event_id            integer references public.events(id),
+    error               text,
+    expires_date        timestamptz NOT NULL,
+    delivered_date      timestamptz,
+    acknowledged_date   timestamptz,
+    created_date        timestamptz NOT NULL,
+    updated_date        timestamptz NOT NULL,
+    deleted_date        timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS commands_device_status_idx ON public.commands (device_id, status);
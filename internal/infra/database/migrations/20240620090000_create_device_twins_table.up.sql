@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS public.device_twins
+(
+    device_id           integer PRIMARY KEY references public.devices(id),
+    organization_id     integer NOT NULL references public.organizations(id),
+    desired             jsonb NOT NULL DEFAULT '{}',
+    desired_version     integer NOT NULL DEFAULT 0,
+    desired_date        timestamptz,
+    reported            jsonb NOT NULL DEFAULT '{}',
+    reported_version    integer NOT NULL DEFAULT 0,
+    reported_date       timestamptz,
+    created_date        timestamptz NOT NULL,
+    updated_date        timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS device_twins_organization_idx ON public.device_twins (organization_id);
@@ -0,0 +1 @@
+ALTER TABLE public.devices DROP COLUMN IF EXISTS current_state;
@@ -0,0 +1 @@
+ALTER TABLE public.devices ADD COLUMN IF NOT EXISTS current_state varchar(10);
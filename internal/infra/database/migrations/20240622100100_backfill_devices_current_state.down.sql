@@ -0,0 +1 @@
+UPDATE public.devices SET current_state = NULL;
@@ -0,0 +1,10 @@
+UPDATE public.devices d
+SET current_state = e.action
+FROM (
+    SELECT DISTINCT ON (device_id) device_id, action
+    FROM public.events
+    WHERE deleted_date IS NULL
+    ORDER BY device_id, created_date DESC, id DESC
+) e
+WHERE d.id = e.device_id
+  AND d.category = 'ACTUATOR';
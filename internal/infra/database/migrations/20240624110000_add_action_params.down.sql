@@ -0,0 +1,13 @@
+ALTER TABLE public.commands
+    DROP COLUMN IF EXISTS level,
+    DROP COLUMN IF EXISTS setpoint,
+    DROP COLUMN IF EXISTS setpoint_unit,
+    DROP COLUMN IF EXISTS mode;
+
+ALTER TABLE public.events
+    DROP COLUMN IF EXISTS level,
+    DROP COLUMN IF EXISTS setpoint,
+    DROP COLUMN IF EXISTS setpoint_unit,
+    DROP COLUMN IF EXISTS mode;
+
+ALTER TABLE public.devices DROP COLUMN IF EXISTS capabilities;
@@ -0,0 +1,13 @@
+ALTER TABLE public.devices ADD COLUMN IF NOT EXISTS capabilities jsonb NOT NULL DEFAULT '{}';
+
+ALTER TABLE public.events
+    ADD COLUMN IF NOT EXISTS level         FLOAT,
+    ADD COLUMN IF NOT EXISTS setpoint      FLOAT,
+    ADD COLUMN IF NOT EXISTS setpoint_unit VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS mode          VARCHAR(50);
+
+ALTER TABLE public.commands
+    ADD COLUMN IF NOT EXISTS level         FLOAT,
+    ADD COLUMN IF NOT EXISTS setpoint      FLOAT,
+    ADD COLUMN IF NOT EXISTS setpoint_unit VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS mode          VARCHAR(50);
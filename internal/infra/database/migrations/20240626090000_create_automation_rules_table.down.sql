@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.rule_executions;
+DROP TABLE IF EXISTS public.automation_rules;
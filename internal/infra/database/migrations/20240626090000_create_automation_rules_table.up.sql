@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS public.automation_rules
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL references public.organizations(id),
+    user_id             integer NOT NULL references public.users(id),
+    "name"              VARCHAR(255) NOT NULL,
+    enabled             boolean NOT NULL DEFAULT true,
+    dry_run             boolean NOT NULL DEFAULT false,
+    "trigger"           jsonb NOT NULL,
+    conditions          jsonb NOT NULL DEFAULT '[]',
+    actions             jsonb NOT NULL,
+    cooldown_seconds    integer NOT NULL DEFAULT 0,
+    last_fired_date     timestamptz,
+    created_date        timestamptz NOT NULL,
+    updated_date        timestamptz NOT NULL,
+    deleted_date        timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS automation_rules_organization_idx ON public.automation_rules (organization_id);
+
+CREATE TABLE IF NOT EXISTS public.rule_executions
+(
+    id                  serial PRIMARY KEY,
+    rule_id             integer NOT NULL references public.automation_rules(id),
+    organization_id     integer NOT NULL references public.organizations(id),
+    status              VARCHAR(50) NOT NULL,
+    cause               text NOT NULL,
+    depth               integer NOT NULL DEFAULT 0,
+    results             jsonb NOT NULL DEFAULT '[]',
+    reason              text,
+    created_date        timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rule_executions_rule_idx ON public.rule_executions (rule_id, created_date);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.schedule_firings;
+DROP TABLE IF EXISTS public.schedules;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS public.schedules
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL references public.organizations(id),
+    device_id           integer references public.devices(id),
+    user_id             integer NOT NULL references public.users(id),
+    "name"              VARCHAR(255) NOT NULL,
+    enabled             boolean NOT NULL DEFAULT true,
+    blocks              jsonb NOT NULL DEFAULT '[]',
+    exceptions          jsonb NOT NULL DEFAULT '[]',
+    created_date        timestamptz NOT NULL,
+    updated_date        timestamptz NOT NULL,
+    deleted_date        timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS schedules_organization_uidx ON public.schedules (organization_id)
+    WHERE device_id IS NULL AND deleted_date IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS schedules_device_uidx ON public.schedules (device_id)
+    WHERE deleted_date IS NULL;
+
+CREATE TABLE IF NOT EXISTS public.schedule_firings
+(
+    schedule_id         integer NOT NULL references public.schedules(id),
+    device_id           integer NOT NULL references public.devices(id),
+    fire_date           timestamptz NOT NULL,
+    "action"            VARCHAR(50) NOT NULL,
+    event_id            integer references public.events(id),
+    created_date        timestamptz NOT NULL,
+    PRIMARY KEY (schedule_id, fire_date)
+);
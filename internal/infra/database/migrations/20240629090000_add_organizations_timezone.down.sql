@@ -0,0 +1 @@
+ALTER TABLE public.organizations DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1 @@
+ALTER TABLE public.organizations ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS public.device_installations
+(
+    id                  serial PRIMARY KEY,
+    device_id           integer NOT NULL references public.devices(id),
+    room_id             integer NOT NULL references public.rooms(id),
+    installed_date      timestamptz NOT NULL,
+    removed_date        timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS device_installations_device_idx ON public.device_installations (device_id, installed_date);
+CREATE INDEX IF NOT EXISTS device_installations_room_idx ON public.device_installations (room_id, installed_date);
+CREATE UNIQUE INDEX IF NOT EXISTS device_installations_open_uidx ON public.device_installations (device_id)
+    WHERE removed_date IS NULL;
+
+-- the history before this migration is unknown, devices are assumed to be in their room since they were created
+INSERT INTO public.device_installations (device_id, room_id, installed_date)
+SELECT id, room_id, created_date
+FROM public.devices
+WHERE room_id IS NOT NULL AND deleted_date IS NULL;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS public.tariffs
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL references public.organizations(id),
+    user_id             integer NOT NULL references public.users(id),
+    "name"              VARCHAR(255) NOT NULL,
+    currency            CHAR(3) NOT NULL,
+    "type"              VARCHAR(50) NOT NULL,
+    active              boolean NOT NULL DEFAULT false,
+    rate                numeric NOT NULL DEFAULT 0,
+    windows             jsonb NOT NULL DEFAULT '[]',
+    tiers               jsonb NOT NULL DEFAULT '[]',
+    seasons             jsonb NOT NULL DEFAULT '[]',
+    created_date        timestamptz NOT NULL,
+    updated_date        timestamptz NOT NULL,
+    deleted_date        timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS tariffs_organization_idx ON public.tariffs (organization_id);
+CREATE UNIQUE INDEX IF NOT EXISTS tariffs_active_uidx ON public.tariffs (organization_id)
+    WHERE active AND deleted_date IS NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS public.notifications;
+DROP TABLE IF EXISTS public.budget_alerts;
+DROP TABLE IF EXISTS public.budgets;
@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS public.budgets
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL references public.organizations(id),
+    room_id             integer references public.rooms(id),
+    user_id             integer NOT NULL references public.users(id),
+    "name"              VARCHAR(255) NOT NULL,
+    metric              VARCHAR(50) NOT NULL,
+    amount              numeric NOT NULL,
+    thresholds          jsonb NOT NULL DEFAULT '[]',
+    alert_on_projection boolean NOT NULL DEFAULT true,
+    enabled             boolean NOT NULL DEFAULT true,
+    created_date        timestamptz NOT NULL,
+    updated_date        timestamptz NOT NULL,
+    deleted_date        timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS budgets_organization_idx ON public.budgets (organization_id);
+
+CREATE TABLE IF NOT EXISTS public.budget_alerts
+(
+    budget_id           integer NOT NULL references public.budgets(id),
+    "month"             CHAR(7) NOT NULL,
+    kind                VARCHAR(50) NOT NULL,
+    threshold           numeric NOT NULL DEFAULT 0,
+    used                numeric NOT NULL,
+    projected           numeric NOT NULL,
+    created_date        timestamptz NOT NULL,
+    PRIMARY KEY (budget_id, "month", kind, threshold)
+);
+
+CREATE TABLE IF NOT EXISTS public.notifications
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL references public.organizations(id),
+    user_id             integer NOT NULL references public.users(id),
+    severity            VARCHAR(50) NOT NULL,
+    title               VARCHAR(255) NOT NULL,
+    message             text NOT NULL,
+    "source"            VARCHAR(50) NOT NULL,
+    source_id           integer NOT NULL DEFAULT 0,
+    read_date           timestamptz,
+    created_date        timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS notifications_user_idx ON public.notifications (user_id, created_date DESC);
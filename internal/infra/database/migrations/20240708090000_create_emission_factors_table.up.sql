@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS public.emission_factors
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL references public.organizations(id),
+    user_id             integer NOT NULL references public.users(id),
+    "name"              VARCHAR(255) NOT NULL,
+    "source"            VARCHAR(255) NOT NULL DEFAULT '',
+    active              boolean NOT NULL DEFAULT false,
+    factor              numeric NOT NULL DEFAULT 0,
+    entries             jsonb NOT NULL DEFAULT '[]',
+    created_date        timestamptz NOT NULL,
+    updated_date        timestamptz NOT NULL,
+    deleted_date        timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS emission_factors_organization_idx ON public.emission_factors (organization_id);
+CREATE UNIQUE INDEX IF NOT EXISTS emission_factors_active_uidx ON public.emission_factors (organization_id)
+    WHERE active AND deleted_date IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS public.measurements_device_created_idx;
+
+ALTER TABLE public.devices
+    DROP COLUMN IF EXISTS counter_max,
+    DROP COLUMN IF EXISTS metered_device_id;
@@ -0,0 +1,5 @@
+ALTER TABLE public.devices
+    ADD COLUMN IF NOT EXISTS metered_device_id integer references public.devices(id),
+    ADD COLUMN IF NOT EXISTS counter_max numeric;
+
+CREATE INDEX IF NOT EXISTS measurements_device_created_idx ON public.measurements (device_id, created_date);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS public.demand_decisions;
+DROP TABLE IF EXISTS public.demand_requests;
+DROP TABLE IF EXISTS public.demand_policies;
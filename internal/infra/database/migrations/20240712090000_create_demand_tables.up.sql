@@ -0,0 +1,54 @@
+CREATE TABLE IF NOT EXISTS public.demand_policies
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL UNIQUE references public.organizations(id),
+    user_id             integer NOT NULL references public.users(id),
+    max_kw              numeric NOT NULL,
+    strategy            VARCHAR(50) NOT NULL,
+    enabled             boolean NOT NULL DEFAULT true,
+    priorities          jsonb NOT NULL DEFAULT '[]',
+    created_date        timestamptz NOT NULL,
+    updated_date        timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS public.demand_requests
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL references public.organizations(id),
+    device_id           integer NOT NULL references public.devices(id),
+    user_id             integer references public.users(id),
+    "source"            VARCHAR(50) NOT NULL,
+    "action"            VARCHAR(50) NOT NULL,
+    level               numeric,
+    setpoint            numeric,
+    setpoint_unit       VARCHAR(50),
+    mode                VARCHAR(50),
+    command_ttl         bigint NOT NULL DEFAULT 0,
+    status              VARCHAR(50) NOT NULL,
+    error               text,
+    expires_date        timestamptz NOT NULL,
+    released_date       timestamptz,
+    created_date        timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS demand_requests_queued_idx ON public.demand_requests (organization_id, created_date)
+    WHERE status = 'QUEUED';
+
+CREATE TABLE IF NOT EXISTS public.demand_decisions
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL references public.organizations(id),
+    device_id           integer NOT NULL references public.devices(id),
+    "source"            VARCHAR(50) NOT NULL,
+    "action"            VARCHAR(50) NOT NULL,
+    outcome             VARCHAR(50) NOT NULL,
+    requested_kw        numeric NOT NULL,
+    load_kw             numeric NOT NULL,
+    limit_kw            numeric NOT NULL,
+    shed_device_ids     jsonb NOT NULL DEFAULT '[]',
+    request_id          integer references public.demand_requests(id),
+    reason              text NOT NULL,
+    created_date        timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS demand_decisions_organization_idx ON public.demand_decisions (organization_id, created_date DESC);
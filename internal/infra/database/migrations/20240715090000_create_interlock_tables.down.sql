@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.interlock_overrides;
+DROP TABLE IF EXISTS public.interlocks;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS public.interlocks
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL references public.organizations(id),
+    user_id             integer NOT NULL references public.users(id),
+    "name"              VARCHAR(100) NOT NULL,
+    device_id           integer NOT NULL references public.devices(id),
+    "state"             VARCHAR(50) NOT NULL,
+    other_device_id     integer NOT NULL references public.devices(id),
+    other_state         VARCHAR(50) NOT NULL,
+    enabled             boolean NOT NULL DEFAULT true,
+    created_date        timestamptz NOT NULL,
+    updated_date        timestamptz NOT NULL,
+    deleted_date        timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS interlocks_device_id_idx ON public.interlocks (device_id);
+CREATE INDEX IF NOT EXISTS interlocks_other_device_id_idx ON public.interlocks (other_device_id);
+
+CREATE TABLE IF NOT EXISTS public.interlock_overrides
+(
+    id                  serial PRIMARY KEY,
+    interlock_id        integer NOT NULL references public.interlocks(id),
+    organization_id     integer NOT NULL references public.organizations(id),
+    device_id           integer NOT NULL references public.devices(id),
+    "action"            VARCHAR(50) NOT NULL,
+    user_id             integer NOT NULL references public.users(id),
+    reason              text NOT NULL,
+    created_date        timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS interlock_overrides_organization_id_idx ON public.interlock_overrides (organization_id, created_date);
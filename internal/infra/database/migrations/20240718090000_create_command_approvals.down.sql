@@ -0,0 +1,11 @@
+ALTER TABLE public.demand_requests
+    DROP COLUMN IF EXISTS approval_id;
+
+ALTER TABLE public.commands
+    DROP COLUMN IF EXISTS approval_id;
+
+DROP TABLE IF EXISTS public.command_approvals;
+DROP TABLE IF EXISTS public.organization_members;
+
+ALTER TABLE public.devices
+    DROP COLUMN IF EXISTS critical;
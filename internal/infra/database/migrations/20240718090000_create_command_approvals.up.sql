@@ -0,0 +1,44 @@
+ALTER TABLE public.devices
+    ADD COLUMN IF NOT EXISTS critical boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS public.organization_members
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL references public.organizations(id),
+    user_id             integer NOT NULL references public.users(id),
+    "role"              VARCHAR(50) NOT NULL,
+    created_date        timestamptz NOT NULL,
+    updated_date        timestamptz NOT NULL,
+    UNIQUE (organization_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS public.command_approvals
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL references public.organizations(id),
+    device_id           integer NOT NULL references public.devices(id),
+    requested_by        integer NOT NULL references public.users(id),
+    "action"            VARCHAR(50) NOT NULL,
+    level               numeric,
+    setpoint            numeric,
+    setpoint_unit       VARCHAR(50),
+    mode                VARCHAR(50),
+    command_ttl         bigint NOT NULL DEFAULT 0,
+    status              VARCHAR(50) NOT NULL,
+    decided_by          integer references public.users(id),
+    "comment"           text,
+    command_id          integer references public.commands(id),
+    expires_date        timestamptz NOT NULL,
+    decided_date        timestamptz,
+    created_date        timestamptz NOT NULL,
+    updated_date        timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS command_approvals_pending_idx ON public.command_approvals (organization_id, created_date)
+    WHERE status = 'PENDING';
+
+ALTER TABLE public.commands
+    ADD COLUMN IF NOT EXISTS approval_id integer references public.command_approvals(id);
+
+ALTER TABLE public.demand_requests
+    ADD COLUMN IF NOT EXISTS approval_id integer references public.command_approvals(id);
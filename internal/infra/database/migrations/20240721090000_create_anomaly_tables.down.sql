@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.measurement_anomalies;
+DROP TABLE IF EXISTS public.anomaly_settings;
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS public.anomaly_settings
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL references public.organizations(id),
+    device_id           integer NOT NULL UNIQUE references public.devices(id),
+    enabled             boolean NOT NULL DEFAULT true,
+    window_size         integer NOT NULL,
+    z_threshold         numeric NOT NULL,
+    ewma_alpha          numeric NOT NULL,
+    baseline_alpha      numeric NOT NULL,
+    drift_threshold     numeric NOT NULL,
+    stuck_count         integer NOT NULL,
+    flatline_count      integer NOT NULL,
+    flatline_ratio      numeric NOT NULL,
+    notify              boolean NOT NULL DEFAULT true,
+    created_date        timestamptz NOT NULL,
+    updated_date        timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS public.measurement_anomalies
+(
+    id                  serial PRIMARY KEY,
+    measurement_id      integer NOT NULL references public.measurements(id),
+    organization_id     integer NOT NULL references public.organizations(id),
+    device_id           integer NOT NULL references public.devices(id),
+    kind                VARCHAR(50) NOT NULL,
+    "value"             numeric NOT NULL,
+    score               numeric NOT NULL,
+    reason              text NOT NULL,
+    created_date        timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS measurement_anomalies_device_idx ON public.measurement_anomalies (device_id, created_date);
+CREATE INDEX IF NOT EXISTS measurement_anomalies_organization_idx ON public.measurement_anomalies (organization_id, created_date);
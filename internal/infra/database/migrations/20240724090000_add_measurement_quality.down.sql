@@ -0,0 +1,8 @@
+ALTER TABLE public.measurements
+    DROP COLUMN IF EXISTS quality_reason,
+    DROP COLUMN IF EXISTS quality;
+
+ALTER TABLE public.devices
+    DROP COLUMN IF EXISTS max_rate,
+    DROP COLUMN IF EXISTS valid_max,
+    DROP COLUMN IF EXISTS valid_min;
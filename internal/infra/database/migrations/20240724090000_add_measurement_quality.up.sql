@@ -0,0 +1,8 @@
+ALTER TABLE public.devices
+    ADD COLUMN IF NOT EXISTS valid_min numeric,
+    ADD COLUMN IF NOT EXISTS valid_max numeric,
+    ADD COLUMN IF NOT EXISTS max_rate numeric;
+
+ALTER TABLE public.measurements
+    ADD COLUMN IF NOT EXISTS quality VARCHAR(50) NOT NULL DEFAULT 'GOOD',
+    ADD COLUMN IF NOT EXISTS quality_reason text;
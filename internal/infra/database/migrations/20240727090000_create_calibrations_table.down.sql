@@ -0,0 +1,5 @@
+ALTER TABLE public.measurements
+    DROP COLUMN IF EXISTS calibration_id,
+    DROP COLUMN IF EXISTS raw_value;
+
+DROP TABLE IF EXISTS public.calibrations;
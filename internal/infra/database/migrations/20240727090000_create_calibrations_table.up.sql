@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS public.calibrations
+(
+    id                  serial PRIMARY KEY,
+    organization_id     integer NOT NULL references public.organizations(id),
+    device_id           integer NOT NULL references public.devices(id),
+    user_id             integer NOT NULL references public.users(id),
+    offset_value        numeric NOT NULL DEFAULT 0,
+    gain                numeric NOT NULL DEFAULT 1,
+    coefficients        jsonb NOT NULL DEFAULT '[]',
+    effective_from      timestamptz NOT NULL,
+    note                text,
+    created_date        timestamptz NOT NULL,
+    deleted_date        timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS calibrations_device_idx ON public.calibrations (device_id, effective_from);
+
+ALTER TABLE public.measurements
+    ADD COLUMN IF NOT EXISTS raw_value numeric,
+    ADD COLUMN IF NOT EXISTS calibration_id integer references public.calibrations(id);
+
+UPDATE public.measurements SET raw_value = value WHERE raw_value IS NULL;
+
+ALTER TABLE public.measurements
+    ALTER COLUMN raw_value SET NOT NULL;
@@ -0,0 +1,12 @@
+ALTER TABLE public.measurement_anomalies
+    DROP COLUMN IF EXISTS channel_id;
+
+ALTER TABLE public.calibrations
+    DROP COLUMN IF EXISTS channel_id;
+
+DROP INDEX IF EXISTS public.measurements_channel_idx;
+
+ALTER TABLE public.measurements
+    DROP COLUMN IF EXISTS channel_id;
+
+DROP TABLE IF EXISTS public.device_channels;
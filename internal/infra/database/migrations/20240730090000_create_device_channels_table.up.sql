@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS public.device_channels
+(
+    id                  serial PRIMARY KEY,
+    device_id           integer NOT NULL references public.devices(id),
+    "key"               VARCHAR(32) NOT NULL,
+    quantity            VARCHAR(100) NOT NULL,
+    units               VARCHAR(50) NOT NULL,
+    valid_min           numeric,
+    valid_max           numeric,
+    max_rate            numeric,
+    created_date        timestamptz NOT NULL,
+    updated_date        timestamptz NOT NULL,
+    deleted_date        timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS device_channels_key_idx ON public.device_channels (device_id, "key") WHERE deleted_date IS NULL;
+
+ALTER TABLE public.measurements
+    ADD COLUMN IF NOT EXISTS channel_id integer references public.device_channels(id);
+
+CREATE INDEX IF NOT EXISTS measurements_channel_idx ON public.measurements (device_id, channel_id, created_date);
+
+ALTER TABLE public.calibrations
+    ADD COLUMN IF NOT EXISTS channel_id integer references public.device_channels(id);
+
+ALTER TABLE public.measurement_anomalies
+    ADD COLUMN IF NOT EXISTS channel_id integer references public.device_channels(id);
@@ -0,0 +1,15 @@
+UPDATE public.devices d
+SET units = n.original_units
+FROM public.units_normalization n
+WHERE n.table_name = 'devices'
+  AND n.row_id = d.id
+  AND n.units IS NOT NULL;
+
+UPDATE public.device_channels ch
+SET units = n.original_units
+FROM public.units_normalization n
+WHERE n.table_name = 'device_channels'
+  AND n.row_id = ch.id
+  AND n.units IS NOT NULL;
+
+DROP TABLE IF EXISTS public.units_normalization;
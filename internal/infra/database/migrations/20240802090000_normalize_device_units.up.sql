@@ -0,0 +1,100 @@
+-- the units of devices and channels are stored by their code in the units registry. The aliases
+-- are the codes and the aliases of domain.unitRegistry as they were when the registry was added,
+-- lowercased, which is how ParseUnit finds them when no exact spelling matches
+CREATE TEMPORARY TABLE unit_aliases (alias text PRIMARY KEY, code text NOT NULL);
+
+INSERT INTO unit_aliases (alias, code) VALUES
+    ('degc', 'degC'), ('cel', 'degC'), ('°c', 'degC'), ('℃', 'degC'), ('c', 'degC'), ('celsius', 'degC'),
+    ('degf', 'degF'), ('[degf]', 'degF'), ('°f', 'degF'), ('℉', 'degF'), ('f', 'degF'), ('fahrenheit', 'degF'),
+    ('k', 'K'), ('kelvin', 'K'),
+    ('%', '%'), ('%rh', '%'), ('percent', '%'),
+    ('pa', 'Pa'), ('pascal', 'Pa'),
+    ('hpa', 'hPa'), ('mbar', 'hPa'),
+    ('kpa', 'kPa'),
+    ('bar', 'bar'),
+    ('psi', 'psi'), ('[psi]', 'psi'),
+    ('mmhg', 'mmHg'), ('mm[hg]', 'mmHg'),
+    ('ppm', 'ppm'), ('[ppm]', 'ppm'),
+    ('ppb', 'ppb'), ('[ppb]', 'ppb'),
+    ('ug/m3', 'ug/m3'), ('µg/m3', 'ug/m3'), ('µg/m³', 'ug/m3'), ('μg/m³', 'ug/m3'), ('ug/m³', 'ug/m3'),
+    ('mg/m3', 'mg/m3'), ('mg/m³', 'mg/m3'),
+    ('lx', 'lx'), ('lux', 'lx'),
+    ('w', 'W'), ('watt', 'W'),
+    ('kw', 'kW'),
+    ('mw', 'MW'),
+    ('kwh', 'kWh'), ('kw.h', 'kWh'),
+    ('wh', 'Wh'), ('w.h', 'Wh'),
+    ('mwh', 'MWh'), ('mw.h', 'MWh'),
+    ('j', 'J'), ('joule', 'J'),
+    ('kj', 'kJ'),
+    ('mj', 'MJ'),
+    ('v', 'V'), ('volt', 'V'),
+    ('mv', 'mV'),
+    ('a', 'A'), ('amp', 'A'), ('ampere', 'A'),
+    ('ma', 'mA'),
+    ('m/s', 'm/s'), ('m.s-1', 'm/s'),
+    ('km/h', 'km/h'), ('km.h-1', 'km/h'), ('kph', 'km/h'),
+    ('mph', 'mph'), ('[mi_i]/h', 'mph'),
+    ('m', 'm'), ('meter', 'm'), ('metre', 'm'),
+    ('cm', 'cm'),
+    ('mm', 'mm'),
+    ('m3', 'm3'), ('m³', 'm3'),
+    ('l', 'L'), ('liter', 'L'), ('litre', 'L'),
+    ('m3/h', 'm3/h'), ('m³/h', 'm3/h'), ('m3.h-1', 'm3/h'),
+    ('l/min', 'L/min'), ('l.min-1', 'L/min'),
+    ('l/s', 'L/s'), ('l.s-1', 'L/s'),
+    ('db', 'dB'), ('dba', 'dB'), ('db(a)', 'dB');
+
+-- units which aren't stored by their code are recorded with the code they become, or with NULL
+-- when they aren't in the registry and are left as they are. The down migration restores them
+-- from here, and the unknown units can be looked up here and fixed by hand
+CREATE TABLE IF NOT EXISTS public.units_normalization
+(
+    table_name          VARCHAR(50) NOT NULL,
+    row_id              integer NOT NULL,
+    original_units      VARCHAR(255) NOT NULL,
+    units               VARCHAR(50),
+    PRIMARY KEY (table_name, row_id)
+);
+
+INSERT INTO public.units_normalization (table_name, row_id, original_units, units)
+SELECT 'devices', d.id, d.units, a.code
+FROM public.devices d
+LEFT JOIN unit_aliases a ON a.alias = lower(trim(d.units))
+WHERE d.units IS NOT NULL
+  AND d.units IS DISTINCT FROM a.code;
+
+INSERT INTO public.units_normalization (table_name, row_id, original_units, units)
+SELECT 'device_channels', ch.id, ch.units, a.code
+FROM public.device_channels ch
+LEFT JOIN unit_aliases a ON a.alias = lower(trim(ch.units))
+WHERE ch.units IS DISTINCT FROM a.code;
+
+UPDATE public.devices d
+SET units = n.units
+FROM public.units_normalization n
+WHERE n.table_name = 'devices'
+  AND n.row_id = d.id
+  AND n.units IS NOT NULL;
+
+UPDATE public.device_channels ch
+SET units = n.units
+FROM public.units_normalization n
+WHERE n.table_name = 'device_channels'
+  AND n.row_id = ch.id
+  AND n.units IS NOT NULL;
+
+DO $$
+DECLARE
+    unknown text;
+BEGIN
+    SELECT string_agg(format('%s %s: %s', table_name, row_id, original_units), ', ' ORDER BY table_name, row_id)
+    INTO unknown
+    FROM public.units_normalization
+    WHERE units IS NULL;
+    IF unknown IS NOT NULL THEN
+        RAISE WARNING 'units not in the registry were left as they are, see units_normalization: %', unknown;
+    END IF;
+END $$;
+
+DROP TABLE unit_aliases;
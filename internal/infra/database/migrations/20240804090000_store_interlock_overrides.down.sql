@@ -0,0 +1,11 @@
+ALTER TABLE public.command_approvals
+    DROP COLUMN IF EXISTS override_reason,
+    DROP COLUMN IF EXISTS override_user_id;
+
+ALTER TABLE public.demand_requests
+    DROP COLUMN IF EXISTS override_reason,
+    DROP COLUMN IF EXISTS override_user_id;
+
+ALTER TABLE public.commands
+    DROP COLUMN IF EXISTS override_reason,
+    DROP COLUMN IF EXISTS override_user_id;
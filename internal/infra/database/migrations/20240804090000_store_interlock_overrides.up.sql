@@ -0,0 +1,11 @@
+ALTER TABLE public.commands
+    ADD COLUMN IF NOT EXISTS override_user_id integer references public.users(id),
+    ADD COLUMN IF NOT EXISTS override_reason text;
+
+ALTER TABLE public.demand_requests
+    ADD COLUMN IF NOT EXISTS override_user_id integer references public.users(id),
+    ADD COLUMN IF NOT EXISTS override_reason text;
+
+ALTER TABLE public.command_approvals
+    ADD COLUMN IF NOT EXISTS override_user_id integer references public.users(id),
+    ADD COLUMN IF NOT EXISTS override_reason text;
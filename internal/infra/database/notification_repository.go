@@ -0,0 +1,122 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const NotificationsTableName = "notifications"
+
+type notification struct {
+	Id             uint64     `db:"id,omitempty"`
+	OrganizationId uint64     `db:"organization_id"`
+	UserId         uint64     `db:"user_id"`
+	Severity       string     `db:"severity"`
+	Title          string     `db:"title"`
+	Message        string     `db:"message"`
+	Source         string     `db:"source"`
+	SourceId       uint64     `db:"source_id"`
+	ReadDate       *time.Time `db:"read_date"`
+	CreatedDate    time.Time  `db:"created_date"`
+}
+
+type NotificationRepository interface {
+	Save(n domain.Notification) (domain.Notification, error)
+	Find(id uint64) (domain.Notification, error)
+	FindForUser(userId uint64, unread bool, limit uint) ([]domain.Notification, error)
+	MarkRead(id uint64) (domain.Notification, error)
+}
+
+type notificationRepository struct {
+	coll db.Collection
+}
+
+func NewNotificationRepository(sess db.Session) NotificationRepository {
+	return &notificationRepository{
+		coll: sess.Collection(NotificationsTableName),
+	}
+}
+
+func (r *notificationRepository) Save(n domain.Notification) (domain.Notification, error) {
+	m := r.mapDomainToModel(n)
+	m.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		log.Printf("NotificationRepository: Error saving notification: %s", err)
+		return domain.Notification{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *notificationRepository) Find(id uint64) (domain.Notification, error) {
+	var m notification
+	err := r.coll.Find(db.Cond{"id": id}).One(&m)
+	if err != nil {
+		return domain.Notification{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+// FindForUser returns the latest notifications of the user, only the unread ones when unread is set.
+func (r *notificationRepository) FindForUser(userId uint64, unread bool, limit uint) ([]domain.Notification, error) {
+	cond := db.Cond{"user_id": userId}
+	if unread {
+		cond["read_date"] = nil
+	}
+	var notifications []notification
+	err := r.coll.Find(cond).OrderBy("-created_date", "-id").Limit(int(limit)).All(&notifications)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(notifications), nil
+}
+
+func (r *notificationRepository) MarkRead(id uint64) (domain.Notification, error) {
+	err := r.coll.Find(db.Cond{"id": id, "read_date": nil}).Update(map[string]interface{}{"read_date": time.Now()})
+	if err != nil {
+		log.Printf("NotificationRepository: Error marking notification read: %s", err)
+		return domain.Notification{}, err
+	}
+	return r.Find(id)
+}
+
+func (r *notificationRepository) mapDomainToModel(d domain.Notification) notification {
+	return notification{
+		Id:             d.Id,
+		OrganizationId: d.OrganizationId,
+		UserId:         d.UserId,
+		Severity:       string(d.Severity),
+		Title:          d.Title,
+		Message:        d.Message,
+		Source:         d.Source,
+		SourceId:       d.SourceId,
+		ReadDate:       d.ReadDate,
+		CreatedDate:    d.CreatedDate,
+	}
+}
+
+func (r *notificationRepository) mapModelToDomain(m notification) domain.Notification {
+	return domain.Notification{
+		Id:             m.Id,
+		OrganizationId: m.OrganizationId,
+		UserId:         m.UserId,
+		Severity:       domain.NotificationSeverity(m.Severity),
+		Title:          m.Title,
+		Message:        m.Message,
+		Source:         m.Source,
+		SourceId:       m.SourceId,
+		ReadDate:       m.ReadDate,
+		CreatedDate:    m.CreatedDate,
+	}
+}
+
+func (r *notificationRepository) mapModelToDomainCollection(notifications []notification) []domain.Notification {
+	res := make([]domain.Notification, len(notifications))
+	for i, m := range notifications {
+		res[i] = r.mapModelToDomain(m)
+	}
+	return res
+}
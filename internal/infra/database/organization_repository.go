@@ -18,6 +18,7 @@ type organization struct {
 	Address     string     `db:"address"`
 	Lat         float64    `db:"lat"`
 	Lon         float64    `db:"lon"`
+	Timezone    string     `db:"timezone"`
 	CreatedDate time.Time  `db:"created_date"`
 	UpdatedDate time.Time  `db:"updated_date"`
 	DeletedDate *time.Time `db:"deleted_date"`
@@ -99,6 +100,7 @@ func (r organizationRepository) mapDomainToModel(d domain.Organization) organiza
 		Address:     d.Address,
 		Lat:         d.Lat,
 		Lon:         d.Lon,
+		Timezone:    d.Timezone,
 		CreatedDate: d.CreatedDate,
 		UpdatedDate: d.UpdatedDate,
 		DeletedDate: d.DeletedDate,
@@ -115,6 +117,7 @@ func (r organizationRepository) mapModelToDomain(d organization) domain.Organiza
 		Address:     d.Address,
 		Lat:         d.Lat,
 		Lon:         d.Lon,
+		Timezone:    d.Timezone,
 		CreatedDate: d.CreatedDate,
 		UpdatedDate: d.UpdatedDate,
 		DeletedDate: d.DeletedDate,
@@ -0,0 +1,276 @@
+package database
+
+import (
+	"database/sql/driver"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+)
+
+const (
+	RulesTableName          = "automation_rules"
+	RuleExecutionsTableName = "rule_executions"
+)
+
+type ruleTrigger domain.RuleTrigger
+
+func (t *ruleTrigger) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(t, src)
+}
+
+func (t ruleTrigger) Value() (driver.Value, error) {
+	return postgresql.JSONBValue(t)
+}
+
+type ruleConditions []domain.RuleCondition
+
+func (c *ruleConditions) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(c, src)
+}
+
+func (c ruleConditions) Value() (driver.Value, error) {
+	if c == nil {
+		c = ruleConditions{}
+	}
+	return postgresql.JSONBValue([]domain.RuleCondition(c))
+}
+
+type ruleActions []domain.RuleAction
+
+func (a *ruleActions) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(a, src)
+}
+
+func (a ruleActions) Value() (driver.Value, error) {
+	return postgresql.JSONBValue([]domain.RuleAction(a))
+}
+
+type ruleActionResults []domain.RuleActionResult
+
+func (r *ruleActionResults) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(r, src)
+}
+
+func (r ruleActionResults) Value() (driver.Value, error) {
+	if r == nil {
+		r = ruleActionResults{}
+	}
+	return postgresql.JSONBValue([]domain.RuleActionResult(r))
+}
+
+type rule struct {
+	Id              uint64         `db:"id,omitempty"`
+	OrganizationId  uint64         `db:"organization_id"`
+	UserId          uint64         `db:"user_id"`
+	Name            string         `db:"name"`
+	Enabled         bool           `db:"enabled"`
+	DryRun          bool           `db:"dry_run"`
+	Trigger         ruleTrigger    `db:"trigger"`
+	Conditions      ruleConditions `db:"conditions"`
+	Actions         ruleActions    `db:"actions"`
+	CooldownSeconds uint64         `db:"cooldown_seconds"`
+	LastFiredDate   *time.Time     `db:"last_fired_date"`
+	CreatedDate     time.Time      `db:"created_date"`
+	UpdatedDate     time.Time      `db:"updated_date"`
+	DeletedDate     *time.Time     `db:"deleted_date"`
+}
+
+type ruleExecution struct {
+	Id             uint64            `db:"id,omitempty"`
+	RuleId         uint64            `db:"rule_id"`
+	OrganizationId uint64            `db:"organization_id"`
+	Status         string            `db:"status"`
+	Cause          string            `db:"cause"`
+	Depth          uint              `db:"depth"`
+	Results        ruleActionResults `db:"results"`
+	Reason         *string           `db:"reason"`
+	CreatedDate    time.Time         `db:"created_date"`
+}
+
+type RuleRepository interface {
+	Save(r domain.Rule) (domain.Rule, error)
+	Find(id uint64) (domain.Rule, error)
+	FindByOrgId(orgId uint64) ([]domain.Rule, error)
+	FindEnabledByOrgId(orgId uint64) ([]domain.Rule, error)
+	FindEnabled() ([]domain.Rule, error)
+	Update(r domain.Rule) (domain.Rule, error)
+	MarkFired(id uint64, at time.Time) error
+	Delete(id uint64) error
+	SaveExecution(e domain.RuleExecution) (domain.RuleExecution, error)
+	FindExecutions(ruleId uint64, limit uint) ([]domain.RuleExecution, error)
+}
+
+type ruleRepository struct {
+	coll     db.Collection
+	execColl db.Collection
+}
+
+func NewRuleRepository(sess db.Session) RuleRepository {
+	return &ruleRepository{
+		coll:     sess.Collection(RulesTableName),
+		execColl: sess.Collection(RuleExecutionsTableName),
+	}
+}
+
+func (r *ruleRepository) Save(dr domain.Rule) (domain.Rule, error) {
+	m := r.mapDomainToModel(dr)
+	now := time.Now()
+	m.CreatedDate, m.UpdatedDate = now, now
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		log.Printf("RuleRepository: Error saving rule: %s", err)
+		return domain.Rule{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *ruleRepository) Find(id uint64) (domain.Rule, error) {
+	var m rule
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&m)
+	if err != nil {
+		return domain.Rule{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *ruleRepository) FindByOrgId(orgId uint64) ([]domain.Rule, error) {
+	var rules []rule
+	err := r.coll.Find(db.Cond{"organization_id": orgId, "deleted_date": nil}).OrderBy("id").All(&rules)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(rules), nil
+}
+
+func (r *ruleRepository) FindEnabledByOrgId(orgId uint64) ([]domain.Rule, error) {
+	var rules []rule
+	err := r.coll.Find(db.Cond{"organization_id": orgId, "enabled": true, "deleted_date": nil}).OrderBy("id").All(&rules)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(rules), nil
+}
+
+func (r *ruleRepository) FindEnabled() ([]domain.Rule, error) {
+	var rules []rule
+	err := r.coll.Find(db.Cond{"enabled": true, "deleted_date": nil}).OrderBy("id").All(&rules)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(rules), nil
+}
+
+func (r *ruleRepository) Update(dr domain.Rule) (domain.Rule, error) {
+	m := r.mapDomainToModel(dr)
+	m.UpdatedDate = time.Now()
+	err := r.coll.Find(db.Cond{"id": m.Id, "deleted_date": nil}).Update(&m)
+	if err != nil {
+		log.Printf("RuleRepository: Error updating rule: %s", err)
+		return domain.Rule{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *ruleRepository) MarkFired(id uint64, at time.Time) error {
+	return r.coll.Find(db.Cond{"id": id}).Update(map[string]interface{}{"last_fired_date": at})
+}
+
+func (r *ruleRepository) Delete(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now()})
+}
+
+func (r *ruleRepository) SaveExecution(de domain.RuleExecution) (domain.RuleExecution, error) {
+	m := ruleExecution{
+		RuleId:         de.RuleId,
+		OrganizationId: de.OrganizationId,
+		Status:         string(de.Status),
+		Cause:          de.Cause,
+		Depth:          de.Depth,
+		Results:        ruleActionResults(de.Results),
+		Reason:         de.Reason,
+		CreatedDate:    time.Now(),
+	}
+	err := r.execColl.InsertReturning(&m)
+	if err != nil {
+		log.Printf("RuleRepository: Error saving execution: %s", err)
+		return domain.RuleExecution{}, err
+	}
+	return r.mapExecutionToDomain(m), nil
+}
+
+func (r *ruleRepository) FindExecutions(ruleId uint64, limit uint) ([]domain.RuleExecution, error) {
+	var execs []ruleExecution
+	err := r.execColl.Find(db.Cond{"rule_id": ruleId}).OrderBy("-created_date", "-id").Limit(int(limit)).All(&execs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.RuleExecution, len(execs))
+	for i, e := range execs {
+		res[i] = r.mapExecutionToDomain(e)
+	}
+	return res, nil
+}
+
+func (r *ruleRepository) mapDomainToModel(d domain.Rule) rule {
+	return rule{
+		Id:              d.Id,
+		OrganizationId:  d.OrganizationId,
+		UserId:          d.UserId,
+		Name:            d.Name,
+		Enabled:         d.Enabled,
+		DryRun:          d.DryRun,
+		Trigger:         ruleTrigger(d.Trigger),
+		Conditions:      ruleConditions(d.Conditions),
+		Actions:         ruleActions(d.Actions),
+		CooldownSeconds: d.CooldownSeconds,
+		LastFiredDate:   d.LastFiredDate,
+		CreatedDate:     d.CreatedDate,
+		UpdatedDate:     d.UpdatedDate,
+		DeletedDate:     d.DeletedDate,
+	}
+}
+
+func (r *ruleRepository) mapModelToDomain(m rule) domain.Rule {
+	return domain.Rule{
+		Id:              m.Id,
+		OrganizationId:  m.OrganizationId,
+		UserId:          m.UserId,
+		Name:            m.Name,
+		Enabled:         m.Enabled,
+		DryRun:          m.DryRun,
+		Trigger:         domain.RuleTrigger(m.Trigger),
+		Conditions:      []domain.RuleCondition(m.Conditions),
+		Actions:         []domain.RuleAction(m.Actions),
+		CooldownSeconds: m.CooldownSeconds,
+		LastFiredDate:   m.LastFiredDate,
+		CreatedDate:     m.CreatedDate,
+		UpdatedDate:     m.UpdatedDate,
+		DeletedDate:     m.DeletedDate,
+	}
+}
+
+func (r *ruleRepository) mapModelToDomainCollection(rules []rule) []domain.Rule {
+	res := make([]domain.Rule, len(rules))
+	for i, m := range rules {
+		res[i] = r.mapModelToDomain(m)
+	}
+	return res
+}
+
+func (r *ruleRepository) mapExecutionToDomain(m ruleExecution) domain.RuleExecution {
+	return domain.RuleExecution{
+		Id:             m.Id,
+		RuleId:         m.RuleId,
+		OrganizationId: m.OrganizationId,
+		Status:         domain.RuleExecutionStatus(m.Status),
+		Cause:          m.Cause,
+		Depth:          m.Depth,
+		Results:        []domain.RuleActionResult(m.Results),
+		Reason:         m.Reason,
+		CreatedDate:    m.CreatedDate,
+	}
+}
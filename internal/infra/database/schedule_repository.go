@@ -0,0 +1,226 @@
+package database
+
+import (
+	"database/sql/driver"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+)
+
+const (
+	SchedulesTableName       = "schedules"
+	ScheduleFiringsTableName = "schedule_firings"
+)
+
+type scheduleBlocks []domain.ScheduleBlock
+
+func (b *scheduleBlocks) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(b, src)
+}
+
+func (b scheduleBlocks) Value() (driver.Value, error) {
+	if b == nil {
+		b = scheduleBlocks{}
+	}
+	return postgresql.JSONBValue([]domain.ScheduleBlock(b))
+}
+
+type scheduleExceptions []domain.ScheduleException
+
+func (e *scheduleExceptions) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(e, src)
+}
+
+func (e scheduleExceptions) Value() (driver.Value, error) {
+	if e == nil {
+		e = scheduleExceptions{}
+	}
+	return postgresql.JSONBValue([]domain.ScheduleException(e))
+}
+
+type schedule struct {
+	Id             uint64             `db:"id,omitempty"`
+	OrganizationId uint64             `db:"organization_id"`
+	DeviceId       *uint64            `db:"device_id"`
+	UserId         uint64             `db:"user_id"`
+	Name           string             `db:"name"`
+	Enabled        bool               `db:"enabled"`
+	Blocks         scheduleBlocks     `db:"blocks"`
+	Exceptions     scheduleExceptions `db:"exceptions"`
+	CreatedDate    time.Time          `db:"created_date"`
+	UpdatedDate    time.Time          `db:"updated_date"`
+	DeletedDate    *time.Time         `db:"deleted_date"`
+}
+
+type ScheduleRepository interface {
+	Save(s domain.Schedule) (domain.Schedule, error)
+	Find(id uint64) (domain.Schedule, error)
+	FindByOrgId(orgId uint64) ([]domain.Schedule, error)
+	FindForOrganization(orgId uint64) (domain.Schedule, error)
+	FindForDevice(deviceId uint64) (domain.Schedule, error)
+	FindEnabledForDevices() ([]domain.Schedule, error)
+	Update(s domain.Schedule) (domain.Schedule, error)
+	Delete(id uint64) error
+	SaveFiring(f domain.ScheduleFiring) (bool, error)
+	SetFiringEvent(scheduleId uint64, fireDate time.Time, eventId uint64) error
+}
+
+type scheduleRepository struct {
+	sess db.Session
+	coll db.Collection
+}
+
+func NewScheduleRepository(sess db.Session) ScheduleRepository {
+	return &scheduleRepository{
+		sess: sess,
+		coll: sess.Collection(SchedulesTableName),
+	}
+}
+
+func (r *scheduleRepository) Save(ds domain.Schedule) (domain.Schedule, error) {
+	m := r.mapDomainToModel(ds)
+	now := time.Now()
+	m.CreatedDate, m.UpdatedDate = now, now
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		log.Printf("ScheduleRepository: Error saving schedule: %s", err)
+		return domain.Schedule{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *scheduleRepository) Find(id uint64) (domain.Schedule, error) {
+	var m schedule
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&m)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *scheduleRepository) FindByOrgId(orgId uint64) ([]domain.Schedule, error) {
+	var schedules []schedule
+	err := r.coll.Find(db.Cond{"organization_id": orgId, "deleted_date": nil}).OrderBy("id").All(&schedules)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(schedules), nil
+}
+
+// FindForOrganization returns the schedule of the organization itself, the one without a device.
+func (r *scheduleRepository) FindForOrganization(orgId uint64) (domain.Schedule, error) {
+	var m schedule
+	err := r.coll.Find(db.Cond{"organization_id": orgId, "device_id": nil, "deleted_date": nil}).One(&m)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *scheduleRepository) FindForDevice(deviceId uint64) (domain.Schedule, error) {
+	var m schedule
+	err := r.coll.Find(db.Cond{"device_id": deviceId, "deleted_date": nil}).One(&m)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *scheduleRepository) FindEnabledForDevices() ([]domain.Schedule, error) {
+	var schedules []schedule
+	err := r.coll.Find(db.Cond{"device_id IS NOT": nil, "enabled": true, "deleted_date": nil}).OrderBy("id").All(&schedules)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(schedules), nil
+}
+
+func (r *scheduleRepository) Update(ds domain.Schedule) (domain.Schedule, error) {
+	m := r.mapDomainToModel(ds)
+	m.UpdatedDate = time.Now()
+	err := r.coll.Find(db.Cond{"id": m.Id, "deleted_date": nil}).Update(&m)
+	if err != nil {
+		log.Printf("ScheduleRepository: Error updating schedule: %s", err)
+		return domain.Schedule{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *scheduleRepository) Delete(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now()})
+}
+
+// SaveFiring records a schedule boundary and reports whether it wasn't recorded before,
+// so every boundary is acted on only once, even across restarts.
+func (r *scheduleRepository) SaveFiring(f domain.ScheduleFiring) (bool, error) {
+	res, err := r.sess.SQL().
+		InsertInto(ScheduleFiringsTableName).
+		Columns("schedule_id", "device_id", "fire_date", "action", "created_date").
+		Values(f.ScheduleId, f.DeviceId, f.FireDate, string(f.Action), time.Now()).
+		Amend(func(q string) string {
+			return q + " ON CONFLICT (schedule_id, fire_date) DO NOTHING"
+		}).
+		Exec()
+	if err != nil {
+		log.Printf("ScheduleRepository: Error saving firing: %s", err)
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *scheduleRepository) SetFiringEvent(scheduleId uint64, fireDate time.Time, eventId uint64) error {
+	_, err := r.sess.SQL().
+		Update(ScheduleFiringsTableName).
+		Set("event_id", eventId).
+		Where("schedule_id = ? AND fire_date = ?", scheduleId, fireDate).
+		Exec()
+	return err
+}
+
+func (r *scheduleRepository) mapDomainToModel(d domain.Schedule) schedule {
+	return schedule{
+		Id:             d.Id,
+		OrganizationId: d.OrganizationId,
+		DeviceId:       d.DeviceId,
+		UserId:         d.UserId,
+		Name:           d.Name,
+		Enabled:        d.Enabled,
+		Blocks:         scheduleBlocks(d.Blocks),
+		Exceptions:     scheduleExceptions(d.Exceptions),
+		CreatedDate:    d.CreatedDate,
+		UpdatedDate:    d.UpdatedDate,
+		DeletedDate:    d.DeletedDate,
+	}
+}
+
+func (r *scheduleRepository) mapModelToDomain(m schedule) domain.Schedule {
+	return domain.Schedule{
+		Id:             m.Id,
+		OrganizationId: m.OrganizationId,
+		DeviceId:       m.DeviceId,
+		UserId:         m.UserId,
+		Name:           m.Name,
+		Enabled:        m.Enabled,
+		Blocks:         []domain.ScheduleBlock(m.Blocks),
+		Exceptions:     []domain.ScheduleException(m.Exceptions),
+		CreatedDate:    m.CreatedDate,
+		UpdatedDate:    m.UpdatedDate,
+		DeletedDate:    m.DeletedDate,
+	}
+}
+
+func (r *scheduleRepository) mapModelToDomainCollection(schedules []schedule) []domain.Schedule {
+	res := make([]domain.Schedule, len(schedules))
+	for i, m := range schedules {
+		res[i] = r.mapModelToDomain(m)
+	}
+	return res
+}
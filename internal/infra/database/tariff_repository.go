@@ -0,0 +1,214 @@
+package database
+
+import (
+	"database/sql/driver"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+)
+
+const TariffsTableName = "tariffs"
+
+type tariffWindows []domain.TariffWindow
+
+func (w *tariffWindows) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(w, src)
+}
+
+func (w tariffWindows) Value() (driver.Value, error) {
+	if w == nil {
+		w = tariffWindows{}
+	}
+	return postgresql.JSONBValue([]domain.TariffWindow(w))
+}
+
+type tariffTiers []domain.TariffTier
+
+func (t *tariffTiers) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(t, src)
+}
+
+func (t tariffTiers) Value() (driver.Value, error) {
+	if t == nil {
+		t = tariffTiers{}
+	}
+	return postgresql.JSONBValue([]domain.TariffTier(t))
+}
+
+type tariffSeasons []domain.TariffSeason
+
+func (s *tariffSeasons) Scan(src interface{}) error {
+	return postgresql.ScanJSONB(s, src)
+}
+
+func (s tariffSeasons) Value() (driver.Value, error) {
+	if s == nil {
+		s = tariffSeasons{}
+	}
+	return postgresql.JSONBValue([]domain.TariffSeason(s))
+}
+
+type tariff struct {
+	Id             uint64        `db:"id,omitempty"`
+	OrganizationId uint64        `db:"organization_id"`
+	UserId         uint64        `db:"user_id"`
+	Name           string        `db:"name"`
+	Currency       string        `db:"currency"`
+	Type           string        `db:"type"`
+	Active         bool          `db:"active"`
+	Rate           float64       `db:"rate"`
+	Windows        tariffWindows `db:"windows"`
+	Tiers          tariffTiers   `db:"tiers"`
+	Seasons        tariffSeasons `db:"seasons"`
+	CreatedDate    time.Time     `db:"created_date"`
+	UpdatedDate    time.Time     `db:"updated_date"`
+	DeletedDate    *time.Time    `db:"deleted_date"`
+}
+
+type TariffRepository interface {
+	Save(t domain.Tariff) (domain.Tariff, error)
+	Find(id uint64) (domain.Tariff, error)
+	FindByOrgId(orgId uint64) ([]domain.Tariff, error)
+	FindActive(orgId uint64) (domain.Tariff, error)
+	Update(t domain.Tariff) (domain.Tariff, error)
+	Delete(id uint64) error
+}
+
+type tariffRepository struct {
+	sess db.Session
+	coll db.Collection
+}
+
+func NewTariffRepository(sess db.Session) TariffRepository {
+	return &tariffRepository{
+		sess: sess,
+		coll: sess.Collection(TariffsTableName),
+	}
+}
+
+func (r *tariffRepository) Save(dt domain.Tariff) (domain.Tariff, error) {
+	m := r.mapDomainToModel(dt)
+	now := time.Now()
+	m.CreatedDate, m.UpdatedDate = now, now
+	err := r.sess.Tx(func(tx db.Session) error {
+		err := r.deactivateOthers(tx, m)
+		if err != nil {
+			return err
+		}
+		return tx.Collection(TariffsTableName).InsertReturning(&m)
+	})
+	if err != nil {
+		log.Printf("TariffRepository: Error saving tariff: %s", err)
+		return domain.Tariff{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *tariffRepository) Find(id uint64) (domain.Tariff, error) {
+	var m tariff
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&m)
+	if err != nil {
+		return domain.Tariff{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *tariffRepository) FindByOrgId(orgId uint64) ([]domain.Tariff, error) {
+	var tariffs []tariff
+	err := r.coll.Find(db.Cond{"organization_id": orgId, "deleted_date": nil}).OrderBy("id").All(&tariffs)
+	if err != nil {
+		return nil, err
+	}
+	return r.mapModelToDomainCollection(tariffs), nil
+}
+
+func (r *tariffRepository) FindActive(orgId uint64) (domain.Tariff, error) {
+	var m tariff
+	err := r.coll.Find(db.Cond{"organization_id": orgId, "active": true, "deleted_date": nil}).One(&m)
+	if err != nil {
+		return domain.Tariff{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *tariffRepository) Update(dt domain.Tariff) (domain.Tariff, error) {
+	m := r.mapDomainToModel(dt)
+	m.UpdatedDate = time.Now()
+	err := r.sess.Tx(func(tx db.Session) error {
+		err := r.deactivateOthers(tx, m)
+		if err != nil {
+			return err
+		}
+		return tx.Collection(TariffsTableName).Find(db.Cond{"id": m.Id, "deleted_date": nil}).Update(&m)
+	})
+	if err != nil {
+		log.Printf("TariffRepository: Error updating tariff: %s", err)
+		return domain.Tariff{}, err
+	}
+	return r.mapModelToDomain(m), nil
+}
+
+func (r *tariffRepository) Delete(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now(), "active": false})
+}
+
+// deactivateOthers keeps a single active tariff per organization.
+func (r *tariffRepository) deactivateOthers(tx db.Session, m tariff) error {
+	if !m.Active {
+		return nil
+	}
+	cond := db.Cond{"organization_id": m.OrganizationId, "active": true, "deleted_date": nil}
+	if m.Id != 0 {
+		cond["id !="] = m.Id
+	}
+	return tx.Collection(TariffsTableName).Find(cond).Update(map[string]interface{}{"active": false})
+}
+
+func (r *tariffRepository) mapDomainToModel(d domain.Tariff) tariff {
+	return tariff{
+		Id:             d.Id,
+		OrganizationId: d.OrganizationId,
+		UserId:         d.UserId,
+		Name:           d.Name,
+		Currency:       d.Currency,
+		Type:           string(d.Type),
+		Active:         d.Active,
+		Rate:           d.Rate,
+		Windows:        tariffWindows(d.Windows),
+		Tiers:          tariffTiers(d.Tiers),
+		Seasons:        tariffSeasons(d.Seasons),
+		CreatedDate:    d.CreatedDate,
+		UpdatedDate:    d.UpdatedDate,
+		DeletedDate:    d.DeletedDate,
+	}
+}
+
+func (r *tariffRepository) mapModelToDomain(m tariff) domain.Tariff {
+	return domain.Tariff{
+		Id:             m.Id,
+		OrganizationId: m.OrganizationId,
+		UserId:         m.UserId,
+		Name:           m.Name,
+		Currency:       m.Currency,
+		Type:           domain.TariffType(m.Type),
+		Active:         m.Active,
+		Rate:           m.Rate,
+		Windows:        []domain.TariffWindow(m.Windows),
+		Tiers:          []domain.TariffTier(m.Tiers),
+		Seasons:        []domain.TariffSeason(m.Seasons),
+		CreatedDate:    m.CreatedDate,
+		UpdatedDate:    m.UpdatedDate,
+		DeletedDate:    m.DeletedDate,
+	}
+}
+
+func (r *tariffRepository) mapModelToDomainCollection(tariffs []tariff) []domain.Tariff {
+	res := make([]domain.Tariff, len(tariffs))
+	for i, m := range tariffs {
+		res[i] = r.mapModelToDomain(m)
+	}
+	return res
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"github.com/upper/db/v4"
+)
+
+// organizationLockSpace is the first key of the advisory locks of organizations, the id of the
+// organization is the second.
+const organizationLockSpace = 1
+
+// Tx holds the repositories of one transaction, what they write is kept or rolled back together.
+type Tx struct {
+	Devices    DeviceRepository
+	Events     EventRepository
+	Commands   CommandRepository
+	Demand     DemandRepository
+	Interlocks InterlockRepository
+	sess       db.Session
+}
+
+// LockOrganization waits until no other transaction holds the lock of the organization and holds
+// it until this one ends. Checks which depend on the state of the whole organization, such as its
+// demand, take it before they read so that two of them don't let conflicting writes through.
+func (tx Tx) LockOrganization(orgId uint64) error {
+	_, err := tx.sess.SQL().Exec("SELECT pg_advisory_xact_lock(?, ?)", organizationLockSpace, orgId)
+	return err
+}
+
+type Transactor interface {
+	// Tx runs fn in one transaction, an error of fn rolls it back.
+	Tx(fn func(tx Tx) error) error
+}
+
+type transactor struct {
+	sess db.Session
+}
+
+func NewTransactor(sess db.Session) Transactor {
+	return transactor{
+		sess: sess,
+	}
+}
+
+func (t transactor) Tx(fn func(tx Tx) error) error {
+	return t.sess.Tx(func(sess db.Session) error {
+		return fn(newTx(sess))
+	})
+}
+
+func newTx(sess db.Session) Tx {
+	deviceRepo := NewDeviceRepository(sess)
+	return Tx{
+		Devices:    deviceRepo,
+		Events:     NewEventRepository(sess, deviceRepo),
+		Commands:   NewCommandRepository(sess),
+		Demand:     NewDemandRepository(sess),
+		Interlocks: NewInterlockRepository(sess),
+		sess:       sess,
+	}
+}
+
+// inTx runs fn in the transaction of the session, or in a new one when the session isn't part of one.
+func inTx(sess db.Session, fn func(tx db.Session) error) error {
+	if t, ok := sess.(interface{ IsTransaction() bool }); ok && t.IsTransaction() {
+		return fn(sess)
+	}
+	return sess.Tx(fn)
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type AnomalyController struct {
+	anomalyService      app.AnomalyService
+	organizationService app.OrganizationService
+}
+
+func NewAnomalyController(as app.AnomalyService, os app.OrganizationService) AnomalyController {
+	return AnomalyController{
+		anomalyService:      as,
+		organizationService: os,
+	}
+}
+
+func (c AnomalyController) FindSettings() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := c.sensor(w, r)
+		if !ok {
+			return
+		}
+
+		settings, err := c.anomalyService.FindSettings(device)
+		if err != nil {
+			log.Printf("AnomalyController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.AnomalySettingsDto{}.DomainToDto(settings))
+	}
+}
+
+func (c AnomalyController) SaveSettings() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := c.sensor(w, r)
+		if !ok {
+			return
+		}
+
+		settings, err := requests.Bind(r, &requests.AnomalySettingsRequest{}, domain.AnomalySettings{})
+		if err != nil {
+			log.Printf("AnomalyController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		settings.OrganizationId = device.OrganizationId
+		settings.DeviceId = device.Id
+		settings, err = c.anomalyService.SaveSettings(settings)
+		if err != nil {
+			log.Printf("AnomalyController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Success(w, resources.AnomalySettingsDto{}.DomainToDto(settings))
+	}
+}
+
+// DeleteSettings returns the sensor to the default settings.
+func (c AnomalyController) DeleteSettings() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := c.sensor(w, r)
+		if !ok {
+			return
+		}
+
+		err := c.anomalyService.DeleteSettings(device)
+		if err != nil {
+			log.Printf("AnomalyController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
+
+// FindForDevice returns the anomalies of the period, the last 7 days by default.
+func (c AnomalyController) FindForDevice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := c.sensor(w, r)
+		if !ok {
+			return
+		}
+
+		loc := organizationLocation(c.organizationService, device.OrganizationId)
+		q := r.URL.Query()
+		var (
+			period domain.Period
+			err    error
+		)
+		if q.Get("period") == "" && q.Get("startDate") == "" && q.Get("endDate") == "" {
+			now := time.Now().In(loc)
+			period = domain.Period{Start: now.AddDate(0, 0, -7), End: now, Location: loc}
+		} else {
+			period, err = periodFromQuery(r, loc)
+			if err != nil {
+				BadRequest(w, err)
+				return
+			}
+		}
+
+		anomalies, err := c.anomalyService.FindByDeviceId(device.Id, period.Start, period.End)
+		if err != nil {
+			log.Printf("AnomalyController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.MeasurementAnomaliesDto{}.DomainToDto(anomalies))
+	}
+}
+
+func (c AnomalyController) FindForOrganization() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if org.UserId != user.Id {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		anomalies, err := c.anomalyService.FindByOrgId(org.Id)
+		if err != nil {
+			log.Printf("AnomalyController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.MeasurementAnomaliesDto{}.DomainToDto(anomalies))
+	}
+}
+
+// sensor returns the sensor of the path when the user owns its organization.
+func (c AnomalyController) sensor(w http.ResponseWriter, r *http.Request) (domain.Device, bool) {
+	user := r.Context().Value(UserKey).(domain.User)
+	device := r.Context().Value(DevKey).(domain.Device)
+	if !ownsOrganization(c.organizationService, user, device.OrganizationId) {
+		Forbidden(w, fmt.Errorf("access denied"))
+		return domain.Device{}, false
+	}
+	if device.Category != domain.Sensor {
+		BadRequest(w, errors.New("only sensors are inspected for anomalies"))
+		return domain.Device{}, false
+	}
+	return device, true
+}
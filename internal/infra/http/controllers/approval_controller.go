@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type ApprovalController struct {
+	approvalService     app.ApprovalService
+	organizationService app.OrganizationService
+}
+
+func NewApprovalController(as app.ApprovalService, os app.OrganizationService) ApprovalController {
+	return ApprovalController{
+		approvalService:     as,
+		organizationService: os,
+	}
+}
+
+// FindForOrganization returns the pending approvals, ?status=<status> or ?status=all for others.
+func (c ApprovalController) FindForOrganization() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if !c.isMember(w, org.Id, user.Id) {
+			return
+		}
+
+		status := domain.ApprovalPending
+		filter := &status
+		switch param := strings.ToUpper(r.URL.Query().Get("status")); param {
+		case "":
+		case "ALL":
+			filter = nil
+		default:
+			status = domain.ApprovalStatus(param)
+		}
+
+		approvals, err := c.approvalService.FindByOrgId(org.Id, filter)
+		if err != nil {
+			log.Printf("ApprovalController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.ApprovalsDto{}.DomainToDto(approvals))
+	}
+}
+
+func (c ApprovalController) Find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		approval := r.Context().Value(ApprovalKey).(domain.CommandApproval)
+		if !c.isMember(w, approval.OrganizationId, user.Id) {
+			return
+		}
+
+		Success(w, resources.ApprovalDto{}.DomainToDto(approval))
+	}
+}
+
+func (c ApprovalController) Approve() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		approval := r.Context().Value(ApprovalKey).(domain.CommandApproval)
+
+		approval, err := c.approvalService.Approve(approval, user.Id)
+		if errors.Is(err, domain.ErrDemandQueued) {
+			Accepted(w, resources.ApprovalDto{}.DomainToDto(approval))
+			return
+		}
+		if err != nil {
+			log.Printf("ApprovalController: %s", err)
+			c.decisionError(w, err)
+			return
+		}
+
+		Success(w, resources.ApprovalDto{}.DomainToDto(approval))
+	}
+}
+
+func (c ApprovalController) Reject() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		approval := r.Context().Value(ApprovalKey).(domain.CommandApproval)
+
+		comment, err := requests.Bind(r, &requests.ApprovalDecisionRequest{}, (*string)(nil))
+		if err != nil {
+			log.Printf("ApprovalController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		approval, err = c.approvalService.Reject(approval, user.Id, comment)
+		if err != nil {
+			log.Printf("ApprovalController: %s", err)
+			c.decisionError(w, err)
+			return
+		}
+
+		Success(w, resources.ApprovalDto{}.DomainToDto(approval))
+	}
+}
+
+func (c ApprovalController) isMember(w http.ResponseWriter, orgId, userId uint64) bool {
+	_, ok, err := c.organizationService.MemberRole(orgId, userId)
+	if err != nil {
+		log.Printf("ApprovalController: %s", err)
+		InternalServerError(w, err)
+		return false
+	}
+	if !ok {
+		Forbidden(w, fmt.Errorf("access denied"))
+		return false
+	}
+	return true
+}
+
+func (c ApprovalController) decisionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, app.ErrNotManager), errors.Is(err, app.ErrOwnApproval):
+		Forbidden(w, err)
+	case errors.Is(err, app.ErrApprovalDecided), errors.Is(err, domain.ErrInterlock), errors.Is(err, domain.ErrDemandLimit):
+		Conflict(w, err)
+	default:
+		BadRequest(w, err)
+	}
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type BudgetController struct {
+	budgetService       app.BudgetService
+	organizationService app.OrganizationService
+}
+
+func NewBudgetController(bs app.BudgetService, os app.OrganizationService) BudgetController {
+	return BudgetController{
+		budgetService:       bs,
+		organizationService: os,
+	}
+}
+
+func (c BudgetController) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		budget, err := requests.Bind(r, &requests.BudgetRequest{}, domain.Budget{})
+		if err != nil {
+			log.Printf("BudgetController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		if budget.OrganizationId == 0 {
+			BadRequest(w, errors.New("organizationId is required"))
+			return
+		}
+		if !ownsOrganization(c.organizationService, user, budget.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		budget.UserId = user.Id
+		budget, err = c.budgetService.Save(budget)
+		if err != nil {
+			log.Printf("BudgetController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Created(w, resources.BudgetDto{}.DomainToDto(budget))
+	}
+}
+
+func (c BudgetController) FindForOrganization() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if org.UserId != user.Id {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		budgets, err := c.budgetService.FindByOrgId(org.Id)
+		if err != nil {
+			log.Printf("BudgetController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.BudgetsDto{}.DomainToDto(budgets))
+	}
+}
+
+func (c BudgetController) Find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		budget := r.Context().Value(BudgetKey).(domain.Budget)
+		if !ownsOrganization(c.organizationService, user, budget.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		Success(w, resources.BudgetDto{}.DomainToDto(budget))
+	}
+}
+
+func (c BudgetController) Status() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		budget := r.Context().Value(BudgetKey).(domain.Budget)
+		if !ownsOrganization(c.organizationService, user, budget.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		status, err := c.budgetService.Status(budget, time.Now())
+		if err != nil {
+			log.Printf("BudgetController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Success(w, resources.BudgetStatusDto{}.DomainToDto(status))
+	}
+}
+
+func (c BudgetController) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		budget := r.Context().Value(BudgetKey).(domain.Budget)
+		if !ownsOrganization(c.organizationService, user, budget.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		updated, err := requests.Bind(r, &requests.BudgetRequest{}, domain.Budget{})
+		if err != nil {
+			log.Printf("BudgetController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		budget.RoomId = updated.RoomId
+		budget.Name = updated.Name
+		budget.Metric = updated.Metric
+		budget.Amount = updated.Amount
+		budget.Thresholds = updated.Thresholds
+		budget.AlertOnProjection = updated.AlertOnProjection
+		budget.Enabled = updated.Enabled
+		budget, err = c.budgetService.Update(budget)
+		if err != nil {
+			log.Printf("BudgetController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Success(w, resources.BudgetDto{}.DomainToDto(budget))
+	}
+}
+
+func (c BudgetController) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		budget := r.Context().Value(BudgetKey).(domain.Budget)
+		if !ownsOrganization(c.organizationService, user, budget.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		err := c.budgetService.Delete(budget.Id)
+		if err != nil {
+			log.Printf("BudgetController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type CalibrationController struct {
+	calibrationService  app.CalibrationService
+	organizationService app.OrganizationService
+}
+
+func NewCalibrationController(cs app.CalibrationService, os app.OrganizationService) CalibrationController {
+	return CalibrationController{
+		calibrationService:  cs,
+		organizationService: os,
+	}
+}
+
+// Save calibrates the sensor. With ?recompute=true the readings already taken since the
+// calibration took effect are corrected too.
+func (c CalibrationController) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		device := r.Context().Value(DevKey).(domain.Device)
+		if !ownsOrganization(c.organizationService, user, device.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		calibration, err := requests.Bind(r, &requests.CalibrationRequest{}, domain.Calibration{})
+		if err != nil {
+			log.Printf("CalibrationController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		calibration.OrganizationId = device.OrganizationId
+		calibration.DeviceId = device.Id
+		calibration.UserId = user.Id
+		calibration, err = c.calibrationService.Save(calibration)
+		if err != nil {
+			log.Printf("CalibrationController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		dto := resources.CalibrationDto{}.DomainToDto(calibration)
+		if r.URL.Query().Get("recompute") == "true" && calibration.EffectiveFrom.Before(time.Now()) {
+			count, err := c.calibrationService.Recompute(calibration)
+			if err != nil {
+				log.Printf("CalibrationController: %s", err)
+				InternalServerError(w, err)
+				return
+			}
+			dto.Recomputed = &count
+		}
+
+		Created(w, dto)
+	}
+}
+
+func (c CalibrationController) FindForDevice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		device := r.Context().Value(DevKey).(domain.Device)
+		if !ownsOrganization(c.organizationService, user, device.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		calibrations, err := c.calibrationService.FindByDeviceId(device.Id)
+		if err != nil {
+			log.Printf("CalibrationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.CalibrationsDto{}.DomainToDto(calibrations))
+	}
+}
+
+func (c CalibrationController) Find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		calibration, ok := c.calibration(w, r)
+		if !ok {
+			return
+		}
+
+		Success(w, resources.CalibrationDto{}.DomainToDto(calibration))
+	}
+}
+
+// Recompute corrects the readings the calibration applies to.
+func (c CalibrationController) Recompute() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		calibration, ok := c.calibration(w, r)
+		if !ok {
+			return
+		}
+
+		count, err := c.calibrationService.Recompute(calibration)
+		if err != nil {
+			log.Printf("CalibrationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.RecomputedDto{Recomputed: count})
+	}
+}
+
+// Delete removes the calibration. With ?recompute=true its readings are corrected by the
+// calibration before it, or restored to the raw values.
+func (c CalibrationController) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		calibration, ok := c.calibration(w, r)
+		if !ok {
+			return
+		}
+
+		count, err := c.calibrationService.Delete(calibration, r.URL.Query().Get("recompute") == "true")
+		if err != nil {
+			log.Printf("CalibrationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.RecomputedDto{Recomputed: count})
+	}
+}
+
+// calibration returns the calibration of the path when the user owns its organization.
+func (c CalibrationController) calibration(w http.ResponseWriter, r *http.Request) (domain.Calibration, bool) {
+	user := r.Context().Value(UserKey).(domain.User)
+	calibration := r.Context().Value(CalibrationKey).(domain.Calibration)
+	if calibration.DeletedDate != nil {
+		NotFound(w, errors.New("calibration not found"))
+		return domain.Calibration{}, false
+	}
+	if !ownsOrganization(c.organizationService, user, calibration.OrganizationId) {
+		Forbidden(w, fmt.Errorf("access denied"))
+		return domain.Calibration{}, false
+	}
+	return calibration, true
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+	"github.com/go-chi/chi/v5"
+)
+
+const maxCommandPollWait = 60 * time.Second
+
+type CommandController struct {
+	commandService      app.CommandService
+	deviceService       app.DeviceService
+	organizationService app.OrganizationService
+}
+
+func NewCommandController(cs app.CommandService, ds app.DeviceService, os app.OrganizationService) CommandController {
+	return CommandController{
+		commandService:      cs,
+		deviceService:       ds,
+		organizationService: os,
+	}
+}
+
+func (c CommandController) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		var req requests.CommandRequest
+		cmd, err := requests.Bind(r, &req, domain.Command{})
+		if err != nil {
+			log.Printf("CommandController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		device, err := c.deviceService.Find(cmd.DeviceId)
+		if err != nil {
+			log.Printf("CommandController: %s", err)
+			BadRequest(w, errors.New("device not found"))
+			return
+		}
+		if !ownsOrganization(c.organizationService, user, device.(domain.Device).OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		cmd.UserId = user.Id
+		cmd.Override, err = interlockOverride(user, req.Override)
+		if err != nil {
+			Forbidden(w, err)
+			return
+		}
+		cmd, err = c.commandService.Create(cmd, time.Duration(req.TtlSeconds)*time.Second)
+		if errors.Is(err, domain.ErrDemandQueued) {
+			Accepted(w, map[string]interface{}{"status": domain.DemandRequestQueued, "message": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrApprovalPending) {
+			Accepted(w, map[string]interface{}{"status": domain.ApprovalPending, "message": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrDemandLimit) || errors.Is(err, domain.ErrInterlock) {
+			Conflict(w, err)
+			return
+		}
+		if err != nil {
+			log.Printf("CommandController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Created(w, resources.CommandDto{}.DomainToDto(cmd))
+	}
+}
+
+func (c CommandController) Find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cmd := r.Context().Value(CommandKey).(domain.Command)
+		Success(w, resources.CommandDto{}.DomainToDto(cmd))
+	}
+}
+
+func (c CommandController) FindByDevice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device := r.Context().Value(DevKey).(domain.Device)
+
+		cmds, err := c.commandService.FindByDeviceId(device.Id)
+		if err != nil {
+			log.Printf("CommandController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.CommandsDto{}.DomainToDto(cmds))
+	}
+}
+
+// Poll is called by devices. With ?wait=<seconds> the request is held open until a command arrives.
+func (c CommandController) Poll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device := r.Context().Value(DevKey).(domain.Device)
+
+		var wait time.Duration
+		if waitParam := r.URL.Query().Get("wait"); waitParam != "" {
+			seconds, err := strconv.ParseUint(waitParam, 10, 64)
+			if err != nil {
+				BadRequest(w, errors.New("invalid wait parameter"))
+				return
+			}
+			wait = time.Duration(seconds) * time.Second
+			if wait > maxCommandPollWait {
+				wait = maxCommandPollWait
+			}
+		}
+
+		cmds, err := c.commandService.Poll(r.Context(), device, wait)
+		if err != nil {
+			log.Printf("CommandController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.CommandsDto{}.DomainToDto(cmds))
+	}
+}
+
+func (c CommandController) Acknowledge() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device := r.Context().Value(DevKey).(domain.Device)
+
+		commandId, err := strconv.ParseUint(chi.URLParam(r, "commandId"), 10, 64)
+		if err != nil {
+			BadRequest(w, errors.New("invalid commandId parameter(only non-negative integers)"))
+			return
+		}
+
+		var req requests.CommandAckRequest
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Printf("CommandController: Error decoding request body: %s", err)
+			BadRequest(w, errors.New("invalid request payload"))
+			return
+		}
+
+		cmd, err := c.commandService.Acknowledge(device, commandId, req.Success, req.Error)
+		if err != nil {
+			log.Printf("CommandController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Success(w, resources.CommandDto{}.DomainToDto(cmd))
+	}
+}
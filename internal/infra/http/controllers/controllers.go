@@ -2,9 +2,14 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 )
 
 /* should not use built-in type string as key for value;
@@ -15,13 +20,23 @@ type CtxKey struct {
 }
 
 var (
-	UserKey        = CtxKey{Name: "user"}
-	SessKey        = CtxKey{Name: "sess"}
-	OrgKey         = CtxKey{Name: "org"}
-	RoKey          = CtxKey{Name: "ro"}
-	DevKey         = CtxKey{Name: "dev"}
-	MeasurementKey = CtxKey{Name: "measurement"}
-	EventKey       = CtxKey{Name: "event"}
+	UserKey           = CtxKey{Name: "user"}
+	SessKey           = CtxKey{Name: "sess"}
+	OrgKey            = CtxKey{Name: "org"}
+	RoKey             = CtxKey{Name: "ro"}
+	DevKey            = CtxKey{Name: "dev"}
+	MeasurementKey    = CtxKey{Name: "measurement"}
+	EventKey          = CtxKey{Name: "event"}
+	CommandKey        = CtxKey{Name: "command"}
+	RuleKey           = CtxKey{Name: "rule"}
+	ScheduleKey       = CtxKey{Name: "schedule"}
+	TariffKey         = CtxKey{Name: "tariff"}
+	BudgetKey         = CtxKey{Name: "budget"}
+	NotificationKey   = CtxKey{Name: "notification"}
+	EmissionFactorKey = CtxKey{Name: "emission_factor"}
+	InterlockKey      = CtxKey{Name: "interlock"}
+	ApprovalKey       = CtxKey{Name: "approval"}
+	CalibrationKey    = CtxKey{Name: "calibration"}
 )
 
 func Ok(w http.ResponseWriter) {
@@ -50,6 +65,17 @@ func Created(w http.ResponseWriter, body interface{}) {
 	}
 }
 
+// Accepted is for a request which will be carried out later.
+func Accepted(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		log.Print(err)
+	}
+}
+
 func noContent(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusNoContent)
@@ -69,6 +95,13 @@ func Forbidden(w http.ResponseWriter, err error) {
 	encodeErrorBody(w, err)
 }
 
+func Conflict(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+
+	encodeErrorBody(w, err)
+}
+
 func InternalServerError(w http.ResponseWriter, err error) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusInternalServerError)
@@ -121,3 +154,48 @@ func Unauthorized(w http.ResponseWriter, err error) {
 
 	encodeErrorBody(w, err)
 }
+
+// ownsOrganization reports whether the user is the owner of the organization.
+func ownsOrganization(os app.OrganizationService, user domain.User, orgId uint64) bool {
+	org, err := os.Find(orgId)
+	if err != nil {
+		log.Print(err)
+		return false
+	}
+	return org.(domain.Organization).UserId == user.Id
+}
+
+// organizationLocation returns the timezone of the organization, UTC when it can't be found.
+func organizationLocation(os app.OrganizationService, orgId uint64) *time.Location {
+	org, err := os.Find(orgId)
+	if err != nil {
+		log.Print(err)
+		return time.UTC
+	}
+	return org.(domain.Organization).Location()
+}
+
+// periodFromQuery reads either ?startDate=&endDate= or ?period=day|week|month&date=, where date
+// defaults to today. Dates are interpreted in loc, timestamps may be given in RFC3339. A date as
+// endDate includes that whole day, so ?startDate=2024-03-31&endDate=2024-03-31 is March 31 from
+// midnight to midnight in loc, while a timestamp as endDate is exclusive.
+func periodFromQuery(r *http.Request, loc *time.Location) (domain.Period, error) {
+	q := r.URL.Query()
+	unit := q.Get("period")
+	if unit == "" {
+		return domain.ParsePeriod(q.Get("startDate"), q.Get("endDate"), loc)
+	}
+
+	at := time.Now()
+	if date := q.Get("date"); date != "" {
+		var err error
+		at, err = time.Parse(time.RFC3339, date)
+		if err != nil {
+			at, err = time.ParseInLocation(domain.ScheduleDateFormat, date, loc)
+		}
+		if err != nil {
+			return domain.Period{}, errors.New("date must be an RFC3339 timestamp or a YYYY-MM-DD date")
+		}
+	}
+	return domain.PeriodContaining(domain.PeriodUnit(unit), at, loc)
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+	"github.com/upper/db/v4"
+)
+
+type DemandController struct {
+	demandService app.DemandService
+}
+
+func NewDemandController(ds app.DemandService) DemandController {
+	return DemandController{
+		demandService: ds,
+	}
+}
+
+func (c DemandController) FindPolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		org, ok := c.organization(w, r)
+		if !ok {
+			return
+		}
+
+		p, err := c.demandService.FindPolicy(org.Id)
+		if errors.Is(err, db.ErrNoMoreRows) {
+			NotFound(w, errors.New("organization has no demand policy"))
+			return
+		}
+		if err != nil {
+			log.Printf("DemandController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.DemandPolicyDto{}.DomainToDto(p))
+	}
+}
+
+func (c DemandController) SavePolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		org, ok := c.organization(w, r)
+		if !ok {
+			return
+		}
+		user := r.Context().Value(UserKey).(domain.User)
+
+		p, err := requests.Bind(r, &requests.DemandPolicyRequest{}, domain.DemandPolicy{})
+		if err != nil {
+			log.Printf("DemandController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		p.OrganizationId = org.Id
+		p.UserId = user.Id
+		p, err = c.demandService.SavePolicy(p)
+		if err != nil {
+			log.Printf("DemandController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Success(w, resources.DemandPolicyDto{}.DomainToDto(p))
+	}
+}
+
+func (c DemandController) DeletePolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		org, ok := c.organization(w, r)
+		if !ok {
+			return
+		}
+
+		err := c.demandService.DeletePolicy(org.Id)
+		if err != nil {
+			log.Printf("DemandController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
+
+func (c DemandController) FindDecisions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		org, ok := c.organization(w, r)
+		if !ok {
+			return
+		}
+
+		decisions, err := c.demandService.FindDecisions(org.Id)
+		if err != nil {
+			log.Printf("DemandController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.DemandDecisionsDto{}.DomainToDto(decisions))
+	}
+}
+
+func (c DemandController) FindRequests() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		org, ok := c.organization(w, r)
+		if !ok {
+			return
+		}
+
+		reqs, err := c.demandService.FindRequests(org.Id)
+		if err != nil {
+			log.Printf("DemandController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.DemandRequestsDto{}.DomainToDto(reqs))
+	}
+}
+
+// organization returns the organization of the path when it belongs to the user.
+func (c DemandController) organization(w http.ResponseWriter, r *http.Request) (domain.Organization, bool) {
+	user := r.Context().Value(UserKey).(domain.User)
+	org := r.Context().Value(OrgKey).(domain.Organization)
+	if org.UserId != user.Id {
+		Forbidden(w, fmt.Errorf("access denied"))
+		return domain.Organization{}, false
+	}
+	return org, true
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 
@@ -10,19 +11,23 @@ import (
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+	"github.com/go-chi/chi/v5"
+	"github.com/upper/db/v4"
 )
 
 type DeviceController struct {
 	DeviceService       app.DeviceService
 	RoomService         app.RoomService
 	OrganizationService app.OrganizationService
+	NotificationService app.NotificationService
 }
 
-func NewDeviceController(ds app.DeviceService, rs app.RoomService, os app.OrganizationService) DeviceController {
+func NewDeviceController(ds app.DeviceService, rs app.RoomService, os app.OrganizationService, ns app.NotificationService) DeviceController {
 	return DeviceController{
 		DeviceService:       ds,
 		RoomService:         rs,
 		OrganizationService: os,
+		NotificationService: ns,
 	}
 }
 
@@ -58,6 +63,10 @@ func (c *DeviceController) Save() http.HandlerFunc {
 		}
 
 		createdDevice, err := c.DeviceService.Save(device)
+		if errors.Is(err, domain.ErrInvalidMeter) || errors.Is(err, domain.ErrInvalidQualityLimits) || errors.Is(err, domain.ErrInvalidUnits) {
+			BadRequest(w, err)
+			return
+		}
 		if err != nil {
 			log.Printf("DeviceController: %s", err)
 			InternalServerError(w, errors.New("failed to save device"))
@@ -102,9 +111,13 @@ func (c *DeviceController) Find() http.HandlerFunc {
 	}
 }
 
+// Update changes the device. Only managers of the organization may make a device critical or
+// clear it, and clearing it is reported to the owner.
 func (c *DeviceController) Update() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
 		device := r.Context().Value(DevKey).(domain.Device)
+		wasCritical := device.Critical
 
 		var deviceRequest requests.DeviceRequest
 		err := json.NewDecoder(r.Body).Decode(&deviceRequest)
@@ -117,14 +130,59 @@ func (c *DeviceController) Update() http.HandlerFunc {
 		device.Characteristics = deviceRequest.Characteristics
 		device.PowerConsumption = deviceRequest.PowerConsumption
 		device.Units = deviceRequest.Units
+		device.MeteredDeviceId = deviceRequest.MeteredDeviceId
+		device.CounterMax = deviceRequest.CounterMax
+		device.ValidMin = deviceRequest.ValidMin
+		device.ValidMax = deviceRequest.ValidMax
+		device.MaxRate = deviceRequest.MaxRate
+		if deviceRequest.Critical != nil && *deviceRequest.Critical != device.Critical {
+			if *deviceRequest.Critical && device.Category != domain.Actuator {
+				BadRequest(w, errors.New("only actuators can be critical"))
+				return
+			}
+			role, ok, err := c.OrganizationService.MemberRole(device.OrganizationId, user.Id)
+			if err != nil {
+				log.Printf("DeviceController: %s", err)
+				InternalServerError(w, errors.New("failed to update device"))
+				return
+			}
+			if !ok || role != domain.MemberRoleManager {
+				Forbidden(w, errors.New("only managers of the organization may change whether a device is critical"))
+				return
+			}
+			device.Critical = *deviceRequest.Critical
+		}
+		if deviceRequest.Capabilities != nil {
+			if device.Category != domain.Actuator {
+				BadRequest(w, errors.New("only actuators have capabilities"))
+				return
+			}
+			device.Capabilities, err = deviceRequest.Capabilities.ToDomainModel()
+			if err != nil {
+				BadRequest(w, err)
+				return
+			}
+		}
 
 		updatedDevice, err := c.DeviceService.Update(device)
+		if errors.Is(err, domain.ErrUnitsInUse) {
+			Conflict(w, err)
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidMeter) || errors.Is(err, domain.ErrInvalidQualityLimits) || errors.Is(err, domain.ErrInvalidUnits) {
+			BadRequest(w, err)
+			return
+		}
 		if err != nil {
 			log.Printf("DeviceController: Error updating device: %s", err)
 			InternalServerError(w, errors.New("failed to update device"))
 			return
 		}
 
+		if wasCritical && !updatedDevice.Critical {
+			c.notifyCriticalCleared(updatedDevice, user)
+		}
+
 		deviceDto := resources.DeviceDto{}.DomainToDto(updatedDevice)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -132,6 +190,30 @@ func (c *DeviceController) Update() http.HandlerFunc {
 	}
 }
 
+// notifyCriticalCleared tells the owner of the organization that commands to the device no
+// longer need an approval.
+func (c *DeviceController) notifyCriticalCleared(device domain.Device, user domain.User) {
+	log.Printf("DeviceController: User %d cleared the critical flag of device %d", user.Id, device.Id)
+	org, err := c.OrganizationService.Find(device.OrganizationId)
+	if err != nil {
+		log.Printf("DeviceController: %s", err)
+		return
+	}
+
+	_, err = c.NotificationService.Create(domain.Notification{
+		OrganizationId: device.OrganizationId,
+		UserId:         org.(domain.Organization).UserId,
+		Severity:       domain.WarningNotification,
+		Title:          "Device is no longer critical",
+		Message:        fmt.Sprintf("%s (%d) cleared the critical flag of device %d, its commands no longer need an approval", user.Email, user.Id, device.Id),
+		Source:         "device",
+		SourceId:       device.Id,
+	})
+	if err != nil {
+		log.Printf("DeviceController: device %d: %s", device.Id, err)
+	}
+}
+
 func (c *DeviceController) Delete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		device := r.Context().Value(DevKey).(domain.Device)
@@ -173,6 +255,14 @@ func (c *DeviceController) Install() http.HandlerFunc {
 
 		device.RoomId = req.RoomId
 		updatedDevice, err := c.DeviceService.Update(device)
+		if errors.Is(err, domain.ErrUnitsInUse) {
+			Conflict(w, err)
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidMeter) || errors.Is(err, domain.ErrInvalidQualityLimits) || errors.Is(err, domain.ErrInvalidUnits) {
+			BadRequest(w, err)
+			return
+		}
 		if err != nil {
 			log.Printf("DeviceController: Error installing device: %s", err)
 			InternalServerError(w, errors.New("failed to install device"))
@@ -209,3 +299,137 @@ func (c *DeviceController) Uninstall() http.HandlerFunc {
 		json.NewEncoder(w).Encode(deviceDto)
 	}
 }
+
+func (c *DeviceController) RotateSecret() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device := r.Context().Value(DevKey).(domain.Device)
+
+		updatedDevice, err := c.DeviceService.RotateSecret(device)
+		if err != nil {
+			log.Printf("DeviceController: Error rotating device secret: %s", err)
+			InternalServerError(w, errors.New("failed to rotate device secret"))
+			return
+		}
+
+		Success(w, resources.DeviceDto{}.DomainToDto(updatedDevice))
+	}
+}
+
+func (c DeviceController) FindChannels() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := c.ownDevice(w, r)
+		if !ok {
+			return
+		}
+
+		channels, err := c.DeviceService.FindChannels(device)
+		if err != nil {
+			log.Printf("DeviceController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.ChannelsDto{}.DomainToDto(channels))
+	}
+}
+
+func (c DeviceController) SaveChannel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := c.ownDevice(w, r)
+		if !ok {
+			return
+		}
+
+		ch, err := requests.Bind(r, &requests.ChannelRequest{}, domain.DeviceChannel{})
+		if err != nil {
+			log.Printf("DeviceController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		ch, err = c.DeviceService.SaveChannel(device, ch)
+		if errors.Is(err, domain.ErrInvalidChannel) {
+			BadRequest(w, err)
+			return
+		}
+		if err != nil {
+			log.Printf("DeviceController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Created(w, resources.ChannelDto{}.DomainToDto(ch))
+	}
+}
+
+func (c DeviceController) UpdateChannel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := c.ownDevice(w, r)
+		if !ok {
+			return
+		}
+
+		ch, err := requests.Bind(r, &requests.ChannelRequest{}, domain.DeviceChannel{})
+		if err != nil {
+			log.Printf("DeviceController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		// the key of a channel names it in the readings, it doesn't change
+		ch.Key = chi.URLParam(r, "channelKey")
+		ch, err = c.DeviceService.UpdateChannel(device, ch)
+		if errors.Is(err, domain.ErrUnitsInUse) {
+			Conflict(w, err)
+			return
+		}
+		if errors.Is(err, db.ErrNoMoreRows) {
+			NotFound(w, errors.New("channel not found"))
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidChannel) {
+			BadRequest(w, err)
+			return
+		}
+		if err != nil {
+			log.Printf("DeviceController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.ChannelDto{}.DomainToDto(ch))
+	}
+}
+
+func (c DeviceController) DeleteChannel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device, ok := c.ownDevice(w, r)
+		if !ok {
+			return
+		}
+
+		err := c.DeviceService.DeleteChannel(device, chi.URLParam(r, "channelKey"))
+		if errors.Is(err, db.ErrNoMoreRows) {
+			NotFound(w, errors.New("channel not found"))
+			return
+		}
+		if err != nil {
+			log.Printf("DeviceController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
+
+// ownDevice returns the device of the path when the user owns its organization.
+func (c DeviceController) ownDevice(w http.ResponseWriter, r *http.Request) (domain.Device, bool) {
+	user := r.Context().Value(UserKey).(domain.User)
+	device := r.Context().Value(DevKey).(domain.Device)
+	if !ownsOrganization(c.OrganizationService, user, device.OrganizationId) {
+		Forbidden(w, fmt.Errorf("access denied"))
+		return domain.Device{}, false
+	}
+	return device, true
+}
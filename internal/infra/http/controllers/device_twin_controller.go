@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type DeviceTwinController struct {
+	twinService         app.DeviceTwinService
+	organizationService app.OrganizationService
+}
+
+func NewDeviceTwinController(ts app.DeviceTwinService, os app.OrganizationService) DeviceTwinController {
+	return DeviceTwinController{
+		twinService:         ts,
+		organizationService: os,
+	}
+}
+
+func (c DeviceTwinController) Find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		device := r.Context().Value(DevKey).(domain.Device)
+		if !ownsOrganization(c.organizationService, user, device.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		twin, err := c.twinService.Find(device)
+		if err != nil {
+			log.Printf("DeviceTwinController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.DeviceTwinDto{}.DomainToDto(twin))
+	}
+}
+
+func (c DeviceTwinController) UpdateDesired() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		device := r.Context().Value(DevKey).(domain.Device)
+		if !ownsOrganization(c.organizationService, user, device.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		var req requests.TwinStateRequest
+		state, err := requests.Bind(r, &req, domain.TwinState{})
+		if err != nil {
+			log.Printf("DeviceTwinController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		twin, err := c.twinService.UpdateDesired(device, user.Id, state, req.Version)
+		if err != nil {
+			log.Printf("DeviceTwinController: %s", err)
+			if errors.Is(err, domain.ErrTwinVersionConflict) {
+				Conflict(w, err)
+				return
+			}
+			BadRequest(w, err)
+			return
+		}
+
+		Success(w, resources.DeviceTwinDto{}.DomainToDto(twin))
+	}
+}
+
+// UpdateReported is called by devices with the state they are actually in.
+func (c DeviceTwinController) UpdateReported() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device := r.Context().Value(DevKey).(domain.Device)
+
+		var req requests.TwinStateRequest
+		state, err := requests.Bind(r, &req, domain.TwinState{})
+		if err != nil {
+			log.Printf("DeviceTwinController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		twin, err := c.twinService.UpdateReported(device, state)
+		if err != nil {
+			log.Printf("DeviceTwinController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.DeviceTwinDto{}.DomainToDto(twin))
+	}
+}
+
+func (c DeviceTwinController) FindDrift() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if org.UserId != user.Id {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		drift, err := c.twinService.FindDriftForOrg(org.Id)
+		if err != nil {
+			log.Printf("DeviceTwinController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.TwinDriftsDto{}.DomainToDto(drift))
+	}
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+const maxFactorCSVSize = 4 << 20
+
+type EmissionFactorController struct {
+	emissionFactorService app.EmissionFactorService
+	organizationService   app.OrganizationService
+}
+
+func NewEmissionFactorController(fs app.EmissionFactorService, os app.OrganizationService) EmissionFactorController {
+	return EmissionFactorController{
+		emissionFactorService: fs,
+		organizationService:   os,
+	}
+}
+
+func (c EmissionFactorController) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		factor, err := requests.Bind(r, &requests.EmissionFactorRequest{}, domain.EmissionFactor{})
+		if err != nil {
+			log.Printf("EmissionFactorController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		if factor.OrganizationId == 0 {
+			BadRequest(w, errors.New("organizationId is required"))
+			return
+		}
+		if !ownsOrganization(c.organizationService, user, factor.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		factor.UserId = user.Id
+		factor, err = c.emissionFactorService.Save(factor)
+		if err != nil {
+			log.Printf("EmissionFactorController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Created(w, resources.EmissionFactorDto{}.DomainToDto(factor))
+	}
+}
+
+func (c EmissionFactorController) FindForOrganization() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if org.UserId != user.Id {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		factors, err := c.emissionFactorService.FindByOrgId(org.Id)
+		if err != nil {
+			log.Printf("EmissionFactorController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.EmissionFactorsDto{}.DomainToDto(factors))
+	}
+}
+
+func (c EmissionFactorController) Find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		factor := r.Context().Value(EmissionFactorKey).(domain.EmissionFactor)
+		if !ownsOrganization(c.organizationService, user, factor.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		Success(w, resources.EmissionFactorDto{}.DomainToDto(factor))
+	}
+}
+
+func (c EmissionFactorController) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		factor := r.Context().Value(EmissionFactorKey).(domain.EmissionFactor)
+		if !ownsOrganization(c.organizationService, user, factor.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		updated, err := requests.Bind(r, &requests.EmissionFactorRequest{}, domain.EmissionFactor{})
+		if err != nil {
+			log.Printf("EmissionFactorController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		factor.Name = updated.Name
+		factor.Source = updated.Source
+		factor.Active = updated.Active
+		factor.Factor = updated.Factor
+		factor.Entries = updated.Entries
+		factor, err = c.emissionFactorService.Update(factor)
+		if err != nil {
+			log.Printf("EmissionFactorController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Success(w, resources.EmissionFactorDto{}.DomainToDto(factor))
+	}
+}
+
+// ImportEntries replaces the factor table with a CSV body of start,factor rows. Dates without a
+// timezone are read in the timezone of the organization.
+func (c EmissionFactorController) ImportEntries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		factor := r.Context().Value(EmissionFactorKey).(domain.EmissionFactor)
+		if !ownsOrganization(c.organizationService, user, factor.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		loc := organizationLocation(c.organizationService, factor.OrganizationId)
+		entries, err := domain.ParseEmissionFactorCSV(http.MaxBytesReader(w, r.Body, maxFactorCSVSize), loc)
+		if err != nil {
+			log.Printf("EmissionFactorController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		factor.Entries = entries
+		factor, err = c.emissionFactorService.Update(factor)
+		if err != nil {
+			log.Printf("EmissionFactorController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Success(w, resources.EmissionFactorDto{}.DomainToDto(factor))
+	}
+}
+
+func (c EmissionFactorController) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		factor := r.Context().Value(EmissionFactorKey).(domain.EmissionFactor)
+		if !ownsOrganization(c.organizationService, user, factor.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		err := c.emissionFactorService.Delete(factor.Id)
+		if err != nil {
+			log.Printf("EmissionFactorController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
@@ -13,18 +14,23 @@ import (
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
-	"github.com/go-chi/chi/v5"
 )
 
 type EventController struct {
-	eventService app.EventService
-	deviceRepo   database.DeviceRepository
+	eventService        app.EventService
+	deviceRepo          database.DeviceRepository
+	organizationService app.OrganizationService
+	tariffService       app.TariffService
+	factorService       app.EmissionFactorService
 }
 
-func NewEventController(es app.EventService, dr database.DeviceRepository) *EventController {
+func NewEventController(es app.EventService, dr database.DeviceRepository, os app.OrganizationService, ts app.TariffService, fs app.EmissionFactorService) *EventController {
 	return &EventController{
-		eventService: es,
-		deviceRepo:   dr,
+		eventService:        es,
+		deviceRepo:          dr,
+		organizationService: os,
+		tariffService:       ts,
+		factorService:       fs,
 	}
 }
 
@@ -53,8 +59,25 @@ func (c *EventController) Save() http.HandlerFunc {
 		}
 
 		event.RoomId = deviceDomain.RoomId
+		event.Override, err = interlockOverride(r.Context().Value(UserKey).(domain.User), eventRequest.Override)
+		if err != nil {
+			Forbidden(w, err)
+			return
+		}
 
 		createdEvent, err := c.eventService.Save(event)
+		if errors.Is(err, domain.ErrApprovalRequired) {
+			Forbidden(w, err)
+			return
+		}
+		if errors.Is(err, domain.ErrDemandQueued) {
+			Accepted(w, map[string]interface{}{"status": domain.DemandRequestQueued, "message": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrNoStateChange) || errors.Is(err, domain.ErrDemandLimit) || errors.Is(err, domain.ErrInterlock) {
+			Conflict(w, err)
+			return
+		}
 		if err != nil {
 			log.Printf("EventController: %s", err)
 			http.Error(w, "Failed to save event", http.StatusInternalServerError)
@@ -99,49 +122,386 @@ func (c *EventController) FindAll() http.HandlerFunc {
 	}
 }
 
+// GetPowerConsumptionByRoom sums up the consumption of the room in the period, see periodFromQuery.
+// A date as endDate includes that whole day in the timezone of the organization, the body gives
+// the applied start, exclusive end and timezone.
 func (c *EventController) GetPowerConsumptionByRoom() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		roomIDParam := chi.URLParam(r, "roomId")
-		if roomIDParam == "" {
-			log.Printf("EventController: Room ID is empty")
-			http.Error(w, "Room ID is empty", http.StatusBadRequest)
+		room := r.Context().Value(RoKey).(domain.Room)
+
+		loc := organizationLocation(c.organizationService, room.OrganizationId)
+		period, err := periodFromQuery(r, loc)
+		if err != nil {
+			log.Printf("EventController: Error parsing period: %s", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		roomID, err := strconv.ParseUint(roomIDParam, 10, 64)
+		totalPowerConsumption, err := c.eventService.GetPowerConsumptionByRoom(room.Id, period.Start, period.End)
 		if err != nil {
-			log.Printf("EventController: Error parsing room ID: %s", err)
-			http.Error(w, "Invalid room ID", http.StatusBadRequest)
+			log.Printf("EventController: Error calculating power consumption by room: %s", err)
+			http.Error(w, "Failed to calculate power consumption by room", http.StatusInternalServerError)
 			return
 		}
 
-		startDateParam := r.URL.Query().Get("startDate")
-		endDateParam := r.URL.Query().Get("endDate")
+		response := resources.PowerConsumptionDto{}.DomainToDto(totalPowerConsumption, period)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (c *EventController) GetConsumptionForOrg() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if org.UserId != user.Id {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
 
-		startDate, err := time.Parse("2006-01-02", startDateParam)
+		loc := org.Location()
+		period, err := periodFromQuery(r, loc)
 		if err != nil {
-			log.Printf("EventController: Error parsing start date: %s", err)
-			http.Error(w, "Invalid start date", http.StatusBadRequest)
+			BadRequest(w, err)
 			return
 		}
 
-		endDate, err := time.Parse("2006-01-02", endDateParam)
+		report, err := c.eventService.GetConsumptionReport(org.Id, period.Start, period.End)
 		if err != nil {
-			log.Printf("EventController: Error parsing end date: %s", err)
-			http.Error(w, "Invalid end date", http.StatusBadRequest)
+			log.Printf("EventController: Error building consumption report: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.ConsumptionReportDto{}.DomainToDto(report, loc))
+	}
+}
+
+func (c *EventController) GetPowerSeriesForOrg() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if org.UserId != user.Id {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		c.powerSeries(w, r, domain.ConsumptionScope{OrganizationId: org.Id})
+	}
+}
+
+func (c *EventController) GetPowerSeriesForRoom() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		room := r.Context().Value(RoKey).(domain.Room)
+		if !ownsOrganization(c.organizationService, user, room.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		c.powerSeries(w, r, domain.ConsumptionScope{OrganizationId: room.OrganizationId, RoomId: &room.Id})
+	}
+}
+
+func (c *EventController) GetPowerSeriesForDevice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		device := r.Context().Value(DevKey).(domain.Device)
+		if !ownsOrganization(c.organizationService, user, device.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
 			return
 		}
 
-		totalPowerConsumption, err := c.eventService.GetPowerConsumptionByRoom(roomID, startDate, endDate)
+		c.powerSeries(w, r, domain.ConsumptionScope{OrganizationId: device.OrganizationId, DeviceId: &device.Id})
+	}
+}
+
+// powerSeries reads the period and ?interval=hour|day, hour by default.
+func (c *EventController) powerSeries(w http.ResponseWriter, r *http.Request, scope domain.ConsumptionScope) {
+	loc := organizationLocation(c.organizationService, scope.OrganizationId)
+	period, err := periodFromQuery(r, loc)
+	if err != nil {
+		BadRequest(w, err)
+		return
+	}
+
+	size := domain.HourBucket
+	if interval := r.URL.Query().Get("interval"); interval != "" {
+		size = domain.BucketSize(interval)
+	}
+	_, err = period.Split(size)
+	if err != nil {
+		BadRequest(w, err)
+		return
+	}
+
+	series, err := c.eventService.GetPowerSeries(scope, period, size)
+	if err != nil {
+		log.Printf("EventController: Error building power series: %s", err)
+		InternalServerError(w, err)
+		return
+	}
+
+	Success(w, resources.PowerSeriesDto{}.DomainToDto(series, loc))
+}
+
+func (c *EventController) GetEnergyCostForOrg() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if org.UserId != user.Id {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		c.energyCost(w, r, domain.ConsumptionScope{OrganizationId: org.Id})
+	}
+}
+
+func (c *EventController) GetEnergyCostForRoom() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		room := r.Context().Value(RoKey).(domain.Room)
+		if !ownsOrganization(c.organizationService, user, room.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		c.energyCost(w, r, domain.ConsumptionScope{OrganizationId: room.OrganizationId, RoomId: &room.Id})
+	}
+}
+
+func (c *EventController) GetEnergyCostForDevice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		device := r.Context().Value(DevKey).(domain.Device)
+		if !ownsOrganization(c.organizationService, user, device.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		c.energyCost(w, r, domain.ConsumptionScope{OrganizationId: device.OrganizationId, DeviceId: &device.Id})
+	}
+}
+
+// energyCost prices the period by ?tariffId=, the active tariff of the organization by default.
+func (c *EventController) energyCost(w http.ResponseWriter, r *http.Request, scope domain.ConsumptionScope) {
+	loc := organizationLocation(c.organizationService, scope.OrganizationId)
+	period, err := periodFromQuery(r, loc)
+	if err != nil {
+		BadRequest(w, err)
+		return
+	}
+
+	var tariff domain.Tariff
+	if tariffParam := r.URL.Query().Get("tariffId"); tariffParam != "" {
+		id, err := strconv.ParseUint(tariffParam, 10, 64)
 		if err != nil {
-			log.Printf("EventController: Error calculating power consumption by room: %s", err)
-			http.Error(w, "Failed to calculate power consumption by room", http.StatusInternalServerError)
+			BadRequest(w, errors.New("invalid tariffId parameter"))
+			return
+		}
+		t, err := c.tariffService.Find(id)
+		if err != nil || t.(domain.Tariff).OrganizationId != scope.OrganizationId {
+			NotFound(w, errors.New("tariff not found"))
+			return
+		}
+		tariff = t.(domain.Tariff)
+	} else {
+		tariff, err = c.tariffService.FindActive(scope.OrganizationId)
+		if err != nil {
+			NotFound(w, errors.New("organization has no active tariff"))
+			return
+		}
+	}
+
+	cost, err := c.eventService.GetEnergyCost(scope, period, tariff)
+	if err != nil {
+		log.Printf("EventController: Error calculating energy cost: %s", err)
+		InternalServerError(w, err)
+		return
+	}
+
+	Success(w, resources.EnergyCostDto{}.DomainToDto(cost, loc))
+}
+
+func (c *EventController) GetEmissionsForOrg() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if org.UserId != user.Id {
+			Forbidden(w, fmt.Errorf("access denied"))
 			return
 		}
 
-		response := map[string]float64{"total_power_consumption": totalPowerConsumption}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(response)
+		c.emissions(w, r, domain.ConsumptionScope{OrganizationId: org.Id})
+	}
+}
+
+func (c *EventController) GetEmissionsForRoom() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		room := r.Context().Value(RoKey).(domain.Room)
+		if !ownsOrganization(c.organizationService, user, room.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		c.emissions(w, r, domain.ConsumptionScope{OrganizationId: room.OrganizationId, RoomId: &room.Id})
+	}
+}
+
+func (c *EventController) GetEmissionsForDevice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		device := r.Context().Value(DevKey).(domain.Device)
+		if !ownsOrganization(c.organizationService, user, device.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		c.emissions(w, r, domain.ConsumptionScope{OrganizationId: device.OrganizationId, DeviceId: &device.Id})
+	}
+}
+
+// emissions converts the period by ?factorId=, the active emission factor of the organization by default.
+func (c *EventController) emissions(w http.ResponseWriter, r *http.Request, scope domain.ConsumptionScope) {
+	loc := organizationLocation(c.organizationService, scope.OrganizationId)
+	period, err := periodFromQuery(r, loc)
+	if err != nil {
+		BadRequest(w, err)
+		return
+	}
+
+	var factor domain.EmissionFactor
+	if factorParam := r.URL.Query().Get("factorId"); factorParam != "" {
+		id, err := strconv.ParseUint(factorParam, 10, 64)
+		if err != nil {
+			BadRequest(w, errors.New("invalid factorId parameter"))
+			return
+		}
+		f, err := c.factorService.Find(id)
+		if err != nil || f.(domain.EmissionFactor).OrganizationId != scope.OrganizationId {
+			NotFound(w, errors.New("emission factor not found"))
+			return
+		}
+		factor = f.(domain.EmissionFactor)
+	} else {
+		factor, err = c.factorService.FindActive(scope.OrganizationId)
+		if err != nil {
+			NotFound(w, errors.New("organization has no active emission factor"))
+			return
+		}
 	}
+
+	emissions, err := c.eventService.GetEmissions(scope, period, factor)
+	if err != nil {
+		log.Printf("EventController: Error calculating emissions: %s", err)
+		InternalServerError(w, err)
+		return
+	}
+
+	Success(w, resources.EmissionsDto{}.DomainToDto(emissions, loc))
+}
+
+func (c *EventController) GetForecastForOrg() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if org.UserId != user.Id {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		c.forecast(w, r, domain.ConsumptionScope{OrganizationId: org.Id})
+	}
+}
+
+func (c *EventController) GetForecastForRoom() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		room := r.Context().Value(RoKey).(domain.Room)
+		if !ownsOrganization(c.organizationService, user, room.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		c.forecast(w, r, domain.ConsumptionScope{OrganizationId: room.OrganizationId, RoomId: &room.Id})
+	}
+}
+
+func (c *EventController) GetForecastForDevice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		device := r.Context().Value(DevKey).(domain.Device)
+		if !ownsOrganization(c.organizationService, user, device.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		c.forecast(w, r, domain.ConsumptionScope{OrganizationId: device.OrganizationId, DeviceId: &device.Id})
+	}
+}
+
+// forecast reads the period, the next 7 days by default, ?interval=hour|day, day by default,
+// ?model=profile|holt_winters, ?trainingWeeks= and ?confidence=.
+func (c *EventController) forecast(w http.ResponseWriter, r *http.Request, scope domain.ConsumptionScope) {
+	loc := organizationLocation(c.organizationService, scope.OrganizationId)
+	q := r.URL.Query()
+
+	var (
+		period domain.Period
+		err    error
+	)
+	if q.Get("period") == "" && q.Get("startDate") == "" && q.Get("endDate") == "" {
+		now := time.Now().In(loc)
+		period = domain.Period{Start: now, End: now.AddDate(0, 0, 7), Location: loc}
+	} else {
+		period, err = periodFromQuery(r, loc)
+		if err != nil {
+			BadRequest(w, err)
+			return
+		}
+	}
+
+	size := domain.DayBucket
+	if interval := q.Get("interval"); interval != "" {
+		size = domain.BucketSize(interval)
+	}
+
+	opts := domain.ForecastOptions{
+		Model:         domain.ProfileModel,
+		TrainingWeeks: domain.DefaultTrainingWeeks,
+		Confidence:    domain.DefaultConfidence,
+	}
+	if model := q.Get("model"); model != "" {
+		opts.Model = domain.ForecastModel(model)
+	}
+	if weeks := q.Get("trainingWeeks"); weeks != "" {
+		opts.TrainingWeeks, err = strconv.Atoi(weeks)
+		if err != nil {
+			BadRequest(w, errors.New("invalid trainingWeeks parameter"))
+			return
+		}
+	}
+	if confidence := q.Get("confidence"); confidence != "" {
+		opts.Confidence, err = strconv.ParseFloat(confidence, 64)
+		if err != nil {
+			BadRequest(w, errors.New("invalid confidence parameter"))
+			return
+		}
+	}
+
+	forecast, err := c.eventService.GetForecast(scope, period, size, opts)
+	if errors.Is(err, domain.ErrInvalidForecast) {
+		BadRequest(w, err)
+		return
+	}
+	if err != nil {
+		log.Printf("EventController: Error forecasting consumption: %s", err)
+		InternalServerError(w, errors.New("failed to forecast consumption"))
+		return
+	}
+
+	Success(w, resources.ForecastDto{}.DomainToDto(forecast, loc))
 }
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type InterlockController struct {
+	interlockService    app.InterlockService
+	organizationService app.OrganizationService
+}
+
+func NewInterlockController(is app.InterlockService, os app.OrganizationService) InterlockController {
+	return InterlockController{
+		interlockService:    is,
+		organizationService: os,
+	}
+}
+
+func (c InterlockController) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		interlock, err := requests.Bind(r, &requests.InterlockRequest{}, domain.Interlock{})
+		if err != nil {
+			log.Printf("InterlockController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		if interlock.OrganizationId == 0 {
+			BadRequest(w, errors.New("organizationId is required"))
+			return
+		}
+		if !ownsOrganization(c.organizationService, user, interlock.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		interlock.UserId = user.Id
+		interlock, err = c.interlockService.Save(interlock)
+		if err != nil {
+			log.Printf("InterlockController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Created(w, resources.InterlockDto{}.DomainToDto(interlock))
+	}
+}
+
+func (c InterlockController) FindForOrganization() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if org.UserId != user.Id {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		interlocks, err := c.interlockService.FindByOrgId(org.Id)
+		if err != nil {
+			log.Printf("InterlockController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.InterlocksDto{}.DomainToDto(interlocks))
+	}
+}
+
+func (c InterlockController) FindOverrides() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if org.UserId != user.Id && user.Role != domain.AdminRole {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		overrides, err := c.interlockService.FindOverrides(org.Id)
+		if err != nil {
+			log.Printf("InterlockController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.InterlockOverridesDto{}.DomainToDto(overrides))
+	}
+}
+
+func (c InterlockController) Find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		interlock := r.Context().Value(InterlockKey).(domain.Interlock)
+		if !ownsOrganization(c.organizationService, user, interlock.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		Success(w, resources.InterlockDto{}.DomainToDto(interlock))
+	}
+}
+
+func (c InterlockController) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		interlock := r.Context().Value(InterlockKey).(domain.Interlock)
+		if !ownsOrganization(c.organizationService, user, interlock.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		updated, err := requests.Bind(r, &requests.InterlockRequest{}, domain.Interlock{})
+		if err != nil {
+			log.Printf("InterlockController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		interlock.Name = updated.Name
+		interlock.DeviceId = updated.DeviceId
+		interlock.State = updated.State
+		interlock.OtherDeviceId = updated.OtherDeviceId
+		interlock.OtherState = updated.OtherState
+		interlock.Enabled = updated.Enabled
+		interlock, err = c.interlockService.Update(interlock)
+		if err != nil {
+			log.Printf("InterlockController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Success(w, resources.InterlockDto{}.DomainToDto(interlock))
+	}
+}
+
+func (c InterlockController) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		interlock := r.Context().Value(InterlockKey).(domain.Interlock)
+		if !ownsOrganization(c.organizationService, user, interlock.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		err := c.interlockService.Delete(interlock.Id)
+		if err != nil {
+			log.Printf("InterlockController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
+
+// interlockOverride returns the override of the request, only admins may override interlocks.
+func interlockOverride(user domain.User, req *requests.OverrideRequest) (*domain.InterlockOverride, error) {
+	if req == nil {
+		return nil, nil
+	}
+	if user.Role != domain.AdminRole {
+		return nil, errors.New("only admins may override interlocks")
+	}
+	return &domain.InterlockOverride{UserId: user.Id, Reason: req.Reason}, nil
+}
@@ -3,9 +3,10 @@ package controllers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
-	"time"
+	"strings"
 
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
@@ -14,14 +15,16 @@ import (
 )
 
 type MeasurementController struct {
-	MeasurementService app.MeasurementService
-	DeviceService      app.DeviceService
+	MeasurementService  app.MeasurementService
+	DeviceService       app.DeviceService
+	OrganizationService app.OrganizationService
 }
 
-func NewMeasurementController(ms app.MeasurementService, ds app.DeviceService) *MeasurementController {
+func NewMeasurementController(ms app.MeasurementService, ds app.DeviceService, os app.OrganizationService) *MeasurementController {
 	return &MeasurementController{
-		DeviceService:      ds,
-		MeasurementService: ms,
+		DeviceService:       ds,
+		MeasurementService:  ms,
+		OrganizationService: os,
 	}
 }
 
@@ -56,6 +59,27 @@ func (c *MeasurementController) Save() http.HandlerFunc {
 			return
 		}
 
+		readings, err := measurementRequest.Readings()
+		if err != nil {
+			BadRequest(w, err)
+			return
+		}
+		if readings != nil {
+			measurements, err := c.MeasurementService.SaveReadings(deviceDomain.Id, deviceDomain.RoomId, readings)
+			if errors.Is(err, domain.ErrInvalidChannel) {
+				BadRequest(w, err)
+				return
+			}
+			if err != nil {
+				log.Printf("MeasurementController: Error saving readings: %s", err)
+				InternalServerError(w, errors.New("failed to save measurement"))
+				return
+			}
+
+			Created(w, resources.MeasurementsDto{Measurements: resources.MeasurementDto{}.DomainToDtoCollection(measurements)})
+			return
+		}
+
 		measurement, err := measurementRequest.ToDomainModel()
 		if err != nil {
 			log.Printf("MeasurementController: Error converting to domain model: %s", err)
@@ -66,6 +90,10 @@ func (c *MeasurementController) Save() http.HandlerFunc {
 		measurement.RoomId = deviceDomain.RoomId
 
 		createdMeasurement, err := c.MeasurementService.Save(measurement)
+		if errors.Is(err, domain.ErrInvalidChannel) {
+			BadRequest(w, err)
+			return
+		}
 		if err != nil {
 			log.Printf("MeasurementController: Error saving measurement: %s", err)
 			InternalServerError(w, errors.New("failed to save measurement"))
@@ -79,47 +107,115 @@ func (c *MeasurementController) Save() http.HandlerFunc {
 	}
 }
 
+// FindByDeviceAndDate returns the readings of the period, see periodFromQuery. A date as endDate
+// includes that whole day in the timezone of the organization, the body gives the applied start,
+// exclusive end and timezone. ?channel= picks the readings of a channel of a sensor which reports
+// several, ?unit= converts them, e.g. ?unit=degF.
 func (c *MeasurementController) FindByDeviceAndDate() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		device := r.Context().Value(DevKey).(domain.Device)
 
-		startDateStr := r.URL.Query().Get("startDate")
-		if startDateStr == "" {
-			http.Error(w, "Invalid start_date", http.StatusBadRequest)
+		loc := organizationLocation(c.OrganizationService, device.OrganizationId)
+		period, err := periodFromQuery(r, loc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		unit, err := unitFromQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		startDate, err := time.Parse("2006-01-02", startDateStr)
+		measurements, err := c.MeasurementService.FindByDeviceAndDate(device.Id, period.Start, period.End)
 		if err != nil {
-			http.Error(w, "Invalid start_date", http.StatusBadRequest)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		if key := r.URL.Query().Get("channel"); key != "" {
+			channels, err := c.DeviceService.FindChannels(device)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			device.Channels = channels
+			ch, ok := device.Channel(key)
+			if !ok {
+				http.Error(w, fmt.Sprintf("device has no channel %q", key), http.StatusBadRequest)
+				return
+			}
+			var readings []domain.Measurement
+			for _, m := range measurements {
+				if m.ChannelId != nil && *m.ChannelId == ch.Id {
+					readings = append(readings, m)
+				}
+			}
+			measurements = readings
+		}
+		if unit != nil {
+			measurements, err = c.MeasurementService.ConvertTo(device, measurements, *unit)
+			if errors.Is(err, domain.ErrIncompatibleUnits) || errors.Is(err, domain.ErrInvalidUnits) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("X-Unit", unit.Code)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Timezone", loc.String())
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(resources.MeasurementPeriodDto{}.DomainToDto(period, measurements)); err != nil {
+			log.Printf("Error encoding response: %v", err)
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+	}
+}
 
-		endDateStr := r.URL.Query().Get("endDate")
-		log.Printf("Received endDateStr: %s", endDateStr)
-		if endDateStr == "" {
-			http.Error(w, "Invalid end_date", http.StatusBadRequest)
+// Summarize sums up the usable readings of a sensor per ?interval=hour|day, hour by default.
+// ?channels=temperature,humidity picks the channels of a sensor which reports several, all of
+// them by default, ?unit= converts the statistics.
+func (c *MeasurementController) Summarize() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		device := r.Context().Value(DevKey).(domain.Device)
+		if !ownsOrganization(c.OrganizationService, user, device.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
 			return
 		}
 
-		endDate, err := time.Parse("2006-01-02", endDateStr)
+		loc := organizationLocation(c.OrganizationService, device.OrganizationId)
+		period, err := periodFromQuery(r, loc)
 		if err != nil {
-			http.Error(w, "Invalid end_date", http.StatusBadRequest)
+			BadRequest(w, err)
 			return
 		}
-
-		measurements, err := c.MeasurementService.FindByDeviceAndDate(device.Id, startDate, endDate)
+		size := domain.HourBucket
+		if interval := r.URL.Query().Get("interval"); interval != "" {
+			size = domain.BucketSize(interval)
+		}
+		unit, err := unitFromQuery(r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			BadRequest(w, err)
 			return
 		}
+		var channels []string
+		if keys := r.URL.Query().Get("channels"); keys != "" {
+			for _, key := range strings.Split(keys, ",") {
+				channels = append(channels, strings.TrimSpace(key))
+			}
+		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(measurements); err != nil {
-			log.Printf("Error encoding response: %v", err)
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		summaries, err := c.MeasurementService.Summarize(device, channels, period, size, unit)
+		if err != nil {
+			log.Printf("MeasurementController: Error summarizing measurements: %s", err)
+			BadRequest(w, err)
+			return
 		}
+
+		Success(w, resources.MeasurementSummaryDto{}.DomainToDto(device.Id, period, size, summaries))
 	}
 }
 
@@ -153,3 +249,16 @@ func (c MeasurementController) FindAll() http.HandlerFunc {
 		json.NewEncoder(w).Encode(measurementDtos)
 	}
 }
+
+// unitFromQuery reads the unit readings are converted into from ?unit=, nil without one.
+func unitFromQuery(r *http.Request) (*domain.Unit, error) {
+	code := r.URL.Query().Get("unit")
+	if code == "" {
+		return nil, nil
+	}
+	unit, err := domain.ParseUnit(code)
+	if err != nil {
+		return nil, err
+	}
+	return &unit, nil
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type NotificationController struct {
+	notificationService app.NotificationService
+}
+
+func NewNotificationController(ns app.NotificationService) NotificationController {
+	return NotificationController{
+		notificationService: ns,
+	}
+}
+
+// FindForUser returns the latest notifications of the user, only the unread ones with ?unread=true.
+func (c NotificationController) FindForUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		unread := r.URL.Query().Get("unread") == "true"
+
+		notifications, err := c.notificationService.FindForUser(user.Id, unread)
+		if err != nil {
+			log.Printf("NotificationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.NotificationsDto{}.DomainToDto(notifications))
+	}
+}
+
+func (c NotificationController) MarkRead() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		notification := r.Context().Value(NotificationKey).(domain.Notification)
+		if notification.UserId != user.Id {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		notification, err := c.notificationService.MarkRead(notification)
+		if err != nil {
+			log.Printf("NotificationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.NotificationDto{}.DomainToDto(notification))
+	}
+}
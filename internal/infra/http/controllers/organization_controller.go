@@ -1,14 +1,23 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultSolarDays = 7
+	maxSolarDays     = 31
 )
 
 type OrganizationController struct {
@@ -98,6 +107,7 @@ func (c OrganizationController) Update() http.HandlerFunc {
 		organization.City = org.City
 		organization.Lat = org.Lat
 		organization.Lon = org.Lon
+		organization.Timezone = org.Timezone
 		organization, err = c.organizationService.Update(organization)
 		if err != nil {
 			log.Printf("OrganizationController: %s", err)
@@ -131,3 +141,118 @@ func (c OrganizationController) Delete() http.HandlerFunc {
 		Ok(w)
 	}
 }
+
+func (c OrganizationController) SaveMember() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+
+		if org.UserId != user.Id {
+			err := fmt.Errorf("access denied")
+			Forbidden(w, err)
+			return
+		}
+
+		member, err := requests.Bind(r, &requests.MemberRequest{}, domain.OrganizationMember{})
+		if err != nil {
+			log.Printf("OrganizationController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		member, err = c.organizationService.SaveMember(org, member.Email, member.Role)
+		if err != nil {
+			log.Printf("OrganizationController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Success(w, resources.MemberDto{}.DomainToDto(member))
+	}
+}
+
+func (c OrganizationController) FindMembers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+
+		_, ok, err := c.organizationService.MemberRole(org.Id, user.Id)
+		if err != nil {
+			log.Printf("OrganizationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+		if !ok {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		members, err := c.organizationService.FindMembers(org.Id)
+		if err != nil {
+			log.Printf("OrganizationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.MembersDto{}.DomainToDto(members))
+	}
+}
+
+func (c OrganizationController) DeleteMember() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+
+		if org.UserId != user.Id {
+			err := fmt.Errorf("access denied")
+			Forbidden(w, err)
+			return
+		}
+
+		userId, err := strconv.ParseUint(chi.URLParam(r, "userId"), 10, 64)
+		if err != nil {
+			BadRequest(w, errors.New("invalid userId"))
+			return
+		}
+
+		err = c.organizationService.DeleteMember(org.Id, userId)
+		if err != nil {
+			log.Printf("OrganizationController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
+
+// Solar returns the upcoming sunrise, sunset and civil twilight at the organization, ?days=<n>.
+func (c OrganizationController) Solar() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+
+		if org.UserId != user.Id {
+			err := fmt.Errorf("access denied")
+			Forbidden(w, err)
+			return
+		}
+
+		days := defaultSolarDays
+		if daysParam := r.URL.Query().Get("days"); daysParam != "" {
+			n, err := strconv.Atoi(daysParam)
+			if err != nil || n < 1 || n > maxSolarDays {
+				BadRequest(w, fmt.Errorf("days must be between 1 and %d", maxSolarDays))
+				return
+			}
+			days = n
+		}
+		if org.Lat == 0 && org.Lon == 0 {
+			BadRequest(w, errors.New("organization has no coordinates"))
+			return
+		}
+
+		times := c.organizationService.SolarTimes(org, time.Now(), days)
+		Success(w, resources.SolarDto{}.DomainToDto(org, times))
+	}
+}
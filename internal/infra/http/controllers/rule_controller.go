@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type RuleController struct {
+	automationService   app.AutomationService
+	organizationService app.OrganizationService
+}
+
+func NewRuleController(as app.AutomationService, os app.OrganizationService) RuleController {
+	return RuleController{
+		automationService:   as,
+		organizationService: os,
+	}
+}
+
+func (c RuleController) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		rule, err := requests.Bind(r, &requests.RuleRequest{}, domain.Rule{})
+		if err != nil {
+			log.Printf("RuleController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		if rule.OrganizationId == 0 {
+			BadRequest(w, errors.New("organizationId is required"))
+			return
+		}
+		if !ownsOrganization(c.organizationService, user, rule.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		rule.UserId = user.Id
+		rule, err = c.automationService.Save(rule)
+		if err != nil {
+			log.Printf("RuleController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Created(w, resources.RuleDto{}.DomainToDto(rule))
+	}
+}
+
+func (c RuleController) FindForOrganization() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if org.UserId != user.Id {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		rules, err := c.automationService.FindByOrgId(org.Id)
+		if err != nil {
+			log.Printf("RuleController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.RulesDto{}.DomainToDto(rules))
+	}
+}
+
+func (c RuleController) Find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		rule := r.Context().Value(RuleKey).(domain.Rule)
+		if !ownsOrganization(c.organizationService, user, rule.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		Success(w, resources.RuleDto{}.DomainToDto(rule))
+	}
+}
+
+func (c RuleController) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		rule := r.Context().Value(RuleKey).(domain.Rule)
+		if !ownsOrganization(c.organizationService, user, rule.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		updated, err := requests.Bind(r, &requests.RuleRequest{}, domain.Rule{})
+		if err != nil {
+			log.Printf("RuleController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		rule.Name = updated.Name
+		rule.Enabled = updated.Enabled
+		rule.DryRun = updated.DryRun
+		rule.Trigger = updated.Trigger
+		rule.Conditions = updated.Conditions
+		rule.Actions = updated.Actions
+		rule.CooldownSeconds = updated.CooldownSeconds
+		rule, err = c.automationService.Update(rule)
+		if err != nil {
+			log.Printf("RuleController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Success(w, resources.RuleDto{}.DomainToDto(rule))
+	}
+}
+
+func (c RuleController) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		rule := r.Context().Value(RuleKey).(domain.Rule)
+		if !ownsOrganization(c.organizationService, user, rule.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		err := c.automationService.Delete(rule.Id)
+		if err != nil {
+			log.Printf("RuleController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
+
+func (c RuleController) FindExecutions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		rule := r.Context().Value(RuleKey).(domain.Rule)
+		if !ownsOrganization(c.organizationService, user, rule.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		execs, err := c.automationService.FindExecutions(rule.Id)
+		if err != nil {
+			log.Printf("RuleController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.RuleExecutionsDto{}.DomainToDto(execs))
+	}
+}
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type ScheduleController struct {
+	scheduleService     app.ScheduleService
+	organizationService app.OrganizationService
+}
+
+func NewScheduleController(ss app.ScheduleService, os app.OrganizationService) ScheduleController {
+	return ScheduleController{
+		scheduleService:     ss,
+		organizationService: os,
+	}
+}
+
+func (c ScheduleController) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		schedule, err := requests.Bind(r, &requests.ScheduleRequest{}, domain.Schedule{})
+		if err != nil {
+			log.Printf("ScheduleController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		if schedule.OrganizationId == 0 {
+			BadRequest(w, errors.New("organizationId is required"))
+			return
+		}
+		if !ownsOrganization(c.organizationService, user, schedule.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		schedule.UserId = user.Id
+		schedule, err = c.scheduleService.Save(schedule)
+		if err != nil {
+			log.Printf("ScheduleController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Created(w, resources.ScheduleDto{}.DomainToDto(schedule))
+	}
+}
+
+func (c ScheduleController) FindForOrganization() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if org.UserId != user.Id {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		schedules, err := c.scheduleService.FindByOrgId(org.Id)
+		if err != nil {
+			log.Printf("ScheduleController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.SchedulesDto{}.DomainToDto(schedules))
+	}
+}
+
+func (c ScheduleController) Find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		schedule := r.Context().Value(ScheduleKey).(domain.Schedule)
+		if !ownsOrganization(c.organizationService, user, schedule.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		Success(w, resources.ScheduleDto{}.DomainToDto(schedule))
+	}
+}
+
+func (c ScheduleController) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		schedule := r.Context().Value(ScheduleKey).(domain.Schedule)
+		if !ownsOrganization(c.organizationService, user, schedule.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		updated, err := requests.Bind(r, &requests.ScheduleRequest{}, domain.Schedule{})
+		if err != nil {
+			log.Printf("ScheduleController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		schedule.Name = updated.Name
+		schedule.Enabled = updated.Enabled
+		schedule.Blocks = updated.Blocks
+		schedule.Exceptions = updated.Exceptions
+		schedule, err = c.scheduleService.Update(schedule)
+		if err != nil {
+			log.Printf("ScheduleController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Success(w, resources.ScheduleDto{}.DomainToDto(schedule))
+	}
+}
+
+func (c ScheduleController) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		schedule := r.Context().Value(ScheduleKey).(domain.Schedule)
+		if !ownsOrganization(c.organizationService, user, schedule.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		err := c.scheduleService.Delete(schedule.Id)
+		if err != nil {
+			log.Printf("ScheduleController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/pubsub"
+	"golang.org/x/net/websocket"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+type StreamController struct {
+	hub pubsub.Hub
+}
+
+func NewStreamController(h pubsub.Hub) StreamController {
+	return StreamController{
+		hub: h,
+	}
+}
+
+func (c StreamController) Organization() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+
+		if org.UserId != user.Id {
+			err := fmt.Errorf("access denied")
+			Forbidden(w, err)
+			return
+		}
+
+		c.serve(w, r, pubsub.Filter{OrganizationId: &org.Id})
+	}
+}
+
+func (c StreamController) Room() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		room := r.Context().Value(RoKey).(domain.Room)
+		c.serve(w, r, pubsub.Filter{RoomId: &room.Id})
+	}
+}
+
+func (c StreamController) Device() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		device := r.Context().Value(DevKey).(domain.Device)
+		c.serve(w, r, pubsub.Filter{DeviceId: &device.Id})
+	}
+}
+
+func (c StreamController) serve(w http.ResponseWriter, r *http.Request, f pubsub.Filter) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		c.serveWebSocket(w, r, f)
+		return
+	}
+	c.serveSSE(w, r, f)
+}
+
+func (c StreamController) serveSSE(w http.ResponseWriter, r *http.Request, f pubsub.Filter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		InternalServerError(w, errors.New("streaming is not supported"))
+		return
+	}
+
+	sub := c.hub.Subscribe(f)
+	defer c.hub.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(w, ": ping\n\n")
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		case m, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			body, err := json.Marshal(resources.StreamMessageDto{}.DomainToDto(m))
+			if err != nil {
+				log.Printf("StreamController: %s", err)
+				continue
+			}
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", m.Type, body)
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (c StreamController) serveWebSocket(w http.ResponseWriter, r *http.Request, f pubsub.Filter) {
+	websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		sub := c.hub.Subscribe(f)
+		defer c.hub.Unsubscribe(sub)
+
+		// Clients are not expected to send anything, reading only detects a closed connection.
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			var ignored string
+			for websocket.Message.Receive(ws, &ignored) == nil {
+			}
+		}()
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				err := websocket.JSON.Send(ws, map[string]string{"type": "ping"})
+				if err != nil {
+					return
+				}
+			case m, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				err := websocket.JSON.Send(ws, resources.StreamMessageDto{}.DomainToDto(m))
+				if err != nil {
+					log.Printf("StreamController: %s", err)
+					return
+				}
+			}
+		}
+	}}.ServeHTTP(w, r)
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type TariffController struct {
+	tariffService       app.TariffService
+	organizationService app.OrganizationService
+}
+
+func NewTariffController(ts app.TariffService, os app.OrganizationService) TariffController {
+	return TariffController{
+		tariffService:       ts,
+		organizationService: os,
+	}
+}
+
+func (c TariffController) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		tariff, err := requests.Bind(r, &requests.TariffRequest{}, domain.Tariff{})
+		if err != nil {
+			log.Printf("TariffController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		if tariff.OrganizationId == 0 {
+			BadRequest(w, errors.New("organizationId is required"))
+			return
+		}
+		if !ownsOrganization(c.organizationService, user, tariff.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		tariff.UserId = user.Id
+		tariff, err = c.tariffService.Save(tariff)
+		if err != nil {
+			log.Printf("TariffController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Created(w, resources.TariffDto{}.DomainToDto(tariff))
+	}
+}
+
+func (c TariffController) FindForOrganization() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		org := r.Context().Value(OrgKey).(domain.Organization)
+		if org.UserId != user.Id {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		tariffs, err := c.tariffService.FindByOrgId(org.Id)
+		if err != nil {
+			log.Printf("TariffController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.TariffsDto{}.DomainToDto(tariffs))
+	}
+}
+
+func (c TariffController) Find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		tariff := r.Context().Value(TariffKey).(domain.Tariff)
+		if !ownsOrganization(c.organizationService, user, tariff.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		Success(w, resources.TariffDto{}.DomainToDto(tariff))
+	}
+}
+
+func (c TariffController) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		tariff := r.Context().Value(TariffKey).(domain.Tariff)
+		if !ownsOrganization(c.organizationService, user, tariff.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		updated, err := requests.Bind(r, &requests.TariffRequest{}, domain.Tariff{})
+		if err != nil {
+			log.Printf("TariffController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		tariff.Name = updated.Name
+		tariff.Currency = updated.Currency
+		tariff.Type = updated.Type
+		tariff.Active = updated.Active
+		tariff.Rate = updated.Rate
+		tariff.Windows = updated.Windows
+		tariff.Tiers = updated.Tiers
+		tariff.Seasons = updated.Seasons
+		tariff, err = c.tariffService.Update(tariff)
+		if err != nil {
+			log.Printf("TariffController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		Success(w, resources.TariffDto{}.DomainToDto(tariff))
+	}
+}
+
+func (c TariffController) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		tariff := r.Context().Value(TariffKey).(domain.Tariff)
+		if !ownsOrganization(c.organizationService, user, tariff.OrganizationId) {
+			Forbidden(w, fmt.Errorf("access denied"))
+			return
+		}
+
+		err := c.tariffService.Delete(tariff.Id)
+		if err != nil {
+			log.Printf("TariffController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Ok(w)
+	}
+}
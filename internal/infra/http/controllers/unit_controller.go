@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type UnitController struct{}
+
+func NewUnitController() UnitController {
+	return UnitController{}
+}
+
+// FindAll lists the units devices may report in, grouped by the quantity kind they measure.
+func (c UnitController) FindAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		Success(w, resources.UnitsDto{}.DomainToDto(domain.UnitRegistry()))
+	}
+}
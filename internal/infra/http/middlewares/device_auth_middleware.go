@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/controllers"
+)
+
+// DeviceAuthMiddleware authenticates devices with HTTP basic auth, the GUID being the username
+// and the device secret the password.
+func DeviceAuthMiddleware(ds app.DeviceService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		hfn := func(w http.ResponseWriter, r *http.Request) {
+			guid, secret, ok := r.BasicAuth()
+			if !ok {
+				controllers.Unauthorized(w, errors.New("device credentials are required"))
+				return
+			}
+
+			device, err := ds.Authenticate(guid, secret)
+			if err != nil {
+				controllers.Unauthorized(w, err)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), controllers.DevKey, device)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(hfn)
+	}
+}
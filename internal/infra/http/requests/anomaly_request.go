@@ -0,0 +1,52 @@
+package requests
+
+import "github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+
+// AnomalySettingsRequest replaces the anomaly settings of a sensor, missing fields take the defaults.
+type AnomalySettingsRequest struct {
+	Enabled        *bool    `json:"enabled"`
+	Window         *int     `json:"window"`
+	ZThreshold     *float64 `json:"zThreshold"`
+	EwmaAlpha      *float64 `json:"ewmaAlpha"`
+	BaselineAlpha  *float64 `json:"baselineAlpha"`
+	DriftThreshold *float64 `json:"driftThreshold"`
+	StuckCount     *int     `json:"stuckCount"`
+	FlatlineCount  *int     `json:"flatlineCount"`
+	FlatlineRatio  *float64 `json:"flatlineRatio"`
+	Notify         *bool    `json:"notify"`
+}
+
+func (r AnomalySettingsRequest) ToDomainModel() (interface{}, error) {
+	s := domain.DefaultAnomalySettings(0, 0)
+	if r.Enabled != nil {
+		s.Enabled = *r.Enabled
+	}
+	if r.Window != nil {
+		s.Window = *r.Window
+	}
+	if r.ZThreshold != nil {
+		s.ZThreshold = *r.ZThreshold
+	}
+	if r.EwmaAlpha != nil {
+		s.EwmaAlpha = *r.EwmaAlpha
+	}
+	if r.BaselineAlpha != nil {
+		s.BaselineAlpha = *r.BaselineAlpha
+	}
+	if r.DriftThreshold != nil {
+		s.DriftThreshold = *r.DriftThreshold
+	}
+	if r.StuckCount != nil {
+		s.StuckCount = *r.StuckCount
+	}
+	if r.FlatlineCount != nil {
+		s.FlatlineCount = *r.FlatlineCount
+	}
+	if r.FlatlineRatio != nil {
+		s.FlatlineRatio = *r.FlatlineRatio
+	}
+	if r.Notify != nil {
+		s.Notify = *r.Notify
+	}
+	return s, nil
+}
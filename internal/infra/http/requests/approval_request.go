@@ -0,0 +1,9 @@
+package requests
+
+type ApprovalDecisionRequest struct {
+	Comment *string `json:"comment"`
+}
+
+func (r ApprovalDecisionRequest) ToDomainModel() (interface{}, error) {
+	return r.Comment, nil
+}
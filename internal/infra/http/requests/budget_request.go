@@ -0,0 +1,44 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type BudgetRequest struct {
+	OrganizationId    uint64    `json:"organizationId"`
+	RoomId            *uint64   `json:"roomId"`
+	Name              string    `json:"name" validate:"required"`
+	Metric            string    `json:"metric" validate:"required"`
+	Amount            float64   `json:"amount" validate:"gt=0"`
+	Thresholds        []float64 `json:"thresholds"`
+	AlertOnProjection *bool     `json:"alertOnProjection"`
+	Enabled           *bool     `json:"enabled"`
+}
+
+func (r BudgetRequest) ToDomainModel() (interface{}, error) {
+	thresholds := r.Thresholds
+	if thresholds == nil {
+		thresholds = domain.DefaultBudgetThresholds
+	}
+	projection := true
+	if r.AlertOnProjection != nil {
+		projection = *r.AlertOnProjection
+	}
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+
+	return domain.Budget{
+		OrganizationId:    r.OrganizationId,
+		RoomId:            r.RoomId,
+		Name:              r.Name,
+		Metric:            domain.BudgetMetric(strings.ToUpper(r.Metric)),
+		Amount:            r.Amount,
+		Thresholds:        thresholds,
+		AlertOnProjection: projection,
+		Enabled:           enabled,
+	}, nil
+}
@@ -0,0 +1,43 @@
+package requests
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type CalibrationRequest struct {
+	// ChannelId is the channel of a sensor reporting several quantities
+	ChannelId    *uint64   `json:"channelId"`
+	Offset       *float64  `json:"offset"`
+	Gain         *float64  `json:"gain"`
+	Coefficients []float64 `json:"coefficients"`
+	// EffectiveFrom is an RFC3339 timestamp, now by default
+	EffectiveFrom string  `json:"effectiveFrom"`
+	Note          *string `json:"note"`
+}
+
+func (r CalibrationRequest) ToDomainModel() (interface{}, error) {
+	c := domain.Calibration{
+		ChannelId:     r.ChannelId,
+		Gain:          1,
+		Coefficients:  r.Coefficients,
+		EffectiveFrom: time.Now(),
+		Note:          r.Note,
+	}
+	if r.Offset != nil {
+		c.Offset = *r.Offset
+	}
+	if r.Gain != nil {
+		c.Gain = *r.Gain
+	}
+	if r.EffectiveFrom != "" {
+		from, err := time.Parse(time.RFC3339, r.EffectiveFrom)
+		if err != nil {
+			return nil, errors.New("effectiveFrom must be an RFC3339 timestamp")
+		}
+		c.EffectiveFrom = from
+	}
+	return c, nil
+}
@@ -0,0 +1,23 @@
+package requests
+
+import "github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+
+type ChannelRequest struct {
+	Key      string   `json:"key"`
+	Quantity string   `json:"quantity" validate:"required"`
+	Units    *string  `json:"units" validate:"required"`
+	ValidMin *float64 `json:"validMin"`
+	ValidMax *float64 `json:"validMax"`
+	MaxRate  *float64 `json:"maxRate"`
+}
+
+func (r ChannelRequest) ToDomainModel() (interface{}, error) {
+	return domain.DeviceChannel{
+		Key:      r.Key,
+		Quantity: r.Quantity,
+		Units:    r.Units,
+		ValidMin: r.ValidMin,
+		ValidMax: r.ValidMax,
+		MaxRate:  r.MaxRate,
+	}, nil
+}
@@ -0,0 +1,31 @@
+package requests
+
+import (
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type CommandRequest struct {
+	DeviceId   uint64 `json:"device_id" validate:"required"`
+	Action     string `json:"action" validate:"required"`
+	TtlSeconds uint64 `json:"ttl_seconds"`
+	ActionParamsRequest
+	Override *OverrideRequest `json:"override"`
+}
+
+type CommandAckRequest struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+}
+
+func (r CommandRequest) ToDomainModel() (interface{}, error) {
+	action, err := domain.ParseEventAction(r.Action)
+	if err != nil {
+		return domain.Command{}, err
+	}
+
+	return domain.Command{
+		DeviceId:     r.DeviceId,
+		Action:       action,
+		ActionParams: r.ActionParamsRequest.ToDomainModel(action),
+	}, nil
+}
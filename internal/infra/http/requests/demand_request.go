@@ -0,0 +1,40 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type DevicePriorityRequest struct {
+	DeviceId uint64 `json:"deviceId" validate:"required"`
+	Class    string `json:"class" validate:"required"`
+}
+
+type DemandPolicyRequest struct {
+	MaxKw      float64                 `json:"maxKw" validate:"gt=0"`
+	Strategy   string                  `json:"strategy" validate:"required"`
+	Enabled    *bool                   `json:"enabled"`
+	Priorities []DevicePriorityRequest `json:"priorities" validate:"dive"`
+}
+
+func (r DemandPolicyRequest) ToDomainModel() (interface{}, error) {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	priorities := make([]domain.DevicePriority, len(r.Priorities))
+	for i, p := range r.Priorities {
+		priorities[i] = domain.DevicePriority{
+			DeviceId: p.DeviceId,
+			Class:    domain.DemandClass(strings.ToUpper(p.Class)),
+		}
+	}
+
+	return domain.DemandPolicy{
+		MaxKw:      r.MaxKw,
+		Strategy:   domain.DemandStrategy(strings.ToUpper(r.Strategy)),
+		Enabled:    enabled,
+		Priorities: priorities,
+	}, nil
+}
@@ -7,14 +7,33 @@ import (
 )
 
 type DeviceRequest struct {
-	OrganizationId   uint64   `json:"organizationId"`
-	RoomId           *uint64  `json:"room_id"`
-	InventoryNumber  string   `json:"inventoryNumber"`
-	SerialNumber     string   `json:"serialNumber"`
-	Characteristics  string   `json:"characteristics" validate:"required"`
-	PowerConsumption *float64 `json:"power_consumption" validate:"omitempty"`
-	Units            *string  `json:"units" validate:"omitempty"`
-	Category         string   `json:"category" validate:"required"`
+	OrganizationId   uint64               `json:"organizationId"`
+	RoomId           *uint64              `json:"room_id"`
+	InventoryNumber  string               `json:"inventoryNumber"`
+	SerialNumber     string               `json:"serialNumber"`
+	Characteristics  string               `json:"characteristics" validate:"required"`
+	PowerConsumption *float64             `json:"power_consumption" validate:"omitempty"`
+	Units            *string              `json:"units" validate:"omitempty"`
+	Category         string               `json:"category" validate:"required"`
+	Capabilities     *CapabilitiesRequest `json:"capabilities"`
+	MeteredDeviceId  *uint64              `json:"meteredDeviceId"`
+	CounterMax       *float64             `json:"counterMax"`
+	Critical         *bool                `json:"critical"`
+	ValidMin         *float64             `json:"validMin"`
+	ValidMax         *float64             `json:"validMax"`
+	MaxRate          *float64             `json:"maxRate"`
+}
+
+type CapabilitiesRequest struct {
+	Level    bool             `json:"level"`
+	Setpoint *SetpointRequest `json:"setpoint"`
+	Modes    []string         `json:"modes"`
+}
+
+type SetpointRequest struct {
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Unit string  `json:"unit"`
 }
 
 func (r DeviceRequest) ToDomainModel() (domain.Device, error) {
@@ -30,6 +49,22 @@ func (r DeviceRequest) ToDomainModel() (domain.Device, error) {
 		return domain.Device{}, err
 	}
 
+	var capabilities domain.DeviceCapabilities
+	if r.Capabilities != nil {
+		if category != domain.Actuator {
+			return domain.Device{}, errors.New("only actuators have capabilities")
+		}
+		capabilities, err = r.Capabilities.ToDomainModel()
+		if err != nil {
+			return domain.Device{}, err
+		}
+	}
+
+	critical := r.Critical != nil && *r.Critical
+	if critical && category != domain.Actuator {
+		return domain.Device{}, errors.New("only actuators can be critical")
+	}
+
 	return domain.Device{
 		OrganizationId:   r.OrganizationId,
 		RoomId:           r.RoomId,
@@ -39,5 +74,33 @@ func (r DeviceRequest) ToDomainModel() (domain.Device, error) {
 		PowerConsumption: r.PowerConsumption,
 		Units:            r.Units,
 		Category:         category,
+		Capabilities:     capabilities,
+		MeteredDeviceId:  r.MeteredDeviceId,
+		CounterMax:       r.CounterMax,
+		Critical:         critical,
+		ValidMin:         r.ValidMin,
+		ValidMax:         r.ValidMax,
+		MaxRate:          r.MaxRate,
 	}, nil
 }
+
+func (r CapabilitiesRequest) ToDomainModel() (domain.DeviceCapabilities, error) {
+	c := domain.DeviceCapabilities{
+		Level: r.Level,
+		Modes: r.Modes,
+	}
+	if r.Setpoint != nil {
+		if r.Setpoint.Min > r.Setpoint.Max {
+			return domain.DeviceCapabilities{}, errors.New("setpoint min must not be greater than max")
+		}
+		if r.Setpoint.Unit == "" {
+			return domain.DeviceCapabilities{}, errors.New("setpoint unit is required")
+		}
+		c.Setpoint = &domain.SetpointRange{
+			Min:  r.Setpoint.Min,
+			Max:  r.Setpoint.Max,
+			Unit: r.Setpoint.Unit,
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,38 @@
+package requests
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type TwinStateRequest struct {
+	Power    *string  `json:"power"`
+	Level    *float64 `json:"level" validate:"omitempty,min=0,max=100"`
+	Setpoint *float64 `json:"setpoint"`
+	Mode     *string  `json:"mode"`
+	Version  *uint64  `json:"version"`
+}
+
+func (r TwinStateRequest) ToDomainModel() (interface{}, error) {
+	state := domain.TwinState{
+		Level:    r.Level,
+		Setpoint: r.Setpoint,
+		Mode:     r.Mode,
+	}
+	if r.Power != nil {
+		var power domain.EventAction
+		switch strings.ToUpper(*r.Power) {
+		case "ON":
+			power = domain.TurnOn
+		case "OFF":
+			power = domain.TurnOff
+		default:
+			return domain.TwinState{}, errors.New("invalid power state")
+		}
+		state.Power = &power
+	}
+
+	return state, nil
+}
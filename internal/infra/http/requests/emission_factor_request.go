@@ -0,0 +1,25 @@
+package requests
+
+import (
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type EmissionFactorRequest struct {
+	OrganizationId uint64                       `json:"organizationId"`
+	Name           string                       `json:"name" validate:"required"`
+	Source         string                       `json:"source"`
+	Active         bool                         `json:"active"`
+	Factor         float64                      `json:"factor" validate:"gte=0"`
+	Entries        []domain.EmissionFactorEntry `json:"entries"`
+}
+
+func (r EmissionFactorRequest) ToDomainModel() (interface{}, error) {
+	return domain.EmissionFactor{
+		OrganizationId: r.OrganizationId,
+		Name:           r.Name,
+		Source:         r.Source,
+		Active:         r.Active,
+		Factor:         r.Factor,
+		Entries:        r.Entries,
+	}, nil
+}
@@ -1,7 +1,6 @@
 package requests
 
 import (
-	"errors"
 	"time"
 
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
@@ -10,25 +9,41 @@ import (
 type EventRequest struct {
 	DeviceId uint64  `json:"device_id"`
 	RoomId   *uint64 `json:"room_id"`
-	Action   string  `json:"action" validate:"required,oneof='ON' 'OFF'"`
+	Action   string  `json:"action" validate:"required,oneof='ON' 'OFF' 'SET_LEVEL' 'SET_SETPOINT' 'SET_MODE'"`
+	ActionParamsRequest
+	Override *OverrideRequest `json:"override"`
+}
+
+// ActionParamsRequest holds the arguments of SET_LEVEL, SET_SETPOINT and SET_MODE.
+type ActionParamsRequest struct {
+	Level    *float64 `json:"level" validate:"omitempty,min=0,max=100"`
+	Setpoint *float64 `json:"setpoint"`
+	Unit     *string  `json:"unit"`
+	Mode     *string  `json:"mode"`
 }
 
 func (r EventRequest) ToDomainModel() (domain.Event, error) {
-	var action domain.EventAction
-	switch r.Action {
-	case "ON":
-		action = domain.TurnOn
-	case "OFF":
-		action = domain.TurnOff
-	default:
-		return domain.Event{}, errors.New("invalid action")
+	action, err := domain.ParseEventAction(r.Action)
+	if err != nil {
+		return domain.Event{}, err
 	}
 
 	return domain.Event{
-		DeviceId:    r.DeviceId,
-		RoomId:      r.RoomId,
-		Action:      action,
-		CreatedDate: time.Now(),
-		UpdatedDate: time.Now(),
+		DeviceId:     r.DeviceId,
+		RoomId:       r.RoomId,
+		Action:       action,
+		ActionParams: r.ActionParamsRequest.ToDomainModel(action),
+		CreatedDate:  time.Now(),
+		UpdatedDate:  time.Now(),
 	}, nil
 }
+
+// ToDomainModel keeps only the parameters the action uses.
+func (r ActionParamsRequest) ToDomainModel(action domain.EventAction) domain.ActionParams {
+	return domain.ActionParams{
+		Level:        r.Level,
+		Setpoint:     r.Setpoint,
+		SetpointUnit: r.Unit,
+		Mode:         r.Mode,
+	}.For(action)
+}
@@ -0,0 +1,39 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type InterlockRequest struct {
+	OrganizationId uint64 `json:"organizationId"`
+	Name           string `json:"name" validate:"required"`
+	DeviceId       uint64 `json:"deviceId" validate:"required"`
+	State          string `json:"state" validate:"required"`
+	OtherDeviceId  uint64 `json:"otherDeviceId" validate:"required"`
+	OtherState     string `json:"otherState" validate:"required"`
+	Enabled        *bool  `json:"enabled"`
+}
+
+// OverrideRequest lets an admin through the interlocks of an event or a command.
+type OverrideRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+func (r InterlockRequest) ToDomainModel() (interface{}, error) {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+
+	return domain.Interlock{
+		OrganizationId: r.OrganizationId,
+		Name:           r.Name,
+		DeviceId:       r.DeviceId,
+		State:          domain.EventAction(strings.ToUpper(r.State)),
+		OtherDeviceId:  r.OtherDeviceId,
+		OtherState:     domain.EventAction(strings.ToUpper(r.OtherState)),
+		Enabled:        enabled,
+	}, nil
+}
@@ -2,28 +2,34 @@ package requests
 
 import (
 	"errors"
+	"sort"
 	"time"
 
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 )
 
+// MeasurementRequest is a single reading in Value, a reading of one channel of a sensor in Channel
+// and Value, or the readings of several channels taken at once in Values.
 type MeasurementRequest struct {
-	DeviceId uint64  `json:"device_id" validate:"required"`
-	RoomId   *uint64 `json:"room_id"`
-	Value    float64 `json:"value" validate:"required"`
+	DeviceId uint64             `json:"device_id" validate:"required"`
+	RoomId   *uint64            `json:"room_id"`
+	Value    *float64           `json:"value"`
+	Channel  *string            `json:"channel"`
+	Values   map[string]float64 `json:"values"`
 }
 
 func (r MeasurementRequest) ToDomainModel() (domain.Measurement, error) {
 	if r.DeviceId == 0 {
 		return domain.Measurement{}, errors.New("device_id is required")
 	}
-	if r.Value == 0 {
+	// zero is a valid reading, e.g. of a temperature
+	if r.Value == nil {
 		return domain.Measurement{}, errors.New("value is required")
 	}
 
 	measurement := domain.Measurement{
 		DeviceId:    r.DeviceId,
-		Value:       r.Value,
+		Value:       *r.Value,
 		CreatedDate: time.Now(),
 		UpdatedDate: time.Now(),
 	}
@@ -34,3 +40,27 @@ func (r MeasurementRequest) ToDomainModel() (domain.Measurement, error) {
 
 	return measurement, nil
 }
+
+// Readings returns the readings of the channels of the request, sorted by channel, or nil for a
+// single reading without a channel.
+func (r MeasurementRequest) Readings() ([]domain.ChannelReading, error) {
+	if len(r.Values) == 0 {
+		if r.Channel == nil {
+			return nil, nil
+		}
+		if r.Value == nil {
+			return nil, errors.New("value is required")
+		}
+		return []domain.ChannelReading{{Channel: *r.Channel, Value: *r.Value}}, nil
+	}
+	if r.Value != nil || r.Channel != nil {
+		return nil, errors.New("values can't be combined with value and channel")
+	}
+
+	readings := make([]domain.ChannelReading, 0, len(r.Values))
+	for channel, value := range r.Values {
+		readings = append(readings, domain.ChannelReading{Channel: channel, Value: value})
+	}
+	sort.Slice(readings, func(i, j int) bool { return readings[i].Channel < readings[j].Channel })
+	return readings, nil
+}
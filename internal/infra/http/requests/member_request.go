@@ -0,0 +1,19 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type MemberRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required"`
+}
+
+func (r MemberRequest) ToDomainModel() (interface{}, error) {
+	return domain.OrganizationMember{
+		Email: r.Email,
+		Role:  domain.MemberRole(strings.ToUpper(r.Role)),
+	}, nil
+}
@@ -1,6 +1,11 @@
 package requests
 
-import "github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+import (
+	"errors"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
 
 type OrganizationRequest struct {
 	Name        string  `json:"name" validate:"required"`
@@ -9,9 +14,18 @@ type OrganizationRequest struct {
 	Address     string  `json:"address" validate:"required"`
 	Lat         float64 `json:"lat" validate:"required"`
 	Lon         float64 `json:"lon" validate:"required"`
+	Timezone    string  `json:"timezone"`
 }
 
 func (r OrganizationRequest) ToDomainModel() (interface{}, error) {
+	timezone := r.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return domain.Organization{}, errors.New("unknown timezone")
+	}
+
 	return domain.Organization{
 		Name:        r.Name,
 		Description: r.Description,
@@ -19,5 +33,6 @@ func (r OrganizationRequest) ToDomainModel() (interface{}, error) {
 		Address:     r.Address,
 		Lat:         r.Lat,
 		Lon:         r.Lon,
+		Timezone:    timezone,
 	}, nil
 }
@@ -0,0 +1,44 @@
+package requests
+
+import (
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type RuleRequest struct {
+	OrganizationId  uint64                 `json:"organizationId"`
+	Name            string                 `json:"name" validate:"required"`
+	Enabled         *bool                  `json:"enabled"`
+	DryRun          bool                   `json:"dryRun"`
+	Trigger         domain.RuleTrigger     `json:"trigger"`
+	Conditions      []domain.RuleCondition `json:"conditions"`
+	Actions         []domain.RuleAction    `json:"actions" validate:"required,min=1"`
+	CooldownSeconds uint64                 `json:"cooldownSeconds"`
+}
+
+func (r RuleRequest) ToDomainModel() (interface{}, error) {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+
+	actions := make([]domain.RuleAction, len(r.Actions))
+	for i, a := range r.Actions {
+		action, err := domain.ParseEventAction(string(a.Action))
+		if err != nil {
+			return domain.Rule{}, err
+		}
+		a.Action = action
+		actions[i] = a
+	}
+
+	return domain.Rule{
+		OrganizationId:  r.OrganizationId,
+		Name:            r.Name,
+		Enabled:         enabled,
+		DryRun:          r.DryRun,
+		Trigger:         r.Trigger,
+		Conditions:      r.Conditions,
+		Actions:         actions,
+		CooldownSeconds: r.CooldownSeconds,
+	}, nil
+}
@@ -0,0 +1,30 @@
+package requests
+
+import (
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type ScheduleRequest struct {
+	OrganizationId uint64                     `json:"organizationId"`
+	DeviceId       *uint64                    `json:"deviceId"`
+	Name           string                     `json:"name" validate:"required"`
+	Enabled        *bool                      `json:"enabled"`
+	Blocks         []domain.ScheduleBlock     `json:"blocks"`
+	Exceptions     []domain.ScheduleException `json:"exceptions"`
+}
+
+func (r ScheduleRequest) ToDomainModel() (interface{}, error) {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+
+	return domain.Schedule{
+		OrganizationId: r.OrganizationId,
+		DeviceId:       r.DeviceId,
+		Name:           r.Name,
+		Enabled:        enabled,
+		Blocks:         r.Blocks,
+		Exceptions:     r.Exceptions,
+	}, nil
+}
@@ -0,0 +1,33 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type TariffRequest struct {
+	OrganizationId uint64                `json:"organizationId"`
+	Name           string                `json:"name" validate:"required"`
+	Currency       string                `json:"currency" validate:"required"`
+	Type           string                `json:"type" validate:"required"`
+	Active         bool                  `json:"active"`
+	Rate           float64               `json:"rate" validate:"gte=0"`
+	Windows        []domain.TariffWindow `json:"windows"`
+	Tiers          []domain.TariffTier   `json:"tiers"`
+	Seasons        []domain.TariffSeason `json:"seasons"`
+}
+
+func (r TariffRequest) ToDomainModel() (interface{}, error) {
+	return domain.Tariff{
+		OrganizationId: r.OrganizationId,
+		Name:           r.Name,
+		Currency:       strings.ToUpper(r.Currency),
+		Type:           domain.TariffType(strings.ToUpper(r.Type)),
+		Active:         r.Active,
+		Rate:           r.Rate,
+		Windows:        r.Windows,
+		Tiers:          r.Tiers,
+		Seasons:        r.Seasons,
+	}, nil
+}
@@ -0,0 +1,88 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type AnomalySettingsDto struct {
+	DeviceId       uint64     `json:"deviceId"`
+	OrganizationId uint64     `json:"organizationId"`
+	Enabled        bool       `json:"enabled"`
+	Window         int        `json:"window"`
+	ZThreshold     float64    `json:"zThreshold"`
+	EwmaAlpha      float64    `json:"ewmaAlpha"`
+	BaselineAlpha  float64    `json:"baselineAlpha"`
+	DriftThreshold float64    `json:"driftThreshold"`
+	StuckCount     int        `json:"stuckCount"`
+	FlatlineCount  int        `json:"flatlineCount"`
+	FlatlineRatio  float64    `json:"flatlineRatio"`
+	Notify         bool       `json:"notify"`
+	Default        bool       `json:"default"`
+	UpdatedDate    *time.Time `json:"updatedDate"`
+}
+
+type MeasurementAnomaliesDto struct {
+	Anomalies []MeasurementAnomalyDto `json:"anomalies"`
+}
+
+type MeasurementAnomalyDto struct {
+	Id             uint64    `json:"id"`
+	MeasurementId  uint64    `json:"measurementId"`
+	OrganizationId uint64    `json:"organizationId"`
+	DeviceId       uint64    `json:"deviceId"`
+	ChannelId      *uint64   `json:"channelId"`
+	Kind           string    `json:"kind"`
+	Value          float64   `json:"value"`
+	Score          float64   `json:"score"`
+	Reason         string    `json:"reason"`
+	CreatedDate    time.Time `json:"createdDate"`
+}
+
+func (d AnomalySettingsDto) DomainToDto(s domain.AnomalySettings) AnomalySettingsDto {
+	// settings which were never saved are the defaults
+	var updated *time.Time
+	if s.Id != 0 {
+		updated = &s.UpdatedDate
+	}
+	return AnomalySettingsDto{
+		DeviceId:       s.DeviceId,
+		OrganizationId: s.OrganizationId,
+		Enabled:        s.Enabled,
+		Window:         s.Window,
+		ZThreshold:     s.ZThreshold,
+		EwmaAlpha:      s.EwmaAlpha,
+		BaselineAlpha:  s.BaselineAlpha,
+		DriftThreshold: s.DriftThreshold,
+		StuckCount:     s.StuckCount,
+		FlatlineCount:  s.FlatlineCount,
+		FlatlineRatio:  s.FlatlineRatio,
+		Notify:         s.Notify,
+		Default:        s.Id == 0,
+		UpdatedDate:    updated,
+	}
+}
+
+func (d MeasurementAnomalyDto) DomainToDto(a domain.MeasurementAnomaly) MeasurementAnomalyDto {
+	return MeasurementAnomalyDto{
+		Id:             a.Id,
+		MeasurementId:  a.MeasurementId,
+		OrganizationId: a.OrganizationId,
+		DeviceId:       a.DeviceId,
+		ChannelId:      a.ChannelId,
+		Kind:           string(a.Kind),
+		Value:          a.Value,
+		Score:          a.Score,
+		Reason:         a.Reason,
+		CreatedDate:    a.CreatedDate,
+	}
+}
+
+func (d MeasurementAnomaliesDto) DomainToDto(anomalies []domain.MeasurementAnomaly) MeasurementAnomaliesDto {
+	res := make([]MeasurementAnomalyDto, len(anomalies))
+	for i, a := range anomalies {
+		res[i] = MeasurementAnomalyDto{}.DomainToDto(a)
+	}
+	return MeasurementAnomaliesDto{Anomalies: res}
+}
@@ -0,0 +1,55 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type ApprovalsDto struct {
+	Approvals []ApprovalDto `json:"approvals"`
+}
+
+type ApprovalDto struct {
+	Id             uint64 `json:"id"`
+	OrganizationId uint64 `json:"organizationId"`
+	DeviceId       uint64 `json:"deviceId"`
+	RequestedBy    uint64 `json:"requestedBy"`
+	Action         string `json:"action"`
+	ActionParamsDto
+	TtlSeconds  uint64     `json:"ttlSeconds"`
+	Status      string     `json:"status"`
+	DecidedBy   *uint64    `json:"decidedBy"`
+	Comment     *string    `json:"comment"`
+	CommandId   *uint64    `json:"commandId"`
+	ExpiresDate time.Time  `json:"expiresDate"`
+	DecidedDate *time.Time `json:"decidedDate"`
+	CreatedDate time.Time  `json:"createdDate"`
+}
+
+func (d ApprovalDto) DomainToDto(a domain.CommandApproval) ApprovalDto {
+	return ApprovalDto{
+		Id:              a.Id,
+		OrganizationId:  a.OrganizationId,
+		DeviceId:        a.DeviceId,
+		RequestedBy:     a.RequestedBy,
+		Action:          string(a.Action),
+		ActionParamsDto: ActionParamsDto{}.DomainToDto(a.ActionParams),
+		TtlSeconds:      uint64(a.CommandTtl.Seconds()),
+		Status:          string(a.Status),
+		DecidedBy:       a.DecidedBy,
+		Comment:         a.Comment,
+		CommandId:       a.CommandId,
+		ExpiresDate:     a.ExpiresDate,
+		DecidedDate:     a.DecidedDate,
+		CreatedDate:     a.CreatedDate,
+	}
+}
+
+func (d ApprovalsDto) DomainToDto(approvals []domain.CommandApproval) ApprovalsDto {
+	res := make([]ApprovalDto, len(approvals))
+	for i, a := range approvals {
+		res[i] = ApprovalDto{}.DomainToDto(a)
+	}
+	return ApprovalsDto{Approvals: res}
+}
@@ -0,0 +1,85 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type BudgetsDto struct {
+	Budgets []BudgetDto `json:"budgets"`
+}
+
+type BudgetDto struct {
+	Id                uint64    `json:"id"`
+	OrganizationId    uint64    `json:"organizationId"`
+	RoomId            *uint64   `json:"roomId"`
+	Name              string    `json:"name"`
+	Metric            string    `json:"metric"`
+	Amount            float64   `json:"amount"`
+	Thresholds        []float64 `json:"thresholds"`
+	AlertOnProjection bool      `json:"alertOnProjection"`
+	Enabled           bool      `json:"enabled"`
+	CreatedDate       time.Time `json:"createdDate"`
+	UpdatedDate       time.Time `json:"updatedDate"`
+}
+
+type BudgetStatusDto struct {
+	BudgetId         uint64    `json:"budgetId"`
+	Metric           string    `json:"metric"`
+	Currency         string    `json:"currency,omitempty"`
+	StartDate        time.Time `json:"startDate"`
+	EndDate          time.Time `json:"endDate"`
+	AsOf             time.Time `json:"asOf"`
+	Timezone         string    `json:"timezone"`
+	Amount           float64   `json:"amount"`
+	Used             float64   `json:"used"`
+	Percent          float64   `json:"percent"`
+	Projected        float64   `json:"projected"`
+	ProjectedPercent float64   `json:"projectedPercent"`
+}
+
+func (d BudgetDto) DomainToDto(b domain.Budget) BudgetDto {
+	thresholds := b.Thresholds
+	if thresholds == nil {
+		thresholds = []float64{}
+	}
+	return BudgetDto{
+		Id:                b.Id,
+		OrganizationId:    b.OrganizationId,
+		RoomId:            b.RoomId,
+		Name:              b.Name,
+		Metric:            string(b.Metric),
+		Amount:            b.Amount,
+		Thresholds:        thresholds,
+		AlertOnProjection: b.AlertOnProjection,
+		Enabled:           b.Enabled,
+		CreatedDate:       b.CreatedDate,
+		UpdatedDate:       b.UpdatedDate,
+	}
+}
+
+func (d BudgetsDto) DomainToDto(budgets []domain.Budget) BudgetsDto {
+	res := make([]BudgetDto, len(budgets))
+	for i, b := range budgets {
+		res[i] = BudgetDto{}.DomainToDto(b)
+	}
+	return BudgetsDto{Budgets: res}
+}
+
+func (d BudgetStatusDto) DomainToDto(s domain.BudgetStatus) BudgetStatusDto {
+	return BudgetStatusDto{
+		BudgetId:         s.BudgetId,
+		Metric:           string(s.Metric),
+		Currency:         s.Currency,
+		StartDate:        s.Month.Start,
+		EndDate:          s.Month.End,
+		AsOf:             s.AsOf,
+		Timezone:         s.Month.Location.String(),
+		Amount:           s.Amount,
+		Used:             s.Used,
+		Percent:          s.Percent(),
+		Projected:        s.Projected,
+		ProjectedPercent: s.ProjectedPercent(),
+	}
+}
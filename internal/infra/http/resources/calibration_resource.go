@@ -0,0 +1,59 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type CalibrationsDto struct {
+	Calibrations []CalibrationDto `json:"calibrations"`
+}
+
+type CalibrationDto struct {
+	Id             uint64    `json:"id"`
+	OrganizationId uint64    `json:"organizationId"`
+	DeviceId       uint64    `json:"deviceId"`
+	ChannelId      *uint64   `json:"channelId"`
+	UserId         uint64    `json:"userId"`
+	Offset         float64   `json:"offset"`
+	Gain           float64   `json:"gain"`
+	Coefficients   []float64 `json:"coefficients"`
+	EffectiveFrom  time.Time `json:"effectiveFrom"`
+	Note           *string   `json:"note"`
+	CreatedDate    time.Time `json:"createdDate"`
+	// Recomputed is how many past readings were corrected, when they were
+	Recomputed *int `json:"recomputed,omitempty"`
+}
+
+type RecomputedDto struct {
+	Recomputed int `json:"recomputed"`
+}
+
+func (d CalibrationDto) DomainToDto(c domain.Calibration) CalibrationDto {
+	coefficients := c.Coefficients
+	if coefficients == nil {
+		coefficients = []float64{}
+	}
+	return CalibrationDto{
+		Id:             c.Id,
+		OrganizationId: c.OrganizationId,
+		DeviceId:       c.DeviceId,
+		ChannelId:      c.ChannelId,
+		UserId:         c.UserId,
+		Offset:         c.Offset,
+		Gain:           c.Gain,
+		Coefficients:   coefficients,
+		EffectiveFrom:  c.EffectiveFrom,
+		Note:           c.Note,
+		CreatedDate:    c.CreatedDate,
+	}
+}
+
+func (d CalibrationsDto) DomainToDto(calibrations []domain.Calibration) CalibrationsDto {
+	res := make([]CalibrationDto, len(calibrations))
+	for i, c := range calibrations {
+		res[i] = CalibrationDto{}.DomainToDto(c)
+	}
+	return CalibrationsDto{Calibrations: res}
+}
@@ -0,0 +1,47 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type ChannelsDto struct {
+	Channels []ChannelDto `json:"channels"`
+}
+
+type ChannelDto struct {
+	Id          uint64    `json:"id"`
+	DeviceId    uint64    `json:"deviceId"`
+	Key         string    `json:"key"`
+	Quantity    string    `json:"quantity"`
+	Units       *string   `json:"units"`
+	ValidMin    *float64  `json:"validMin,omitempty"`
+	ValidMax    *float64  `json:"validMax,omitempty"`
+	MaxRate     *float64  `json:"maxRate,omitempty"`
+	CreatedDate time.Time `json:"createdDate"`
+	UpdatedDate time.Time `json:"updatedDate"`
+}
+
+func (d ChannelDto) DomainToDto(ch domain.DeviceChannel) ChannelDto {
+	return ChannelDto{
+		Id:          ch.Id,
+		DeviceId:    ch.DeviceId,
+		Key:         ch.Key,
+		Quantity:    ch.Quantity,
+		Units:       ch.Units,
+		ValidMin:    ch.ValidMin,
+		ValidMax:    ch.ValidMax,
+		MaxRate:     ch.MaxRate,
+		CreatedDate: ch.CreatedDate,
+		UpdatedDate: ch.UpdatedDate,
+	}
+}
+
+func (d ChannelsDto) DomainToDto(channels []domain.DeviceChannel) ChannelsDto {
+	res := make([]ChannelDto, len(channels))
+	for i, ch := range channels {
+		res[i] = ChannelDto{}.DomainToDto(ch)
+	}
+	return ChannelsDto{Channels: res}
+}
@@ -0,0 +1,57 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type CommandsDto struct {
+	Commands []CommandDto `json:"commands"`
+}
+
+type CommandDto struct {
+	Id             uint64 `json:"id"`
+	OrganizationId uint64 `json:"organizationId"`
+	DeviceId       uint64 `json:"device_id"`
+	UserId         uint64 `json:"userId"`
+	Action         string `json:"action"`
+	ActionParamsDto
+	Status           string     `json:"status"`
+	EventId          *uint64    `json:"event_id"`
+	ApprovalId       *uint64    `json:"approvalId,omitempty"`
+	Error            *string    `json:"error,omitempty"`
+	ExpiresDate      time.Time  `json:"expiresDate"`
+	DeliveredDate    *time.Time `json:"deliveredDate"`
+	AcknowledgedDate *time.Time `json:"acknowledgedDate"`
+	CreatedDate      time.Time  `json:"createdDate"`
+	UpdatedDate      time.Time  `json:"updatedDate"`
+}
+
+func (d CommandDto) DomainToDto(c domain.Command) CommandDto {
+	return CommandDto{
+		Id:               c.Id,
+		OrganizationId:   c.OrganizationId,
+		DeviceId:         c.DeviceId,
+		UserId:           c.UserId,
+		Action:           string(c.Action),
+		ActionParamsDto:  ActionParamsDto{}.DomainToDto(c.ActionParams),
+		Status:           string(c.Status),
+		EventId:          c.EventId,
+		ApprovalId:       c.ApprovalId,
+		Error:            c.Error,
+		ExpiresDate:      c.ExpiresDate,
+		DeliveredDate:    c.DeliveredDate,
+		AcknowledgedDate: c.AcknowledgedDate,
+		CreatedDate:      c.CreatedDate,
+		UpdatedDate:      c.UpdatedDate,
+	}
+}
+
+func (d CommandsDto) DomainToDto(cmds []domain.Command) CommandsDto {
+	commands := make([]CommandDto, len(cmds))
+	for i, c := range cmds {
+		commands[i] = CommandDto{}.DomainToDto(c)
+	}
+	return CommandsDto{Commands: commands}
+}
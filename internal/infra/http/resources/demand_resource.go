@@ -0,0 +1,131 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type DevicePriorityDto struct {
+	DeviceId uint64 `json:"deviceId"`
+	Class    string `json:"class"`
+}
+
+type DemandPolicyDto struct {
+	Id             uint64              `json:"id"`
+	OrganizationId uint64              `json:"organizationId"`
+	MaxKw          float64             `json:"maxKw"`
+	Strategy       string              `json:"strategy"`
+	Enabled        bool                `json:"enabled"`
+	Priorities     []DevicePriorityDto `json:"priorities"`
+	CreatedDate    time.Time           `json:"createdDate"`
+	UpdatedDate    time.Time           `json:"updatedDate"`
+}
+
+type DemandDecisionsDto struct {
+	Decisions []DemandDecisionDto `json:"decisions"`
+}
+
+type DemandDecisionDto struct {
+	Id            uint64    `json:"id"`
+	DeviceId      uint64    `json:"deviceId"`
+	Source        string    `json:"source"`
+	Action        string    `json:"action"`
+	Outcome       string    `json:"outcome"`
+	RequestedKw   float64   `json:"requestedKw"`
+	LoadKw        float64   `json:"loadKw"`
+	LimitKw       float64   `json:"limitKw"`
+	ShedDeviceIds []uint64  `json:"shedDeviceIds"`
+	RequestId     *uint64   `json:"requestId"`
+	Reason        string    `json:"reason"`
+	CreatedDate   time.Time `json:"createdDate"`
+}
+
+type DemandRequestsDto struct {
+	Requests []DemandRequestDto `json:"requests"`
+}
+
+type DemandRequestDto struct {
+	Id           uint64     `json:"id"`
+	DeviceId     uint64     `json:"deviceId"`
+	Source       string     `json:"source"`
+	Action       string     `json:"action"`
+	Level        *float64   `json:"level,omitempty"`
+	Status       string     `json:"status"`
+	Error        *string    `json:"error,omitempty"`
+	ExpiresDate  time.Time  `json:"expiresDate"`
+	ReleasedDate *time.Time `json:"releasedDate"`
+	CreatedDate  time.Time  `json:"createdDate"`
+}
+
+func (d DemandPolicyDto) DomainToDto(p domain.DemandPolicy) DemandPolicyDto {
+	priorities := make([]DevicePriorityDto, len(p.Priorities))
+	for i, dp := range p.Priorities {
+		priorities[i] = DevicePriorityDto{
+			DeviceId: dp.DeviceId,
+			Class:    string(dp.Class),
+		}
+	}
+	return DemandPolicyDto{
+		Id:             p.Id,
+		OrganizationId: p.OrganizationId,
+		MaxKw:          p.MaxKw,
+		Strategy:       string(p.Strategy),
+		Enabled:        p.Enabled,
+		Priorities:     priorities,
+		CreatedDate:    p.CreatedDate,
+		UpdatedDate:    p.UpdatedDate,
+	}
+}
+
+func (d DemandDecisionDto) DomainToDto(dd domain.DemandDecision) DemandDecisionDto {
+	shed := dd.ShedDeviceIds
+	if shed == nil {
+		shed = []uint64{}
+	}
+	return DemandDecisionDto{
+		Id:            dd.Id,
+		DeviceId:      dd.DeviceId,
+		Source:        string(dd.Source),
+		Action:        string(dd.Action),
+		Outcome:       string(dd.Outcome),
+		RequestedKw:   dd.RequestedKw,
+		LoadKw:        dd.LoadKw,
+		LimitKw:       dd.LimitKw,
+		ShedDeviceIds: shed,
+		RequestId:     dd.RequestId,
+		Reason:        dd.Reason,
+		CreatedDate:   dd.CreatedDate,
+	}
+}
+
+func (d DemandDecisionsDto) DomainToDto(decisions []domain.DemandDecision) DemandDecisionsDto {
+	res := make([]DemandDecisionDto, len(decisions))
+	for i, dd := range decisions {
+		res[i] = DemandDecisionDto{}.DomainToDto(dd)
+	}
+	return DemandDecisionsDto{Decisions: res}
+}
+
+func (d DemandRequestDto) DomainToDto(r domain.DemandRequest) DemandRequestDto {
+	return DemandRequestDto{
+		Id:           r.Id,
+		DeviceId:     r.DeviceId,
+		Source:       string(r.Source),
+		Action:       string(r.Action),
+		Level:        r.Level,
+		Status:       string(r.Status),
+		Error:        r.Error,
+		ExpiresDate:  r.ExpiresDate,
+		ReleasedDate: r.ReleasedDate,
+		CreatedDate:  r.CreatedDate,
+	}
+}
+
+func (d DemandRequestsDto) DomainToDto(reqs []domain.DemandRequest) DemandRequestsDto {
+	res := make([]DemandRequestDto, len(reqs))
+	for i, r := range reqs {
+		res[i] = DemandRequestDto{}.DomainToDto(r)
+	}
+	return DemandRequestsDto{Requests: res}
+}
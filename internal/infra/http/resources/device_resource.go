@@ -10,11 +10,39 @@ type DevicesDto struct {
 	Devices []DeviceDto `json:"devices"`
 }
 
+type CapabilitiesDto struct {
+	Level    bool         `json:"level"`
+	Setpoint *SetpointDto `json:"setpoint"`
+	Modes    []string     `json:"modes"`
+}
+
+type SetpointDto struct {
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Unit string  `json:"unit"`
+}
+
+func (d CapabilitiesDto) DomainToDto(c domain.DeviceCapabilities) CapabilitiesDto {
+	dto := CapabilitiesDto{
+		Level: c.Level,
+		Modes: c.Modes,
+	}
+	if c.Setpoint != nil {
+		dto.Setpoint = &SetpointDto{
+			Min:  c.Setpoint.Min,
+			Max:  c.Setpoint.Max,
+			Unit: c.Setpoint.Unit,
+		}
+	}
+	return dto
+}
+
 type DeviceDto struct {
 	Id               uint64           `json:"id"`
 	OrganizationId   uint64           `json:"organizationId"`
 	RoomId           *uint64          `json:"room_id"`
 	GUID             string           `json:"guid"`
+	Secret           string           `json:"secret,omitempty"`
 	InventoryNumber  string           `json:"inventoryNumber"`
 	SerialNumber     string           `json:"serialNumber"`
 	Characteristics  string           `json:"characteristics"`
@@ -22,6 +50,15 @@ type DeviceDto struct {
 	Units            *string          `json:"units"`
 	Measurements     []MeasurementDto `json:"measurements"`
 	PowerConsumption *float64         `json:"power_consumption"`
+	CurrentState     *string          `json:"currentState"`
+	Capabilities     CapabilitiesDto  `json:"capabilities"`
+	MeteredDeviceId  *uint64          `json:"meteredDeviceId,omitempty"`
+	CounterMax       *float64         `json:"counterMax,omitempty"`
+	Critical         bool             `json:"critical"`
+	ValidMin         *float64         `json:"validMin,omitempty"`
+	ValidMax         *float64         `json:"validMax,omitempty"`
+	MaxRate          *float64         `json:"maxRate,omitempty"`
+	Channels         []ChannelDto     `json:"channels,omitempty"`
 	Events           []EventDto       `json:"events"`
 	CreatedDate      time.Time        `json:"createdDate"`
 	UpdatedDate      time.Time        `json:"updatedDate"`
@@ -38,11 +75,21 @@ func (d DeviceDto) DomainToDto(o domain.Device) DeviceDto {
 		eDto := EventDto{}.DomainToDto(de)
 		events = append(events, eDto)
 	}
+	var channels []ChannelDto
+	for _, ch := range o.Channels {
+		channels = append(channels, ChannelDto{}.DomainToDto(ch))
+	}
+	var state *string
+	if o.CurrentState != nil {
+		s := string(*o.CurrentState)
+		state = &s
+	}
 	return DeviceDto{
 		Id:               o.Id,
 		OrganizationId:   o.OrganizationId,
 		RoomId:           o.RoomId,
 		GUID:             o.GUID,
+		Secret:           o.Secret,
 		InventoryNumber:  o.InventoryNumber,
 		SerialNumber:     o.SerialNumber,
 		Characteristics:  o.Characteristics,
@@ -50,6 +97,15 @@ func (d DeviceDto) DomainToDto(o domain.Device) DeviceDto {
 		Units:            o.Units,
 		Measurements:     measurements,
 		PowerConsumption: o.PowerConsumption,
+		CurrentState:     state,
+		Capabilities:     CapabilitiesDto{}.DomainToDto(o.Capabilities),
+		MeteredDeviceId:  o.MeteredDeviceId,
+		CounterMax:       o.CounterMax,
+		Critical:         o.Critical,
+		ValidMin:         o.ValidMin,
+		ValidMax:         o.ValidMax,
+		MaxRate:          o.MaxRate,
+		Channels:         channels,
 		Events:           events,
 		CreatedDate:      o.CreatedDate,
 		UpdatedDate:      o.UpdatedDate,
@@ -0,0 +1,83 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type TwinStateDto struct {
+	Power    *string    `json:"power"`
+	Level    *float64   `json:"level"`
+	Setpoint *float64   `json:"setpoint"`
+	Mode     *string    `json:"mode"`
+	Version  uint64     `json:"version"`
+	Date     *time.Time `json:"date"`
+}
+
+type DeviceTwinDto struct {
+	DeviceId       uint64       `json:"device_id"`
+	OrganizationId uint64       `json:"organizationId"`
+	RoomId         *uint64      `json:"room_id"`
+	Desired        TwinStateDto `json:"desired"`
+	Reported       TwinStateDto `json:"reported"`
+	InSync         bool         `json:"inSync"`
+}
+
+type TwinDriftDto struct {
+	DeviceId        uint64      `json:"device_id"`
+	RoomId          *uint64     `json:"room_id"`
+	Field           string      `json:"field"`
+	Desired         interface{} `json:"desired"`
+	Reported        interface{} `json:"reported"`
+	DesiredVersion  uint64      `json:"desiredVersion"`
+	ReportedVersion uint64      `json:"reportedVersion"`
+	DesiredDate     *time.Time  `json:"desiredDate"`
+}
+
+type TwinDriftsDto struct {
+	Drift []TwinDriftDto `json:"drift"`
+}
+
+func (d DeviceTwinDto) DomainToDto(t domain.DeviceTwin) DeviceTwinDto {
+	return DeviceTwinDto{
+		DeviceId:       t.DeviceId,
+		OrganizationId: t.OrganizationId,
+		RoomId:         t.RoomId,
+		Desired:        twinStateToDto(t.Desired, t.DesiredVersion, t.DesiredDate),
+		Reported:       twinStateToDto(t.Reported, t.ReportedVersion, t.ReportedDate),
+		InSync:         len(t.Drift()) == 0,
+	}
+}
+
+func (d TwinDriftsDto) DomainToDto(drift []domain.TwinDrift) TwinDriftsDto {
+	res := make([]TwinDriftDto, len(drift))
+	for i, dr := range drift {
+		res[i] = TwinDriftDto{
+			DeviceId:        dr.DeviceId,
+			RoomId:          dr.RoomId,
+			Field:           dr.Field,
+			Desired:         dr.Desired,
+			Reported:        dr.Reported,
+			DesiredVersion:  dr.DesiredVersion,
+			ReportedVersion: dr.ReportedVersion,
+			DesiredDate:     dr.DesiredDate,
+		}
+	}
+	return TwinDriftsDto{Drift: res}
+}
+
+func twinStateToDto(s domain.TwinState, version uint64, date *time.Time) TwinStateDto {
+	dto := TwinStateDto{
+		Level:    s.Level,
+		Setpoint: s.Setpoint,
+		Mode:     s.Mode,
+		Version:  version,
+		Date:     date,
+	}
+	if s.Power != nil {
+		power := string(*s.Power)
+		dto.Power = &power
+	}
+	return dto
+}
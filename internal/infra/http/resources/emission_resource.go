@@ -0,0 +1,73 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type EmissionFactorsDto struct {
+	EmissionFactors []EmissionFactorDto `json:"emissionFactors"`
+}
+
+type EmissionFactorDto struct {
+	Id             uint64                       `json:"id"`
+	OrganizationId uint64                       `json:"organizationId"`
+	Name           string                       `json:"name"`
+	Source         string                       `json:"source"`
+	Active         bool                         `json:"active"`
+	Factor         float64                      `json:"factor"`
+	Entries        []domain.EmissionFactorEntry `json:"entries"`
+	CreatedDate    time.Time                    `json:"createdDate"`
+	UpdatedDate    time.Time                    `json:"updatedDate"`
+}
+
+type EmissionsDto struct {
+	FactorId      uint64    `json:"factorId"`
+	FactorName    string    `json:"factorName"`
+	StartDate     time.Time `json:"startDate"`
+	EndDate       time.Time `json:"endDate"`
+	Timezone      string    `json:"timezone"`
+	Kwh           float64   `json:"kwh"`
+	Co2eKg        float64   `json:"co2eKg"`
+	AverageFactor float64   `json:"averageFactor"`
+}
+
+func (d EmissionFactorDto) DomainToDto(f domain.EmissionFactor) EmissionFactorDto {
+	entries := f.Entries
+	if entries == nil {
+		entries = []domain.EmissionFactorEntry{}
+	}
+	return EmissionFactorDto{
+		Id:             f.Id,
+		OrganizationId: f.OrganizationId,
+		Name:           f.Name,
+		Source:         f.Source,
+		Active:         f.Active,
+		Factor:         f.Factor,
+		Entries:        entries,
+		CreatedDate:    f.CreatedDate,
+		UpdatedDate:    f.UpdatedDate,
+	}
+}
+
+func (d EmissionFactorsDto) DomainToDto(factors []domain.EmissionFactor) EmissionFactorsDto {
+	res := make([]EmissionFactorDto, len(factors))
+	for i, f := range factors {
+		res[i] = EmissionFactorDto{}.DomainToDto(f)
+	}
+	return EmissionFactorsDto{EmissionFactors: res}
+}
+
+func (d EmissionsDto) DomainToDto(e domain.Emissions, loc *time.Location) EmissionsDto {
+	return EmissionsDto{
+		FactorId:      e.FactorId,
+		FactorName:    e.FactorName,
+		StartDate:     e.Start.In(loc),
+		EndDate:       e.End.In(loc),
+		Timezone:      loc.String(),
+		Kwh:           e.Energy,
+		Co2eKg:        e.Co2e,
+		AverageFactor: e.AverageFactor(),
+	}
+}
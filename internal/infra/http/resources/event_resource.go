@@ -11,22 +11,40 @@ type EventsDto struct {
 }
 
 type EventDto struct {
-	Id          uint64    `json:"id"`
-	DeviceId    uint64    `json:"device_id"`
-	RoomId      *uint64   `json:"room_id"`
-	Action      string    `json:"action"`
+	Id       uint64  `json:"id"`
+	DeviceId uint64  `json:"device_id"`
+	RoomId   *uint64 `json:"room_id"`
+	Action   string  `json:"action"`
+	ActionParamsDto
 	CreatedDate time.Time `json:"createdDate"`
 	UpdatedDate time.Time `json:"updatedDate"`
 }
 
 func (d EventDto) DomainToDto(o domain.Event) EventDto {
 	return EventDto{
-		Id:          o.Id,
-		DeviceId:    o.DeviceId,
-		RoomId:      o.RoomId,
-		Action:      string(o.Action),
-		CreatedDate: o.CreatedDate,
-		UpdatedDate: o.UpdatedDate,
+		Id:              o.Id,
+		DeviceId:        o.DeviceId,
+		RoomId:          o.RoomId,
+		Action:          string(o.Action),
+		ActionParamsDto: ActionParamsDto{}.DomainToDto(o.ActionParams),
+		CreatedDate:     o.CreatedDate,
+		UpdatedDate:     o.UpdatedDate,
+	}
+}
+
+type ActionParamsDto struct {
+	Level    *float64 `json:"level,omitempty"`
+	Setpoint *float64 `json:"setpoint,omitempty"`
+	Unit     *string  `json:"unit,omitempty"`
+	Mode     *string  `json:"mode,omitempty"`
+}
+
+func (d ActionParamsDto) DomainToDto(p domain.ActionParams) ActionParamsDto {
+	return ActionParamsDto{
+		Level:    p.Level,
+		Setpoint: p.Setpoint,
+		Unit:     p.SetpointUnit,
+		Mode:     p.Mode,
 	}
 }
 
@@ -38,3 +56,137 @@ func (d EventsDto) DomainToDto(events []domain.Event) EventsDto {
 	}
 	return EventsDto{Events: eventDtos}
 }
+
+type PowerConsumptionDto struct {
+	TotalPowerConsumption float64   `json:"total_power_consumption"`
+	StartDate             time.Time `json:"start_date"`
+	EndDate               time.Time `json:"end_date"`
+	Timezone              string    `json:"timezone"`
+}
+
+func (d PowerConsumptionDto) DomainToDto(total float64, p domain.Period) PowerConsumptionDto {
+	return PowerConsumptionDto{
+		TotalPowerConsumption: total,
+		StartDate:             p.Start,
+		EndDate:               p.End,
+		Timezone:              p.Location.String(),
+	}
+}
+
+type ConsumptionReportDto struct {
+	OrganizationId uint64                 `json:"organizationId"`
+	StartDate      time.Time              `json:"startDate"`
+	EndDate        time.Time              `json:"endDate"`
+	Timezone       string                 `json:"timezone"`
+	OnHours        float64                `json:"onHours"`
+	Kwh            float64                `json:"kwh"`
+	Rooms          []RoomConsumptionDto   `json:"rooms"`
+	Devices        []DeviceConsumptionDto `json:"devices"`
+}
+
+type RoomConsumptionDto struct {
+	RoomId  *uint64                `json:"roomId"`
+	Name    string                 `json:"name"`
+	OnHours float64                `json:"onHours"`
+	Kwh     float64                `json:"kwh"`
+	Share   float64                `json:"share"`
+	Devices []DeviceConsumptionDto `json:"devices"`
+}
+
+type DeviceConsumptionDto struct {
+	DeviceId        uint64  `json:"deviceId"`
+	InventoryNumber string  `json:"inventoryNumber"`
+	OnHours         float64 `json:"onHours"`
+	Kwh             float64 `json:"kwh"`
+	Share           float64 `json:"share"`
+}
+
+func (d ConsumptionReportDto) DomainToDto(r domain.ConsumptionReport, loc *time.Location) ConsumptionReportDto {
+	rooms := make([]RoomConsumptionDto, len(r.Rooms))
+	for i, rc := range r.Rooms {
+		rooms[i] = RoomConsumptionDto{
+			RoomId:  rc.RoomId,
+			Name:    rc.Name,
+			OnHours: rc.OnHours,
+			Kwh:     rc.Energy,
+			Share:   rc.Share,
+			Devices: DeviceConsumptionDto{}.DomainToDtoCollection(rc.Devices),
+		}
+	}
+	return ConsumptionReportDto{
+		OrganizationId: r.OrganizationId,
+		StartDate:      r.Start.In(loc),
+		EndDate:        r.End.In(loc),
+		Timezone:       loc.String(),
+		OnHours:        r.OnHours,
+		Kwh:            r.Energy,
+		Rooms:          rooms,
+		Devices:        DeviceConsumptionDto{}.DomainToDtoCollection(r.Devices),
+	}
+}
+
+func (d DeviceConsumptionDto) DomainToDtoCollection(devices []domain.DeviceConsumption) []DeviceConsumptionDto {
+	res := make([]DeviceConsumptionDto, len(devices))
+	for i, dc := range devices {
+		res[i] = DeviceConsumptionDto{
+			DeviceId:        dc.DeviceId,
+			InventoryNumber: dc.InventoryNumber,
+			OnHours:         dc.OnHours,
+			Kwh:             dc.Energy,
+			Share:           dc.Share,
+		}
+	}
+	return res
+}
+
+type PowerSeriesDto struct {
+	StartDate time.Time        `json:"startDate"`
+	EndDate   time.Time        `json:"endDate"`
+	Timezone  string           `json:"timezone"`
+	Interval  string           `json:"interval"`
+	Kwh       float64          `json:"kwh"`
+	PeakKw    float64          `json:"peakKw"`
+	PeakAt    *time.Time       `json:"peakAt"`
+	Buckets   []PowerBucketDto `json:"buckets"`
+}
+
+type PowerBucketDto struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Kwh       float64   `json:"kwh"`
+	AverageKw float64   `json:"averageKw"`
+	PeakKw    float64   `json:"peakKw"`
+}
+
+func (d PowerSeriesDto) DomainToDto(s domain.PowerSeries, loc *time.Location) PowerSeriesDto {
+	buckets := make([]PowerBucketDto, len(s.Buckets))
+	for i, b := range s.Buckets {
+		var avg float64
+		if hours := b.End.Sub(b.Start).Hours(); hours > 0 {
+			avg = b.Energy / hours
+		}
+		buckets[i] = PowerBucketDto{
+			Start:     b.Start.In(loc),
+			End:       b.End.In(loc),
+			Kwh:       b.Energy,
+			AverageKw: avg,
+			PeakKw:    b.Peak,
+		}
+	}
+
+	var peakAt *time.Time
+	if s.PeakAt != nil {
+		at := s.PeakAt.In(loc)
+		peakAt = &at
+	}
+	return PowerSeriesDto{
+		StartDate: s.Start.In(loc),
+		EndDate:   s.End.In(loc),
+		Timezone:  loc.String(),
+		Interval:  string(s.Size),
+		Kwh:       s.Energy,
+		PeakKw:    s.Peak,
+		PeakAt:    peakAt,
+		Buckets:   buckets,
+	}
+}
@@ -0,0 +1,86 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type ForecastDto struct {
+	Model         string               `json:"model"`
+	Confidence    float64              `json:"confidence"`
+	TrainingStart time.Time            `json:"trainingStart"`
+	TrainingEnd   time.Time            `json:"trainingEnd"`
+	StartDate     time.Time            `json:"startDate"`
+	EndDate       time.Time            `json:"endDate"`
+	Timezone      string               `json:"timezone"`
+	Interval      string               `json:"interval"`
+	Kwh           float64              `json:"kwh"`
+	LowerKwh      float64              `json:"lowerKwh"`
+	UpperKwh      float64              `json:"upperKwh"`
+	Buckets       []ForecastBucketDto  `json:"buckets"`
+	Backtest      *ForecastAccuracyDto `json:"backtest"`
+}
+
+type ForecastBucketDto struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Kwh      float64   `json:"kwh"`
+	LowerKwh float64   `json:"lowerKwh"`
+	UpperKwh float64   `json:"upperKwh"`
+}
+
+type ForecastAccuracyDto struct {
+	StartDate time.Time `json:"startDate"`
+	EndDate   time.Time `json:"endDate"`
+	Hours     int       `json:"hours"`
+	MaeKwh    float64   `json:"maeKwh"`
+	RmseKwh   float64   `json:"rmseKwh"`
+	Mape      *float64  `json:"mape"`
+	Wape      *float64  `json:"wape"`
+	BiasKwh   float64   `json:"biasKwh"`
+	Coverage  float64   `json:"coverage"`
+}
+
+func (d ForecastDto) DomainToDto(f domain.Forecast, loc *time.Location) ForecastDto {
+	buckets := make([]ForecastBucketDto, len(f.Buckets))
+	for i, b := range f.Buckets {
+		buckets[i] = ForecastBucketDto{
+			Start:    b.Start.In(loc),
+			End:      b.End.In(loc),
+			Kwh:      b.Energy,
+			LowerKwh: b.Lower,
+			UpperKwh: b.Upper,
+		}
+	}
+
+	var backtest *ForecastAccuracyDto
+	if a := f.Backtest; a != nil {
+		backtest = &ForecastAccuracyDto{
+			StartDate: a.Start.In(loc),
+			EndDate:   a.End.In(loc),
+			Hours:     a.Hours,
+			MaeKwh:    a.Mae,
+			RmseKwh:   a.Rmse,
+			Mape:      a.Mape,
+			Wape:      a.Wape,
+			BiasKwh:   a.Bias,
+			Coverage:  a.Coverage,
+		}
+	}
+	return ForecastDto{
+		Model:         string(f.Model),
+		Confidence:    f.Confidence,
+		TrainingStart: f.TrainingStart.In(loc),
+		TrainingEnd:   f.TrainingEnd.In(loc),
+		StartDate:     f.Start.In(loc),
+		EndDate:       f.End.In(loc),
+		Timezone:      loc.String(),
+		Interval:      string(f.Size),
+		Kwh:           f.Energy,
+		LowerKwh:      f.Lower,
+		UpperKwh:      f.Upper,
+		Buckets:       buckets,
+		Backtest:      backtest,
+	}
+}
@@ -0,0 +1,81 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type InterlocksDto struct {
+	Interlocks []InterlockDto `json:"interlocks"`
+}
+
+type InterlockDto struct {
+	Id             uint64    `json:"id"`
+	OrganizationId uint64    `json:"organizationId"`
+	Name           string    `json:"name"`
+	DeviceId       uint64    `json:"deviceId"`
+	State          string    `json:"state"`
+	OtherDeviceId  uint64    `json:"otherDeviceId"`
+	OtherState     string    `json:"otherState"`
+	Enabled        bool      `json:"enabled"`
+	CreatedDate    time.Time `json:"createdDate"`
+	UpdatedDate    time.Time `json:"updatedDate"`
+}
+
+type InterlockOverridesDto struct {
+	Overrides []InterlockOverrideDto `json:"overrides"`
+}
+
+type InterlockOverrideDto struct {
+	Id          uint64    `json:"id"`
+	InterlockId uint64    `json:"interlockId"`
+	DeviceId    uint64    `json:"deviceId"`
+	Action      string    `json:"action"`
+	UserId      uint64    `json:"userId"`
+	Reason      string    `json:"reason"`
+	CreatedDate time.Time `json:"createdDate"`
+}
+
+func (d InterlockDto) DomainToDto(i domain.Interlock) InterlockDto {
+	return InterlockDto{
+		Id:             i.Id,
+		OrganizationId: i.OrganizationId,
+		Name:           i.Name,
+		DeviceId:       i.DeviceId,
+		State:          string(i.State),
+		OtherDeviceId:  i.OtherDeviceId,
+		OtherState:     string(i.OtherState),
+		Enabled:        i.Enabled,
+		CreatedDate:    i.CreatedDate,
+		UpdatedDate:    i.UpdatedDate,
+	}
+}
+
+func (d InterlocksDto) DomainToDto(interlocks []domain.Interlock) InterlocksDto {
+	res := make([]InterlockDto, len(interlocks))
+	for i, il := range interlocks {
+		res[i] = InterlockDto{}.DomainToDto(il)
+	}
+	return InterlocksDto{Interlocks: res}
+}
+
+func (d InterlockOverrideDto) DomainToDto(o domain.InterlockOverride) InterlockOverrideDto {
+	return InterlockOverrideDto{
+		Id:          o.Id,
+		InterlockId: o.InterlockId,
+		DeviceId:    o.DeviceId,
+		Action:      string(o.Action),
+		UserId:      o.UserId,
+		Reason:      o.Reason,
+		CreatedDate: o.CreatedDate,
+	}
+}
+
+func (d InterlockOverridesDto) DomainToDto(overrides []domain.InterlockOverride) InterlockOverridesDto {
+	res := make([]InterlockOverrideDto, len(overrides))
+	for i, o := range overrides {
+		res[i] = InterlockOverrideDto{}.DomainToDto(o)
+	}
+	return InterlockOverridesDto{Overrides: res}
+}
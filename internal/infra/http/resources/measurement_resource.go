@@ -10,23 +10,42 @@ type MeasurementsDto struct {
 	Measurements []MeasurementDto `json:"measurements"`
 }
 
+// MeasurementPeriodDto holds the readings of a period, EndDate is exclusive. The dates are in the
+// timezone of the organization, which is given as well.
+type MeasurementPeriodDto struct {
+	StartDate    time.Time        `json:"start_date"`
+	EndDate      time.Time        `json:"end_date"`
+	Timezone     string           `json:"timezone"`
+	Measurements []MeasurementDto `json:"measurements"`
+}
+
 type MeasurementDto struct {
-	Id          uint64    `json:"id"`
-	DeviceId    uint64    `json:"device_id"`
-	RoomId      *uint64   `json:"room_id"`
-	Value       float64   `json:"value"`
-	CreatedDate time.Time `json:"created_date"`
-	UpdatedDate time.Time `json:"updated_date"`
+	Id            uint64    `json:"id"`
+	DeviceId      uint64    `json:"device_id"`
+	RoomId        *uint64   `json:"room_id"`
+	ChannelId     *uint64   `json:"channel_id"`
+	Value         float64   `json:"value"`
+	RawValue      float64   `json:"raw_value"`
+	CalibrationId *uint64   `json:"calibration_id"`
+	Quality       string    `json:"quality"`
+	QualityReason *string   `json:"quality_reason"`
+	CreatedDate   time.Time `json:"created_date"`
+	UpdatedDate   time.Time `json:"updated_date"`
 }
 
 func (d MeasurementDto) DomainToDto(m domain.Measurement) MeasurementDto {
 	return MeasurementDto{
-		Id:          m.Id,
-		DeviceId:    m.DeviceId,
-		RoomId:      m.RoomId,
-		Value:       m.Value,
-		CreatedDate: m.CreatedDate,
-		UpdatedDate: m.UpdatedDate,
+		Id:            m.Id,
+		DeviceId:      m.DeviceId,
+		RoomId:        m.RoomId,
+		ChannelId:     m.ChannelId,
+		Value:         m.Value,
+		RawValue:      m.RawValue,
+		CalibrationId: m.CalibrationId,
+		Quality:       string(m.Quality),
+		QualityReason: m.QualityReason,
+		CreatedDate:   m.CreatedDate,
+		UpdatedDate:   m.UpdatedDate,
 	}
 }
 
@@ -38,3 +57,90 @@ func (d MeasurementDto) DomainToDtoCollection(measurements []domain.Measurement)
 	}
 	return measurementDtos
 }
+
+func (d MeasurementPeriodDto) DomainToDto(period domain.Period, measurements []domain.Measurement) MeasurementPeriodDto {
+	loc := period.Location
+	measurementDtos := make([]MeasurementDto, len(measurements))
+	for i, m := range measurements {
+		measurementDtos[i] = MeasurementDto{}.DomainToDto(m)
+		measurementDtos[i].CreatedDate = m.CreatedDate.In(loc)
+		measurementDtos[i].UpdatedDate = m.UpdatedDate.In(loc)
+	}
+	return MeasurementPeriodDto{
+		StartDate:    period.Start.In(loc),
+		EndDate:      period.End.In(loc),
+		Timezone:     loc.String(),
+		Measurements: measurementDtos,
+	}
+}
+
+type MeasurementSummaryDto struct {
+	DeviceId  uint64              `json:"device_id"`
+	StartDate time.Time           `json:"start_date"`
+	EndDate   time.Time           `json:"end_date"`
+	Timezone  string              `json:"timezone"`
+	Interval  string              `json:"interval"`
+	Channels  []ChannelSummaryDto `json:"channels"`
+}
+
+type ChannelSummaryDto struct {
+	ChannelId *uint64 `json:"channel_id"`
+	Channel   string  `json:"channel"`
+	Quantity  string  `json:"quantity"`
+	Units     *string `json:"units"`
+	MeasurementStatsDto
+	Buckets []MeasurementBucketDto `json:"buckets"`
+}
+
+type MeasurementStatsDto struct {
+	Count int      `json:"count"`
+	Min   *float64 `json:"min"`
+	Max   *float64 `json:"max"`
+	Mean  *float64 `json:"mean"`
+}
+
+type MeasurementBucketDto struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	MeasurementStatsDto
+}
+
+func (d MeasurementStatsDto) DomainToDto(s domain.MeasurementStats) MeasurementStatsDto {
+	return MeasurementStatsDto{
+		Count: s.Count,
+		Min:   s.Min,
+		Max:   s.Max,
+		Mean:  s.Mean,
+	}
+}
+
+func (d MeasurementSummaryDto) DomainToDto(deviceId uint64, period domain.Period, size domain.BucketSize, summaries []domain.ChannelSummary) MeasurementSummaryDto {
+	loc := period.Location
+	channels := make([]ChannelSummaryDto, len(summaries))
+	for i, s := range summaries {
+		buckets := make([]MeasurementBucketDto, len(s.Buckets))
+		for j, b := range s.Buckets {
+			buckets[j] = MeasurementBucketDto{
+				Start:               b.Start.In(loc),
+				End:                 b.End.In(loc),
+				MeasurementStatsDto: MeasurementStatsDto{}.DomainToDto(b.MeasurementStats),
+			}
+		}
+		channels[i] = ChannelSummaryDto{
+			ChannelId:           s.ChannelId,
+			Channel:             s.Channel,
+			Quantity:            s.Quantity,
+			Units:               s.Units,
+			MeasurementStatsDto: MeasurementStatsDto{}.DomainToDto(s.MeasurementStats),
+			Buckets:             buckets,
+		}
+	}
+	return MeasurementSummaryDto{
+		DeviceId:  deviceId,
+		StartDate: period.Start.In(loc),
+		EndDate:   period.End.In(loc),
+		Timezone:  loc.String(),
+		Interval:  string(size),
+		Channels:  channels,
+	}
+}
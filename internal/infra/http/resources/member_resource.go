@@ -0,0 +1,37 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type MembersDto struct {
+	Members []MemberDto `json:"members"`
+}
+
+type MemberDto struct {
+	UserId      uint64    `json:"userId"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	CreatedDate time.Time `json:"createdDate"`
+	UpdatedDate time.Time `json:"updatedDate"`
+}
+
+func (d MemberDto) DomainToDto(m domain.OrganizationMember) MemberDto {
+	return MemberDto{
+		UserId:      m.UserId,
+		Email:       m.Email,
+		Role:        string(m.Role),
+		CreatedDate: m.CreatedDate,
+		UpdatedDate: m.UpdatedDate,
+	}
+}
+
+func (d MembersDto) DomainToDto(members []domain.OrganizationMember) MembersDto {
+	res := make([]MemberDto, len(members))
+	for i, m := range members {
+		res[i] = MemberDto{}.DomainToDto(m)
+	}
+	return MembersDto{Members: res}
+}
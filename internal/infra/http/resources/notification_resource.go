@@ -0,0 +1,45 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type NotificationsDto struct {
+	Notifications []NotificationDto `json:"notifications"`
+}
+
+type NotificationDto struct {
+	Id             uint64     `json:"id"`
+	OrganizationId uint64     `json:"organizationId"`
+	Severity       string     `json:"severity"`
+	Title          string     `json:"title"`
+	Message        string     `json:"message"`
+	Source         string     `json:"source"`
+	SourceId       uint64     `json:"sourceId"`
+	ReadDate       *time.Time `json:"readDate"`
+	CreatedDate    time.Time  `json:"createdDate"`
+}
+
+func (d NotificationDto) DomainToDto(n domain.Notification) NotificationDto {
+	return NotificationDto{
+		Id:             n.Id,
+		OrganizationId: n.OrganizationId,
+		Severity:       string(n.Severity),
+		Title:          n.Title,
+		Message:        n.Message,
+		Source:         n.Source,
+		SourceId:       n.SourceId,
+		ReadDate:       n.ReadDate,
+		CreatedDate:    n.CreatedDate,
+	}
+}
+
+func (d NotificationsDto) DomainToDto(notifications []domain.Notification) NotificationsDto {
+	res := make([]NotificationDto, len(notifications))
+	for i, n := range notifications {
+		res[i] = NotificationDto{}.DomainToDto(n)
+	}
+	return NotificationsDto{Notifications: res}
+}
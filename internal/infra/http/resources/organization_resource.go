@@ -19,6 +19,7 @@ type OrgDto struct {
 	Address     string    `json:"address"`
 	Lat         float64   `json:"lat"`
 	Lon         float64   `json:"lon"`
+	Timezone    string    `json:"timezone"`
 	Rooms       []RoomDto `json:"rooms"`
 	CreatedDate time.Time `json:"createdDate"`
 	UpdatedDate time.Time `json:"updatedDate"`
@@ -40,6 +41,7 @@ func (d OrgDto) DomainToDto(o domain.Organization) OrgDto {
 		Address:     o.Address,
 		Lat:         o.Lat,
 		Lon:         o.Lon,
+		Timezone:    o.Timezone,
 		Rooms:       rooms,
 		CreatedDate: o.CreatedDate,
 		UpdatedDate: o.UpdatedDate,
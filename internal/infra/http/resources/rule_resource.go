@@ -0,0 +1,97 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type RulesDto struct {
+	Rules []RuleDto `json:"rules"`
+}
+
+type RuleDto struct {
+	Id              uint64                 `json:"id"`
+	OrganizationId  uint64                 `json:"organizationId"`
+	UserId          uint64                 `json:"userId"`
+	Name            string                 `json:"name"`
+	Enabled         bool                   `json:"enabled"`
+	DryRun          bool                   `json:"dryRun"`
+	Trigger         domain.RuleTrigger     `json:"trigger"`
+	Conditions      []domain.RuleCondition `json:"conditions"`
+	Actions         []domain.RuleAction    `json:"actions"`
+	CooldownSeconds uint64                 `json:"cooldownSeconds"`
+	LastFiredDate   *time.Time             `json:"lastFiredDate"`
+	CreatedDate     time.Time              `json:"createdDate"`
+	UpdatedDate     time.Time              `json:"updatedDate"`
+}
+
+type RuleExecutionsDto struct {
+	Executions []RuleExecutionDto `json:"executions"`
+}
+
+type RuleExecutionDto struct {
+	Id          uint64                    `json:"id"`
+	RuleId      uint64                    `json:"ruleId"`
+	Status      string                    `json:"status"`
+	Cause       string                    `json:"cause"`
+	Depth       uint                      `json:"depth"`
+	Results     []domain.RuleActionResult `json:"results"`
+	Reason      *string                   `json:"reason"`
+	CreatedDate time.Time                 `json:"createdDate"`
+}
+
+func (d RuleDto) DomainToDto(r domain.Rule) RuleDto {
+	conditions := r.Conditions
+	if conditions == nil {
+		conditions = []domain.RuleCondition{}
+	}
+	return RuleDto{
+		Id:              r.Id,
+		OrganizationId:  r.OrganizationId,
+		UserId:          r.UserId,
+		Name:            r.Name,
+		Enabled:         r.Enabled,
+		DryRun:          r.DryRun,
+		Trigger:         r.Trigger,
+		Conditions:      conditions,
+		Actions:         r.Actions,
+		CooldownSeconds: r.CooldownSeconds,
+		LastFiredDate:   r.LastFiredDate,
+		CreatedDate:     r.CreatedDate,
+		UpdatedDate:     r.UpdatedDate,
+	}
+}
+
+func (d RulesDto) DomainToDto(rules []domain.Rule) RulesDto {
+	res := make([]RuleDto, len(rules))
+	for i, r := range rules {
+		res[i] = RuleDto{}.DomainToDto(r)
+	}
+	return RulesDto{Rules: res}
+}
+
+func (d RuleExecutionDto) DomainToDto(e domain.RuleExecution) RuleExecutionDto {
+	results := e.Results
+	if results == nil {
+		results = []domain.RuleActionResult{}
+	}
+	return RuleExecutionDto{
+		Id:          e.Id,
+		RuleId:      e.RuleId,
+		Status:      string(e.Status),
+		Cause:       e.Cause,
+		Depth:       e.Depth,
+		Results:     results,
+		Reason:      e.Reason,
+		CreatedDate: e.CreatedDate,
+	}
+}
+
+func (d RuleExecutionsDto) DomainToDto(execs []domain.RuleExecution) RuleExecutionsDto {
+	res := make([]RuleExecutionDto, len(execs))
+	for i, e := range execs {
+		res[i] = RuleExecutionDto{}.DomainToDto(e)
+	}
+	return RuleExecutionsDto{Executions: res}
+}
@@ -0,0 +1,55 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type SchedulesDto struct {
+	Schedules []ScheduleDto `json:"schedules"`
+}
+
+type ScheduleDto struct {
+	Id             uint64                     `json:"id"`
+	OrganizationId uint64                     `json:"organizationId"`
+	DeviceId       *uint64                    `json:"deviceId"`
+	UserId         uint64                     `json:"userId"`
+	Name           string                     `json:"name"`
+	Enabled        bool                       `json:"enabled"`
+	Blocks         []domain.ScheduleBlock     `json:"blocks"`
+	Exceptions     []domain.ScheduleException `json:"exceptions"`
+	CreatedDate    time.Time                  `json:"createdDate"`
+	UpdatedDate    time.Time                  `json:"updatedDate"`
+}
+
+func (d ScheduleDto) DomainToDto(s domain.Schedule) ScheduleDto {
+	blocks := s.Blocks
+	if blocks == nil {
+		blocks = []domain.ScheduleBlock{}
+	}
+	exceptions := s.Exceptions
+	if exceptions == nil {
+		exceptions = []domain.ScheduleException{}
+	}
+	return ScheduleDto{
+		Id:             s.Id,
+		OrganizationId: s.OrganizationId,
+		DeviceId:       s.DeviceId,
+		UserId:         s.UserId,
+		Name:           s.Name,
+		Enabled:        s.Enabled,
+		Blocks:         blocks,
+		Exceptions:     exceptions,
+		CreatedDate:    s.CreatedDate,
+		UpdatedDate:    s.UpdatedDate,
+	}
+}
+
+func (d SchedulesDto) DomainToDto(schedules []domain.Schedule) SchedulesDto {
+	res := make([]ScheduleDto, len(schedules))
+	for i, s := range schedules {
+		res[i] = ScheduleDto{}.DomainToDto(s)
+	}
+	return SchedulesDto{Schedules: res}
+}
@@ -0,0 +1,43 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type SolarDto struct {
+	OrganizationId uint64        `json:"organizationId"`
+	Lat            float64       `json:"lat"`
+	Lon            float64       `json:"lon"`
+	Timezone       string        `json:"timezone"`
+	Days           []SolarDayDto `json:"days"`
+}
+
+type SolarDayDto struct {
+	Date      string     `json:"date"`
+	CivilDawn *time.Time `json:"civilDawn"`
+	Sunrise   *time.Time `json:"sunrise"`
+	Sunset    *time.Time `json:"sunset"`
+	CivilDusk *time.Time `json:"civilDusk"`
+}
+
+func (d SolarDto) DomainToDto(o domain.Organization, times []domain.SolarTimes) SolarDto {
+	days := make([]SolarDayDto, len(times))
+	for i, t := range times {
+		days[i] = SolarDayDto{
+			Date:      t.Date.Format(domain.ScheduleDateFormat),
+			CivilDawn: t.CivilDawn,
+			Sunrise:   t.Sunrise,
+			Sunset:    t.Sunset,
+			CivilDusk: t.CivilDusk,
+		}
+	}
+	return SolarDto{
+		OrganizationId: o.Id,
+		Lat:            o.Lat,
+		Lon:            o.Lon,
+		Timezone:       o.Location().String(),
+		Days:           days,
+	}
+}
@@ -0,0 +1,48 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/pubsub"
+)
+
+type StreamMessageDto struct {
+	Type           string      `json:"type"`
+	OrganizationId uint64      `json:"organizationId"`
+	RoomId         *uint64     `json:"room_id"`
+	DeviceId       uint64      `json:"device_id"`
+	Data           interface{} `json:"data"`
+	CreatedDate    time.Time   `json:"createdDate"`
+}
+
+func (d StreamMessageDto) DomainToDto(m pubsub.Message) StreamMessageDto {
+	var data interface{}
+	switch p := m.Payload.(type) {
+	case domain.Measurement:
+		data = MeasurementDto{}.DomainToDto(p)
+	case domain.Event:
+		data = EventDto{}.DomainToDto(p)
+	case domain.Device:
+		data = DeviceDto{}.DomainToDto(p)
+	case domain.Command:
+		data = CommandDto{}.DomainToDto(p)
+	case domain.DeviceTwin:
+		data = DeviceTwinDto{}.DomainToDto(p)
+	case domain.RuleExecution:
+		data = RuleExecutionDto{}.DomainToDto(p)
+	case domain.Notification:
+		data = NotificationDto{}.DomainToDto(p)
+	default:
+		data = p
+	}
+
+	return StreamMessageDto{
+		Type:           string(m.Type),
+		OrganizationId: m.OrganizationId,
+		RoomId:         m.RoomId,
+		DeviceId:       m.DeviceId,
+		Data:           data,
+		CreatedDate:    m.CreatedDate,
+	}
+}
@@ -0,0 +1,103 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type TariffsDto struct {
+	Tariffs []TariffDto `json:"tariffs"`
+}
+
+type TariffDto struct {
+	Id             uint64                `json:"id"`
+	OrganizationId uint64                `json:"organizationId"`
+	Name           string                `json:"name"`
+	Currency       string                `json:"currency"`
+	Type           string                `json:"type"`
+	Active         bool                  `json:"active"`
+	Rate           float64               `json:"rate"`
+	Windows        []domain.TariffWindow `json:"windows"`
+	Tiers          []domain.TariffTier   `json:"tiers"`
+	Seasons        []domain.TariffSeason `json:"seasons"`
+	CreatedDate    time.Time             `json:"createdDate"`
+	UpdatedDate    time.Time             `json:"updatedDate"`
+}
+
+type EnergyCostDto struct {
+	TariffId   uint64            `json:"tariffId"`
+	TariffName string            `json:"tariffName"`
+	Currency   string            `json:"currency"`
+	StartDate  time.Time         `json:"startDate"`
+	EndDate    time.Time         `json:"endDate"`
+	Timezone   string            `json:"timezone"`
+	Kwh        float64           `json:"kwh"`
+	Cost       float64           `json:"cost"`
+	Items      []CostLineItemDto `json:"items"`
+}
+
+type CostLineItemDto struct {
+	Name string  `json:"name"`
+	Rate float64 `json:"rate"`
+	Kwh  float64 `json:"kwh"`
+	Cost float64 `json:"cost"`
+}
+
+func (d TariffDto) DomainToDto(t domain.Tariff) TariffDto {
+	windows, tiers, seasons := t.Windows, t.Tiers, t.Seasons
+	if windows == nil {
+		windows = []domain.TariffWindow{}
+	}
+	if tiers == nil {
+		tiers = []domain.TariffTier{}
+	}
+	if seasons == nil {
+		seasons = []domain.TariffSeason{}
+	}
+	return TariffDto{
+		Id:             t.Id,
+		OrganizationId: t.OrganizationId,
+		Name:           t.Name,
+		Currency:       t.Currency,
+		Type:           string(t.Type),
+		Active:         t.Active,
+		Rate:           t.Rate,
+		Windows:        windows,
+		Tiers:          tiers,
+		Seasons:        seasons,
+		CreatedDate:    t.CreatedDate,
+		UpdatedDate:    t.UpdatedDate,
+	}
+}
+
+func (d TariffsDto) DomainToDto(tariffs []domain.Tariff) TariffsDto {
+	res := make([]TariffDto, len(tariffs))
+	for i, t := range tariffs {
+		res[i] = TariffDto{}.DomainToDto(t)
+	}
+	return TariffsDto{Tariffs: res}
+}
+
+func (d EnergyCostDto) DomainToDto(c domain.EnergyCost, loc *time.Location) EnergyCostDto {
+	items := make([]CostLineItemDto, len(c.Items))
+	for i, item := range c.Items {
+		items[i] = CostLineItemDto{
+			Name: item.Name,
+			Rate: item.Rate,
+			Kwh:  item.Energy,
+			Cost: item.Cost,
+		}
+	}
+	return EnergyCostDto{
+		TariffId:   c.TariffId,
+		TariffName: c.TariffName,
+		Currency:   c.Currency,
+		StartDate:  c.Start.In(loc),
+		EndDate:    c.End.In(loc),
+		Timezone:   loc.String(),
+		Kwh:        c.Energy,
+		Cost:       c.Cost,
+		Items:      items,
+	}
+}
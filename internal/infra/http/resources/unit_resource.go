@@ -0,0 +1,41 @@
+package resources
+
+import "github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+
+type UnitsDto struct {
+	Kinds []QuantityKindDto `json:"kinds"`
+}
+
+type QuantityKindDto struct {
+	Kind      string    `json:"kind"`
+	Canonical string    `json:"canonical"`
+	Units     []UnitDto `json:"units"`
+}
+
+type UnitDto struct {
+	Code    string   `json:"code"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+func (d UnitsDto) DomainToDto(units []domain.Unit) UnitsDto {
+	var kinds []QuantityKindDto
+	index := make(map[domain.QuantityKind]int)
+	for _, u := range units {
+		i, ok := index[u.Kind]
+		if !ok {
+			i = len(kinds)
+			index[u.Kind] = i
+			kinds = append(kinds, QuantityKindDto{Kind: string(u.Kind)})
+		}
+		if u.Canonical() {
+			kinds[i].Canonical = u.Code
+		}
+		aliases := u.Aliases
+		if aliases == nil {
+			aliases = []string{}
+		}
+		kinds[i].Units = append(kinds[i].Units, UnitDto{Code: u.Code, Name: u.Name, Aliases: aliases})
+	}
+	return UnitsDto{Kinds: kinds}
+}
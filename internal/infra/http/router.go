@@ -46,6 +46,20 @@ func Router(cont container.Container) http.Handler {
 				})
 			})
 
+			// Device routes
+			apiRouter.Group(func(apiRouter chi.Router) {
+				apiRouter.Use(cont.DeviceAuthMw)
+
+				DeviceApiRouter(apiRouter, cont.CommandController, cont.DeviceTwinController)
+			})
+
+			// Live streams
+			apiRouter.Group(func(apiRouter chi.Router) {
+				apiRouter.Use(cont.StreamAuthMw)
+
+				StreamRouter(apiRouter, cont.StreamController, cont.OrganizationService, cont.RoomService, cont.DeviceService)
+			})
+
 			// Protected routes
 			apiRouter.Group(func(apiRouter chi.Router) {
 				apiRouter.Use(cont.AuthMw)
@@ -55,7 +69,21 @@ func Router(cont container.Container) http.Handler {
 				RoomRouter(apiRouter, cont.RoomController, cont.RoomService)
 				DeviceRouter(apiRouter, cont.DeviceController, cont.DeviceService)
 				MeasurementRouter(apiRouter, cont.MeasurementController, cont.MeasurementService, cont.DeviceService)
-				EventRouter(apiRouter, cont.EventController, cont.EventService, cont.OrganizationService, cont.RoomService)
+				EventRouter(apiRouter, cont.EventController, cont.EventService, cont.OrganizationService, cont.RoomService, cont.DeviceService)
+				CommandRouter(apiRouter, cont.CommandController, cont.CommandService, cont.DeviceService)
+				DeviceTwinRouter(apiRouter, cont.DeviceTwinController, cont.OrganizationService, cont.DeviceService)
+				RuleRouter(apiRouter, cont.RuleController, cont.AutomationService, cont.OrganizationService)
+				ScheduleRouter(apiRouter, cont.ScheduleController, cont.ScheduleService, cont.OrganizationService)
+				TariffRouter(apiRouter, cont.TariffController, cont.TariffService, cont.OrganizationService)
+				BudgetRouter(apiRouter, cont.BudgetController, cont.BudgetService, cont.OrganizationService)
+				NotificationRouter(apiRouter, cont.NotificationController, cont.NotificationService)
+				EmissionFactorRouter(apiRouter, cont.EmissionFactorController, cont.EmissionFactorService, cont.OrganizationService)
+				DemandRouter(apiRouter, cont.DemandController, cont.OrganizationService)
+				InterlockRouter(apiRouter, cont.InterlockController, cont.InterlockService, cont.OrganizationService)
+				ApprovalRouter(apiRouter, cont.ApprovalController, cont.ApprovalService, cont.OrganizationService)
+				AnomalyRouter(apiRouter, cont.AnomalyController, cont.DeviceService, cont.OrganizationService)
+				CalibrationRouter(apiRouter, cont.CalibrationController, cont.CalibrationService, cont.DeviceService)
+				UnitRouter(apiRouter, cont.UnitController)
 				apiRouter.Handle("/*", NotFoundJSON())
 			})
 		})
@@ -130,6 +158,22 @@ func OrganizationRouter(r chi.Router, oc controllers.OrganizationController, os
 			"/{orgId}",
 			oc.Delete(),
 		)
+		apiRouter.With(opom).Get(
+			"/{orgId}/solar",
+			oc.Solar(),
+		)
+		apiRouter.With(opom).Get(
+			"/{orgId}/members",
+			oc.FindMembers(),
+		)
+		apiRouter.With(opom).Put(
+			"/{orgId}/members",
+			oc.SaveMember(),
+		)
+		apiRouter.With(opom).Delete(
+			"/{orgId}/members/{userId}",
+			oc.DeleteMember(),
+		)
 	})
 }
 
@@ -188,6 +232,26 @@ func DeviceRouter(r chi.Router, dc controllers.DeviceController, ds app.DeviceSe
 			"/{deviceId}/uninstall",
 			dc.Uninstall(),
 		)
+		apiRouter.With(dOpom).Put(
+			"/{deviceId}/secret",
+			dc.RotateSecret(),
+		)
+		apiRouter.With(dOpom).Get(
+			"/{deviceId}/channels",
+			dc.FindChannels(),
+		)
+		apiRouter.With(dOpom).Post(
+			"/{deviceId}/channels",
+			dc.SaveChannel(),
+		)
+		apiRouter.With(dOpom).Put(
+			"/{deviceId}/channels/{channelKey}",
+			dc.UpdateChannel(),
+		)
+		apiRouter.With(dOpom).Delete(
+			"/{deviceId}/channels/{channelKey}",
+			dc.DeleteChannel(),
+		)
 		apiRouter.With(dOpom).Delete(
 			"/{deviceId}",
 			dc.Delete(),
@@ -210,12 +274,17 @@ func MeasurementRouter(r chi.Router, cm controllers.MeasurementController, ms ap
 			"/{deviceId}",
 			cm.FindByDeviceAndDate(),
 		)
+		apiRouter.With(dOpom).Get(
+			"/{deviceId}/summary",
+			cm.Summarize(),
+		)
 	})
 }
 
-func EventRouter(r chi.Router, ec controllers.EventController, es app.EventService, os app.OrganizationService, rs app.RoomService) {
-	// opom := middlewares.PathObject("orgId", controllers.OrgKey, os)
+func EventRouter(r chi.Router, ec controllers.EventController, es app.EventService, os app.OrganizationService, rs app.RoomService, ds app.DeviceService) {
+	opom := middlewares.PathObject("orgId", controllers.OrgKey, os)
 	rOpom := middlewares.PathObject("roomId", controllers.RoKey, rs)
+	dOpom := middlewares.PathObject("deviceId", controllers.DevKey, ds)
 
 	r.Route("/events", func(apiRouter chi.Router) {
 		apiRouter.Post(
@@ -226,10 +295,58 @@ func EventRouter(r chi.Router, ec controllers.EventController, es app.EventServi
 			"/",
 			ec.FindAll(),
 		)
-		// apiRouter.With(opom).Get(
-		// 	"/{orgId}",
-		// 	ec.GetTotalPowerConsumptionForOrg(),
-		// )
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}",
+			ec.GetConsumptionForOrg(),
+		)
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}/series",
+			ec.GetPowerSeriesForOrg(),
+		)
+		apiRouter.With(rOpom).Get(
+			"/rooms/{roomId}/series",
+			ec.GetPowerSeriesForRoom(),
+		)
+		apiRouter.With(dOpom).Get(
+			"/devices/{deviceId}/series",
+			ec.GetPowerSeriesForDevice(),
+		)
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}/cost",
+			ec.GetEnergyCostForOrg(),
+		)
+		apiRouter.With(rOpom).Get(
+			"/rooms/{roomId}/cost",
+			ec.GetEnergyCostForRoom(),
+		)
+		apiRouter.With(dOpom).Get(
+			"/devices/{deviceId}/cost",
+			ec.GetEnergyCostForDevice(),
+		)
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}/emissions",
+			ec.GetEmissionsForOrg(),
+		)
+		apiRouter.With(rOpom).Get(
+			"/rooms/{roomId}/emissions",
+			ec.GetEmissionsForRoom(),
+		)
+		apiRouter.With(dOpom).Get(
+			"/devices/{deviceId}/emissions",
+			ec.GetEmissionsForDevice(),
+		)
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}/forecast",
+			ec.GetForecastForOrg(),
+		)
+		apiRouter.With(rOpom).Get(
+			"/rooms/{roomId}/forecast",
+			ec.GetForecastForRoom(),
+		)
+		apiRouter.With(dOpom).Get(
+			"/devices/{deviceId}/forecast",
+			ec.GetForecastForDevice(),
+		)
 		apiRouter.With(rOpom).Get(
 			"/{roomId}",
 			ec.GetPowerConsumptionByRoom(),
@@ -237,6 +354,400 @@ func EventRouter(r chi.Router, ec controllers.EventController, es app.EventServi
 	})
 }
 
+func CommandRouter(r chi.Router, cc controllers.CommandController, cs app.CommandService, ds app.DeviceService) {
+	cOpom := middlewares.PathObject("commandId", controllers.CommandKey, cs)
+	dOpom := middlewares.PathObject("deviceId", controllers.DevKey, ds)
+
+	r.Route("/commands", func(apiRouter chi.Router) {
+		apiRouter.Post(
+			"/",
+			cc.Save(),
+		)
+		apiRouter.With(cOpom).Get(
+			"/{commandId}",
+			cc.Find(),
+		)
+		apiRouter.With(dOpom).Get(
+			"/devices/{deviceId}",
+			cc.FindByDevice(),
+		)
+	})
+}
+
+func DeviceTwinRouter(r chi.Router, tc controllers.DeviceTwinController, os app.OrganizationService, ds app.DeviceService) {
+	opom := middlewares.PathObject("orgId", controllers.OrgKey, os)
+	dOpom := middlewares.PathObject("deviceId", controllers.DevKey, ds)
+
+	r.Route("/twins", func(apiRouter chi.Router) {
+		apiRouter.With(dOpom).Get(
+			"/devices/{deviceId}",
+			tc.Find(),
+		)
+		apiRouter.With(dOpom).Put(
+			"/devices/{deviceId}/desired",
+			tc.UpdateDesired(),
+		)
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}/drift",
+			tc.FindDrift(),
+		)
+	})
+}
+
+func RuleRouter(r chi.Router, rc controllers.RuleController, as app.AutomationService, os app.OrganizationService) {
+	ruOpom := middlewares.PathObject("ruleId", controllers.RuleKey, as)
+	opom := middlewares.PathObject("orgId", controllers.OrgKey, os)
+
+	r.Route("/rules", func(apiRouter chi.Router) {
+		apiRouter.Post(
+			"/",
+			rc.Save(),
+		)
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}",
+			rc.FindForOrganization(),
+		)
+		apiRouter.With(ruOpom).Get(
+			"/{ruleId}",
+			rc.Find(),
+		)
+		apiRouter.With(ruOpom).Put(
+			"/{ruleId}",
+			rc.Update(),
+		)
+		apiRouter.With(ruOpom).Delete(
+			"/{ruleId}",
+			rc.Delete(),
+		)
+		apiRouter.With(ruOpom).Get(
+			"/{ruleId}/executions",
+			rc.FindExecutions(),
+		)
+	})
+}
+
+func ScheduleRouter(r chi.Router, sc controllers.ScheduleController, ss app.ScheduleService, os app.OrganizationService) {
+	sOpom := middlewares.PathObject("scheduleId", controllers.ScheduleKey, ss)
+	opom := middlewares.PathObject("orgId", controllers.OrgKey, os)
+
+	r.Route("/schedules", func(apiRouter chi.Router) {
+		apiRouter.Post(
+			"/",
+			sc.Save(),
+		)
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}",
+			sc.FindForOrganization(),
+		)
+		apiRouter.With(sOpom).Get(
+			"/{scheduleId}",
+			sc.Find(),
+		)
+		apiRouter.With(sOpom).Put(
+			"/{scheduleId}",
+			sc.Update(),
+		)
+		apiRouter.With(sOpom).Delete(
+			"/{scheduleId}",
+			sc.Delete(),
+		)
+	})
+}
+
+func TariffRouter(r chi.Router, tc controllers.TariffController, ts app.TariffService, os app.OrganizationService) {
+	tOpom := middlewares.PathObject("tariffId", controllers.TariffKey, ts)
+	opom := middlewares.PathObject("orgId", controllers.OrgKey, os)
+
+	r.Route("/tariffs", func(apiRouter chi.Router) {
+		apiRouter.Post(
+			"/",
+			tc.Save(),
+		)
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}",
+			tc.FindForOrganization(),
+		)
+		apiRouter.With(tOpom).Get(
+			"/{tariffId}",
+			tc.Find(),
+		)
+		apiRouter.With(tOpom).Put(
+			"/{tariffId}",
+			tc.Update(),
+		)
+		apiRouter.With(tOpom).Delete(
+			"/{tariffId}",
+			tc.Delete(),
+		)
+	})
+}
+
+func BudgetRouter(r chi.Router, bc controllers.BudgetController, bs app.BudgetService, os app.OrganizationService) {
+	bOpom := middlewares.PathObject("budgetId", controllers.BudgetKey, bs)
+	opom := middlewares.PathObject("orgId", controllers.OrgKey, os)
+
+	r.Route("/budgets", func(apiRouter chi.Router) {
+		apiRouter.Post(
+			"/",
+			bc.Save(),
+		)
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}",
+			bc.FindForOrganization(),
+		)
+		apiRouter.With(bOpom).Get(
+			"/{budgetId}",
+			bc.Find(),
+		)
+		apiRouter.With(bOpom).Get(
+			"/{budgetId}/status",
+			bc.Status(),
+		)
+		apiRouter.With(bOpom).Put(
+			"/{budgetId}",
+			bc.Update(),
+		)
+		apiRouter.With(bOpom).Delete(
+			"/{budgetId}",
+			bc.Delete(),
+		)
+	})
+}
+
+func NotificationRouter(r chi.Router, nc controllers.NotificationController, ns app.NotificationService) {
+	nOpom := middlewares.PathObject("notificationId", controllers.NotificationKey, ns)
+
+	r.Route("/notifications", func(apiRouter chi.Router) {
+		apiRouter.Get(
+			"/",
+			nc.FindForUser(),
+		)
+		apiRouter.With(nOpom).Put(
+			"/{notificationId}/read",
+			nc.MarkRead(),
+		)
+	})
+}
+
+func EmissionFactorRouter(r chi.Router, fc controllers.EmissionFactorController, fs app.EmissionFactorService, os app.OrganizationService) {
+	fOpom := middlewares.PathObject("factorId", controllers.EmissionFactorKey, fs)
+	opom := middlewares.PathObject("orgId", controllers.OrgKey, os)
+
+	r.Route("/emission-factors", func(apiRouter chi.Router) {
+		apiRouter.Post(
+			"/",
+			fc.Save(),
+		)
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}",
+			fc.FindForOrganization(),
+		)
+		apiRouter.With(fOpom).Get(
+			"/{factorId}",
+			fc.Find(),
+		)
+		apiRouter.With(fOpom).Put(
+			"/{factorId}",
+			fc.Update(),
+		)
+		apiRouter.With(fOpom).Put(
+			"/{factorId}/entries",
+			fc.ImportEntries(),
+		)
+		apiRouter.With(fOpom).Delete(
+			"/{factorId}",
+			fc.Delete(),
+		)
+	})
+}
+
+func DemandRouter(r chi.Router, dc controllers.DemandController, os app.OrganizationService) {
+	opom := middlewares.PathObject("orgId", controllers.OrgKey, os)
+
+	r.Route("/demand/organizations/{orgId}", func(apiRouter chi.Router) {
+		apiRouter.Use(opom)
+		apiRouter.Get(
+			"/policy",
+			dc.FindPolicy(),
+		)
+		apiRouter.Put(
+			"/policy",
+			dc.SavePolicy(),
+		)
+		apiRouter.Delete(
+			"/policy",
+			dc.DeletePolicy(),
+		)
+		apiRouter.Get(
+			"/decisions",
+			dc.FindDecisions(),
+		)
+		apiRouter.Get(
+			"/requests",
+			dc.FindRequests(),
+		)
+	})
+}
+
+func InterlockRouter(r chi.Router, ic controllers.InterlockController, is app.InterlockService, os app.OrganizationService) {
+	iOpom := middlewares.PathObject("interlockId", controllers.InterlockKey, is)
+	opom := middlewares.PathObject("orgId", controllers.OrgKey, os)
+
+	r.Route("/interlocks", func(apiRouter chi.Router) {
+		apiRouter.Post(
+			"/",
+			ic.Save(),
+		)
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}",
+			ic.FindForOrganization(),
+		)
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}/overrides",
+			ic.FindOverrides(),
+		)
+		apiRouter.With(iOpom).Get(
+			"/{interlockId}",
+			ic.Find(),
+		)
+		apiRouter.With(iOpom).Put(
+			"/{interlockId}",
+			ic.Update(),
+		)
+		apiRouter.With(iOpom).Delete(
+			"/{interlockId}",
+			ic.Delete(),
+		)
+	})
+}
+
+func ApprovalRouter(r chi.Router, ac controllers.ApprovalController, as app.ApprovalService, os app.OrganizationService) {
+	aOpom := middlewares.PathObject("approvalId", controllers.ApprovalKey, as)
+	opom := middlewares.PathObject("orgId", controllers.OrgKey, os)
+
+	r.Route("/approvals", func(apiRouter chi.Router) {
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}",
+			ac.FindForOrganization(),
+		)
+		apiRouter.With(aOpom).Get(
+			"/{approvalId}",
+			ac.Find(),
+		)
+		apiRouter.With(aOpom).Post(
+			"/{approvalId}/approve",
+			ac.Approve(),
+		)
+		apiRouter.With(aOpom).Post(
+			"/{approvalId}/reject",
+			ac.Reject(),
+		)
+	})
+}
+
+func AnomalyRouter(r chi.Router, ac controllers.AnomalyController, ds app.DeviceService, os app.OrganizationService) {
+	dOpom := middlewares.PathObject("deviceId", controllers.DevKey, ds)
+	opom := middlewares.PathObject("orgId", controllers.OrgKey, os)
+
+	r.Route("/anomalies", func(apiRouter chi.Router) {
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}",
+			ac.FindForOrganization(),
+		)
+		apiRouter.With(dOpom).Get(
+			"/devices/{deviceId}",
+			ac.FindForDevice(),
+		)
+		apiRouter.With(dOpom).Get(
+			"/devices/{deviceId}/settings",
+			ac.FindSettings(),
+		)
+		apiRouter.With(dOpom).Put(
+			"/devices/{deviceId}/settings",
+			ac.SaveSettings(),
+		)
+		apiRouter.With(dOpom).Delete(
+			"/devices/{deviceId}/settings",
+			ac.DeleteSettings(),
+		)
+	})
+}
+
+func CalibrationRouter(r chi.Router, cc controllers.CalibrationController, cs app.CalibrationService, ds app.DeviceService) {
+	cOpom := middlewares.PathObject("calibrationId", controllers.CalibrationKey, cs)
+	dOpom := middlewares.PathObject("deviceId", controllers.DevKey, ds)
+
+	r.Route("/calibrations", func(apiRouter chi.Router) {
+		apiRouter.With(dOpom).Post(
+			"/devices/{deviceId}",
+			cc.Save(),
+		)
+		apiRouter.With(dOpom).Get(
+			"/devices/{deviceId}",
+			cc.FindForDevice(),
+		)
+		apiRouter.With(cOpom).Get(
+			"/{calibrationId}",
+			cc.Find(),
+		)
+		apiRouter.With(cOpom).Post(
+			"/{calibrationId}/recompute",
+			cc.Recompute(),
+		)
+		apiRouter.With(cOpom).Delete(
+			"/{calibrationId}",
+			cc.Delete(),
+		)
+	})
+}
+
+func UnitRouter(r chi.Router, uc controllers.UnitController) {
+	r.Route("/units", func(apiRouter chi.Router) {
+		apiRouter.Get(
+			"/",
+			uc.FindAll(),
+		)
+	})
+}
+
+func DeviceApiRouter(r chi.Router, cc controllers.CommandController, tc controllers.DeviceTwinController) {
+	r.Route("/device-api", func(apiRouter chi.Router) {
+		apiRouter.Get(
+			"/commands",
+			cc.Poll(),
+		)
+		apiRouter.Post(
+			"/commands/{commandId}/ack",
+			cc.Acknowledge(),
+		)
+		apiRouter.Put(
+			"/twin/reported",
+			tc.UpdateReported(),
+		)
+	})
+}
+
+func StreamRouter(r chi.Router, sc controllers.StreamController, os app.OrganizationService, rs app.RoomService, ds app.DeviceService) {
+	opom := middlewares.PathObject("orgId", controllers.OrgKey, os)
+	rOpom := middlewares.PathObject("roomId", controllers.RoKey, rs)
+	dOpom := middlewares.PathObject("deviceId", controllers.DevKey, ds)
+
+	r.Route("/stream", func(apiRouter chi.Router) {
+		apiRouter.With(opom).Get(
+			"/organizations/{orgId}",
+			sc.Organization(),
+		)
+		apiRouter.With(rOpom).Get(
+			"/rooms/{roomId}",
+			sc.Room(),
+		)
+		apiRouter.With(dOpom).Get(
+			"/devices/{deviceId}",
+			sc.Device(),
+		)
+	})
+}
+
 func NotFoundJSON() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
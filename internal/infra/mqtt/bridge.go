@@ -0,0 +1,388 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/config"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/pubsub"
+)
+
+const (
+	EmbeddedMode = "embedded"
+	ExternalMode = "external"
+
+	measurementTopic = "measurement"
+	eventTopic       = "event"
+	commandTopic     = "command"
+	commandAckTopic  = "command/ack"
+	twinReportTopic  = "twin/reported"
+)
+
+// transport is implemented by the embedded broker and by the client of an external broker.
+type transport interface {
+	Subscribe(filter string, handler func(topic string, payload []byte)) error
+	Publish(topic string, payload []byte) error
+	Close() error
+}
+
+// measurementPayload is a single reading in Value, a reading of one channel of a sensor in
+// Channel and Value, or the readings of several channels taken at once in Values.
+type measurementPayload struct {
+	Value   *float64           `json:"value"`
+	Channel *string            `json:"channel"`
+	Values  map[string]float64 `json:"values"`
+}
+
+// readings returns the readings of the channels of the payload sorted by channel.
+func (p measurementPayload) readings() []domain.ChannelReading {
+	if len(p.Values) == 0 {
+		return []domain.ChannelReading{{Channel: *p.Channel, Value: *p.Value}}
+	}
+	readings := make([]domain.ChannelReading, 0, len(p.Values))
+	for channel, value := range p.Values {
+		readings = append(readings, domain.ChannelReading{Channel: channel, Value: value})
+	}
+	sort.Slice(readings, func(i, j int) bool { return readings[i].Channel < readings[j].Channel })
+	return readings
+}
+
+// actionParams are the arguments of SET_* actions in event and command payloads.
+type actionParams struct {
+	Level    *float64 `json:"level,omitempty"`
+	Setpoint *float64 `json:"setpoint,omitempty"`
+	Unit     *string  `json:"unit,omitempty"`
+	Mode     *string  `json:"mode,omitempty"`
+}
+
+type eventPayload struct {
+	Action string `json:"action"`
+	actionParams
+}
+
+type commandPayload struct {
+	Id          uint64    `json:"id"`
+	Action      string    `json:"action"`
+	ExpiresDate time.Time `json:"expiresDate"`
+	actionParams
+}
+
+type twinReportPayload struct {
+	Power    *string  `json:"power"`
+	Level    *float64 `json:"level"`
+	Setpoint *float64 `json:"setpoint"`
+	Mode     *string  `json:"mode"`
+}
+
+type commandAckPayload struct {
+	Id      uint64 `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+}
+
+// Bridge maps device topics of the form org/{orgId}/device/{guid}/{kind} onto the application services.
+type Bridge struct {
+	transport          transport
+	hub                pubsub.Hub
+	sub                *pubsub.Subscription
+	deviceService      app.DeviceService
+	measurementService app.MeasurementService
+	eventService       app.EventService
+	commandService     app.CommandService
+	twinService        app.DeviceTwinService
+}
+
+func NewBridge(conf config.Configuration, h pubsub.Hub, ds app.DeviceService, ms app.MeasurementService, es app.EventService, cs app.CommandService, ts app.DeviceTwinService) (*Bridge, error) {
+	var (
+		t   transport
+		err error
+	)
+	switch conf.MqttMode {
+	case EmbeddedMode:
+		t, err = NewEmbeddedBroker(conf.MqttListenAddress, ds)
+	case ExternalMode:
+		t, err = newExternalClient(conf)
+	default:
+		err = fmt.Errorf("unknown mqtt mode %q", conf.MqttMode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newBridge(t, h, ds, ms, es, cs, ts), nil
+}
+
+func newBridge(t transport, h pubsub.Hub, ds app.DeviceService, ms app.MeasurementService, es app.EventService, cs app.CommandService, ts app.DeviceTwinService) *Bridge {
+	return &Bridge{
+		transport:          t,
+		hub:                h,
+		deviceService:      ds,
+		measurementService: ms,
+		eventService:       es,
+		commandService:     cs,
+		twinService:        ts,
+	}
+}
+
+func (b *Bridge) Start() error {
+	err := b.transport.Subscribe(DeviceTopic("+", "+", measurementTopic), b.handleMeasurement)
+	if err != nil {
+		return err
+	}
+
+	err = b.transport.Subscribe(DeviceTopic("+", "+", eventTopic), b.handleEvent)
+	if err != nil {
+		return err
+	}
+
+	err = b.transport.Subscribe(DeviceTopic("+", "+", commandAckTopic), b.handleCommandAck)
+	if err != nil {
+		return err
+	}
+
+	err = b.transport.Subscribe(DeviceTopic("+", "+", twinReportTopic), b.handleTwinReport)
+	if err != nil {
+		return err
+	}
+
+	b.sub = b.hub.Subscribe(pubsub.Filter{Types: []pubsub.MessageType{pubsub.CommandCreated}})
+	go b.pushCommands(b.sub)
+
+	log.Printf("MqttBridge: listening for device measurements and events")
+	return nil
+}
+
+func (b *Bridge) Close() error {
+	if b.sub != nil {
+		b.hub.Unsubscribe(b.sub)
+	}
+	return b.transport.Close()
+}
+
+func (b *Bridge) handleMeasurement(topic string, payload []byte) {
+	device, err := b.deviceForTopic(topic)
+	if err != nil {
+		log.Printf("MqttBridge: %s: %s", topic, err)
+		return
+	}
+
+	var p measurementPayload
+	err = json.Unmarshal(payload, &p)
+	if err != nil || (p.Value == nil && len(p.Values) == 0) || (p.Value != nil && len(p.Values) > 0) {
+		log.Printf("MqttBridge: %s: invalid measurement payload", topic)
+		return
+	}
+
+	if len(p.Values) > 0 || p.Channel != nil {
+		_, err = b.measurementService.SaveReadings(device.Id, device.RoomId, p.readings())
+		if err != nil {
+			log.Printf("MqttBridge: %s: %s", topic, err)
+		}
+		return
+	}
+	_, err = b.measurementService.Save(domain.Measurement{
+		DeviceId: device.Id,
+		RoomId:   device.RoomId,
+		Value:    *p.Value,
+	})
+	if err != nil {
+		log.Printf("MqttBridge: %s: %s", topic, err)
+	}
+}
+
+func (b *Bridge) handleEvent(topic string, payload []byte) {
+	device, err := b.deviceForTopic(topic)
+	if err != nil {
+		log.Printf("MqttBridge: %s: %s", topic, err)
+		return
+	}
+
+	var p eventPayload
+	err = json.Unmarshal(payload, &p)
+	if err != nil {
+		log.Printf("MqttBridge: %s: invalid event payload", topic)
+		return
+	}
+
+	action, err := domain.ParseEventAction(p.Action)
+	if err != nil {
+		log.Printf("MqttBridge: %s: invalid action %q", topic, p.Action)
+		return
+	}
+
+	_, err = b.eventService.Record(domain.Event{
+		DeviceId: device.Id,
+		RoomId:   device.RoomId,
+		Action:   action,
+		ActionParams: domain.ActionParams{
+			Level:        p.Level,
+			Setpoint:     p.Setpoint,
+			SetpointUnit: p.Unit,
+			Mode:         p.Mode,
+		}.For(action),
+	})
+	if errors.Is(err, domain.ErrNoStateChange) {
+		log.Printf("MqttBridge: %s: ignoring %s, device is already in this state", topic, action)
+		return
+	}
+	if err != nil {
+		log.Printf("MqttBridge: %s: %s", topic, err)
+	}
+}
+
+func (b *Bridge) handleCommandAck(topic string, payload []byte) {
+	device, err := b.deviceForTopic(topic)
+	if err != nil {
+		log.Printf("MqttBridge: %s: %s", topic, err)
+		return
+	}
+
+	var p commandAckPayload
+	err = json.Unmarshal(payload, &p)
+	if err != nil || p.Id == 0 {
+		log.Printf("MqttBridge: %s: invalid command acknowledgement payload", topic)
+		return
+	}
+
+	_, err = b.commandService.Acknowledge(device, p.Id, p.Success, p.Error)
+	if err != nil {
+		log.Printf("MqttBridge: %s: %s", topic, err)
+	}
+}
+
+func (b *Bridge) handleTwinReport(topic string, payload []byte) {
+	device, err := b.deviceForTopic(topic)
+	if err != nil {
+		log.Printf("MqttBridge: %s: %s", topic, err)
+		return
+	}
+
+	var p twinReportPayload
+	err = json.Unmarshal(payload, &p)
+	if err != nil {
+		log.Printf("MqttBridge: %s: invalid twin payload", topic)
+		return
+	}
+
+	state := domain.TwinState{
+		Level:    p.Level,
+		Setpoint: p.Setpoint,
+		Mode:     p.Mode,
+	}
+	if p.Power != nil {
+		power, ok := parsePower(*p.Power)
+		if !ok {
+			log.Printf("MqttBridge: %s: invalid power %q", topic, *p.Power)
+			return
+		}
+		state.Power = &power
+	}
+
+	_, err = b.twinService.UpdateReported(device, state)
+	if err != nil {
+		log.Printf("MqttBridge: %s: %s", topic, err)
+	}
+}
+
+// pushCommands forwards new commands to the command topic of their device.
+func (b *Bridge) pushCommands(sub *pubsub.Subscription) {
+	for m := range sub.C {
+		cmd, ok := m.Payload.(domain.Command)
+		if !ok {
+			continue
+		}
+
+		device, err := b.deviceService.Find(cmd.DeviceId)
+		if err != nil {
+			log.Printf("MqttBridge: %s", err)
+			continue
+		}
+		d := device.(domain.Device)
+
+		body, err := json.Marshal(commandPayload{
+			Id:          cmd.Id,
+			Action:      string(cmd.Action),
+			ExpiresDate: cmd.ExpiresDate,
+			actionParams: actionParams{
+				Level:    cmd.Level,
+				Setpoint: cmd.Setpoint,
+				Unit:     cmd.SetpointUnit,
+				Mode:     cmd.Mode,
+			},
+		})
+		if err != nil {
+			log.Printf("MqttBridge: %s", err)
+			continue
+		}
+
+		topic := DeviceTopic(strconv.FormatUint(d.OrganizationId, 10), d.GUID, commandTopic)
+		err = b.transport.Publish(topic, body)
+		if err != nil {
+			log.Printf("MqttBridge: %s: %s", topic, err)
+			continue
+		}
+
+		_, err = b.commandService.MarkDelivered(cmd)
+		if err != nil {
+			log.Printf("MqttBridge: %s", err)
+		}
+	}
+}
+
+func (b *Bridge) deviceForTopic(topic string) (domain.Device, error) {
+	orgId, guid, _, err := ParseDeviceTopic(topic)
+	if err != nil {
+		return domain.Device{}, err
+	}
+
+	device, err := b.deviceService.FindByGUID(guid)
+	if err != nil {
+		return domain.Device{}, errors.New("unknown device")
+	}
+	if device.OrganizationId != orgId {
+		return domain.Device{}, errors.New("device does not belong to organization")
+	}
+
+	return device, nil
+}
+
+func parsePower(a string) (domain.EventAction, bool) {
+	switch strings.ToUpper(a) {
+	case string(domain.TurnOn):
+		return domain.TurnOn, true
+	case string(domain.TurnOff):
+		return domain.TurnOff, true
+	default:
+		return "", false
+	}
+}
+
+func DeviceTopic(orgId, guid, kind string) string {
+	return fmt.Sprintf("org/%s/device/%s/%s", orgId, guid, kind)
+}
+
+func DevicePrefix(d domain.Device) string {
+	return fmt.Sprintf("org/%d/device/%s/", d.OrganizationId, d.GUID)
+}
+
+func ParseDeviceTopic(topic string) (uint64, string, string, error) {
+	parts := strings.Split(topic, "/")
+	if len(parts) < 5 || parts[0] != "org" || parts[2] != "device" {
+		return 0, "", "", fmt.Errorf("invalid device topic %q", topic)
+	}
+
+	orgId, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, "", "", fmt.Errorf("invalid organization id in topic %q", topic)
+	}
+
+	return orgId, parts[3], strings.Join(parts[4:], "/"), nil
+}
@@ -0,0 +1,308 @@
+package mqtt
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/pubsub"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const testTimeout = 5 * time.Second
+
+var errBadCredentials = errors.New("invalid device credentials")
+
+// fakeDevices knows the devices by GUID, each with the secret it connects with.
+type fakeDevices struct {
+	app.DeviceService
+	devices map[string]domain.Device
+	secrets map[string]string
+}
+
+func (f fakeDevices) FindByGUID(guid string) (domain.Device, error) {
+	d, ok := f.devices[guid]
+	if !ok {
+		return domain.Device{}, errors.New("device not found")
+	}
+	return d, nil
+}
+
+func (f fakeDevices) Find(id uint64) (interface{}, error) {
+	for _, d := range f.devices {
+		if d.Id == id {
+			return d, nil
+		}
+	}
+	return nil, errors.New("device not found")
+}
+
+func (f fakeDevices) Authenticate(guid, secret string) (domain.Device, error) {
+	d, ok := f.devices[guid]
+	if !ok || f.secrets[guid] != secret {
+		return domain.Device{}, errBadCredentials
+	}
+	return d, nil
+}
+
+type fakeMeasurements struct {
+	app.MeasurementService
+	saved chan domain.Measurement
+}
+
+func (f fakeMeasurements) Save(m domain.Measurement) (domain.Measurement, error) {
+	f.saved <- m
+	return m, nil
+}
+
+func (f fakeMeasurements) SaveReadings(deviceId uint64, roomId *uint64, readings []domain.ChannelReading) ([]domain.Measurement, error) {
+	var res []domain.Measurement
+	for _, r := range readings {
+		m := domain.Measurement{DeviceId: deviceId, RoomId: roomId, Value: r.Value}
+		f.saved <- m
+		res = append(res, m)
+	}
+	return res, nil
+}
+
+type fakeEvents struct {
+	app.EventService
+	recorded chan domain.Event
+}
+
+func (f fakeEvents) Record(e domain.Event) (domain.Event, error) {
+	f.recorded <- e
+	return e, nil
+}
+
+type commandAck struct {
+	DeviceId  uint64
+	CommandId uint64
+	Success   bool
+	Error     string
+}
+
+type fakeCommands struct {
+	app.CommandService
+	acked chan commandAck
+}
+
+func (f fakeCommands) Acknowledge(device domain.Device, commandId uint64, success bool, errMsg string) (domain.Command, error) {
+	f.acked <- commandAck{DeviceId: device.Id, CommandId: commandId, Success: success, Error: errMsg}
+	return domain.Command{Id: commandId, DeviceId: device.Id}, nil
+}
+
+func (f fakeCommands) MarkDelivered(c domain.Command) (domain.Command, error) {
+	return c, nil
+}
+
+type fakeTwins struct {
+	app.DeviceTwinService
+	reported chan domain.TwinState
+}
+
+func (f fakeTwins) UpdateReported(device domain.Device, reported domain.TwinState) (domain.DeviceTwin, error) {
+	f.reported <- reported
+	return domain.DeviceTwin{DeviceId: device.Id, Reported: reported}, nil
+}
+
+type bridgeFixture struct {
+	address      string
+	bridge       *Bridge
+	measurements fakeMeasurements
+	events       fakeEvents
+	commands     fakeCommands
+	twins        fakeTwins
+}
+
+// startBridge runs the bridge on an embedded broker with two devices of organization 1,
+// "sensor-a" and "sensor-b", whose secrets are "secret-a" and "secret-b".
+func startBridge(t *testing.T) bridgeFixture {
+	t.Helper()
+
+	devices := fakeDevices{
+		devices: map[string]domain.Device{
+			"sensor-a": {Id: 1, GUID: "sensor-a", OrganizationId: 1},
+			"sensor-b": {Id: 2, GUID: "sensor-b", OrganizationId: 1},
+		},
+		secrets: map[string]string{
+			"sensor-a": "secret-a",
+			"sensor-b": "secret-b",
+		},
+	}
+	f := bridgeFixture{
+		address:      freeAddress(t),
+		measurements: fakeMeasurements{saved: make(chan domain.Measurement, 8)},
+		events:       fakeEvents{recorded: make(chan domain.Event, 8)},
+		commands:     fakeCommands{acked: make(chan commandAck, 8)},
+		twins:        fakeTwins{reported: make(chan domain.TwinState, 8)},
+	}
+
+	broker, err := NewEmbeddedBroker(f.address, devices)
+	if err != nil {
+		t.Fatalf("starting broker: %s", err)
+	}
+	f.bridge = newBridge(broker, pubsub.NewHub(), devices, f.measurements, f.events, f.commands, f.twins)
+	err = f.bridge.Start()
+	if err != nil {
+		t.Fatalf("starting bridge: %s", err)
+	}
+	t.Cleanup(func() { _ = f.bridge.Close() })
+	return f
+}
+
+func freeAddress(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("finding a free port: %s", err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func connect(address, guid, secret string) (paho.Client, error) {
+	opts := paho.NewClientOptions().
+		AddBroker("tcp://" + address).
+		SetClientID(fmt.Sprintf("%s-%d", guid, time.Now().UnixNano())).
+		SetUsername(guid).
+		SetPassword(secret).
+		SetConnectRetry(false).
+		SetAutoReconnect(false)
+
+	client := paho.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(testTimeout) {
+		return nil, errors.New("timed out connecting")
+	}
+	return client, token.Error()
+}
+
+func connectDevice(t *testing.T, address, guid, secret string) paho.Client {
+	t.Helper()
+
+	client, err := connect(address, guid, secret)
+	if err != nil {
+		t.Fatalf("connecting %s: %s", guid, err)
+	}
+	t.Cleanup(func() { client.Disconnect(100) })
+	return client
+}
+
+func publish(t *testing.T, client paho.Client, topic, payload string) {
+	t.Helper()
+
+	token := client.Publish(topic, 1, false, payload)
+	if !token.WaitTimeout(testTimeout) {
+		t.Fatalf("timed out publishing to %s", topic)
+	}
+	if err := token.Error(); err != nil {
+		t.Fatalf("publishing to %s: %s", topic, err)
+	}
+}
+
+func receive[T any](t *testing.T, c <-chan T, what string) T {
+	t.Helper()
+
+	select {
+	case v := <-c:
+		return v
+	case <-time.After(testTimeout):
+		t.Fatalf("timed out waiting for %s", what)
+		var zero T
+		return zero
+	}
+}
+
+func TestBridgeMapsTopicsOntoServices(t *testing.T) {
+	f := startBridge(t)
+	client := connectDevice(t, f.address, "sensor-a", "secret-a")
+
+	publish(t, client, "org/1/device/sensor-a/measurement", `{"value": 21.5}`)
+	m := receive(t, f.measurements.saved, "measurement")
+	if m.DeviceId != 1 || m.Value != 21.5 {
+		t.Errorf("measurement = device %d value %v, want device 1 value 21.5", m.DeviceId, m.Value)
+	}
+
+	publish(t, client, "org/1/device/sensor-a/event", `{"action": "set_level", "level": 40}`)
+	e := receive(t, f.events.recorded, "event")
+	if e.DeviceId != 1 || e.Action != domain.SetLevel || e.Level == nil || *e.Level != 40 {
+		t.Errorf("event = %+v, want SET_LEVEL 40 of device 1", e)
+	}
+
+	publish(t, client, "org/1/device/sensor-a/command/ack", `{"id": 7, "success": false, "error": "jammed"}`)
+	ack := receive(t, f.commands.acked, "command acknowledgement")
+	if ack != (commandAck{DeviceId: 1, CommandId: 7, Success: false, Error: "jammed"}) {
+		t.Errorf("acknowledgement = %+v, want failure of command 7 of device 1", ack)
+	}
+
+	publish(t, client, "org/1/device/sensor-a/twin/reported", `{"power": "on"}`)
+	state := receive(t, f.twins.reported, "twin report")
+	if state.Power == nil || *state.Power != domain.TurnOn {
+		t.Errorf("reported power = %v, want ON", state.Power)
+	}
+}
+
+func TestEmbeddedBrokerRejectsBadCredentials(t *testing.T) {
+	f := startBridge(t)
+
+	tests := []struct {
+		name   string
+		guid   string
+		secret string
+	}{
+		{name: "wrong secret", guid: "sensor-a", secret: "secret-b"},
+		{name: "unknown device", guid: "sensor-c", secret: "secret-a"},
+		{name: "no credentials", guid: "", secret: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := connect(f.address, tt.guid, tt.secret)
+			if err == nil {
+				client.Disconnect(100)
+				t.Fatal("connected, want the broker to refuse the credentials")
+			}
+		})
+	}
+}
+
+func TestEmbeddedBrokerRefusesOtherDevicePrefix(t *testing.T) {
+	f := startBridge(t)
+
+	// the broker drops the publishing client, a QoS 1 publish then never completes
+	client := connectDevice(t, f.address, "sensor-a", "secret-a")
+	token := client.Publish("org/1/device/sensor-b/measurement", 1, false, `{"value": 99}`)
+	if !token.WaitTimeout(testTimeout) {
+		t.Fatal("timed out publishing")
+	}
+	if token.Error() == nil {
+		t.Error("publish to another device's topic completed, want it refused")
+	}
+	select {
+	case m := <-f.measurements.saved:
+		t.Errorf("saved measurement of device %d value %v from another device's topic", m.DeviceId, m.Value)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	client = connectDevice(t, f.address, "sensor-a", "secret-a")
+	token = client.Subscribe("org/1/device/sensor-b/command", 1, nil)
+	if !token.WaitTimeout(testTimeout) {
+		t.Fatal("timed out subscribing")
+	}
+	if code := token.(*paho.SubscribeToken).Result()["org/1/device/sensor-b/command"]; code != 0x80 {
+		t.Errorf("subscription to another device's topic granted with code %#x, want refused", code)
+	}
+
+	token = client.Subscribe("org/1/device/sensor-a/command", 1, nil)
+	if !token.WaitTimeout(testTimeout) {
+		t.Fatal("timed out subscribing")
+	}
+	if code := token.(*paho.SubscribeToken).Result()["org/1/device/sensor-a/command"]; code != 1 {
+		t.Errorf("subscription to the device's own topic granted with code %#x, want QoS 1", code)
+	}
+}
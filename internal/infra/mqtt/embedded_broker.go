@@ -0,0 +1,101 @@
+package mqtt
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	mqttserver "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+type EmbeddedBroker struct {
+	server *mqttserver.Server
+	subIds atomic.Int32
+}
+
+// NewEmbeddedBroker starts an in-process broker on the given address. Devices connect
+// with their GUID as the username and their secret as the password.
+func NewEmbeddedBroker(address string, ds app.DeviceService) (*EmbeddedBroker, error) {
+	server := mqttserver.New(&mqttserver.Options{InlineClient: true})
+
+	err := server.AddHook(&deviceAuthHook{deviceService: ds}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.AddListener(listeners.NewTCP("tcp", address, nil))
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.Serve()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("EmbeddedBroker: listening on %s", address)
+	return &EmbeddedBroker{server: server}, nil
+}
+
+func (b *EmbeddedBroker) Subscribe(filter string, handler func(topic string, payload []byte)) error {
+	id := int(b.subIds.Add(1))
+	return b.server.Subscribe(filter, id, func(cl *mqttserver.Client, sub packets.Subscription, pk packets.Packet) {
+		handler(pk.TopicName, pk.Payload)
+	})
+}
+
+func (b *EmbeddedBroker) Publish(topic string, payload []byte) error {
+	return b.server.Publish(topic, payload, false, 1)
+}
+
+func (b *EmbeddedBroker) Close() error {
+	return b.server.Close()
+}
+
+// deviceAuthHook authenticates devices and limits them to their own topics.
+type deviceAuthHook struct {
+	mqttserver.HookBase
+	deviceService app.DeviceService
+	prefixes      sync.Map
+}
+
+func (h *deviceAuthHook) ID() string {
+	return "device-auth"
+}
+
+func (h *deviceAuthHook) Provides(b byte) bool {
+	return bytes.Contains([]byte{
+		mqttserver.OnConnectAuthenticate,
+		mqttserver.OnACLCheck,
+		mqttserver.OnDisconnect,
+	}, []byte{b})
+}
+
+func (h *deviceAuthHook) OnConnectAuthenticate(cl *mqttserver.Client, pk packets.Packet) bool {
+	device, err := h.deviceService.Authenticate(string(pk.Connect.Username), string(pk.Connect.Password))
+	if err != nil {
+		log.Printf("EmbeddedBroker: rejected client %s: %s", cl.ID, err)
+		return false
+	}
+
+	h.prefixes.Store(cl, DevicePrefix(device))
+	return true
+}
+
+func (h *deviceAuthHook) OnACLCheck(cl *mqttserver.Client, topic string, write bool) bool {
+	if cl.Net.Inline {
+		return true
+	}
+
+	prefix, ok := h.prefixes.Load(cl)
+	return ok && strings.HasPrefix(topic, prefix.(string))
+}
+
+func (h *deviceAuthHook) OnDisconnect(cl *mqttserver.Client, err error, expire bool) {
+	h.prefixes.Delete(cl)
+}
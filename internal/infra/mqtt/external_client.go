@@ -0,0 +1,57 @@
+package mqtt
+
+import (
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/config"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const externalTimeout = 10 * time.Second
+
+// externalClient connects to a broker run elsewhere. Device authentication is up to that broker.
+type externalClient struct {
+	client paho.Client
+}
+
+func newExternalClient(conf config.Configuration) (*externalClient, error) {
+	opts := paho.NewClientOptions().
+		AddBroker(conf.MqttBrokerUrl).
+		SetClientID(conf.MqttClientId).
+		SetUsername(conf.MqttUsername).
+		SetPassword(conf.MqttPassword).
+		SetAutoReconnect(true).
+		SetCleanSession(false)
+
+	client := paho.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(externalTimeout) {
+		return nil, paho.ErrNotConnected
+	}
+	if err := token.Error(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("MqttClient: connected to %s", conf.MqttBrokerUrl)
+	return &externalClient{client: client}, nil
+}
+
+func (c *externalClient) Subscribe(filter string, handler func(topic string, payload []byte)) error {
+	token := c.client.Subscribe(filter, 1, func(_ paho.Client, m paho.Message) {
+		handler(m.Topic(), m.Payload())
+	})
+	token.Wait()
+	return token.Error()
+}
+
+func (c *externalClient) Publish(topic string, payload []byte) error {
+	token := c.client.Publish(topic, 1, false, payload)
+	token.Wait()
+	return token.Error()
+}
+
+func (c *externalClient) Close() error {
+	c.client.Disconnect(uint(externalTimeout.Milliseconds()))
+	return nil
+}
@@ -0,0 +1,119 @@
+package pubsub
+
+import (
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+type MessageType string
+
+const (
+	MeasurementSaved    MessageType = "measurement"
+	EventSaved          MessageType = "event"
+	DeviceInstalled     MessageType = "device_installed"
+	DeviceUninstalled   MessageType = "device_uninstalled"
+	CommandCreated      MessageType = "command_created"
+	CommandUpdated      MessageType = "command_updated"
+	TwinUpdated         MessageType = "twin_updated"
+	RuleExecuted        MessageType = "rule_executed"
+	NotificationCreated MessageType = "notification_created"
+)
+
+const subscriptionBuffer = 64
+
+type Message struct {
+	Type           MessageType
+	OrganizationId uint64
+	RoomId         *uint64
+	DeviceId       uint64
+	Payload        interface{}
+	CreatedDate    time.Time
+}
+
+// Filter narrows a subscription down to an organization, a room or a device and
+// optionally to some message types. Empty fields match everything.
+type Filter struct {
+	OrganizationId *uint64
+	RoomId         *uint64
+	DeviceId       *uint64
+	Types          []MessageType
+}
+
+func (f Filter) Match(m Message) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, m.Type) {
+		return false
+	}
+	if f.OrganizationId != nil && *f.OrganizationId != m.OrganizationId {
+		return false
+	}
+	if f.RoomId != nil && (m.RoomId == nil || *f.RoomId != *m.RoomId) {
+		return false
+	}
+	if f.DeviceId != nil && *f.DeviceId != m.DeviceId {
+		return false
+	}
+	return true
+}
+
+type Subscription struct {
+	C      <-chan Message
+	ch     chan Message
+	filter Filter
+}
+
+type Hub interface {
+	Publish(m Message)
+	Subscribe(f Filter) *Subscription
+	Unsubscribe(s *Subscription)
+}
+
+type hub struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+func NewHub() Hub {
+	return &hub{
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+func (h *hub) Publish(m Message) {
+	if m.CreatedDate.IsZero() {
+		m.CreatedDate = time.Now()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for s := range h.subs {
+		if !s.filter.Match(m) {
+			continue
+		}
+		select {
+		case s.ch <- m:
+		default:
+			log.Printf("Hub: subscriber is too slow, dropping %s message for device %d", m.Type, m.DeviceId)
+		}
+	}
+}
+
+func (h *hub) Subscribe(f Filter) *Subscription {
+	ch := make(chan Message, subscriptionBuffer)
+	s := &Subscription{C: ch, ch: ch, filter: f}
+
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+func (h *hub) Unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.ch)
+	}
+}